@@ -0,0 +1,157 @@
+package dynamostore
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults used by WithAdaptiveConsistency when the corresponding
+// AdaptiveConsistencyConfig field isn't set.
+const (
+	DefaultAdaptiveWindow    = 5 * time.Second
+	DefaultAdaptiveThreshold = 3
+	DefaultAdaptiveInterval  = time.Minute
+	DefaultAdaptiveCooldown  = 5 * time.Minute
+)
+
+// AdaptiveConsistencyConfig configures WithAdaptiveConsistency.
+type AdaptiveConsistencyConfig struct {
+	// Window is how long sessions written by the store are checked for
+	// stale reads, as with WithEventualReads. The default is
+	// DefaultAdaptiveWindow.
+	Window time.Duration
+	// Threshold is the number of stale reads within Interval that makes the
+	// store switch to strongly consistent reads. The defaults are
+	// DefaultAdaptiveThreshold and DefaultAdaptiveInterval.
+	Threshold int
+	Interval  time.Duration
+	// Cooldown is how long the store uses strongly consistent reads before
+	// trying eventually consistent reads again. The default is
+	// DefaultAdaptiveCooldown.
+	Cooldown time.Duration
+	// OnChange, if not nil, is called when the store switches between
+	// eventually and strongly consistent reads, so the decision can be
+	// recorded as a metric.
+	OnChange func(consistent bool)
+}
+
+// WithAdaptiveConsistency is like WithEventualReads, but switches to strongly
+// consistent reads while stale reads of the store's own writes are common,
+// such as when replication is lagging. Each instance of the application
+// decides for itself. Every stale read is still retried with a strongly
+// consistent read.
+//
+// WithAdaptiveConsistency can't be used with WithEventualReads or
+// WithOptimisticLocking.
+func WithAdaptiveConsistency(cfg *AdaptiveConsistencyConfig) Option {
+	return func(s *DynamoStore) {
+		if cfg.Window < 0 || cfg.Threshold < 0 || cfg.Interval < 0 || cfg.Cooldown < 0 {
+			s.invalid("WithAdaptiveConsistency requires settings of zero or more")
+			return
+		}
+		a := &adaptiveConsistency{
+			threshold: cfg.Threshold,
+			interval:  cfg.Interval,
+			cooldown:  cfg.Cooldown,
+			onChange:  cfg.OnChange,
+		}
+		if a.threshold == 0 {
+			a.threshold = DefaultAdaptiveThreshold
+		}
+		if a.interval == 0 {
+			a.interval = DefaultAdaptiveInterval
+		}
+		if a.cooldown == 0 {
+			a.cooldown = DefaultAdaptiveCooldown
+		}
+		window := cfg.Window
+		if window == 0 {
+			window = DefaultAdaptiveWindow
+		}
+		if s.recentWrites != nil {
+			s.invalid(repeatedEventualReads)
+			return
+		}
+		s.consistentRead = false
+		s.recentWrites = newRecentWrites(window)
+		s.adaptive = a
+	}
+}
+
+// ConsistentReads returns true if Find currently uses strongly consistent
+// reads.
+func (s *DynamoStore) ConsistentReads() bool {
+	return s.readConsistency()
+}
+
+// readConsistency returns true if the next read should be strongly
+// consistent.
+func (s *DynamoStore) readConsistency() bool {
+	if s.adaptive == nil {
+		return s.consistentRead
+	}
+	return s.adaptive.consistent(s.now())
+}
+
+// staleRead records that an eventually consistent read returned stale
+// data.
+func (s *DynamoStore) staleRead() {
+	if s.adaptive != nil {
+		s.adaptive.stale(s.now())
+	}
+}
+
+type adaptiveConsistency struct {
+	threshold int
+	interval  time.Duration
+	cooldown  time.Duration
+	onChange  func(consistent bool)
+
+	mu          sync.Mutex
+	misses      int
+	since       time.Time
+	strongUntil time.Time
+}
+
+func (a *adaptiveConsistency) consistent(now time.Time) bool {
+	a.mu.Lock()
+	switch {
+	case a.strongUntil.IsZero():
+		a.mu.Unlock()
+		return false
+	case now.Before(a.strongUntil):
+		a.mu.Unlock()
+		return true
+	}
+	a.strongUntil = time.Time{}
+	a.misses = 0
+	a.mu.Unlock()
+
+	if a.onChange != nil {
+		a.onChange(false)
+	}
+	return false
+}
+
+func (a *adaptiveConsistency) stale(now time.Time) {
+	a.mu.Lock()
+	if !a.strongUntil.IsZero() {
+		a.mu.Unlock()
+		return
+	}
+	if now.Sub(a.since) > a.interval {
+		a.misses = 0
+		a.since = now
+	}
+	a.misses++
+	if a.misses < a.threshold {
+		a.mu.Unlock()
+		return
+	}
+	a.strongUntil = now.Add(a.cooldown)
+	a.mu.Unlock()
+
+	if a.onChange != nil {
+		a.onChange(true)
+	}
+}
@@ -0,0 +1,224 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxAdminRequestSize limits the size of requests to AdminHandler.
+const maxAdminRequestSize = 64 << 10
+
+// AdminHandler returns an http.Handler with JSON endpoints for basic session
+// administration, so teams don't have to build their own:
+//
+//	GET  /sessions?cursor=&limit=  lists sessions, see ListSessions
+//	POST /sessions/inspect         describes a session, see GetMetadata
+//	POST /sessions/revoke          revokes or deletes a session
+//	GET  /stats?sample=            reports Stats and TableStats
+//
+// Inspect and revoke take {"token": "..."}, or {"token_hash": "..."} when
+// WithTokenDigest is used, in the request body, so tokens don't appear in
+// access logs. Session data is never returned.
+//
+// The handler doesn't authenticate requests. Mount it under a path that is
+// only reachable by administrators, using http.StripPrefix to remove the
+// path, such as:
+//
+//	mux.Handle("/admin/", requireAdmin(http.StripPrefix("/admin", store.AdminHandler())))
+func (s *DynamoStore) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", s.adminListSessions)
+	mux.HandleFunc("/sessions/inspect", s.adminInspect)
+	mux.HandleFunc("/sessions/revoke", s.adminRevoke)
+	mux.HandleFunc("/stats", s.adminStats)
+	return mux
+}
+
+// adminSession is a session, as returned by AdminHandler.
+type adminSession struct {
+	TokenHash string     `json:"token_hash"`
+	Expiry    time.Time  `json:"expiry"`
+	Size      int        `json:"size"`
+	Subject   string     `json:"subject,omitempty"`
+	Revoked   bool       `json:"revoked,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type adminTokenRequest struct {
+	Token     string `json:"token"`
+	TokenHash string `json:"token_hash"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, msg string) {
+	writeAdminJSON(w, status, &adminError{Error: msg})
+}
+
+// allowMethod reports whether r uses method, and responds if it doesn't.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func (s *DynamoStore) adminListSessions(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			writeAdminError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	infos, cursor, err := s.ListSessions(r.Context(), query.Get("cursor"), limit)
+	switch {
+	case err == ErrInvalidCursor:
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sessions := make([]*adminSession, len(infos))
+	for i, info := range infos {
+		sessions[i] = &adminSession{
+			TokenHash: info.TokenHash,
+			Expiry:    info.Expiry,
+			Size:      info.Size,
+			Subject:   info.Subject,
+			Revoked:   info.Revoked,
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, &struct {
+		Sessions []*adminSession `json:"sessions"`
+		Cursor   string          `json:"cursor,omitempty"`
+	}{sessions, cursor})
+}
+
+// adminToken reads the token identified by a request, responding and
+// returning false if it can't be found.
+func (s *DynamoStore) adminToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req adminTokenRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxAdminRequestSize)).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid request body")
+		return "", false
+	}
+	switch {
+	case req.Token != "":
+		return req.Token, true
+	case req.TokenHash == "":
+		writeAdminError(w, http.StatusBadRequest, "token or token_hash is required")
+		return "", false
+	case !s.tokenDigest:
+		writeAdminError(w, http.StatusBadRequest, "token_hash requires WithTokenDigest")
+		return "", false
+	}
+	token, av, err := s.findByTokenDigest(r.Context(), req.TokenHash)
+	switch {
+	case err != nil:
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return "", false
+	case av == nil:
+		writeAdminError(w, http.StatusNotFound, "session not found")
+		return "", false
+	}
+	return token, true
+}
+
+func (s *DynamoStore) adminInspect(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	token, ok := s.adminToken(w, r)
+	if !ok {
+		return
+	}
+	metadata, err := s.GetMetadata(r.Context(), token)
+	switch {
+	case err != nil:
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	case metadata == nil:
+		writeAdminError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	session := &adminSession{
+		TokenHash: metadata.TokenHash,
+		Expiry:    metadata.Expiry,
+		Size:      metadata.Size,
+		Revoked:   !metadata.RevokedAt.IsZero(),
+	}
+	if !metadata.CreatedAt.IsZero() {
+		session.CreatedAt = &metadata.CreatedAt
+	}
+	writeAdminJSON(w, http.StatusOK, session)
+}
+
+func (s *DynamoStore) adminRevoke(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	token, ok := s.adminToken(w, r)
+	if !ok {
+		return
+	}
+	if err := s.revokeOrDelete(r.Context(), token); err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *DynamoStore) adminStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	sample := 0
+	if v := r.URL.Query().Get("sample"); v != "" {
+		var err error
+		if sample, err = strconv.Atoi(v); err != nil || sample < 1 {
+			writeAdminError(w, http.StatusBadRequest, "invalid sample")
+			return
+		}
+	}
+	table, err := s.TableStats(r.Context(), sample)
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, &struct {
+		Store *Stats      `json:"store"`
+		Table *TableStats `json:"table"`
+	}{s.Stats(), table})
+}
+
+// revokeOrDelete revokes a session when WithRetention or
+// WithRevocationMarkers is used, and deletes it otherwise.
+func (s *DynamoStore) revokeOrDelete(ctx context.Context, token string) error {
+	if s.tombstones() && s.writeBehind == nil {
+		return s.Revoke(ctx, token)
+	}
+	return s.DeleteCtx(ctx, token)
+}
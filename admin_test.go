@@ -0,0 +1,168 @@
+package dynamostore_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func adminRequest(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithRevocationMarkers(time.Hour),
+	)
+	handler := http.StripPrefix("/admin", store.AdminHandler())
+
+	// given
+	require.NoError(store.Commit("foo", []byte("foo"), now.Add(time.Hour)))
+	require.NoError(store.Commit("bar", []byte("bar"), now.Add(time.Hour)))
+	hash, err := store.HashToken(ctx, "foo")
+	require.NoError(err)
+
+	// when sessions are listed
+	w := adminRequest(handler, http.MethodGet, "/admin/sessions?limit=10", "")
+
+	// then they are identified by hash
+	require.Equal(http.StatusOK, w.Code)
+	require.Equal("application/json", w.Header().Get("Content-Type"))
+	require.NotContains(w.Body.String(), `"foo"`)
+	var list struct {
+		Sessions []struct {
+			TokenHash string    `json:"token_hash"`
+			Expiry    time.Time `json:"expiry"`
+		} `json:"sessions"`
+		Cursor string `json:"cursor"`
+	}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(list.Sessions, 2)
+	require.Empty(list.Cursor)
+
+	// when a session is inspected
+	w = adminRequest(handler, http.MethodPost, "/admin/sessions/inspect", `{"token":"foo"}`)
+
+	// then its metadata is returned
+	require.Equal(http.StatusOK, w.Code)
+	var session struct {
+		TokenHash string    `json:"token_hash"`
+		Expiry    time.Time `json:"expiry"`
+		Revoked   bool      `json:"revoked"`
+	}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &session))
+	require.Equal(hash, session.TokenHash)
+	require.True(now.Add(time.Hour).Equal(session.Expiry))
+	require.False(session.Revoked)
+
+	// when a session is revoked
+	w = adminRequest(handler, http.MethodPost, "/admin/sessions/revoke", `{"token":"foo"}`)
+
+	// then it can't be found
+	require.Equal(http.StatusNoContent, w.Code)
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+	_, exists, err = store.Find("bar")
+	require.NoError(err)
+	require.True(exists)
+
+	// when stats are requested
+	w = adminRequest(handler, http.MethodGet, "/admin/stats?sample=10", "")
+
+	// then both the store's and the table's are returned
+	require.Equal(http.StatusOK, w.Code)
+	var stats struct {
+		Store *dynamostore.Stats      `json:"store"`
+		Table *dynamostore.TableStats `json:"table"`
+	}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &stats))
+	require.NotNil(stats.Store)
+	require.NotNil(stats.Table)
+	require.Equal(2, stats.Table.Sampled)
+}
+
+func TestAdminHandlerErrors(t *testing.T) {
+	store := fake.New()
+	handler := store.AdminHandler()
+	require.NoError(t, store.Commit("foo", []byte("foo"), time.Now().Add(time.Hour)))
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"wrong method", http.MethodGet, "/sessions/revoke", "", http.StatusMethodNotAllowed},
+		{"invalid limit", http.MethodGet, "/sessions?limit=x", "", http.StatusBadRequest},
+		{"invalid cursor", http.MethodGet, "/sessions?cursor=x", "", http.StatusBadRequest},
+		{"invalid sample", http.MethodGet, "/stats?sample=0", "", http.StatusBadRequest},
+		{"invalid body", http.MethodPost, "/sessions/inspect", "{", http.StatusBadRequest},
+		{"missing token", http.MethodPost, "/sessions/inspect", "{}", http.StatusBadRequest},
+		{"token hash", http.MethodPost, "/sessions/inspect", `{"token_hash":"x"}`, http.StatusBadRequest},
+		{"unknown token", http.MethodPost, "/sessions/inspect", `{"token":"bar"}`, http.StatusNotFound},
+		{"unknown path", http.MethodGet, "/sessions/foo", "", http.StatusNotFound},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			// when
+			w := adminRequest(handler, tc.method, tc.target, tc.body)
+
+			// then
+			require.Equal(tc.status, w.Code)
+		})
+	}
+}
+
+func TestAdminHandlerTokenHash(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := fake.New(
+		dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(func(context.Context) ([]byte, error) {
+			return []byte("pepper"), nil
+		})),
+		dynamostore.WithTokenDigest(),
+	)
+	handler := store.AdminHandler()
+
+	// given
+	require.NoError(store.Commit("foo", []byte("foo"), time.Now().Add(time.Hour)))
+	hash, err := store.HashToken(ctx, "foo")
+	require.NoError(err)
+
+	// when a session is revoked by the hash of its token
+	w := adminRequest(handler, http.MethodPost, "/sessions/revoke", `{"token_hash":"`+hash+`"}`)
+
+	// then
+	require.Equal(http.StatusNoContent, w.Code)
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+
+	// when the hash is unknown
+	w = adminRequest(handler, http.MethodPost, "/sessions/revoke", `{"token_hash":"`+hash+`"}`)
+
+	// then
+	require.Equal(http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,57 @@
+// Package assumerole creates DynamoStores whose DynamoDB clients assume an
+// IAM role, such as when the session table is owned by a different account.
+//
+// It is a separate module so applications that don't assume roles don't
+// depend on the STS client.
+package assumerole
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Config describes an IAM role to assume before accessing the session
+// table.
+type Config struct {
+	// RoleARN is the role to assume.
+	RoleARN string
+	// ExternalID is passed to AssumeRole, if the role's trust policy
+	// requires one.
+	ExternalID string
+	// SessionName identifies the role session in CloudTrail logs. By
+	// default, a unique name is generated.
+	SessionName string
+	// Duration is how long each set of temporary credentials is valid.
+	// The default is 15 minutes.
+	Duration time.Duration
+}
+
+// NewFromConfig creates a DynamoStore instance using default values, and a
+// DynamoDB client that assumes role using the credentials in cfg. Temporary
+// credentials are requested from STS when first needed, and refreshed before
+// they expire.
+func NewFromConfig(cfg aws.Config, role *Config, opts ...dynamostore.Option) *dynamostore.DynamoStore {
+	return dynamostore.NewFromConfig(AWSConfig(cfg, role), opts...)
+}
+
+// AWSConfig returns a copy of cfg whose credentials are obtained by assuming
+// role. It can be used with dynamostore.NewFromConfigWithTableName, or to
+// create other clients that should use the same role.
+func AWSConfig(cfg aws.Config, role *Config) aws.Config {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role.RoleARN,
+		func(o *stscreds.AssumeRoleOptions) {
+			if role.ExternalID != "" {
+				o.ExternalID = aws.String(role.ExternalID)
+			}
+			o.RoleSessionName = role.SessionName
+			o.Duration = role.Duration
+		},
+	)
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+	return cfg
+}
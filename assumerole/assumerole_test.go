@@ -0,0 +1,99 @@
+package assumerole_test
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/assumerole"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMED</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2100-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/sessions/test</Arn>
+      <AssumedRoleId>AROA:test</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+// stubHTTPClient responds to AssumeRole requests, and to DynamoDB requests
+// with an empty result.
+type stubHTTPClient struct {
+	assumeRole []url.Values
+	requests   []*http.Request
+}
+
+func (c *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.Host, "sts.") {
+		c.requests = append(c.requests, req)
+		body := []byte("{}")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Content-Type": {"application/x-amz-json-1.0"},
+				"X-Amz-Crc32":  {strconv.FormatUint(uint64(crc32.ChecksumIEEE(body)), 10)},
+			},
+			Body:    ioutil.NopCloser(bytes.NewReader(body)),
+			Request: req,
+		}, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	c.assumeRole = append(c.assumeRole, values)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/xml"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(assumeRoleResponse))),
+		Request:    req,
+	}, nil
+}
+
+func TestNewFromConfig(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := assumerole.NewFromConfig(aws.Config{
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", "token"),
+		HTTPClient:  httpClient,
+		Region:      "us-west-2",
+	}, &assumerole.Config{
+		RoleARN:     "arn:aws:iam::123456789012:role/sessions",
+		ExternalID:  "tenant-42",
+		SessionName: "test",
+	})
+
+	for i := 0; i < 2; i++ {
+		_, _, err := store.Find("foo")
+		require.NoError(err)
+	}
+
+	require.Len(httpClient.assumeRole, 1)
+	require.Equal("arn:aws:iam::123456789012:role/sessions", httpClient.assumeRole[0].Get("RoleArn"))
+	require.Equal("tenant-42", httpClient.assumeRole[0].Get("ExternalId"))
+	require.Equal("test", httpClient.assumeRole[0].Get("RoleSessionName"))
+	last := httpClient.requests[len(httpClient.requests)-1]
+	require.Contains(last.Header.Get("Authorization"), "Credential=ASSUMED/")
+	require.Equal("token", last.Header.Get("X-Amz-Security-Token"))
+}
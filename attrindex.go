@@ -0,0 +1,144 @@
+package dynamostore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attributeIndex copies a value from session data to an indexed attribute.
+type attributeIndex struct {
+	attribute string
+	key       string
+}
+
+// name returns the name of the index on the attribute.
+func (a *attributeIndex) name() string {
+	return a.attribute + "Index"
+}
+
+// WithAttributeIndex copies the value stored under key in each session's
+// data, such as a device ID or the prefix of the client's IP address, to a
+// top-level attribute, so FindByAttribute can find every session with a
+// given value by querying a global secondary index, instead of scanning the
+// table. Session data is decoded with the store's codec, and sessions
+// without the key aren't indexed. It may be used more than once.
+//
+// The attribute isn't encrypted by WithEncryption, so don't index values
+// that must be kept secret. CreateTable, CloudFormationTemplate, and
+// TerraformConfig include an index named after the attribute, such as
+// "DeviceIndex" for "Device". It must be added to existing tables, and
+// sessions committed before the option is enabled aren't indexed until they
+// are committed again.
+func WithAttributeIndex(attribute, key string) Option {
+	return func(s *DynamoStore) {
+		if attribute == "" || key == "" {
+			s.invalid("WithAttributeIndex requires an attribute and a key")
+			return
+		}
+		s.attributeIndexes = append(s.attributeIndexes, &attributeIndex{
+			attribute: attribute,
+			key:       key,
+		})
+	}
+}
+
+// reservedAttributes returns the attributes the store uses itself, which
+// can't be indexed.
+func (s *DynamoStore) reservedAttributes() []string {
+	return []string{
+		"token", "Data", s.ttlAttribute,
+		dataHashAttribute, versionAttribute, revokedAttribute, keyIDAttribute,
+		tokenDigestAttribute, expiryBucketAttribute, createdAtAttribute, subjectAttribute,
+		writerRegionAttribute, committedAtAttribute,
+	}
+}
+
+// indexItem adds the attributes indexed by WithAttributeIndex to av.
+func (s *DynamoStore) indexItem(av map[string]types.AttributeValue, data []byte) {
+	if len(s.attributeIndexes) < 1 || data == nil {
+		return
+	}
+	_, values, err := s.codec.Decode(data)
+	if err != nil {
+		return
+	}
+	for _, idx := range s.attributeIndexes {
+		v, ok := values[idx.key]
+		if !ok || v == nil {
+			continue
+		}
+		if value := fmt.Sprint(v); value != "" {
+			av[idx.attribute] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+}
+
+// attributeIndex returns the index created for WithAttributeIndex.
+func (s *DynamoStore) attributeIndex(idx *attributeIndex) types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(idx.name()),
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String(idx.attribute),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		Projection: &types.Projection{
+			ProjectionType:   types.ProjectionTypeInclude,
+			NonKeyAttributes: []string{s.ttlAttribute, revokedAttribute},
+		},
+		ProvisionedThroughput: s.billing.throughput(),
+	}
+}
+
+// FindByAttribute returns the tokens of every unexpired session whose
+// attribute, indexed by WithAttributeIndex, has the given value, so security
+// teams can find and revoke the sessions of a compromised device or
+// network. Revoked sessions are skipped.
+//
+// The index is eventually consistent, so recently committed sessions may be
+// missed.
+func (s *DynamoStore) FindByAttribute(ctx context.Context, attribute, value string) ([]string, error) {
+	var idx *attributeIndex
+	for _, i := range s.attributeIndexes {
+		if i.attribute == attribute {
+			idx = i
+		}
+	}
+	if idx == nil {
+		return nil, fmt.Errorf("FindByAttribute requires WithAttributeIndex for %q", attribute)
+	}
+
+	var tokens []string
+	now := s.now()
+	err := s.query(ctx, &dynamodb.QueryInput{
+		IndexName:              aws.String(idx.name()),
+		KeyConditionExpression: aws.String("#attr = :value"),
+		FilterExpression:       aws.String("attribute_not_exists(#revoked)"),
+		ExpressionAttributeNames: map[string]string{
+			"#attr":    attribute,
+			"#revoked": revokedAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":value": &types.AttributeValueMemberS{Value: value},
+		},
+	}, func(av map[string]types.AttributeValue) error {
+		token, ok := av["token"].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil
+		}
+		if expiry, ok := unixAttribute(av, s.ttlAttribute); ok && s.hideExpired(expiry, now) {
+			return nil
+		}
+		tokens = append(tokens, token.Value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -0,0 +1,73 @@
+package dynamostore_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestFindByAttribute(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now()
+	store := dynamostore.New(fake.NewClient(),
+		dynamostore.WithoutSleep(),
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithAttributeIndex("Device", "device_id"),
+		dynamostore.WithAttributeIndex("Network", "ip_prefix"),
+	)
+	require.NoError(store.Validate())
+	require.NoError(store.CreateTable())
+
+	commit := func(token string, expiry time.Time, values map[string]interface{}) {
+		data, err := scs.GobCodec{}.Encode(expiry, values)
+		require.NoError(err)
+		require.NoError(store.Commit(token, data, expiry))
+	}
+
+	// given
+	commit("a", now.Add(time.Hour), map[string]interface{}{"device_id": "d1", "ip_prefix": "10.0.0"})
+	commit("b", now.Add(time.Hour), map[string]interface{}{"device_id": "d2", "ip_prefix": "10.0.0"})
+	commit("c", now.Add(time.Hour), map[string]interface{}{"device_id": "d1"})
+	commit("d", now.Add(-time.Hour), map[string]interface{}{"device_id": "d1"})
+	commit("e", now.Add(time.Hour), map[string]interface{}{})
+
+	// when
+	device, err := store.FindByAttribute(ctx, "Device", "d1")
+	require.NoError(err)
+	network, err := store.FindByAttribute(ctx, "Network", "10.0.0")
+	require.NoError(err)
+
+	// then expired sessions are skipped
+	sort.Strings(device)
+	require.Equal([]string{"a", "c"}, device)
+	sort.Strings(network)
+	require.Equal([]string{"a", "b"}, network)
+
+	// when an attribute isn't indexed
+	_, err = store.FindByAttribute(ctx, "Subject", "alice")
+
+	// then
+	require.Error(err)
+}
+
+func TestAttributeIndexValidation(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New(
+		dynamostore.WithAttributeIndex("Data", "device_id"),
+		dynamostore.WithAttributeIndex("Device", "device_id"),
+		dynamostore.WithAttributeIndex("Device", "other"),
+	)
+	err := store.Validate()
+	require.Error(err)
+	require.Len(err.(*dynamostore.ConfigError).Problems, 2)
+}
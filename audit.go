@@ -0,0 +1,169 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultAuditRetention is how long audit events are kept by default.
+const DefaultAuditRetention = 90 * 24 * time.Hour
+
+var errNoAudit = errors.New("requires WithAudit")
+
+// AuditEventType identifies what happened to a session.
+type AuditEventType string
+
+const (
+	// AuditCreate is recorded when a session is committed, and there was
+	// no unexpired session with the same token.
+	AuditCreate AuditEventType = "create"
+	// AuditRefresh is recorded when an existing session is committed or
+	// its expiry is extended.
+	AuditRefresh AuditEventType = "refresh"
+	// AuditDelete is recorded when a session is deleted.
+	AuditDelete AuditEventType = "delete"
+	// AuditExpire is recorded when an expired session is deleted by
+	// WithLazyDelete or DeleteExpired.
+	AuditExpire AuditEventType = "expire"
+)
+
+// AuditEvent is a session lifecycle event, as recorded by WithAudit and
+// passed to every SessionEventSink.
+type AuditEvent struct {
+	Type      AuditEventType
+	TokenHash string
+	Time      time.Time
+
+	// Subject is the subject of the session, if WithSubjectKey is used and
+	// the session data was available.
+	Subject string
+	// Source is the value returned by AuditConfig.Source.
+	Source string
+}
+
+// AuditConfig configures WithAudit.
+type AuditConfig struct {
+	// Table is the name of the audit table, see CreateAuditTable.
+	Table string
+	// Retention is how long events are kept before DynamoDB's TTL process
+	// removes them. The default is DefaultAuditRetention.
+	Retention time.Duration
+	// Source, if not nil, describes where a request came from, such as the
+	// client's IP address or the name of the instance, using values stored
+	// in the request's context. Events recorded in the background, such as
+	// by WithLazyDelete, are passed a background context.
+	Source func(context.Context) string
+	// OnError, if not nil, is called when an event can't be recorded.
+	// Failing to record an event never fails the session operation.
+	OnError func(*AuditEvent, error)
+}
+
+// WithAudit records session lifecycle events to a separate table, for
+// forensic investigations. Each event is identified by a hash of the token,
+// as returned by HashToken, so the audit table never grants access to
+// sessions. Events are written synchronously, after the session operation
+// succeeds.
+//
+// Telling a create from a refresh requires the replaced item, so writes
+// return it from DynamoDB. Bulk operations such as Import and DeleteAll
+// aren't recorded.
+func WithAudit(cfg AuditConfig) Option {
+	return func(s *DynamoStore) {
+		if cfg.Table == "" || cfg.Retention < 0 {
+			s.invalid("WithAudit requires a table and a retention of zero or more")
+			return
+		}
+		if cfg.Retention == 0 {
+			cfg.Retention = DefaultAuditRetention
+		}
+		s.audit = &cfg
+		s.sinks = append(s.sinks, eventSink{sink: auditSink{s}, onError: cfg.OnError})
+	}
+}
+
+// auditSink records every event to the audit table.
+type auditSink struct {
+	store *DynamoStore
+}
+
+func (a auditSink) Created(ctx context.Context, event *AuditEvent) error {
+	return a.store.putAuditEvent(ctx, event)
+}
+
+func (a auditSink) Refreshed(ctx context.Context, event *AuditEvent) error {
+	return a.store.putAuditEvent(ctx, event)
+}
+
+func (a auditSink) Revoked(ctx context.Context, event *AuditEvent) error {
+	return a.store.putAuditEvent(ctx, event)
+}
+
+func (a auditSink) Expired(ctx context.Context, event *AuditEvent) error {
+	return a.store.putAuditEvent(ctx, event)
+}
+
+func (s *DynamoStore) putAuditEvent(ctx context.Context, event *AuditEvent) error {
+	item := map[string]types.AttributeValue{
+		"TokenHash": &types.AttributeValueMemberS{Value: event.TokenHash},
+		"Event": &types.AttributeValueMemberS{
+			Value: event.Time.UTC().Format(time.RFC3339Nano) + " " + string(event.Type),
+		},
+		"Type": &types.AttributeValueMemberS{Value: string(event.Type)},
+		defaultTTLAttribute: &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(event.Time.Add(s.audit.Retention).Unix(), 10),
+		},
+	}
+	if event.Subject != "" {
+		item["Subject"] = &types.AttributeValueMemberS{Value: event.Subject}
+	}
+	if event.Source != "" {
+		item["Source"] = &types.AttributeValueMemberS{Value: event.Source}
+	}
+	_, err := s.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.audit.Table),
+		Item:      item,
+	}, s.optFns...)
+	return err
+}
+
+// CreateAuditTable creates the audit table used by WithAudit, if it doesn't
+// already exist, and enables TTL on it. Events for a session share the
+// partition key TokenHash, and are sorted by the time they were recorded.
+// Like CreateTable, it is intended as a convenience for development and
+// testing.
+func (s *DynamoStore) CreateAuditTable(ctx context.Context) error {
+	if s.audit == nil {
+		return errNoAudit
+	}
+	return s.createTTLTable(ctx, &dynamodb.CreateTableInput{
+		BillingMode: types.BillingModePayPerRequest,
+		Tags:        s.tableTags(),
+		TableName:   aws.String(s.audit.Table),
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("TokenHash"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("Event"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("TokenHash"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("Event"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+	})
+}
@@ -0,0 +1,177 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+const auditTable = "scs.audit"
+
+// auditClient records the items written to the audit table, since
+// fakeClient keys every item on its token.
+type auditClient struct {
+	*fakeClient
+
+	mu     sync.Mutex
+	events []map[string]types.AttributeValue
+	err    error
+}
+
+func (c *auditClient) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	if aws.ToString(params.TableName) != auditTable {
+		return c.fakeClient.PutItem(ctx, params, optFns...)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.events = append(c.events, params.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *auditClient) types() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]string, len(c.events))
+	for i, event := range c.events {
+		result[i] = event["Type"].(*types.AttributeValueMemberS).Value
+	}
+	return result
+}
+
+type sourceKey struct{}
+
+func TestAudit(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	svc := &auditClient{fakeClient: newFakeClient()}
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithSubjectKey("userID"),
+		WithAudit(AuditConfig{
+			Table: auditTable,
+			Source: func(ctx context.Context) string {
+				source, _ := ctx.Value(sourceKey{}).(string)
+				return source
+			},
+		}),
+	})
+	ctx := context.WithValue(context.Background(), sourceKey{}, "192.0.2.1")
+	expiry := now.Add(time.Hour)
+	data := encodeSession(t, expiry, map[string]interface{}{"userID": "alice"})
+
+	// when a session is created, refreshed, and deleted
+	require.NoError(store.CommitCtx(ctx, "foo", data, expiry))
+	require.NoError(store.CommitCtx(ctx, "foo", data, expiry.Add(time.Minute)))
+	require.NoError(store.DeleteCtx(ctx, "foo"))
+
+	// then
+	require.Equal([]string{"create", "refresh", "delete"}, svc.types())
+	for _, event := range svc.events {
+		require.Equal(&types.AttributeValueMemberS{Value: hashToken("foo")}, event["TokenHash"])
+		require.Equal(&types.AttributeValueMemberS{Value: "alice"}, event["Subject"])
+		require.Equal(&types.AttributeValueMemberS{Value: "192.0.2.1"}, event["Source"])
+		require.Contains(event, defaultTTLAttribute)
+	}
+	require.Zero(svc.count("GetItem"))
+
+	// when an expired session is replaced, and then deleted as expired
+	svc.events = nil
+	expired := now.Add(-time.Hour)
+	require.NoError(store.Commit("bar", nil, expired))
+	require.NoError(store.Commit("bar", nil, expired))
+	n, err := store.DeleteExpired(context.Background(), 1)
+
+	// then
+	require.NoError(err)
+	require.Equal(1, n)
+	require.Equal([]string{"create", "create", "expire"}, svc.types())
+	require.NotContains(svc.events[2], "Source")
+}
+
+func TestAuditErrors(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := &auditClient{fakeClient: newFakeClient(), err: errors.New("unavailable")}
+	var failed []*AuditEvent
+	store := newStore(svc, DefaultTableName, []Option{
+		WithAudit(AuditConfig{
+			Table: auditTable,
+			OnError: func(event *AuditEvent, err error) {
+				failed = append(failed, event)
+			},
+		}),
+	})
+
+	// when
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+
+	// then
+	require.NoError(err)
+	require.Contains(svc.items, "foo")
+	require.Len(failed, 1)
+	require.Equal(AuditCreate, failed[0].Type)
+	require.Equal(hashToken("foo"), failed[0].TokenHash)
+}
+
+func TestCreateAuditTable(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, nil)
+	require.Equal(errNoAudit, store.CreateAuditTable(context.Background()))
+
+	svc := newFakeClient()
+	store = newStore(svc, DefaultTableName, []Option{
+		WithAudit(AuditConfig{Table: auditTable}),
+	})
+	store.sleep = func(time.Duration) {}
+	require.NoError(store.CreateAuditTable(context.Background()))
+	require.Equal(1, svc.count("CreateTable"))
+	require.Equal(1, svc.count("UpdateTimeToLive"))
+}
+
+func TestCreateAuditTableCreatedByAnotherCaller(t *testing.T) {
+	require := require.New(t)
+
+	// given another caller creates the table, but doesn't enable TTL
+	svc := newFakeClient()
+	svc.failWith("CreateTable", &types.ResourceInUseException{})
+	client := &racingClient{
+		fakeClient: svc,
+		statuses:   []types.TableStatus{types.TableStatusCreating, types.TableStatusActive},
+		ttl:        []bool{false},
+	}
+	store := newStore(client, DefaultTableName, []Option{
+		WithAudit(AuditConfig{Table: auditTable}),
+	})
+	store.sleep = func(time.Duration) {}
+
+	// when
+	err := store.CreateAuditTable(context.Background())
+
+	// then TTL is enabled once the table is active
+	require.NoError(err)
+	require.Equal(1, svc.count("UpdateTimeToLive"))
+
+	// when the other caller deletes the table instead
+	client.statuses = []types.TableStatus{""}
+	err = store.CreateAuditTable(context.Background())
+
+	// then the error is returned
+	require.Equal(&types.ResourceNotFoundException{}, err)
+	require.Equal(1, svc.count("UpdateTimeToLive"))
+}
@@ -0,0 +1,45 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+var errNoAutoScaling = errors.New("requires WithAutoScaling")
+
+// AutoScaler registers the provisioned capacity of a table and its global
+// secondary indexes for scaling. The autoscaling package implements it with
+// Application Auto Scaling.
+type AutoScaler interface {
+	ConfigureAutoScaling(ctx context.Context, table string, indexes []string) error
+}
+
+// WithAutoScaling registers the table's provisioned capacity with scaler, so
+// CreateTable produces a table that can follow production traffic. It
+// requires WithProvisionedThroughput, which sets the initial capacity.
+func WithAutoScaling(scaler AutoScaler) Option {
+	return func(s *DynamoStore) {
+		if scaler == nil {
+			s.invalid("WithAutoScaling requires a scaler")
+			return
+		}
+		s.autoScaling = scaler
+	}
+}
+
+// ConfigureAutoScaling registers the table and its global secondary indexes
+// with the scaler passed to WithAutoScaling. CreateTable calls it after
+// creating a table, and it can be called to apply new bounds to an existing
+// table.
+func (s *DynamoStore) ConfigureAutoScaling(ctx context.Context) error {
+	if s.autoScaling == nil {
+		return errNoAutoScaling
+	}
+	var indexes []string
+	for _, gsi := range s.createTableInput().GlobalSecondaryIndexes {
+		indexes = append(indexes, aws.ToString(gsi.IndexName))
+	}
+	return s.autoScaling.ConfigureAutoScaling(ctx, *s.table, indexes)
+}
@@ -0,0 +1,138 @@
+// Package autoscaling registers the provisioned capacity of session tables
+// with Application Auto Scaling, for use with dynamostore.WithAutoScaling.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the Application Auto Scaling client.
+package autoscaling
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// DefaultTargetUtilization is the percentage of provisioned capacity a
+// Scaler keeps in use, by default.
+const DefaultTargetUtilization = 70
+
+// Client is the subset of the Application Auto Scaling API used by a
+// Scaler. It is implemented by *applicationautoscaling.Client.
+type Client interface {
+	RegisterScalableTarget(
+		context.Context, *applicationautoscaling.RegisterScalableTargetInput,
+		...func(*applicationautoscaling.Options),
+	) (*applicationautoscaling.RegisterScalableTargetOutput, error)
+
+	PutScalingPolicy(
+		context.Context, *applicationautoscaling.PutScalingPolicyInput,
+		...func(*applicationautoscaling.Options),
+	) (*applicationautoscaling.PutScalingPolicyOutput, error)
+}
+
+// Config configures a Scaler.
+type Config struct {
+	Client Client
+	// MinReadUnits and MaxReadUnits bound the read capacity, and
+	// MinWriteUnits and MaxWriteUnits the write capacity, of the table and
+	// each of its global secondary indexes.
+	MinReadUnits  int32
+	MaxReadUnits  int32
+	MinWriteUnits int32
+	MaxWriteUnits int32
+	// TargetUtilization is the percentage of provisioned capacity to keep
+	// in use, between 20 and 90. The default is DefaultTargetUtilization.
+	TargetUtilization float64
+}
+
+// Scaler implements dynamostore.AutoScaler. Read and write capacity, of the
+// table and each of its global secondary indexes, are scaled between the
+// configured bounds to keep their utilization near the target.
+//
+// Registering the first scalable target creates Application Auto Scaling's
+// service-linked role, which requires the iam:CreateServiceLinkedRole
+// permission.
+type Scaler struct {
+	cfg Config
+}
+
+// New returns a Scaler, or an error if cfg is invalid.
+func New(cfg Config) (*Scaler, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("autoscaling: missing client")
+	}
+	if cfg.MinReadUnits < 1 || cfg.MaxReadUnits < cfg.MinReadUnits ||
+		cfg.MinWriteUnits < 1 || cfg.MaxWriteUnits < cfg.MinWriteUnits {
+		return nil, errors.New("autoscaling: requires positive minimums no greater than the maximums")
+	}
+	if cfg.TargetUtilization == 0 {
+		cfg.TargetUtilization = DefaultTargetUtilization
+	}
+	if cfg.TargetUtilization < 20 || cfg.TargetUtilization > 90 {
+		return nil, errors.New("autoscaling: requires a target utilization between 20 and 90")
+	}
+	return &Scaler{cfg: cfg}, nil
+}
+
+// ConfigureAutoScaling registers the scalable targets and target tracking
+// policies of the table and its indexes, replacing any already registered.
+func (a *Scaler) ConfigureAutoScaling(ctx context.Context, table string, indexes []string) error {
+	resources := []string{"table/" + table}
+	for _, index := range indexes {
+		resources = append(resources, "table/"+table+"/index/"+index)
+	}
+	for i, resource := range resources {
+		read := astypes.ScalableDimensionDynamoDBTableReadCapacityUnits
+		write := astypes.ScalableDimensionDynamoDBTableWriteCapacityUnits
+		if i > 0 {
+			read = astypes.ScalableDimensionDynamoDBIndexReadCapacityUnits
+			write = astypes.ScalableDimensionDynamoDBIndexWriteCapacityUnits
+		}
+		err := a.scaleDimension(ctx, resource, read, astypes.MetricTypeDynamoDBReadCapacityUtilization,
+			a.cfg.MinReadUnits, a.cfg.MaxReadUnits,
+		)
+		if err != nil {
+			return err
+		}
+		err = a.scaleDimension(ctx, resource, write, astypes.MetricTypeDynamoDBWriteCapacityUtilization,
+			a.cfg.MinWriteUnits, a.cfg.MaxWriteUnits,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleDimension registers a scalable target and its target tracking policy.
+func (a *Scaler) scaleDimension(
+	ctx context.Context, resource string, dimension astypes.ScalableDimension,
+	metric astypes.MetricType, min, max int32,
+) error {
+	_, err := a.cfg.Client.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  astypes.ServiceNamespaceDynamodb,
+		ResourceId:        aws.String(resource),
+		ScalableDimension: dimension,
+		MinCapacity:       aws.Int32(min),
+		MaxCapacity:       aws.Int32(max),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.cfg.Client.PutScalingPolicy(ctx, &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName:        aws.String(string(metric) + ":" + resource),
+		PolicyType:        astypes.PolicyTypeTargetTrackingScaling,
+		ServiceNamespace:  astypes.ServiceNamespaceDynamodb,
+		ResourceId:        aws.String(resource),
+		ScalableDimension: dimension,
+		TargetTrackingScalingPolicyConfiguration: &astypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(a.cfg.TargetUtilization),
+			PredefinedMetricSpecification: &astypes.PredefinedMetricSpecification{
+				PredefinedMetricType: metric,
+			},
+		},
+	})
+	return err
+}
@@ -0,0 +1,110 @@
+package autoscaling_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/autoscaling"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+type fakeAutoScaling struct {
+	targets  []*applicationautoscaling.RegisterScalableTargetInput
+	policies []*applicationautoscaling.PutScalingPolicyInput
+}
+
+func (c *fakeAutoScaling) RegisterScalableTarget(
+	ctx context.Context, params *applicationautoscaling.RegisterScalableTargetInput,
+	optFns ...func(*applicationautoscaling.Options),
+) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
+	c.targets = append(c.targets, params)
+	return &applicationautoscaling.RegisterScalableTargetOutput{}, nil
+}
+
+func (c *fakeAutoScaling) PutScalingPolicy(
+	ctx context.Context, params *applicationautoscaling.PutScalingPolicyInput,
+	optFns ...func(*applicationautoscaling.Options),
+) (*applicationautoscaling.PutScalingPolicyOutput, error) {
+	c.policies = append(c.policies, params)
+	return &applicationautoscaling.PutScalingPolicyOutput{}, nil
+}
+
+func TestAutoScaling(t *testing.T) {
+	require := require.New(t)
+
+	client := &fakeAutoScaling{}
+	scaler, err := autoscaling.New(autoscaling.Config{
+		Client:        client,
+		MinReadUnits:  5,
+		MaxReadUnits:  500,
+		MinWriteUnits: 5,
+		MaxWriteUnits: 100,
+	})
+	require.NoError(err)
+	store := dynamostore.NewWithTableName(fake.NewClient(), "sessions",
+		dynamostore.WithProvisionedThroughput(5, 5),
+		dynamostore.WithExpiryIndex(0),
+		dynamostore.WithAutoScaling(scaler),
+	)
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then read and write capacity of the table and its index are scaled
+	var targets []string
+	for _, target := range client.targets {
+		targets = append(targets, aws.ToString(target.ResourceId)+" "+string(target.ScalableDimension))
+	}
+	require.Equal([]string{
+		"table/sessions dynamodb:table:ReadCapacityUnits",
+		"table/sessions dynamodb:table:WriteCapacityUnits",
+		"table/sessions/index/ExpiryIndex dynamodb:index:ReadCapacityUnits",
+		"table/sessions/index/ExpiryIndex dynamodb:index:WriteCapacityUnits",
+	}, targets)
+	require.Equal(int32(500), aws.ToInt32(client.targets[0].MaxCapacity))
+	require.Equal(int32(100), aws.ToInt32(client.targets[1].MaxCapacity))
+
+	require.Len(client.policies, 4)
+	policy := client.policies[0]
+	require.Equal("DynamoDBReadCapacityUtilization:table/sessions", aws.ToString(policy.PolicyName))
+	require.Equal(
+		float64(autoscaling.DefaultTargetUtilization),
+		aws.ToFloat64(policy.TargetTrackingScalingPolicyConfiguration.TargetValue),
+	)
+
+	// when the table already exists
+	require.NoError(store.CreateTable())
+
+	// then it isn't registered again
+	require.Len(client.targets, 4)
+}
+
+func TestNew(t *testing.T) {
+	valid := autoscaling.Config{
+		Client:        &fakeAutoScaling{},
+		MinReadUnits:  1,
+		MaxReadUnits:  10,
+		MinWriteUnits: 1,
+		MaxWriteUnits: 10,
+	}
+	_, err := autoscaling.New(valid)
+	require.NoError(t, err)
+
+	for _, invalid := range []func(*autoscaling.Config){
+		func(cfg *autoscaling.Config) { cfg.Client = nil },
+		func(cfg *autoscaling.Config) { cfg.MinReadUnits = 0 },
+		func(cfg *autoscaling.Config) { cfg.MaxWriteUnits = 0 },
+		func(cfg *autoscaling.Config) { cfg.TargetUtilization = 95 },
+	} {
+		cfg := valid
+		invalid(&cfg)
+		_, err := autoscaling.New(cfg)
+		require.Error(t, err)
+	}
+}
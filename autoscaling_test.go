@@ -0,0 +1,58 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+type fakeScaler struct {
+	calls [][]string
+}
+
+func (a *fakeScaler) ConfigureAutoScaling(ctx context.Context, table string, indexes []string) error {
+	a.calls = append(a.calls, append([]string{table}, indexes...))
+	return nil
+}
+
+func TestWithAutoScaling(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	scaler := &fakeScaler{}
+	store := dynamostore.NewWithTableName(fake.NewClient(), "sessions",
+		dynamostore.WithoutSleep(),
+		dynamostore.WithProvisionedThroughput(5, 5),
+		dynamostore.WithExpiryIndex(0),
+		dynamostore.WithAutoScaling(scaler),
+	)
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then the table and its index are registered
+	require.Equal([][]string{{"sessions", "ExpiryIndex"}}, scaler.calls)
+
+	// when the table already exists
+	require.NoError(store.CreateTable())
+
+	// then it isn't registered again
+	require.Len(scaler.calls, 1)
+
+	// when the option isn't used
+	// then
+	require.Error(fake.New().ConfigureAutoScaling(context.Background()))
+
+	// when the table isn't provisioned, or there's no scaler
+	// then
+	require.Error(fake.New(dynamostore.WithAutoScaling(scaler)).Validate())
+	require.Error(fake.New(
+		dynamostore.WithProvisionedThroughput(1, 1),
+		dynamostore.WithAutoScaling(nil),
+	).Validate())
+}
@@ -0,0 +1,132 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchWriteItems is the maximum number of items DynamoDB accepts in a
+// single BatchWriteItem request.
+const maxBatchWriteItems = 25
+
+// maxBatchWriteAttempts limits how many times unprocessed items are retried.
+const maxBatchWriteAttempts = 10
+
+// ErrUnprocessedItems is returned when DynamoDB repeatedly fails to process
+// part of a batch read or write, usually because the table is being
+// throttled.
+var ErrUnprocessedItems = errors.New("batch request left unprocessed items")
+
+// DeleteAll removes every session from the DynamoStore instance, and returns
+// the number of sessions removed. It is primarily intended for tests and
+// tooling, and is expensive for large tables.
+func (s *DynamoStore) DeleteAll(ctx context.Context) (int, error) {
+	if s.cache != nil {
+		defer s.cache.clear()
+	}
+	n := 0
+	requests := make([]types.WriteRequest, 0, maxBatchWriteItems)
+	err := s.scanItems(ctx, func(item *sessionItem) error {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"token": &types.AttributeValueMemberS{
+						Value: item.Token,
+					},
+				},
+			},
+		})
+		if len(requests) < maxBatchWriteItems {
+			return nil
+		}
+		if err := s.batchWrite(ctx, requests); err != nil {
+			return err
+		}
+		n += len(requests)
+		requests = requests[:0]
+		return nil
+	})
+	if err == nil && len(requests) > 0 {
+		if err = s.batchWrite(ctx, requests); err == nil {
+			n += len(requests)
+		}
+	}
+	return n, err
+}
+
+// batchPut writes items using as few requests as possible, retrying items
+// DynamoDB leaves unprocessed.
+func (s *DynamoStore) batchPut(ctx context.Context, items []map[string]types.AttributeValue) error {
+	for len(items) > 0 {
+		n := len(items)
+		if n > maxBatchWriteItems {
+			n = maxBatchWriteItems
+		}
+		requests := make([]types.WriteRequest, n)
+		for i, item := range items[:n] {
+			requests[i] = types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: item},
+			}
+			if token, ok := item["token"].(*types.AttributeValueMemberS); ok {
+				s.forgetCached(token.Value)
+			}
+		}
+		if err := s.batchWrite(ctx, requests); err != nil {
+			return err
+		}
+		items = items[n:]
+	}
+	return nil
+}
+
+func (s *DynamoStore) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	delay := 50 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		size := 0
+		for _, r := range requests {
+			if r.PutRequest != nil {
+				size += itemSize(r.PutRequest.Item)
+			}
+		}
+		units, err := s.limiter.waitWrite(ctx, size)
+		if err != nil {
+			return err
+		}
+		result, err := s.svc.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				*s.table: requests,
+			},
+			ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		}, s.optFns...)
+		if err != nil {
+			return err
+		}
+		for i := range result.ConsumedCapacity {
+			s.limiter.consumedWrite(units, &result.ConsumedCapacity[i])
+			units = 0
+		}
+
+		requests = result.UnprocessedItems[*s.table]
+		switch {
+		case len(requests) < 1:
+			return nil
+		case attempt >= maxBatchWriteAttempts:
+			return ErrUnprocessedItems
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if delay < 5*time.Second {
+			delay *= 2
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAll(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	svc.pageSize = 10
+	store := newStore(svc, DefaultTableName, nil)
+
+	expiry := time.Now().Add(time.Hour)
+	for i := 0; i < 30; i++ {
+		require.NoError(store.Commit(string(rune('A'+i)), []byte{byte(i)}, expiry))
+	}
+
+	n, err := store.DeleteAll(context.Background())
+	require.NoError(err)
+	require.Equal(30, n)
+	require.Equal(2, svc.count("BatchWriteItem"))
+	require.Empty(svc.items)
+}
@@ -0,0 +1,13 @@
+// Package benchmarks measures the performance of DynamoStore's Commit,
+// Find, and Delete across payload sizes and concurrency levels.
+//
+// By default the benchmarks use the in-memory fake client, which isolates
+// the cost of marshaling and the store's own overhead. With the integration
+// build tag they run against DynamoDB Local instead, see dynamolocal:
+//
+//	go test -bench . ./benchmarks
+//	go test -tags integration -bench . ./benchmarks
+//
+// Payloads and tokens are generated from a fixed seed, so runs are
+// comparable with tools such as benchstat.
+package benchmarks
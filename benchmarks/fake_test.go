@@ -0,0 +1,14 @@
+// +build !integration
+
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func newStore(b *testing.B, opts ...dynamostore.Option) *dynamostore.DynamoStore {
+	return fake.New(opts...)
+}
@@ -0,0 +1,40 @@
+// +build integration
+
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/dynamolocal"
+)
+
+const table = "dynamostore.benchmarks"
+
+var local *dynamolocal.Instance
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	var err error
+	local, err = dynamolocal.Start(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if err := local.Terminate(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+func newStore(b *testing.B, opts ...dynamostore.Option) *dynamostore.DynamoStore {
+	store := dynamostore.NewWithTableName(local.Client(), table, opts...)
+	if err := store.CreateTable(); err != nil {
+		b.Fatal(err)
+	}
+	return store
+}
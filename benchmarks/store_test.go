@@ -0,0 +1,140 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// seed makes payloads and tokens identical between runs.
+const seed = 1
+
+// tokenCount is the number of distinct sessions used by each benchmark.
+const tokenCount = 1000
+
+var payloadSizes = []int{128, 1024, 16 * 1024, 64 * 1024}
+
+var parallelism = []int{1, 8, 32}
+
+func payload(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func tokens() []string {
+	rng := rand.New(rand.NewSource(seed))
+	tokens := make([]string, tokenCount)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("bench-%016x", rng.Uint64())
+	}
+	return tokens
+}
+
+// encrypted returns the options of a store that encrypts sessions and
+// stores a keyed digest of each token.
+func encrypted() []dynamostore.Option {
+	key := make([]byte, 32)
+	rand.New(rand.NewSource(seed)).Read(key)
+	return []dynamostore.Option{
+		dynamostore.WithEncryption(&dynamostore.Keyring{
+			Current: "bench",
+			Keys:    map[string][]byte{"bench": key},
+		}),
+		dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(func(context.Context) ([]byte, error) {
+			return key, nil
+		})),
+		dynamostore.WithTokenDigest(),
+	}
+}
+
+// run calls fn with successive tokens, spread across the given number of
+// goroutines per CPU. Allocations are reported, since they dominate the cost
+// of the store itself at high request rates.
+func run(b *testing.B, parallel int, tokens []string, fn func(token string) error) {
+	var next uint64
+	b.ReportAllocs()
+	b.SetParallelism(parallel)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := atomic.AddUint64(&next, 1)
+			if err := fn(tokens[i%uint64(len(tokens))]); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkCommit(b *testing.B) {
+	benchmarkCommit(b, newStore(b))
+}
+
+// BenchmarkCommitEncrypted adds the cost of encrypting each session, and of
+// hashing its token with a pepper for WithTokenDigest.
+func BenchmarkCommitEncrypted(b *testing.B) {
+	benchmarkCommit(b, newStore(b, encrypted()...))
+}
+
+func benchmarkCommit(b *testing.B, store *dynamostore.DynamoStore) {
+	tokens := tokens()
+	expiry := time.Now().Add(time.Hour)
+	for _, size := range payloadSizes {
+		data := payload(size)
+		for _, p := range parallelism {
+			b.Run(fmt.Sprintf("size=%d/parallel=%d", size, p), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				run(b, p, tokens, func(token string) error {
+					return store.Commit(token, data, expiry)
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	benchmarkFind(b, newStore(b))
+}
+
+// BenchmarkFindEncrypted adds the cost of decrypting each session.
+func BenchmarkFindEncrypted(b *testing.B) {
+	benchmarkFind(b, newStore(b, encrypted()...))
+}
+
+func benchmarkFind(b *testing.B, store *dynamostore.DynamoStore) {
+	tokens := tokens()
+	expiry := time.Now().Add(time.Hour)
+	for _, size := range payloadSizes {
+		data := payload(size)
+		for _, token := range tokens {
+			if err := store.Commit(token, data, expiry); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for _, p := range parallelism {
+			b.Run(fmt.Sprintf("size=%d/parallel=%d", size, p), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				run(b, p, tokens, func(token string) error {
+					_, _, err := store.Find(token)
+					return err
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	store := newStore(b)
+	tokens := tokens()
+	for _, p := range parallelism {
+		b.Run(fmt.Sprintf("parallel=%d", p), func(b *testing.B) {
+			run(b, p, tokens, store.Delete)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package dynamostore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchGetItems is the maximum number of keys DynamoDB accepts in a
+// single BatchGetItem request.
+const maxBatchGetItems = 100
+
+// maxBatchGetAttempts limits how many times unprocessed keys are retried.
+const maxBatchGetAttempts = 10
+
+// errNoCache is returned by Prefetch when WithCache isn't used.
+var errNoCache = errors.New("Prefetch requires WithCache")
+
+// WithCache keeps up to size recently found or committed sessions in
+// memory for up to ttl, so Find can return them without reading the table.
+// Commit and Delete keep the cache up to date, but changes made by other
+// instances of the application aren't seen until ttl has passed, so ttl
+// should be short, and sessions should usually be handled by the same
+// instance, such as with sticky load balancing, or WithCacheInvalidation
+// should be used.
+//
+// WithCache can't be used with WithSlidingExpiration or
+// WithOptimisticLocking, which need to read the table every time.
+func WithCache(size int, ttl time.Duration) Option {
+	return func(s *DynamoStore) {
+		if size < 1 || ttl <= 0 {
+			s.invalid("WithCache requires a positive size and ttl")
+			return
+		}
+		s.cache = &sessionCache{
+			size:    size,
+			ttl:     ttl,
+			order:   list.New(),
+			entries: map[string]*list.Element{},
+			hashes:  map[string]string{},
+		}
+	}
+}
+
+// sessionCache is an LRU cache of sessions.
+type sessionCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	// hashes maps token hashes to tokens, for WithCacheInvalidation.
+	hashes map[string]string
+}
+
+type cacheEntry struct {
+	item     sessionItem
+	hash     string
+	loadedAt time.Time
+}
+
+// get returns a copy of a cached session, if it is still fresh at now.
+func (c *sessionCache) get(token string, now time.Time) (*sessionItem, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[token]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if now.Sub(entry.loadedAt) > c.ttl {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	item := entry.item
+	return &item, true
+}
+
+// put adds a copy of item, as found or written at now. The hash of its
+// token is only needed for WithCacheInvalidation, and may be empty.
+func (c *sessionCache) put(item *sessionItem, hash string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hash != "" {
+		c.hashes[hash] = item.Token
+	}
+	if elem, ok := c.entries[item.Token]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.item = *item
+		entry.hash = hash
+		entry.loadedAt = now
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[item.Token] = c.order.PushFront(&cacheEntry{
+		item:     *item,
+		hash:     hash,
+		loadedAt: now,
+	})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *sessionCache) forget(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[token]; ok {
+		c.remove(elem)
+	}
+}
+
+// forgetHash removes the session whose token has the given hash.
+func (c *sessionCache) forgetHash(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[c.hashes[hash]]; ok {
+		c.remove(elem)
+	}
+}
+
+// remove removes an entry. The caller must hold c.mu.
+func (c *sessionCache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.item.Token)
+	if entry.hash != "" {
+		delete(c.hashes, entry.hash)
+	}
+}
+
+func (c *sessionCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+	c.hashes = map[string]string{}
+}
+
+// cached returns a session from the cache, if it is enabled.
+func (s *DynamoStore) cached(token string) *sessionItem {
+	if s.cache == nil {
+		return nil
+	}
+	item, _ := s.cache.get(token, s.now())
+	return item
+}
+
+// cacheItem adds a session to the cache, if it is enabled.
+func (s *DynamoStore) cacheItem(item *sessionItem) {
+	if s.cache == nil {
+		return
+	}
+	var hash string
+	if s.invalidation != nil {
+		var err error
+		if hash, err = s.HashToken(context.Background(), item.Token); err != nil {
+			// Without a hash, invalidations from other instances
+			// can't find the session, so don't cache it.
+			return
+		}
+	}
+	s.cache.put(item, hash, s.now())
+}
+
+// forgetCached removes a session from the cache, if it is enabled.
+func (s *DynamoStore) forgetCached(token string) {
+	if s.cache != nil {
+		s.cache.forget(token)
+	}
+}
+
+// Prefetch loads the given sessions into the cache enabled by WithCache,
+// using as few requests as possible. It is useful when the sessions that
+// will be needed are known in advance, such as when many clients reconnect
+// at once. Sessions that are already cached, missing, or expired are
+// skipped.
+func (s *DynamoStore) Prefetch(ctx context.Context, tokens []string) error {
+	if s.cache == nil {
+		return errNoCache
+	}
+	seen := make(map[string]bool, len(tokens))
+	keys := make([]map[string]types.AttributeValue, 0, maxBatchGetItems)
+	for _, token := range tokens {
+		if token == "" || seen[token] || s.cached(token) != nil {
+			continue
+		}
+		seen[token] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		})
+		if len(keys) < maxBatchGetItems {
+			continue
+		}
+		if err := s.batchGet(ctx, keys); err != nil {
+			return err
+		}
+		keys = make([]map[string]types.AttributeValue, 0, maxBatchGetItems)
+	}
+	if len(keys) > 0 {
+		return s.batchGet(ctx, keys)
+	}
+	return nil
+}
+
+// batchGet reads the sessions with the given keys into the cache, retrying
+// keys DynamoDB leaves unprocessed.
+func (s *DynamoStore) batchGet(ctx context.Context, keys []map[string]types.AttributeValue) error {
+	delay := 50 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		units, err := s.limiter.waitReads(ctx, len(keys))
+		if err != nil {
+			return err
+		}
+		result, err := s.svc.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				*s.table: {
+					Keys:                     keys,
+					ConsistentRead:           aws.Bool(s.consistentRead),
+					ProjectionExpression:     s.projection.expr,
+					ExpressionAttributeNames: s.projection.names,
+				},
+			},
+			ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		}, s.optFns...)
+		if err != nil {
+			return err
+		}
+		for i := range result.ConsumedCapacity {
+			s.limiter.consumedRead(units, &result.ConsumedCapacity[i])
+			units = 0
+		}
+
+		now := s.now()
+		for _, av := range result.Responses[*s.table] {
+			s.stats.read(av)
+			item, err := s.unmarshalItem(av)
+			if err != nil {
+				return err
+			}
+			if item.Token != "" && !s.hideExpired(item.TTL, now) {
+				s.cacheItem(item)
+			}
+		}
+
+		keys = result.UnprocessedKeys[*s.table].Keys
+		switch {
+		case len(keys) < 1:
+			return nil
+		case attempt >= maxBatchGetAttempts:
+			return ErrUnprocessedItems
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if delay < 5*time.Second {
+			delay *= 2
+		}
+	}
+}
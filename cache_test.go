@@ -0,0 +1,147 @@
+package dynamostore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCache(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithCache(10, time.Minute),
+	})
+	require.NoError(store.Validate())
+
+	// given a committed session
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+
+	// when it is found
+	data, exists, err := store.Find("foo")
+	// then it is returned from the cache
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+	require.Equal(0, svc.count("GetItem"))
+
+	// when the cache entry is stale
+	now = now.Add(2 * time.Minute)
+	_, exists, err = store.Find("foo")
+	// then the session is read again, and cached
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(1, svc.count("GetItem"))
+	_, _, err = store.Find("foo")
+	require.NoError(err)
+	require.Equal(1, svc.count("GetItem"))
+
+	// when the session is deleted
+	require.NoError(store.Delete("foo"))
+	_, exists, err = store.Find("foo")
+	// then it isn't cached
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(2, svc.count("GetItem"))
+
+	// when a cached session expires
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Second)))
+	now = now.Add(2 * time.Second)
+	_, exists, err = store.Find("foo")
+	// then it isn't returned
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(2, svc.count("GetItem"))
+
+	// when combined with options that need to read the table
+	store = newStore(svc, DefaultTableName, []Option{
+		WithCache(10, time.Minute),
+		WithSlidingExpiration(time.Hour),
+		WithOptimisticLocking(),
+	})
+	// then the configuration is rejected
+	err = store.Validate()
+	require.Error(err)
+	require.Len(err.(*ConfigError).Problems, 2)
+}
+
+func TestCacheEviction(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithCache(2, time.Minute),
+	})
+	expiry := time.Now().Add(time.Hour)
+
+	// given more sessions than the cache holds
+	for _, token := range []string{"a", "b", "c"} {
+		require.NoError(store.Commit(token, []byte(token), expiry))
+	}
+
+	// when the least recently used session is found
+	_, exists, err := store.Find("a")
+	// then it is read from the table
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(1, svc.count("GetItem"))
+}
+
+func TestPrefetch(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithCache(1000, time.Minute),
+	})
+
+	// given sessions committed by another instance
+	other := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+	})
+	tokens := make([]string, 0, 150)
+	for i := 0; i < 150; i++ {
+		token := fmt.Sprintf("token-%03d", i)
+		tokens = append(tokens, token)
+		require.NoError(other.Commit(token, []byte(token), now.Add(time.Hour)))
+	}
+	require.NoError(other.Commit("expired", []byte("expired"), now.Add(-time.Hour)))
+
+	// when they are prefetched
+	err := store.Prefetch(ctx, append(tokens, "expired", "missing", tokens[0]))
+	// then they are read in batches of 100
+	require.NoError(err)
+	require.Equal(2, svc.count("BatchGetItem"))
+
+	// when they are found
+	for _, token := range tokens {
+		data, exists, err := store.Find(token)
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte(token), data)
+	}
+	_, exists, err := store.Find("expired")
+	require.NoError(err)
+	require.False(exists)
+	// then only the sessions that weren't prefetched are read
+	require.Equal(1, svc.count("GetItem"))
+
+	// when they are prefetched again
+	require.NoError(store.Prefetch(ctx, tokens))
+	// then cached sessions are skipped
+	require.Equal(2, svc.count("BatchGetItem"))
+
+	// when the cache isn't enabled
+	err = other.Prefetch(ctx, tokens)
+	// then
+	require.Error(err)
+}
@@ -0,0 +1,382 @@
+// Package chaos injects faults into DynamoDB requests, so applications can
+// test how they behave when the session store is slow or failing.
+//
+// The fault injection happens beneath the store, by wrapping the
+// dynamostore.Client it uses. The store's own retry and error handling is
+// exercised exactly as it would be by a degraded DynamoDB:
+//
+//	svc := chaos.New(dynamodb.NewFromConfig(cfg), &chaos.Config{
+//		Latency:      50 * time.Millisecond,
+//		ThrottleRate: 0.1,
+//	})
+//	store := dynamostore.New(svc)
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+var _ dynamostore.Client = &Client{}
+
+// Config describes the faults to inject. Rates are probabilities between 0
+// and 1, applied independently to each request.
+type Config struct {
+	// Latency is added to every affected request, plus a random amount up
+	// to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// ThrottleRate is the fraction of requests that fail with
+	// ProvisionedThroughputExceededException.
+	ThrottleRate float64
+	// ErrorRate is the fraction of requests that fail with
+	// InternalServerError.
+	ErrorRate float64
+	// UnprocessedRate is the fraction of the items in each BatchGetItem and
+	// BatchWriteItem request that are returned as unprocessed.
+	UnprocessedRate float64
+
+	// Operations limits fault injection to the named API operations, such
+	// as "GetItem". All operations are affected if it is empty.
+	Operations []string
+
+	// Seed makes the injected faults repeatable. A zero seed uses the
+	// current time.
+	Seed int64
+}
+
+// Client wraps a dynamostore.Client, injecting faults into its requests.
+type Client struct {
+	svc dynamostore.Client
+	cfg Config
+	ops map[string]bool
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// New returns a Client that injects the faults described by cfg into
+// requests sent to svc.
+func New(svc dynamostore.Client, cfg *Config) *Client {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c := &Client{
+		svc: svc,
+		cfg: *cfg,
+		rng: rand.New(rand.NewSource(seed)),
+	}
+	if len(cfg.Operations) > 0 {
+		c.ops = make(map[string]bool, len(cfg.Operations))
+		for _, op := range cfg.Operations {
+			c.ops[op] = true
+		}
+	}
+	return c
+}
+
+func (c *Client) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < rate
+}
+
+// inject delays the request and returns the error it should fail with, if
+// any.
+func (c *Client) inject(ctx context.Context, op string) error {
+	if c.ops != nil && !c.ops[op] {
+		return nil
+	}
+
+	delay := c.cfg.Latency
+	if c.cfg.Jitter > 0 {
+		c.mu.Lock()
+		delay += time.Duration(c.rng.Int63n(int64(c.cfg.Jitter)))
+		c.mu.Unlock()
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	switch {
+	case c.chance(c.cfg.ThrottleRate):
+		return &types.ProvisionedThroughputExceededException{
+			Message: aws.String("chaos: injected throttling"),
+		}
+	case c.chance(c.cfg.ErrorRate):
+		return &types.InternalServerError{
+			Message: aws.String("chaos: injected failure"),
+		}
+	}
+	return nil
+}
+
+// BatchGetItem implements dynamostore.Client. In addition to the faults
+// injected into every operation, some keys may be returned as unprocessed
+// without being read.
+func (c *Client) BatchGetItem(
+	ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+	if err := c.inject(ctx, "BatchGetItem"); err != nil {
+		return nil, err
+	}
+	if c.cfg.UnprocessedRate <= 0 || (c.ops != nil && !c.ops["BatchGetItem"]) {
+		return c.svc.BatchGetItem(ctx, params, optFns...)
+	}
+
+	processed := map[string]types.KeysAndAttributes{}
+	unprocessed := map[string]types.KeysAndAttributes{}
+	for table, request := range params.RequestItems {
+		p, u := request, request
+		p.Keys, u.Keys = nil, nil
+		for _, key := range request.Keys {
+			if c.chance(c.cfg.UnprocessedRate) {
+				u.Keys = append(u.Keys, key)
+			} else {
+				p.Keys = append(p.Keys, key)
+			}
+		}
+		if len(p.Keys) > 0 {
+			processed[table] = p
+		}
+		if len(u.Keys) > 0 {
+			unprocessed[table] = u
+		}
+	}
+
+	result := &dynamodb.BatchGetItemOutput{}
+	if len(processed) > 0 {
+		input := *params
+		input.RequestItems = processed
+		var err error
+		if result, err = c.svc.BatchGetItem(ctx, &input, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if len(unprocessed) > 0 {
+		if result.UnprocessedKeys == nil {
+			result.UnprocessedKeys = map[string]types.KeysAndAttributes{}
+		}
+		for table, request := range unprocessed {
+			if prev, ok := result.UnprocessedKeys[table]; ok {
+				request.Keys = append(prev.Keys, request.Keys...)
+			}
+			result.UnprocessedKeys[table] = request
+		}
+	}
+	return result, nil
+}
+
+// BatchWriteItem implements dynamostore.Client. In addition to the faults
+// injected into every operation, some requests may be returned as
+// unprocessed without being applied.
+func (c *Client) BatchWriteItem(
+	ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := c.inject(ctx, "BatchWriteItem"); err != nil {
+		return nil, err
+	}
+	if c.cfg.UnprocessedRate <= 0 || (c.ops != nil && !c.ops["BatchWriteItem"]) {
+		return c.svc.BatchWriteItem(ctx, params, optFns...)
+	}
+
+	processed := map[string][]types.WriteRequest{}
+	unprocessed := map[string][]types.WriteRequest{}
+	for table, requests := range params.RequestItems {
+		for _, r := range requests {
+			if c.chance(c.cfg.UnprocessedRate) {
+				unprocessed[table] = append(unprocessed[table], r)
+			} else {
+				processed[table] = append(processed[table], r)
+			}
+		}
+	}
+
+	result := &dynamodb.BatchWriteItemOutput{}
+	if len(processed) > 0 {
+		input := *params
+		input.RequestItems = processed
+		var err error
+		if result, err = c.svc.BatchWriteItem(ctx, &input, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if len(unprocessed) > 0 {
+		if result.UnprocessedItems == nil {
+			result.UnprocessedItems = map[string][]types.WriteRequest{}
+		}
+		for table, requests := range unprocessed {
+			result.UnprocessedItems[table] = append(result.UnprocessedItems[table], requests...)
+		}
+	}
+	return result, nil
+}
+
+// CreateTable implements dynamostore.Client.
+func (c *Client) CreateTable(
+	ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.CreateTableOutput, error) {
+	if err := c.inject(ctx, "CreateTable"); err != nil {
+		return nil, err
+	}
+	return c.svc.CreateTable(ctx, params, optFns...)
+}
+
+// DeleteItem implements dynamostore.Client.
+func (c *Client) DeleteItem(
+	ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteItemOutput, error) {
+	if err := c.inject(ctx, "DeleteItem"); err != nil {
+		return nil, err
+	}
+	return c.svc.DeleteItem(ctx, params, optFns...)
+}
+
+// DeleteTable implements dynamostore.Client.
+func (c *Client) DeleteTable(
+	ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteTableOutput, error) {
+	if err := c.inject(ctx, "DeleteTable"); err != nil {
+		return nil, err
+	}
+	return c.svc.DeleteTable(ctx, params, optFns...)
+}
+
+// DescribeTable implements dynamostore.Client.
+func (c *Client) DescribeTable(
+	ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTableOutput, error) {
+	if err := c.inject(ctx, "DescribeTable"); err != nil {
+		return nil, err
+	}
+	return c.svc.DescribeTable(ctx, params, optFns...)
+}
+
+// DescribeTimeToLive implements dynamostore.Client.
+func (c *Client) DescribeTimeToLive(
+	ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if err := c.inject(ctx, "DescribeTimeToLive"); err != nil {
+		return nil, err
+	}
+	return c.svc.DescribeTimeToLive(ctx, params, optFns...)
+}
+
+// GetItem implements dynamostore.Client.
+func (c *Client) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	if err := c.inject(ctx, "GetItem"); err != nil {
+		return nil, err
+	}
+	return c.svc.GetItem(ctx, params, optFns...)
+}
+
+// PutItem implements dynamostore.Client.
+func (c *Client) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	if err := c.inject(ctx, "PutItem"); err != nil {
+		return nil, err
+	}
+	return c.svc.PutItem(ctx, params, optFns...)
+}
+
+// PutResourcePolicy implements dynamostore.Client.
+func (c *Client) PutResourcePolicy(
+	ctx context.Context, params *dynamodb.PutResourcePolicyInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutResourcePolicyOutput, error) {
+	if err := c.inject(ctx, "PutResourcePolicy"); err != nil {
+		return nil, err
+	}
+	return c.svc.PutResourcePolicy(ctx, params, optFns...)
+}
+
+// Query implements dynamostore.Client.
+func (c *Client) Query(
+	ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+	if err := c.inject(ctx, "Query"); err != nil {
+		return nil, err
+	}
+	return c.svc.Query(ctx, params, optFns...)
+}
+
+// Scan implements dynamostore.Client.
+func (c *Client) Scan(
+	ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.ScanOutput, error) {
+	if err := c.inject(ctx, "Scan"); err != nil {
+		return nil, err
+	}
+	return c.svc.Scan(ctx, params, optFns...)
+}
+
+// TransactWriteItems implements dynamostore.Client.
+func (c *Client) TransactWriteItems(
+	ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := c.inject(ctx, "TransactWriteItems"); err != nil {
+		return nil, err
+	}
+	return c.svc.TransactWriteItems(ctx, params, optFns...)
+}
+
+// UpdateContributorInsights implements dynamostore.Client.
+func (c *Client) UpdateContributorInsights(
+	ctx context.Context, params *dynamodb.UpdateContributorInsightsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateContributorInsightsOutput, error) {
+	if err := c.inject(ctx, "UpdateContributorInsights"); err != nil {
+		return nil, err
+	}
+	return c.svc.UpdateContributorInsights(ctx, params, optFns...)
+}
+
+// UpdateItem implements dynamostore.Client.
+func (c *Client) UpdateItem(
+	ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateItemOutput, error) {
+	if err := c.inject(ctx, "UpdateItem"); err != nil {
+		return nil, err
+	}
+	return c.svc.UpdateItem(ctx, params, optFns...)
+}
+
+// UpdateTable implements dynamostore.Client.
+func (c *Client) UpdateTable(
+	ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTableOutput, error) {
+	if err := c.inject(ctx, "UpdateTable"); err != nil {
+		return nil, err
+	}
+	return c.svc.UpdateTable(ctx, params, optFns...)
+}
+
+// UpdateTimeToLive implements dynamostore.Client.
+func (c *Client) UpdateTimeToLive(
+	ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if err := c.inject(ctx, "UpdateTimeToLive"); err != nil {
+		return nil, err
+	}
+	return c.svc.UpdateTimeToLive(ctx, params, optFns...)
+}
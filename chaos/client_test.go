@@ -0,0 +1,134 @@
+package chaos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/chaos"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func newClient() *fake.Client {
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	return svc
+}
+
+func TestThrottling(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	store := dynamostore.New(chaos.New(newClient(), &chaos.Config{
+		ThrottleRate: 1,
+		Operations:   []string{"GetItem"},
+	}))
+
+	// when
+	commitErr := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+	_, _, findErr := store.Find("foo")
+
+	// then
+	require.NoError(commitErr)
+	var throttled *types.ProvisionedThroughputExceededException
+	require.True(errors.As(findErr, &throttled))
+}
+
+func TestErrors(t *testing.T) {
+	require := require.New(t)
+
+	store := dynamostore.New(chaos.New(newClient(), &chaos.Config{
+		ErrorRate: 0.5,
+		Seed:      1,
+	}))
+
+	failures := 0
+	for i := 0; i < 100; i++ {
+		if err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)); err != nil {
+			var internal *types.InternalServerError
+			require.True(errors.As(err, &internal))
+			failures++
+		}
+	}
+	require.InDelta(50, failures, 20)
+}
+
+func TestLatency(t *testing.T) {
+	require := require.New(t)
+
+	svc := chaos.New(newClient(), &chaos.Config{
+		Latency: time.Second,
+	})
+	store := dynamostore.New(svc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := store.Inspect(ctx, "foo")
+	require.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestUnprocessedItems(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	src := memstore.NewWithCleanupInterval(0)
+	expiry := time.Now().Add(time.Hour)
+	for _, token := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(src.Commit(token, encode(t, expiry), expiry))
+	}
+	svc := newClient()
+	store := dynamostore.New(chaos.New(svc, &chaos.Config{
+		UnprocessedRate: 0.5,
+		Seed:            1,
+	}))
+
+	// when
+	n, err := store.ImportFrom(context.Background(), src)
+
+	// then the store retries until every item is written
+	require.NoError(err)
+	require.Equal(6, n)
+	require.Equal(6, svc.Len(dynamostore.DefaultTableName))
+}
+
+func TestUnprocessedKeys(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := newClient()
+	other := dynamostore.New(svc)
+	expiry := time.Now().Add(time.Hour)
+	tokens := []string{"a", "b", "c", "d", "e", "f"}
+	for _, token := range tokens {
+		require.NoError(other.Commit(token, encode(t, expiry), expiry))
+	}
+	store := dynamostore.New(chaos.New(svc, &chaos.Config{
+		UnprocessedRate: 0.5,
+		Seed:            1,
+	}), dynamostore.WithCache(10, time.Hour))
+
+	// when
+	err := store.Prefetch(context.Background(), tokens)
+
+	// then the store retries until every session is cached
+	require.NoError(err)
+	for _, token := range tokens {
+		require.NoError(other.Delete(token))
+		_, exists, err := store.Find(token)
+		require.NoError(err)
+		require.True(exists, token)
+	}
+}
+
+func encode(t *testing.T, expiry time.Time) []byte {
+	data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{})
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,143 @@
+package dynamostore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+var _ Client = &dynamodb.Client{}
+
+// Client is the subset of the DynamoDB API used by DynamoStore. It is
+// implemented by *dynamodb.Client, and may be implemented by wrappers or
+// fakes for testing. Methods may be added as DynamoStore uses more of the
+// API.
+//
+// Like *dynamodb.Client, implementations must not retain the maps in a
+// request after returning, since DynamoStore reuses them.
+type Client interface {
+	BatchGetItem(
+		context.Context, *dynamodb.BatchGetItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.BatchGetItemOutput, error)
+
+	BatchWriteItem(
+		context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.BatchWriteItemOutput, error)
+
+	CreateTable(
+		context.Context, *dynamodb.CreateTableInput, ...func(*dynamodb.Options),
+	) (*dynamodb.CreateTableOutput, error)
+
+	DeleteItem(
+		context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.DeleteItemOutput, error)
+
+	DeleteTable(
+		context.Context, *dynamodb.DeleteTableInput, ...func(*dynamodb.Options),
+	) (*dynamodb.DeleteTableOutput, error)
+
+	DescribeTable(
+		context.Context, *dynamodb.DescribeTableInput, ...func(*dynamodb.Options),
+	) (*dynamodb.DescribeTableOutput, error)
+
+	DescribeTimeToLive(
+		context.Context, *dynamodb.DescribeTimeToLiveInput, ...func(*dynamodb.Options),
+	) (*dynamodb.DescribeTimeToLiveOutput, error)
+
+	GetItem(
+		context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.GetItemOutput, error)
+
+	PutItem(
+		context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.PutItemOutput, error)
+
+	PutResourcePolicy(
+		context.Context, *dynamodb.PutResourcePolicyInput, ...func(*dynamodb.Options),
+	) (*dynamodb.PutResourcePolicyOutput, error)
+
+	Query(
+		context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options),
+	) (*dynamodb.QueryOutput, error)
+
+	Scan(
+		context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options),
+	) (*dynamodb.ScanOutput, error)
+
+	TransactWriteItems(
+		context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options),
+	) (*dynamodb.TransactWriteItemsOutput, error)
+
+	UpdateContributorInsights(
+		context.Context, *dynamodb.UpdateContributorInsightsInput, ...func(*dynamodb.Options),
+	) (*dynamodb.UpdateContributorInsightsOutput, error)
+
+	UpdateItem(
+		context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.UpdateItemOutput, error)
+
+	UpdateTable(
+		context.Context, *dynamodb.UpdateTableInput, ...func(*dynamodb.Options),
+	) (*dynamodb.UpdateTableOutput, error)
+
+	UpdateTimeToLive(
+		context.Context, *dynamodb.UpdateTimeToLiveInput, ...func(*dynamodb.Options),
+	) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// ItemReader is the subset of the DynamoDB API used to read sessions. It is
+// implemented by *dynamodb.Client and by the Amazon DAX client.
+type ItemReader interface {
+	GetItem(
+		context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options),
+	) (*dynamodb.GetItemOutput, error)
+}
+
+// WithDAX routes Find through an Amazon DAX client, while writes and table
+// management continue to use the DynamoDB client.
+//
+// DAX only caches eventually consistent reads, so Find stops using strongly
+// consistent reads. A session may briefly appear stale or missing right after
+// it has been committed, especially if requests for the same session can be
+// handled by different instances of the application.
+func WithDAX(dax ItemReader) Option {
+	return func(s *DynamoStore) {
+		s.reader = dax
+		s.consistentRead = false
+	}
+}
+
+// WithAPIOptions adds smithy-go middleware to every request the store sends,
+// in addition to any middleware registered on the client. It can be used to
+// mutate requests, inject headers, or capture responses without wrapping the
+// client.
+func WithAPIOptions(fns ...func(*middleware.Stack) error) Option {
+	return func(s *DynamoStore) {
+		s.optFns = append(s.optFns, func(o *dynamodb.Options) {
+			o.APIOptions = append(o.APIOptions, fns...)
+		})
+	}
+}
+
+// WithEndpoint sends every request the store makes to url, such as
+// "http://localhost:8000" for DynamoDB Local or "http://localhost:4566" for
+// LocalStack, instead of the regional DynamoDB endpoint. The hostname is used
+// as is, without adding the region or other prefixes.
+func WithEndpoint(url string) Option {
+	return func(s *DynamoStore) {
+		s.endpoint = url
+		s.optFns = append(s.optFns, func(o *dynamodb.Options) {
+			o.BaseEndpoint = aws.String(url)
+		})
+	}
+}
+
+// WithAppID appends "app/<name>" to the User-Agent of every request the store
+// sends, so the traffic of a specific service can be identified in CloudTrail
+// logs and AWS support cases.
+func WithAppID(name string) Option {
+	return WithAPIOptions(awsmiddleware.AddUserAgentKeyValue("app", name))
+}
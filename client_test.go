@@ -0,0 +1,160 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/require"
+)
+
+// stubHTTPClient records requests sent by a real DynamoDB client, and
+// responds to each with an empty result.
+type stubHTTPClient struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (c *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	body := []byte("{}")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type": {"application/x-amz-json-1.0"},
+			"X-Amz-Crc32":  {strconv.FormatUint(uint64(crc32.ChecksumIEEE(body)), 10)},
+		},
+		Body:    ioutil.NopCloser(bytes.NewReader(body)),
+		Request: req,
+	}, nil
+}
+
+func (c *stubHTTPClient) last() *http.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests[len(c.requests)-1]
+}
+
+func stubConfig(httpClient aws.HTTPClient) aws.Config {
+	return aws.Config{
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", "token"),
+		HTTPClient:  httpClient,
+		Region:      "us-west-2",
+	}
+}
+
+type fakeDAX struct {
+	*fakeClient
+	consistent []bool
+}
+
+func (c *fakeDAX) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.consistent = append(c.consistent, aws.ToBool(params.ConsistentRead))
+	return c.fakeClient.GetItem(ctx, params, optFns...)
+}
+
+func TestWithDAX(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	dax := &fakeDAX{fakeClient: svc}
+	store := newStore(svc, DefaultTableName, []Option{WithDAX(dax)})
+
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Minute))
+	require.NoError(err)
+
+	actual, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), actual)
+
+	require.Equal([]bool{false}, dax.consistent)
+	require.Equal(1, svc.count("PutItem"))
+}
+
+func TestWithAPIOptions(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	httpClient := &stubHTTPClient{}
+	svc := dynamodb.NewFromConfig(stubConfig(httpClient))
+	addHeader := func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("AddHeader", func(
+			ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+		) (middleware.BuildOutput, middleware.Metadata, error) {
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Header.Set("X-Test", "dynamostore")
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+	}
+	store := New(svc, WithAPIOptions(addHeader))
+
+	// when
+	_, _, err := store.Find("foo")
+
+	// then
+	require.NoError(err)
+	require.Equal("dynamostore", httpClient.last().Header.Get("X-Test"))
+
+	// when the option isn't used
+	_, _, err = New(svc).Find("foo")
+
+	// then
+	require.NoError(err)
+	require.Empty(httpClient.last().Header.Get("X-Test"))
+}
+
+func TestNewFromConfig(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfigWithTableName(stubConfig(httpClient), "sessions")
+
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	req := httpClient.last()
+	require.Equal("dynamodb.us-west-2.amazonaws.com", req.URL.Host)
+	require.Equal("DynamoDB_20120810.GetItem", req.Header.Get("X-Amz-Target"))
+
+	store = NewFromConfig(stubConfig(httpClient))
+	require.Equal(DefaultTableName, aws.ToString(store.table))
+}
+
+func TestWithEndpoint(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfig(stubConfig(httpClient), WithEndpoint("http://localhost:8000"))
+
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	require.Equal("http://localhost:8000/", httpClient.last().URL.String())
+}
+
+func TestWithAppID(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfig(stubConfig(httpClient), WithAppID("checkout"))
+
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	require.Contains(httpClient.last().Header.Get("User-Agent"), "app/checkout")
+}
@@ -0,0 +1,46 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithClock(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+	})
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Second)))
+
+	// when the expiry hasn't been reached
+	_, exists, err := store.Find("foo")
+	// then the session is found
+	require.NoError(err)
+	require.True(exists)
+
+	// when the clock reaches the expiry
+	now = now.Add(time.Second)
+	_, exists, err = store.Find("foo")
+	// then the session is still found
+	require.NoError(err)
+	require.True(exists)
+
+	// when the clock passes the expiry
+	now = now.Add(time.Nanosecond)
+	_, exists, err = store.Find("foo")
+	// then the session has expired
+	require.NoError(err)
+	require.False(exists)
+
+	var buf bytes.Buffer
+	n, err := store.Export(context.Background(), &buf)
+	require.NoError(err)
+	require.Equal(0, n)
+}
@@ -0,0 +1,137 @@
+package dynamostore
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CloudFormationLogicalID is the logical ID of the table resource in the
+// template returned by CloudFormationTemplate.
+const CloudFormationLogicalID = "SessionTable"
+
+// CloudFormationTemplate returns a CloudFormation template, as indented
+// JSON, that defines the session store table the same way CreateTable would:
+// with the same key schema, TTL attribute, billing mode, and tags. The
+// template can also be embedded in a larger template or SAM application.
+func (s *DynamoStore) CloudFormationTemplate() ([]byte, error) {
+	input := s.createTableInput()
+
+	props := map[string]interface{}{
+		"TableName":   aws.ToString(input.TableName),
+		"BillingMode": string(input.BillingMode),
+		"TimeToLiveSpecification": map[string]interface{}{
+			"AttributeName": s.ttlAttribute,
+			"Enabled":       true,
+		},
+	}
+
+	attrs := make([]map[string]string, len(input.AttributeDefinitions))
+	for i, attr := range input.AttributeDefinitions {
+		attrs[i] = map[string]string{
+			"AttributeName": aws.ToString(attr.AttributeName),
+			"AttributeType": string(attr.AttributeType),
+		}
+	}
+	props["AttributeDefinitions"] = attrs
+
+	props["KeySchema"] = cloudFormationKeySchema(input.KeySchema)
+
+	if pt := input.ProvisionedThroughput; pt != nil {
+		props["ProvisionedThroughput"] = cloudFormationThroughput(pt)
+	}
+
+	if warm := s.billing.warmThroughput(); warm != nil {
+		props["WarmThroughput"] = cloudFormationUnits(map[string]*int64{
+			"ReadUnitsPerSecond":  warm.ReadUnitsPerSecond,
+			"WriteUnitsPerSecond": warm.WriteUnitsPerSecond,
+		})
+	}
+	if limits := s.billing.onDemandThroughput(false); limits != nil {
+		props["OnDemandThroughput"] = cloudFormationUnits(map[string]*int64{
+			"MaxReadRequestUnits":  limits.MaxReadRequestUnits,
+			"MaxWriteRequestUnits": limits.MaxWriteRequestUnits,
+		})
+	}
+	if s.contributorInsights {
+		props["ContributorInsightsSpecification"] = map[string]interface{}{"Enabled": true}
+	}
+	if s.resourcePolicy != "" {
+		props["ResourcePolicy"] = map[string]interface{}{
+			"PolicyDocument": json.RawMessage(s.resourcePolicy),
+		}
+	}
+
+	if len(input.GlobalSecondaryIndexes) > 0 {
+		indexes := make([]map[string]interface{}, len(input.GlobalSecondaryIndexes))
+		for i, gsi := range input.GlobalSecondaryIndexes {
+			projection := map[string]interface{}{
+				"ProjectionType": string(gsi.Projection.ProjectionType),
+			}
+			if len(gsi.Projection.NonKeyAttributes) > 0 {
+				projection["NonKeyAttributes"] = gsi.Projection.NonKeyAttributes
+			}
+			index := map[string]interface{}{
+				"IndexName":  aws.ToString(gsi.IndexName),
+				"KeySchema":  cloudFormationKeySchema(gsi.KeySchema),
+				"Projection": projection,
+			}
+			if pt := gsi.ProvisionedThroughput; pt != nil {
+				index["ProvisionedThroughput"] = cloudFormationThroughput(pt)
+			}
+			indexes[i] = index
+		}
+		props["GlobalSecondaryIndexes"] = indexes
+	}
+
+	if len(input.Tags) > 0 {
+		tags := make([]map[string]string, len(input.Tags))
+		for i, tag := range input.Tags {
+			tags[i] = map[string]string{
+				"Key":   aws.ToString(tag.Key),
+				"Value": aws.ToString(tag.Value),
+			}
+		}
+		props["Tags"] = tags
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Resources": map[string]interface{}{
+			CloudFormationLogicalID: map[string]interface{}{
+				"Type":       "AWS::DynamoDB::Table",
+				"Properties": props,
+			},
+		},
+	}, "", "  ")
+}
+
+func cloudFormationKeySchema(schema []types.KeySchemaElement) []map[string]string {
+	keys := make([]map[string]string, len(schema))
+	for i, key := range schema {
+		keys[i] = map[string]string{
+			"AttributeName": aws.ToString(key.AttributeName),
+			"KeyType":       string(key.KeyType),
+		}
+	}
+	return keys
+}
+
+// cloudFormationUnits omits the units that aren't set.
+func cloudFormationUnits(units map[string]*int64) map[string]int64 {
+	props := make(map[string]int64, len(units))
+	for name, n := range units {
+		if n != nil {
+			props[name] = *n
+		}
+	}
+	return props
+}
+
+func cloudFormationThroughput(pt *types.ProvisionedThroughput) map[string]int64 {
+	return map[string]int64{
+		"ReadCapacityUnits":  aws.ToInt64(pt.ReadCapacityUnits),
+		"WriteCapacityUnits": aws.ToInt64(pt.WriteCapacityUnits),
+	}
+}
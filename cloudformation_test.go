@@ -0,0 +1,57 @@
+package dynamostore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudFormationTemplate(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), "sessions", []Option{
+		WithTTLAttribute("expires"),
+		WithProvisionedThroughput(5, 10),
+		WithTags(map[string]string{"team": "web"}),
+	})
+	b, err := store.CloudFormationTemplate()
+	require.NoError(err)
+	require.JSONEq(`{
+	  "AWSTemplateFormatVersion": "2010-09-09",
+	  "Resources": {
+	    "SessionTable": {
+	      "Type": "AWS::DynamoDB::Table",
+	      "Properties": {
+	        "TableName": "sessions",
+	        "BillingMode": "PROVISIONED",
+	        "AttributeDefinitions": [{"AttributeName": "token", "AttributeType": "S"}],
+	        "KeySchema": [{"AttributeName": "token", "KeyType": "HASH"}],
+	        "ProvisionedThroughput": {"ReadCapacityUnits": 5, "WriteCapacityUnits": 10},
+	        "TimeToLiveSpecification": {"AttributeName": "expires", "Enabled": true},
+	        "Tags": [{"Key": "team", "Value": "web"}]
+	      }
+	    }
+	  }
+	}`, string(b))
+
+	store = newStore(newFakeClient(), DefaultTableName, nil)
+	b, err = store.CloudFormationTemplate()
+	require.NoError(err)
+	require.Contains(string(b), `"PAY_PER_REQUEST"`)
+	require.NotContains(string(b), "ProvisionedThroughput")
+	require.NotContains(string(b), "Tags")
+
+	store = newStore(newFakeClient(), DefaultTableName, []Option{WithWarmThroughput(12000, 0)})
+	b, err = store.CloudFormationTemplate()
+	require.NoError(err)
+	require.Contains(string(b), `"WarmThroughput": {
+          "ReadUnitsPerSecond": 12000
+        }`)
+
+	store = newStore(newFakeClient(), DefaultTableName, []Option{WithMaxOnDemandThroughput(0, 500)})
+	b, err = store.CloudFormationTemplate()
+	require.NoError(err)
+	require.Contains(string(b), `"OnDemandThroughput": {
+          "MaxWriteRequestUnits": 500
+        }`)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mrand "math/rand"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// capacityClient records the capacity consumed by reads and writes.
+type capacityClient struct {
+	dynamostore.Client
+
+	mu         sync.Mutex
+	readUnits  float64
+	writeUnits float64
+}
+
+func (c *capacityClient) consumed(units *float64, cc *types.ConsumedCapacity) {
+	if cc == nil {
+		return
+	}
+	c.mu.Lock()
+	*units += aws.ToFloat64(cc.CapacityUnits)
+	c.mu.Unlock()
+}
+
+func (c *capacityClient) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	result, err := c.Client.GetItem(ctx, &input, optFns...)
+	if err == nil {
+		c.consumed(&c.readUnits, result.ConsumedCapacity)
+	}
+	return result, err
+}
+
+func (c *capacityClient) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	result, err := c.Client.PutItem(ctx, &input, optFns...)
+	if err == nil {
+		c.consumed(&c.writeUnits, result.ConsumedCapacity)
+	}
+	return result, err
+}
+
+// latencies collects operation latencies.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	errors  int
+}
+
+func (l *latencies) add(d time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.errors++
+		return
+	}
+	l.samples = append(l.samples, d)
+}
+
+// percentile returns the latency below which p percent of samples fall.
+// The samples must be sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) < 1 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func bench(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	fs := newFlagSet("bench")
+	sf.register(fs)
+	duration := fs.Duration("duration", 30*time.Second, "how long to run the benchmark")
+	concurrency := fs.Int("concurrency", 8, "number of concurrent `workers`")
+	readRatio := fs.Float64("read-ratio", 0.8, "fraction of operations that are reads")
+	sessions := fs.Int("sessions", 1000, "number of distinct `sessions` to use")
+	size := fs.Int("size", 1024, "session data size in `bytes`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *concurrency < 1:
+		return errors.New("-concurrency must be at least 1")
+	case *readRatio < 0 || *readRatio > 1:
+		return errors.New("-read-ratio must be between 0 and 1")
+	case *sessions < 1:
+		return errors.New("-sessions must be at least 1")
+	}
+
+	svc, err := sf.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	client := &capacityClient{Client: svc}
+	store := dynamostore.NewWithTableName(client, sf.table, sf.options()...)
+
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	tokens := make([]string, *sessions)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("bench-%s-%d", hex.EncodeToString(prefix), i)
+	}
+	data := make([]byte, *size)
+	expiry := time.Now().Add(*duration + time.Hour)
+
+	fmt.Fprintf(stdout, "writing %d sessions...\n", len(tokens))
+	for _, token := range tokens {
+		if err := store.Commit(token, data, expiry); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		for _, token := range tokens {
+			_ = store.Delete(token)
+		}
+	}()
+
+	client.mu.Lock()
+	client.readUnits, client.writeUnits = 0, 0
+	client.mu.Unlock()
+
+	fmt.Fprintf(stdout, "running for %s...\n", *duration)
+	var reads, writes latencies
+	deadline := time.Now().Add(*duration)
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := mrand.New(mrand.NewSource(seed))
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				token := tokens[rng.Intn(len(tokens))]
+				start := time.Now()
+				if rng.Float64() < *readRatio {
+					_, _, err := store.Find(token)
+					reads.add(time.Since(start), err)
+				} else {
+					err := store.Commit(token, data, expiry)
+					writes.add(time.Since(start), err)
+				}
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tOPS\tOPS/SEC\tERRORS\tP50\tP90\tP99\tMAX\tUNITS\tUNITS/SEC\t")
+	seconds := duration.Seconds()
+	for _, row := range []struct {
+		name  string
+		l     *latencies
+		units float64
+	}{
+		{"read", &reads, client.readUnits},
+		{"write", &writes, client.writeUnits},
+	} {
+		sorted := row.l.samples
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%s\t%s\t%s\t%s\t%.1f\t%.1f\t\n",
+			row.name, len(sorted), float64(len(sorted))/seconds, row.l.errors,
+			percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99), percentile(sorted, 100),
+			row.units, row.units/seconds,
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func expired(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("expired")
+	sf.register(fs)
+	cf.register(fs)
+	sample := fs.Int("sample", 1000, "number of `sessions` to sample (0 scans the whole table)")
+	grace := fs.Duration("grace", 0, "expiry grace `period` given to WithExpiryGracePeriod, if any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := append(cf.options(), dynamostore.WithExpiryGracePeriod(*grace))
+	store, err := sf.newStore(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	report, err := store.ReportExpired(ctx, *sample)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Items (approx):\t%d\n", report.ItemCount)
+	fmt.Fprintf(tw, "Scanned:\t%d\n", report.Scanned)
+	fmt.Fprintf(tw, "Expired but present:\t%d (%d bytes)\n", report.Expired, report.ExpiredBytes)
+	if !report.Complete {
+		fmt.Fprintf(tw, "Expired but present (est):\t%d (%d bytes)\n",
+			report.EstimatedExpired(), report.EstimatedExpiredBytes(),
+		)
+	}
+	if !report.OldestExpiry.IsZero() {
+		fmt.Fprintf(tw, "Oldest expiry:\t%s (%s ago)\n",
+			report.OldestExpiry.UTC().Format(time.RFC3339),
+			time.Since(report.OldestExpiry).Round(time.Minute),
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// storeFlags are accepted by every command that uses the session table.
+type storeFlags struct {
+	table    string
+	region   string
+	profile  string
+	endpoint string
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func (f *storeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.table, "table", dynamostore.DefaultTableName, "session table `name`")
+	fs.StringVar(&f.region, "region", "", "AWS `region` (default from the environment)")
+	fs.StringVar(&f.profile, "profile", "", "AWS shared config `profile`")
+	fs.StringVar(&f.endpoint, "endpoint", "", "DynamoDB endpoint `URL`, such as DynamoDB Local")
+}
+
+func (f *storeFlags) newStore(ctx context.Context, opts ...dynamostore.Option) (*dynamostore.DynamoStore, error) {
+	svc, err := f.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := dynamostore.NewWithTableName(svc, f.table, append(f.options(), opts...)...)
+	if err := store.Validate(); err != nil {
+		store.Close(ctx)
+		return nil, err
+	}
+	return store, nil
+}
+
+func (f *storeFlags) newClient(ctx context.Context) (*dynamodb.Client, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if f.region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(f.region))
+	}
+	if f.profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(f.profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(cfg), nil
+}
+
+// options returns the store options selected by the flags.
+func (f *storeFlags) options() []dynamostore.Option {
+	if f.endpoint == "" {
+		return nil
+	}
+	return []dynamostore.Option{dynamostore.WithEndpoint(f.endpoint)}
+}
+
+// tagFlags collects repeated key=value flags.
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + t[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (t tagFlags) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx < 1 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	t[value[:idx]] = value[idx+1:]
+	return nil
+}
+
+// capacityFlags limit the capacity a command may consume.
+type capacityFlags struct {
+	readUnits  float64
+	writeUnits float64
+}
+
+func (f *capacityFlags) register(fs *flag.FlagSet) {
+	fs.Float64Var(&f.readUnits, "read-limit", 0, "maximum read capacity `units` per second (default unlimited)")
+	fs.Float64Var(&f.writeUnits, "write-limit", 0, "maximum write capacity `units` per second (default unlimited)")
+}
+
+func (f *capacityFlags) options() []dynamostore.Option {
+	if f.readUnits <= 0 && f.writeUnits <= 0 {
+		return nil
+	}
+	return []dynamostore.Option{
+		dynamostore.WithCapacityLimit(f.readUnits, f.writeUnits),
+	}
+}
@@ -0,0 +1,94 @@
+// Command dynamostore manages the DynamoDB tables used by dynamostore.
+//
+// Usage:
+//
+//	dynamostore <command> [flags]
+//
+// Run "dynamostore help" for a list of commands, or
+// "dynamostore <command> -h" for the flags a command accepts.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// errUsage is returned when the command line is invalid. The usage message
+// has already been printed.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string, stdout io.Writer) error
+}
+
+func commands() []*command {
+	return []*command{
+		{"bench", "measure latency and consumed capacity for a read/write mix", bench},
+		{"cloudformation", "print a CloudFormation template for the session table", cloudFormation},
+		{"create-table", "create the session table", createTable},
+		{"delete-table", "delete the session table", deleteTable},
+		{"describe", "describe the session table", describe},
+		{"expired", "report expired sessions not yet removed by TTL", expired},
+		{"export", "write sessions to a JSON lines file", export},
+		{"get", "show the stored attributes of a session", get},
+		{"iam-policy", "print the IAM policy needed to use the session table", iamPolicy},
+		{"import", "read sessions from a JSON lines file", importSessions},
+		{"list", "list sessions by token hash", list},
+		{"purge-expired", "delete expired sessions not yet removed by TTL", purgeExpired},
+		{"revoke", "revoke sessions by token or user", revoke},
+		{"stats", "report session counts, sizes, and TTL configuration", stats},
+		{"terraform", "print a Terraform resource for the session table", terraform},
+	}
+}
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) < 1 {
+		usage(stderr)
+		return errUsage
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(stdout)
+		return nil
+	}
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(ctx, args[1:], stdout)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command: %s\n\n", name)
+	usage(stderr)
+	return errUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dynamostore <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestRun(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"help"}, &stdout, &stderr)
+	require.NoError(err)
+	require.Contains(stdout.String(), "create-table")
+
+	stdout.Reset()
+	err = run(context.Background(), []string{"bogus"}, &stdout, &stderr)
+	require.True(errors.Is(err, errUsage))
+	require.Contains(stderr.String(), "unknown command: bogus")
+
+	err = run(context.Background(), []string{"delete-table"}, &stdout, &stderr)
+	require.EqualError(err, "refusing to delete table without -yes")
+
+	stdout.Reset()
+	err = run(context.Background(), []string{"cloudformation", "-table", "sessions"}, &stdout, &stderr)
+	require.NoError(err)
+	require.Contains(stdout.String(), `"TableName": "sessions"`)
+
+	err = run(context.Background(), []string{"revoke"}, &stdout, &stderr)
+	require.EqualError(err, "expected at least one -token or -user")
+
+	err = run(context.Background(), []string{"revoke", "-user", "alice"}, &stdout, &stderr)
+	require.EqualError(err, "-user requires -subject-key")
+}
+
+func TestRevokeSessions(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := fake.New(
+		dynamostore.WithSubjectKey("user"),
+		dynamostore.WithRevocationMarkers(time.Hour),
+	)
+	expiry := time.Now().Add(time.Hour)
+	for token, user := range map[string]string{"a1": "alice", "a2": "alice", "b1": "bob", "c1": "carol"} {
+		data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"user": user})
+		require.NoError(err)
+		require.NoError(store.Commit(token, data, expiry))
+	}
+
+	// when
+	n, err := revokeSessions(ctx, store, []string{"b1"}, []string{"alice"}, true)
+
+	// then
+	require.NoError(err)
+	require.Equal(3, n)
+	for token, expected := range map[string]bool{"a1": false, "a2": false, "b1": false, "c1": true} {
+		_, exists, err := store.Find(token)
+		require.NoError(err)
+		require.Equal(expected, exists, token)
+	}
+
+	// and the revoked token can't be committed again
+	require.NoError(store.Commit("b1", []byte("data"), expiry))
+	_, exists, err := store.Find("b1")
+	require.NoError(err)
+	require.False(exists)
+}
+
+func TestTagFlags(t *testing.T) {
+	require := require.New(t)
+
+	tags := tagFlags{}
+	require.NoError(tags.Set("env=prod"))
+	require.NoError(tags.Set("team=a=b"))
+	require.Error(tags.Set("=value"))
+	require.Error(tags.Set("novalue"))
+	require.Equal("env=prod,team=a=b", tags.String())
+}
+
+func TestStringFlags(t *testing.T) {
+	require := require.New(t)
+
+	var values stringFlags
+	require.NoError(values.Set("foo"))
+	require.NoError(values.Set("bar"))
+	require.Error(values.Set(""))
+	require.Equal("foo,bar", values.String())
+}
+
+func TestWriteStats(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now()
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithExpiryGracePeriod(time.Minute),
+	)
+	require.NoError(store.Commit("expired", []byte("data"), now.Add(-time.Hour)))
+	require.NoError(store.Commit("grace", []byte("data"), now.Add(-time.Second)))
+	require.NoError(store.Commit("active", []byte("data"), now.Add(time.Hour)))
+	require.NoError(store.Commit("other", []byte("data"), now.Add(time.Hour)))
+	info, err := store.DescribeTable()
+	require.NoError(err)
+	stats, err := store.TableStats(ctx, 0)
+	require.NoError(err)
+
+	var buf bytes.Buffer
+	require.NoError(writeStats(&buf, info, stats))
+	require.Regexp(`Sampled sessions: +4\n`, buf.String())
+	require.Regexp(`Expired but present: +1 of 4 sampled \(25\.0%\)\n`, buf.String())
+	require.NotContains(buf.String(), "(est)")
+}
+
+func TestProgressWriter(t *testing.T) {
+	require := require.New(t)
+
+	var out, progress bytes.Buffer
+	w := &progressWriter{Writer: &out}
+	w.out = &progress
+	w.verb = "wrote"
+
+	_, err := w.Write([]byte("a\nb\n"))
+	require.NoError(err)
+	_, err = w.Write([]byte("c\n"))
+	require.NoError(err)
+	require.Equal(3, w.lines)
+	require.Equal("a\nb\nc\n", out.String())
+	require.Equal("wrote 2 records...\n", progress.String())
+}
+
+func TestPercentile(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(time.Duration(0), percentile(nil, 50))
+
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	require.Equal(time.Millisecond, percentile(sorted, 0))
+	require.Equal(50*time.Millisecond, percentile(sorted, 50))
+	require.Equal(99*time.Millisecond, percentile(sorted, 99))
+	require.Equal(100*time.Millisecond, percentile(sorted, 100))
+}
+
+func TestFormatAttributeValue(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(`S "foo"`, formatAttributeValue(&types.AttributeValueMemberS{Value: "foo"}))
+	require.Equal("N 42", formatAttributeValue(&types.AttributeValueMemberN{Value: "42"}))
+	require.Equal("B YmFy (3 bytes)", formatAttributeValue(&types.AttributeValueMemberB{Value: []byte("bar")}))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func iamPolicy(ctx context.Context, args []string, stdout io.Writer) error {
+	var cfg dynamostore.PolicyConfig
+	fs := newFlagSet("iam-policy")
+	table := fs.String("table", dynamostore.DefaultTableName, "session table `name`")
+	region := fs.String("region", "", "AWS `region` of the table")
+	account := fs.String("account", "", "AWS `account` ID that owns the table")
+	fs.StringVar(&cfg.TableARN, "table-arn", "", "session table `ARN`, instead of -table, -region, and -account")
+	fs.BoolVar(&cfg.CreateTable, "create-table", false, "allow creating the table")
+	fs.BoolVar(&cfg.DeleteTable, "delete-table", false, "allow deleting the table")
+	fs.BoolVar(&cfg.Maintenance, "maintenance", false,
+		"allow bulk operations such as export, import, and purge-expired")
+	fs.StringVar(&cfg.DAXClusterARN, "dax-arn", "", "DAX cluster `ARN` used for reads")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if cfg.TableARN == "" {
+		if *region == "" || *account == "" {
+			return errors.New("expected -table-arn, or -region and -account")
+		}
+		cfg.TableARN = dynamostore.TableARN(*region, *account, *table)
+	}
+	policy, err := dynamostore.IAMPolicy(&cfg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, string(policy))
+	return err
+}
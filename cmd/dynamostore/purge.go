@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+func purgeExpired(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("purge-expired")
+	sf.register(fs)
+	cf.register(fs)
+	parallelism := fs.Int("parallelism", 4, "number of table `segments` to scan in parallel")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx, cf.options()...)
+	if err != nil {
+		return err
+	}
+	n, err := store.DeleteExpired(ctx, *parallelism)
+	fmt.Fprintf(stdout, "deleted %d expired sessions\n", n)
+	return err
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// stringFlags collects repeated string flags.
+type stringFlags []string
+
+func (s *stringFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringFlags) Set(value string) error {
+	if value == "" {
+		return errors.New("value must not be empty")
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+func revoke(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	var tokens, users stringFlags
+	fs := newFlagSet("revoke")
+	sf.register(fs)
+	cf.register(fs)
+	fs.Var(&tokens, "token", "session `token` to revoke (may be repeated)")
+	fs.Var(&users, "user", "revoke every session of the `user` (may be repeated, requires -subject-key)")
+	subjectKey := fs.String("subject-key", "", "session data `key` holding the user, as given to WithSubjectKey")
+	subjectIndex := fs.Bool("subject-index", false, "find sessions with the index created by WithSubjectIndex")
+	retention := fs.Duration("retention", 0, "retention `period` given to WithRetention, if any")
+	markers := fs.Duration("revocation-markers", 0, "marker `ttl` given to WithRevocationMarkers, if any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(tokens) < 1 && len(users) < 1 {
+		return errors.New("expected at least one -token or -user")
+	}
+	if len(users) > 0 && *subjectKey == "" {
+		return errors.New("-user requires -subject-key")
+	}
+
+	opts := cf.options()
+	if *subjectKey != "" {
+		opts = append(opts, dynamostore.WithSubjectKey(*subjectKey))
+	}
+	if *subjectIndex {
+		opts = append(opts, dynamostore.WithSubjectIndex())
+	}
+	if *retention > 0 {
+		opts = append(opts, dynamostore.WithRetention(*retention))
+	}
+	if *markers > 0 {
+		opts = append(opts, dynamostore.WithRevocationMarkers(*markers))
+	}
+	store, err := sf.newStore(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	n, err := revokeSessions(ctx, store, tokens, users, *retention > 0 || *markers > 0)
+	fmt.Fprintf(stdout, "revoked %d sessions\n", n)
+	return err
+}
+
+// revokeSessions revokes the sessions with the given tokens, and every
+// session of the given users, and returns the number revoked. Tokens are
+// revoked with Revoke when the table keeps tombstones, so in-flight requests
+// can't bring the sessions back.
+func revokeSessions(
+	ctx context.Context, store *dynamostore.DynamoStore, tokens, users []string, tombstones bool,
+) (int, error) {
+	n := 0
+	for _, token := range tokens {
+		var err error
+		if tombstones {
+			err = store.Revoke(ctx, token)
+		} else {
+			err = store.DeleteCtx(ctx, token)
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	for _, user := range users {
+		deleted, err := store.DeleteBySubject(ctx, user)
+		n += deleted
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
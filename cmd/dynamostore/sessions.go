@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// errStop ends iteration early without reporting an error.
+var errStop = errors.New("stop")
+
+func list(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("list")
+	sf.register(fs)
+	cf.register(fs)
+	limit := fs.Int("limit", 0, "stop after `n` sessions (default all)")
+	pageSize := fs.Int("page-size", 50, "print a header every `n` sessions")
+	expired := fs.Bool("expired", false, "include expired sessions not yet removed by TTL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx, cf.options()...)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	n := 0
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	err = store.ForEachSession(ctx, func(info *dynamostore.SessionInfo) error {
+		isExpired := info.Expiry.Before(now)
+		if isExpired && !*expired {
+			return nil
+		}
+		if *pageSize > 0 && n%*pageSize == 0 {
+			if n > 0 {
+				tw.Flush()
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintln(tw, "TOKEN HASH\tEXPIRY\tSIZE")
+		}
+		expiry := info.Expiry.Format(time.RFC3339)
+		if isExpired {
+			expiry += " (expired)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", info.TokenHash, expiry, info.Size)
+		n++
+		if *limit > 0 && n >= *limit {
+			return errStop
+		}
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+	if flushErr := tw.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func get(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	fs := newFlagSet("get")
+	sf.register(fs)
+	raw := fs.Bool("raw", false, "only print the stored attributes, without decoding the session")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one session token")
+	}
+
+	store, err := sf.newStore(ctx)
+	if err != nil {
+		return err
+	}
+	details, err := store.Inspect(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if details == nil {
+		return errors.New("session not found")
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Attributes:")
+	names := make([]string, 0, len(details.Attributes))
+	for name := range details.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, formatAttributeValue(details.Attributes[name]))
+	}
+	if !*raw {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Decoded:")
+		printDecoded(tw, details)
+	}
+	return tw.Flush()
+}
+
+func printDecoded(w io.Writer, details *dynamostore.SessionDetails) {
+	fmt.Fprintf(w, "  expiry\t%s\n", details.Expiry.Format(time.RFC3339))
+	deadline, values, err := scs.GobCodec{}.Decode(details.Data)
+	if err != nil {
+		fmt.Fprintf(w, "  error\tunable to decode session data: %s\n", err)
+		return
+	}
+	fmt.Fprintf(w, "  deadline\t%s\n", deadline.Format(time.RFC3339))
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  values[%q]\t%#v\n", k, values[k])
+	}
+}
+
+func formatAttributeValue(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("B %s (%d bytes)", base64.StdEncoding.EncodeToString(v.Value), len(v.Value))
+	case *types.AttributeValueMemberBOOL:
+		return fmt.Sprintf("BOOL %t", v.Value)
+	case *types.AttributeValueMemberN:
+		return "N " + v.Value
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberS:
+		return fmt.Sprintf("S %q", v.Value)
+	case *types.AttributeValueMemberSS:
+		return fmt.Sprintf("SS %q", v.Value)
+	case *types.AttributeValueMemberNS:
+		return "NS [" + strings.Join(v.Value, " ") + "]"
+	default:
+		return fmt.Sprintf("%T", av)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func stats(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("stats")
+	sf.register(fs)
+	cf.register(fs)
+	sample := fs.Int("sample", dynamostore.DefaultStatsSample, "number of `sessions` to sample")
+	grace := fs.Duration("grace", 0, "expiry grace `period` given to WithExpiryGracePeriod, if any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := append(cf.options(), dynamostore.WithExpiryGracePeriod(*grace))
+	store, err := sf.newStore(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	info, err := store.DescribeTable()
+	if err != nil {
+		return err
+	}
+	tableStats, err := store.TableStats(ctx, *sample)
+	if err != nil {
+		return err
+	}
+	return writeStats(stdout, info, tableStats)
+}
+
+// writeStats prints the description of the table and the statistics of its
+// sampled sessions.
+func writeStats(w io.Writer, info *dynamostore.TableInfo, stats *dynamostore.TableStats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Items (approx):\t%d\n", info.ItemCount)
+	fmt.Fprintf(tw, "Size (approx):\t%d bytes\n", info.SizeBytes)
+	fmt.Fprintf(tw, "TTL attribute:\t%s\n", info.TTLAttribute)
+	fmt.Fprintf(tw, "TTL status:\t%s\n", info.TTLStatus)
+	fmt.Fprintf(tw, "Sampled sessions:\t%d\n", stats.Sampled)
+	if stats.Sampled > 0 {
+		fmt.Fprintf(tw, "Sampled size:\t%.0f bytes avg\n", stats.AverageItemSize)
+		fmt.Fprintf(tw, "Expired but present:\t%.0f of %d sampled (%.1f%%)\n",
+			stats.ExpiredRatio*float64(stats.Sampled), stats.Sampled, 100*stats.ExpiredRatio,
+		)
+		if !stats.Complete {
+			fmt.Fprintf(tw, "Expired but present (est):\t%d\n", stats.ExpiredItems())
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// tableFlags describe the schema of a new session table.
+type tableFlags struct {
+	readUnits    int64
+	writeUnits   int64
+	warmReads    int64
+	warmWrites   int64
+	maxReads     int64
+	maxWrites    int64
+	insights     bool
+	ttlAttribute string
+	tags         tagFlags
+	replicas     stringFlags
+}
+
+func (f *tableFlags) register(fs *flag.FlagSet) {
+	f.tags = tagFlags{}
+	fs.Int64Var(&f.readUnits, "read-capacity", 0, "provisioned read capacity `units` (default on-demand)")
+	fs.Int64Var(&f.writeUnits, "write-capacity", 0, "provisioned write capacity `units` (default on-demand)")
+	fs.Int64Var(&f.warmReads, "warm-reads", 0, "read `units` per second to pre-warm the table for")
+	fs.Int64Var(&f.warmWrites, "warm-writes", 0, "write `units` per second to pre-warm the table for")
+	fs.Int64Var(&f.maxReads, "max-reads", 0, "on-demand read request `units` per second limit")
+	fs.Int64Var(&f.maxWrites, "max-writes", 0, "on-demand write request `units` per second limit")
+	fs.BoolVar(&f.insights, "contributor-insights", false, "enable CloudWatch Contributor Insights")
+	fs.StringVar(&f.ttlAttribute, "ttl-attribute", "ttl", "`name` of the attribute used for expiry")
+	fs.Var(f.tags, "tag", "table tag as `key=value` (repeatable)")
+	fs.Var(&f.replicas, "replica", "`region` to replicate the table to (repeatable)")
+}
+
+func (f *tableFlags) options() []dynamostore.Option {
+	opts := []dynamostore.Option{
+		dynamostore.WithTTLAttribute(f.ttlAttribute),
+		dynamostore.WithTags(f.tags),
+	}
+	if f.readUnits > 0 || f.writeUnits > 0 {
+		opts = append(opts, dynamostore.WithProvisionedThroughput(f.readUnits, f.writeUnits))
+	}
+	if f.warmReads > 0 || f.warmWrites > 0 {
+		opts = append(opts, dynamostore.WithWarmThroughput(f.warmReads, f.warmWrites))
+	}
+	if f.maxReads > 0 || f.maxWrites > 0 {
+		opts = append(opts, dynamostore.WithMaxOnDemandThroughput(f.maxReads, f.maxWrites))
+	}
+	if f.insights {
+		opts = append(opts, dynamostore.WithContributorInsights())
+	}
+	if len(f.replicas) > 0 {
+		opts = append(opts, dynamostore.WithReplicas(f.replicas...))
+	}
+	return opts
+}
+
+func createTable(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var tf tableFlags
+	fs := newFlagSet("create-table")
+	sf.register(fs)
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx, tf.options()...)
+	if err != nil {
+		return err
+	}
+
+	if err := store.CreateTable(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "table %q is ready\n", sf.table)
+	return nil
+}
+
+func deleteTable(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	fs := newFlagSet("delete-table")
+	sf.register(fs)
+	yes := fs.Bool("yes", false, "confirm that the table and every session in it should be deleted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*yes {
+		return errors.New("refusing to delete table without -yes")
+	}
+
+	store, err := sf.newStore(ctx)
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteTable(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "table %q deleted\n", sf.table)
+	return nil
+}
+
+func describe(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	fs := newFlagSet("describe")
+	sf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx)
+	if err != nil {
+		return err
+	}
+	info, err := store.DescribeTable()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", info.Name)
+	fmt.Fprintf(tw, "ARN:\t%s\n", info.ARN)
+	fmt.Fprintf(tw, "Status:\t%s\n", info.Status)
+	if !info.CreatedAt.IsZero() {
+		fmt.Fprintf(tw, "Created:\t%s\n", info.CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(tw, "Billing mode:\t%s\n", info.BillingMode)
+	if info.ReadCapacityUnits > 0 || info.WriteCapacityUnits > 0 {
+		fmt.Fprintf(tw, "Read capacity:\t%d\n", info.ReadCapacityUnits)
+		fmt.Fprintf(tw, "Write capacity:\t%d\n", info.WriteCapacityUnits)
+	}
+	fmt.Fprintf(tw, "Items (approx):\t%d\n", info.ItemCount)
+	fmt.Fprintf(tw, "Size (approx):\t%d bytes\n", info.SizeBytes)
+	fmt.Fprintf(tw, "TTL attribute:\t%s\n", info.TTLAttribute)
+	fmt.Fprintf(tw, "TTL status:\t%s\n", info.TTLStatus)
+	regions := make([]string, 0, len(info.Replicas))
+	for region := range info.Replicas {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		fmt.Fprintf(tw, "Replica:\t%s (%s)\n", region, info.Replicas[region])
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func cloudFormation(ctx context.Context, args []string, stdout io.Writer) error {
+	var tf tableFlags
+	fs := newFlagSet("cloudformation")
+	table := fs.String("table", dynamostore.DefaultTableName, "session table `name`")
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The template is rendered locally, so the store doesn't need a client.
+	store := dynamostore.NewWithTableName(nil, *table, tf.options()...)
+	template, err := store.CloudFormationTemplate()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, string(template))
+	return err
+}
+
+func terraform(ctx context.Context, args []string, stdout io.Writer) error {
+	var tf tableFlags
+	fs := newFlagSet("terraform")
+	table := fs.String("table", dynamostore.DefaultTableName, "session table `name`")
+	resource := fs.String("resource", "sessions", "Terraform resource `name`")
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := dynamostore.NewWithTableName(nil, *table, tf.options()...)
+	_, err := stdout.Write(store.TerraformConfig(*resource))
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// progressInterval is the minimum time between progress reports.
+const progressInterval = time.Second
+
+// lineCounter counts the lines passing through it, and periodically reports
+// the count to out.
+type lineCounter struct {
+	out   io.Writer
+	verb  string
+	lines int
+	last  time.Time
+}
+
+func (c *lineCounter) count(p []byte) {
+	c.lines += bytes.Count(p, []byte{'\n'})
+	if c.out == nil {
+		return
+	}
+	if now := time.Now(); now.Sub(c.last) >= progressInterval {
+		c.last = now
+		fmt.Fprintf(c.out, "%s %d records...\n", c.verb, c.lines)
+	}
+}
+
+type progressWriter struct {
+	io.Writer
+	lineCounter
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.count(p[:n])
+	return n, err
+}
+
+type progressReader struct {
+	io.Reader
+	lineCounter
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count(p[:n])
+	return n, err
+}
+
+func export(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("export")
+	sf.register(fs)
+	cf.register(fs)
+	out := fs.String("out", "-", "write sessions to `file` (- for stdout)")
+	quiet := fs.Bool("quiet", false, "don't report progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx, cf.options()...)
+	if err != nil {
+		return err
+	}
+
+	w := &progressWriter{Writer: stdout}
+	w.verb = "wrote"
+	var f *os.File
+	if *out != "-" {
+		if f, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer f.Close()
+		w.Writer = f
+		if !*quiet {
+			w.out = stdout
+		}
+	}
+
+	n, err := store.Export(ctx, w)
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "exported %d sessions\n", n)
+	}
+	return nil
+}
+
+func importSessions(ctx context.Context, args []string, stdout io.Writer) error {
+	var sf storeFlags
+	var cf capacityFlags
+	fs := newFlagSet("import")
+	sf.register(fs)
+	cf.register(fs)
+	in := fs.String("in", "-", "read sessions from `file` (- for stdin)")
+	dryRun := fs.Bool("dry-run", false, "validate the sessions without writing them")
+	quiet := fs.Bool("quiet", false, "don't report progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := sf.newStore(ctx, cf.options()...)
+	if err != nil {
+		return err
+	}
+
+	r := &progressReader{Reader: os.Stdin}
+	r.verb = "read"
+	if !*quiet {
+		r.out = stdout
+	}
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r.Reader = f
+	}
+
+	importFn := store.Import
+	if *dryRun {
+		importFn = store.CheckImport
+	}
+	result, err := importFn(ctx, r)
+	if result != nil {
+		for _, failure := range result.Failures {
+			fmt.Fprintf(stdout, "line %d: %s\n", failure.Line, failure.Err)
+		}
+		verb := "imported"
+		if *dryRun {
+			verb = "would import"
+		}
+		fmt.Fprintf(stdout, "%s %d sessions, skipped %d expired, %d failed\n",
+			verb, result.Imported, result.Expired, len(result.Failures),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.Failures) > 0 {
+		return errors.New("some sessions could not be imported")
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dynamostore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithCommitCoalescing delays each Commit by up to window, and combines it
+// with any other commits for the same session made in the meantime, so
+// applications that commit the same session several times while handling a
+// request only write it once. The last commit wins. Unlike write-behind,
+// Commit still waits for the write, and returns its error.
+//
+// The write uses the context of the last commit combined into it. Delete
+// drops pending commits for the session being deleted.
+// WithCommitCoalescing can't be used with WithWriteBehind or
+// WithOptimisticLocking.
+func WithCommitCoalescing(window time.Duration) Option {
+	return func(s *DynamoStore) {
+		if window <= 0 {
+			s.invalid("WithCommitCoalescing requires a positive window")
+			return
+		}
+		s.coalescer = &commitCoalescer{
+			window:  window,
+			pending: map[string]*pendingCommit{},
+		}
+	}
+}
+
+type commitCoalescer struct {
+	store  *DynamoStore
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingCommit
+	closed  bool
+}
+
+type pendingCommit struct {
+	ctx      context.Context
+	data     []byte
+	expiry   time.Time
+	timer    *time.Timer
+	canceled bool
+	// inFlight is set once the commit is being written, after which later
+	// commits can't be combined into it.
+	inFlight bool
+	// prev is the commit that was in flight when this one was queued. It
+	// must finish first, so writes aren't reordered.
+	prev *pendingCommit
+
+	done chan struct{}
+	err  error
+}
+
+// commit queues a commit and waits for it to be written. After the
+// coalescer is closed, it writes immediately.
+func (c *commitCoalescer) commit(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return c.store.commit(ctx, token, data, expiry)
+	}
+	p, ok := c.pending[token]
+	if ok && !p.inFlight {
+		p.ctx, p.data, p.expiry = ctx, data, expiry
+	} else {
+		next := &pendingCommit{ctx: ctx, data: data, expiry: expiry, done: make(chan struct{})}
+		if ok {
+			next.prev = p
+		}
+		c.pending[token] = next
+		next.timer = time.AfterFunc(c.window, func() {
+			c.flush(token, next)
+		})
+		p = next
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush writes the latest data of a pending commit. The commit stays
+// pending until it has been written, so cancel can wait for it.
+func (c *commitCoalescer) flush(token string, p *pendingCommit) {
+	if p.prev != nil {
+		<-p.prev.done
+	}
+	c.mu.Lock()
+	p.inFlight = true
+	ctx, data, expiry, canceled := p.ctx, p.data, p.expiry, p.canceled
+	c.mu.Unlock()
+
+	if !canceled {
+		p.err = c.store.commit(ctx, token, data, expiry)
+	}
+
+	c.mu.Lock()
+	if c.pending[token] == p {
+		delete(c.pending, token)
+	}
+	c.mu.Unlock()
+	close(p.done)
+}
+
+// cancel drops the pending commit for a session, if any, so it can't
+// recreate the session after it is deleted. If the commit is already being
+// written, cancel waits for it to finish.
+func (c *commitCoalescer) cancel(token string) {
+	c.mu.Lock()
+	p, ok := c.pending[token]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.pending, token)
+	p.canceled = true
+	stopped := p.timer.Stop()
+	c.mu.Unlock()
+
+	if stopped {
+		if p.prev != nil {
+			<-p.prev.done
+		}
+		close(p.done)
+	} else {
+		<-p.done
+	}
+}
+
+// close writes pending commits immediately.
+func (c *commitCoalescer) close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	pending := c.pending
+	c.pending = map[string]*pendingCommit{}
+	c.mu.Unlock()
+
+	for token, p := range pending {
+		if p.timer.Stop() {
+			c.flush(token, p)
+		}
+	}
+	for _, p := range pending {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package dynamostore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCommitCoalescing(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	expiry := time.Now().Add(time.Hour)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithCommitCoalescing(10 * time.Millisecond),
+	})
+	require.NoError(store.Validate())
+
+	// when a session is committed
+	err := store.Commit("foo", []byte("bar"), expiry)
+	// then it is written after the window
+	require.NoError(err)
+	require.Equal(1, svc.count("PutItem"))
+
+	// given a window that only ends when the store is closed
+	store = newStore(svc, DefaultTableName, []Option{
+		WithCommitCoalescing(time.Hour),
+	})
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	commit := func(data string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Commit("foo", []byte(data), expiry)
+		}()
+	}
+
+	// when a session is committed several times
+	for _, data := range []string{"first", "second", "third"} {
+		commit(data)
+		require.Eventually(func() bool {
+			store.coalescer.mu.Lock()
+			defer store.coalescer.mu.Unlock()
+			p := store.coalescer.pending["foo"]
+			return p != nil && string(p.data) == data
+		}, time.Second, time.Millisecond)
+	}
+	require.NoError(store.Close(ctx))
+	wg.Wait()
+
+	// then it is written once, with the last data
+	close(errs)
+	for err := range errs {
+		require.NoError(err)
+	}
+	require.Equal(2, svc.count("PutItem"))
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("third"), data)
+}
+
+func TestCommitCoalescingDelete(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	expiry := time.Now().Add(time.Hour)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithCommitCoalescing(time.Hour),
+	})
+
+	// given a pending commit
+	done := make(chan error, 1)
+	go func() {
+		done <- store.Commit("foo", []byte("bar"), expiry)
+	}()
+	require.Eventually(func() bool {
+		store.coalescer.mu.Lock()
+		defer store.coalescer.mu.Unlock()
+		return store.coalescer.pending["foo"] != nil
+	}, time.Second, time.Millisecond)
+
+	// when the session is deleted
+	require.NoError(store.Delete("foo"))
+
+	// then the commit is dropped
+	require.NoError(<-done)
+	require.NoError(store.Close(ctx))
+	require.Equal(0, svc.count("PutItem"))
+
+	// when combined with write-behind
+	store = newStore(svc, DefaultTableName, []Option{
+		WithCommitCoalescing(time.Second),
+		WithWriteBehind(1, nil),
+	})
+	// then the configuration is rejected
+	require.Error(store.Validate())
+	require.NoError(store.Close(ctx))
+}
+
+// blockingClient blocks each PutItem until it is released.
+type blockingClient struct {
+	*fakeClient
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingClient) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	c.started <- struct{}{}
+	<-c.release
+	return c.fakeClient.PutItem(ctx, params, optFns...)
+}
+
+func TestCommitCoalescingDeleteDuringFlush(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	expiry := time.Now().Add(time.Hour)
+	svc := newFakeClient()
+	client := &blockingClient{
+		fakeClient: svc,
+		started:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	store := newStore(client, DefaultTableName, []Option{
+		WithCommitCoalescing(time.Millisecond),
+	})
+
+	// given a commit that is being written
+	committed := make(chan error, 1)
+	go func() {
+		committed <- store.Commit("foo", []byte("bar"), expiry)
+	}()
+	<-client.started
+
+	// when the session is deleted
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- store.Delete("foo")
+	}()
+
+	// then the delete waits for the write
+	select {
+	case err := <-deleted:
+		require.FailNow("delete didn't wait for the write", "err: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(client.release)
+	require.NoError(<-committed)
+	require.NoError(<-deleted)
+	require.NotContains(svc.items, "foo")
+	require.NoError(store.Close(ctx))
+}
@@ -0,0 +1,113 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitAndReturnPrevious(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, opts := range [][]Option{
+		{},
+		{WithSkipUnchanged(0)},
+	} {
+		opts = append(opts, WithClock(func() time.Time { return now }))
+		store := newStore(newFakeClient(), DefaultTableName, opts)
+
+		// when a new session is committed
+		data, expiry, existed, err := store.CommitAndReturnPrevious(
+			ctx, "foo", []byte("bar"), now.Add(time.Hour),
+		)
+		// then there is no previous session
+		require.NoError(err)
+		require.False(existed)
+		require.Nil(data)
+		require.True(expiry.IsZero())
+
+		// when the session is replaced
+		data, expiry, existed, err = store.CommitAndReturnPrevious(
+			ctx, "foo", []byte("baz"), now.Add(2*time.Hour),
+		)
+		// then the previous session is returned
+		require.NoError(err)
+		require.True(existed)
+		require.Equal([]byte("bar"), data)
+		require.True(now.Add(time.Hour).Equal(expiry), expiry)
+
+		// when the same data is committed again
+		data, _, existed, err = store.CommitAndReturnPrevious(ctx, "foo", []byte("baz"), now.Add(2*time.Hour))
+		// then it is still written
+		require.NoError(err)
+		require.True(existed)
+		require.Equal([]byte("baz"), data)
+
+		// when the previous session had expired
+		now = now.Add(3 * time.Hour)
+		_, _, existed, err = store.CommitAndReturnPrevious(ctx, "foo", []byte("qux"), now.Add(time.Hour))
+		// then it isn't returned
+		require.NoError(err)
+		require.False(existed)
+		found, exists, err := store.Find("foo")
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte("qux"), found)
+
+		require.NoError(store.Close(ctx))
+	}
+
+	// given write-behind
+	store := newStore(newFakeClient(), DefaultTableName, []Option{WithWriteBehind(1, nil)})
+	// when
+	_, _, _, err := store.CommitAndReturnPrevious(ctx, "foo", []byte("bar"), now.Add(time.Hour))
+	// then
+	require.Error(err)
+	require.NoError(store.Close(ctx))
+}
+
+func TestDeleteAndCheck(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+	})
+	require.NoError(store.Commit("live", []byte("bar"), now.Add(time.Hour)))
+	require.NoError(store.Commit("expired", []byte("bar"), now.Add(-time.Hour)))
+
+	// when a live session is deleted
+	existed, err := store.DeleteAndCheck(ctx, "live")
+	// then
+	require.NoError(err)
+	require.True(existed)
+	_, exists, err := store.Find("live")
+	require.NoError(err)
+	require.False(exists)
+
+	// when it is deleted again
+	existed, err = store.DeleteAndCheck(ctx, "live")
+	// then
+	require.NoError(err)
+	require.False(existed)
+
+	// when an expired session is deleted
+	existed, err = store.DeleteAndCheck(ctx, "expired")
+	// then
+	require.NoError(err)
+	require.False(existed)
+	require.NoError(store.Close(ctx))
+
+	// given write-behind
+	store = newStore(newFakeClient(), DefaultTableName, []Option{WithWriteBehind(1, nil)})
+	// when
+	_, err = store.DeleteAndCheck(ctx, "live")
+	// then
+	require.Error(err)
+	require.NoError(store.Close(ctx))
+}
@@ -0,0 +1,49 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithContributorInsights(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	insights := func(client *fake.Client) types.ContributorInsightsStatus {
+		result, err := client.DescribeContributorInsights(ctx, &dynamodb.DescribeContributorInsightsInput{
+			TableName: aws.String(dynamostore.DefaultTableName),
+		})
+		require.NoError(err)
+		return result.ContributorInsightsStatus
+	}
+
+	// when the option is used
+	client := fake.NewClient()
+	store := dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithContributorInsights())
+	require.NoError(store.CreateTable())
+
+	// then
+	require.Equal(types.ContributorInsightsStatusEnabled, insights(client))
+
+	// when it isn't
+	client = fake.NewClient()
+	require.NoError(dynamostore.New(client, dynamostore.WithoutSleep()).CreateTable())
+
+	// then
+	require.Equal(types.ContributorInsightsStatusDisabled, insights(client))
+
+	// when the template is rendered
+	template, err := fake.New(dynamostore.WithContributorInsights()).CloudFormationTemplate()
+
+	// then
+	require.NoError(err)
+	require.Contains(string(template), `"ContributorInsightsSpecification"`)
+}
@@ -0,0 +1,181 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// createdAtAttribute stores when a session was first committed, as seconds
+// since the Unix epoch, when WithCreationTime is used.
+const createdAtAttribute = "CreatedAt"
+
+var errNoCreationTime = errors.New("requires WithCreationTime")
+
+// WithCreationTime records when each session is first committed, in the
+// CreatedAt attribute, so RevokeOlderThan can remove sessions established
+// before an incident, and GetMetadata can report their age. Commits update
+// the session in place instead of replacing it, so the creation time is
+// kept, and RenameToken copies it to the new token.
+//
+// Sessions committed before the option is enabled, and sessions written by
+// Import, don't have a creation time.
+func WithCreationTime() Option {
+	return func(s *DynamoStore) {
+		s.creationTime = true
+	}
+}
+
+// putKeepingCreation makes the same change as a PutItem request, including
+// its condition and return values, with an UpdateItem request that sets the
+// creation time only if the item doesn't already have one. Attributes
+// written by the store that aren't in the new item are removed, as a PutItem
+// request would, so a session doesn't keep an old subject, index value, or
+// key ID. Attributes added by other tools are kept.
+func (s *DynamoStore) putKeepingCreation(
+	ctx context.Context, input *dynamodb.PutItemInput,
+) (*dynamodb.PutItemOutput, error) {
+	names := map[string]string{"#created": createdAtAttribute}
+	values := map[string]types.AttributeValue{
+		":created": &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(s.now().Unix(), 10),
+		},
+	}
+	for k, v := range input.ExpressionAttributeNames {
+		names[k] = v
+	}
+	for k, v := range input.ExpressionAttributeValues {
+		values[k] = v
+	}
+
+	attrs := make([]string, 0, len(input.Item))
+	for name := range input.Item {
+		if name != "token" {
+			attrs = append(attrs, name)
+		}
+	}
+	sort.Strings(attrs)
+	actions := []string{"#created = if_not_exists(#created, :created)"}
+	for i, name := range attrs {
+		n := strconv.Itoa(i)
+		names["#p"+n] = name
+		values[":p"+n] = input.Item[name]
+		actions = append(actions, "#p"+n+" = :p"+n)
+	}
+
+	expr := "SET " + strings.Join(actions, ", ")
+	if removed := s.removedAttributes(input.Item); len(removed) > 0 {
+		for i, name := range removed {
+			n := strconv.Itoa(i)
+			names["#r"+n] = name
+			removed[i] = "#r" + n
+		}
+		expr += " REMOVE " + strings.Join(removed, ", ")
+	}
+
+	result, err := s.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: input.TableName,
+		Key: map[string]types.AttributeValue{
+			"token": input.Item["token"],
+		},
+		UpdateExpression:          aws.String(expr),
+		ConditionExpression:       input.ConditionExpression,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnConsumedCapacity:    input.ReturnConsumedCapacity,
+		ReturnValues:              input.ReturnValues,
+	}, s.optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.PutItemOutput{
+		Attributes:       result.Attributes,
+		ConsumedCapacity: result.ConsumedCapacity,
+	}, nil
+}
+
+// removedAttributes returns the attributes written by the store that item
+// doesn't have, except the creation time.
+func (s *DynamoStore) removedAttributes(item map[string]types.AttributeValue) []string {
+	var removed []string
+	for _, name := range s.reservedAttributes() {
+		if _, ok := item[name]; !ok && name != createdAtAttribute {
+			removed = append(removed, name)
+		}
+	}
+	for _, idx := range s.attributeIndexes {
+		if _, ok := item[idx.attribute]; !ok {
+			removed = append(removed, idx.attribute)
+		}
+	}
+	return removed
+}
+
+// RevokeOlderThan removes every session created before cutoff, including
+// sessions without a creation time, and returns the number removed. It is
+// the usual response to credential stuffing or a compromised signing key,
+// when every session established before the incident must end. Sessions are
+// revoked when WithRetention or WithRevocationMarkers is used, and deleted
+// otherwise. It requires WithCreationTime.
+//
+// There is no index of sessions by creation time, so the whole table is
+// scanned. Use WithCapacityLimit to limit the impact on other users of the
+// table.
+func (s *DynamoStore) RevokeOlderThan(ctx context.Context, cutoff time.Time) (int, error) {
+	if !s.creationTime {
+		return 0, errNoCreationTime
+	}
+	n := 0
+	err := s.scan(ctx, &dynamodb.ScanInput{
+		FilterExpression: aws.String(
+			"(attribute_not_exists(#created) OR #created < :cutoff) AND attribute_not_exists(#revoked)",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#created": createdAtAttribute,
+			"#revoked": revokedAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":cutoff": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(cutoff.Unix(), 10),
+			},
+		},
+	}, func(av map[string]types.AttributeValue) error {
+		if created, ok := unixAttribute(av, createdAtAttribute); ok && !created.Before(cutoff) {
+			return nil
+		}
+		if _, ok := av[revokedAttribute]; ok {
+			return nil
+		}
+		token, ok := av["token"].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil
+		}
+		if err := s.revokeOrDelete(ctx, token.Value); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// unixAttribute returns the time stored in the named attribute of av, as
+// seconds since the Unix epoch, if there is one.
+func unixAttribute(av map[string]types.AttributeValue, name string) (time.Time, bool) {
+	n, ok := av[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
@@ -0,0 +1,97 @@
+package dynamostore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestRevokeOlderThan(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithCreationTime(),
+	)
+	expiry := now.Add(24 * time.Hour)
+
+	// given a session created before the incident, and committed again after
+	start := now
+	require.NoError(store.Commit("old", []byte("data"), expiry))
+	now = now.Add(time.Hour)
+	cutoff := now
+	require.NoError(store.Commit("old", []byte("changed"), expiry))
+	require.NoError(store.Commit("new", []byte("data"), expiry))
+
+	// and a session without a creation time
+	_, err := store.Import(ctx, strings.NewReader(
+		`{"token":"imported","data":"ZGF0YQ==","expiry":"`+expiry.Format(time.RFC3339)+`"}`+"\n",
+	))
+	require.NoError(err)
+
+	metadata, err := store.GetMetadata(ctx, "old")
+	require.NoError(err)
+	require.True(start.Equal(metadata.CreatedAt))
+
+	// when sessions created before the cutoff are revoked
+	n, err := store.RevokeOlderThan(ctx, cutoff)
+
+	// then only the new session remains
+	require.NoError(err)
+	require.Equal(2, n)
+	for token, expected := range map[string]bool{"old": false, "imported": false, "new": true} {
+		_, exists, err := store.Find(token)
+		require.NoError(err)
+		require.Equal(expected, exists, token)
+	}
+}
+
+func TestCreationTimeRemovesStaleAttributes(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := dynamostore.New(fake.NewClient(),
+		dynamostore.WithoutSleep(),
+		dynamostore.WithCreationTime(),
+		dynamostore.WithSubjectKey("user"),
+		dynamostore.WithSubjectIndex(),
+		dynamostore.WithAttributeIndex("Device", "device"),
+	)
+	require.NoError(store.CreateTable())
+	expiry := time.Now().Add(time.Hour)
+	data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"user": "alice", "device": "laptop"})
+	require.NoError(err)
+	require.NoError(store.Commit("foo", data, expiry))
+
+	// when the user logs out, and the session is committed without them
+	data, err = scs.GobCodec{}.Encode(expiry, map[string]interface{}{})
+	require.NoError(err)
+	require.NoError(store.Commit("foo", data, expiry))
+
+	// then the old subject and index value are removed
+	details, err := store.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.Contains(details.Attributes, "CreatedAt")
+	require.NotContains(details.Attributes, "Subject")
+	require.NotContains(details.Attributes, "Device")
+	n, err := store.DeleteBySubject(ctx, "alice")
+	require.NoError(err)
+	require.Zero(n)
+}
+
+func TestRevokeOlderThanRequiresCreationTime(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New()
+	_, err := store.RevokeOlderThan(context.Background(), time.Now())
+	require.Error(err)
+}
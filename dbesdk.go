@@ -0,0 +1,70 @@
+package dynamostore
+
+// AttributeAction is how the AWS Database Encryption SDK for DynamoDB
+// protects an attribute. The values match the SDK's CryptoAction names.
+type AttributeAction string
+
+const (
+	// EncryptAndSign encrypts an attribute, and includes it in the item's
+	// signature.
+	EncryptAndSign AttributeAction = "ENCRYPT_AND_SIGN"
+	// SignOnly includes an attribute in the item's signature, without
+	// encrypting it.
+	SignOnly AttributeAction = "SIGN_ONLY"
+	// DoNothing leaves an attribute unencrypted and unsigned.
+	DoNothing AttributeAction = "DO_NOTHING"
+)
+
+// WithDatabaseEncryptionSDK prepares the store for a Client that encrypts
+// and signs items with the AWS Database Encryption SDK for DynamoDB, such as
+// a *dynamodb.Client configured with the SDK's middleware. The SDK is
+// configured separately, using AttributeActions for the table's attribute
+// actions.
+//
+// The SDK verifies whole items, so reads fetch every attribute instead of
+// using a projection. It can't apply partial updates to signed items, so
+// options that update items in place, such as WithSlidingExpiration,
+// WithExpiryOnlyUpdates, WithOptimisticLocking, and WithRetention, can't be
+// used with it. The SDK encrypts session data itself, so WithEncryption and
+// WithKeyProvider can't be used with it either.
+func WithDatabaseEncryptionSDK() Option {
+	return func(s *DynamoStore) {
+		s.dbesdk = true
+	}
+}
+
+// AttributeActions returns the attribute actions for the AWS Database
+// Encryption SDK that match the items written by s. Session data is
+// encrypted. The token and expiry stay readable, since DynamoDB needs them
+// for the key and for TTL, and are signed. Attributes added by other tools
+// should be added to the result before it is passed to the SDK.
+func (s *DynamoStore) AttributeActions() map[string]AttributeAction {
+	actions := map[string]AttributeAction{
+		"token":        SignOnly,
+		"Data":         EncryptAndSign,
+		s.ttlAttribute: SignOnly,
+	}
+	if s.unchanged != nil {
+		actions[dataHashAttribute] = EncryptAndSign
+	}
+	if s.tokenDigest {
+		actions[tokenDigestAttribute] = SignOnly
+	}
+	if s.expiryBucket > 0 {
+		actions[expiryBucketAttribute] = SignOnly
+	}
+	for _, idx := range s.attributeIndexes {
+		actions[idx.attribute] = SignOnly
+	}
+	if s.subjectIndex {
+		actions[subjectAttribute] = SignOnly
+	}
+	if s.encryption != nil {
+		actions[keyIDAttribute] = SignOnly
+	}
+	if s.global != nil {
+		actions[writerRegionAttribute] = SignOnly
+		actions[committedAtAttribute] = SignOnly
+	}
+	return actions
+}
@@ -0,0 +1,96 @@
+package dynamostore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDatabaseEncryptionSDK(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithDatabaseEncryptionSDK(),
+		WithSkipUnchanged(0),
+		WithTTLAttribute("expires"),
+	})
+	require.NoError(store.Validate())
+
+	// then
+	require.Equal(map[string]AttributeAction{
+		"token":    SignOnly,
+		"Data":     EncryptAndSign,
+		"expires":  SignOnly,
+		"DataHash": EncryptAndSign,
+	}, store.AttributeActions())
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)))
+	data, exists, err := store.Find("foo")
+
+	// then whole items are read
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+	require.Nil(store.projection.expr)
+	require.Nil(store.projection.names)
+
+	// when options that update items in place are used
+	store = newStore(svc, DefaultTableName, []Option{
+		WithDatabaseEncryptionSDK(),
+		WithSlidingExpiration(time.Hour),
+		WithSkipUnchanged(0),
+		WithExpiryOnlyUpdates(),
+		WithRetention(time.Hour),
+		WithEncryption(&Keyring{Current: "v1", Keys: map[string][]byte{"v1": make([]byte, 32)}}),
+	})
+
+	// then
+	var configErr *ConfigError
+	require.True(errors.As(store.Validate(), &configErr))
+	require.Equal([]string{
+		"WithSlidingExpiration can't be used with WithDatabaseEncryptionSDK",
+		"WithExpiryOnlyUpdates can't be used with WithDatabaseEncryptionSDK",
+		"WithRetention can't be used with WithDatabaseEncryptionSDK",
+		"WithEncryption and WithKeyProvider can't be used with WithDatabaseEncryptionSDK",
+	}, configErr.Problems)
+}
+
+func TestAttributeActionsCoverItems(t *testing.T) {
+	require := require.New(t)
+
+	// given a store writing every optional attribute
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithDatabaseEncryptionSDK(),
+		WithSkipUnchanged(0),
+		WithTTLAttribute("expires"),
+		WithTokenDigest(),
+		WithExpiryIndex(0),
+		WithAttributeIndex("Device", "device"),
+		WithSubjectKey("user"),
+		WithSubjectIndex(),
+		WithEncryption(&Keyring{Current: "v1", Keys: map[string][]byte{"v1": make([]byte, 32)}}),
+		WithGlobalTable(GlobalTableConfig{Region: "us-east-1"}),
+	})
+	expiry := time.Now().Add(time.Hour)
+	data, err := store.codec.Encode(expiry, map[string]interface{}{
+		"device": "laptop",
+		"user":   "alice",
+	})
+	require.NoError(err)
+
+	// when
+	av, err := store.marshalItem("foo", data, expiry)
+	require.NoError(err)
+	actions := store.AttributeActions()
+
+	// then every attribute has an action
+	require.Len(av, 11)
+	for attr := range av {
+		require.Contains(actions, attr)
+	}
+}
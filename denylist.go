@@ -0,0 +1,193 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxDenylistCache limits the number of denylist lookups cached by a store.
+const maxDenylistCache = 10000
+
+var errNoDenylist = errors.New("requires WithDenylist")
+
+// DenylistConfig configures WithDenylist.
+type DenylistConfig struct {
+	// Table is the name of the denylist table, see CreateDenylistTable.
+	Table string
+	// CacheTTL is how long a token that isn't denied is remembered, to
+	// avoid reading the denylist on every Find. Tokens denied by other
+	// instances can be accepted for up to CacheTTL. The default, zero,
+	// reads the denylist every time a session is found. Denied tokens are
+	// always remembered until their entry expires.
+	CacheTTL time.Duration
+}
+
+// WithDenylist makes Find consult a separate denylist table, so a token can
+// be invalidated with Deny even when a copy of its session is still held
+// by WithCache, write-behind, DAX, or a replica that hasn't seen the delete.
+// Commits to denied tokens are dropped without an error.
+//
+// Entries are keyed by the hash returned by HashToken, so the denylist never
+// grants access to sessions, and are removed by DynamoDB's TTL process once
+// they expire. The denylist is only read for sessions that exist.
+func WithDenylist(cfg DenylistConfig) Option {
+	return func(s *DynamoStore) {
+		if cfg.Table == "" || cfg.CacheTTL < 0 {
+			s.invalid("WithDenylist requires a table and a cache ttl of zero or more")
+			return
+		}
+		s.denylist = &denylist{
+			DenylistConfig: cfg,
+			entries:        map[string]denylistEntry{},
+		}
+	}
+}
+
+type denylist struct {
+	DenylistConfig
+
+	mu      sync.Mutex
+	entries map[string]denylistEntry
+}
+
+type denylistEntry struct {
+	denied bool
+	until  time.Time
+}
+
+// cached returns the remembered result for hash, if any.
+func (d *denylist) cached(hash string, now time.Time) (denied, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.entries[hash]
+	if !ok {
+		return false, false
+	}
+	if !now.Before(entry.until) {
+		delete(d.entries, hash)
+		return false, false
+	}
+	return entry.denied, true
+}
+
+// remember records the result of a lookup until the given time.
+func (d *denylist) remember(hash string, denied bool, until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.entries) >= maxDenylistCache {
+		d.entries = map[string]denylistEntry{}
+	}
+	d.entries[hash] = denylistEntry{denied: denied, until: until}
+}
+
+// denied returns true if token is on the denylist, if WithDenylist is used.
+func (s *DynamoStore) denied(ctx context.Context, token string) (bool, error) {
+	if s.denylist == nil {
+		return false, nil
+	}
+	hash, err := s.HashToken(ctx, token)
+	if err != nil {
+		return false, err
+	}
+	now := s.now()
+	if denied, ok := s.denylist.cached(hash, now); ok {
+		return denied, nil
+	}
+
+	result, err := s.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      aws.String(s.denylist.Table),
+		Key: map[string]types.AttributeValue{
+			"TokenHash": &types.AttributeValueMemberS{Value: hash},
+		},
+	}, s.optFns...)
+	if err != nil {
+		return false, err
+	}
+	if n, ok := result.Item[defaultTTLAttribute].(*types.AttributeValueMemberN); ok {
+		if ttl, err := strconv.ParseInt(n.Value, 10, 64); err == nil && now.Unix() < ttl {
+			s.denylist.remember(hash, true, time.Unix(ttl, 0))
+			return true, nil
+		}
+	}
+	if s.denylist.CacheTTL > 0 {
+		s.denylist.remember(hash, false, now.Add(s.denylist.CacheTTL))
+	}
+	return false, nil
+}
+
+// unlessDenied returns item, or nil if it is on the denylist.
+func (s *DynamoStore) unlessDenied(ctx context.Context, item *sessionItem) (*sessionItem, error) {
+	if item == nil {
+		return nil, nil
+	}
+	if denied, err := s.denied(ctx, item.Token); err != nil || denied {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Deny adds token to the denylist until the given time, after which its
+// session can be found again if it still exists. Use the session's expiry,
+// or later, to invalidate it for good. The session is also removed from this
+// store's cache, and from the caches of other stores if
+// WithCacheInvalidation is used.
+func (s *DynamoStore) Deny(ctx context.Context, token string, until time.Time) error {
+	if s.denylist == nil {
+		return errNoDenylist
+	}
+	hash, err := s.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.denylist.Table),
+		Item: map[string]types.AttributeValue{
+			"TokenHash": &types.AttributeValueMemberS{Value: hash},
+			defaultTTLAttribute: &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(until.Unix(), 10),
+			},
+		},
+	}, s.optFns...)
+	if err != nil {
+		return err
+	}
+	s.denylist.remember(hash, true, until)
+	s.forgetUnchanged(token)
+	s.forgetCached(token)
+	s.publishInvalidation(ctx, token)
+	return nil
+}
+
+// CreateDenylistTable creates the denylist table used by WithDenylist, if
+// it doesn't already exist, and enables TTL on it. Like CreateTable, it is
+// intended as a convenience for development and testing.
+func (s *DynamoStore) CreateDenylistTable(ctx context.Context) error {
+	if s.denylist == nil {
+		return errNoDenylist
+	}
+	return s.createTTLTable(ctx, &dynamodb.CreateTableInput{
+		BillingMode: types.BillingModePayPerRequest,
+		Tags:        s.tableTags(),
+		TableName:   aws.String(s.denylist.Table),
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("TokenHash"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("TokenHash"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+	})
+}
@@ -0,0 +1,152 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithDenylist(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	opts := []dynamostore.Option{
+		dynamostore.WithoutSleep(),
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithCache(10, time.Hour),
+		dynamostore.WithDenylist(dynamostore.DenylistConfig{Table: "scs.denylist"}),
+	}
+	a := dynamostore.New(svc, opts...)
+	b := dynamostore.New(svc, opts...)
+	require.NoError(a.Validate())
+	require.NoError(a.CreateDenylistTable(ctx))
+	require.Equal(0, svc.Len("scs.denylist"))
+
+	// given a session cached by both stores
+	expiry := now.Add(time.Hour)
+	require.NoError(a.Commit("foo", []byte("bar"), expiry))
+	require.NoError(a.Commit("baz", []byte("qux"), expiry))
+	_, exists, err := b.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+
+	// when the token is denied by one store
+	require.NoError(a.Deny(ctx, "foo", expiry))
+
+	// then neither store finds it, though it is still cached and stored
+	_, exists, err = a.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+	_, exists, err = b.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+	details, err := b.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.NotNil(details)
+	require.Equal(1, svc.Len("scs.denylist"))
+
+	_, exists, err = b.Find("baz")
+	require.NoError(err)
+	require.True(exists)
+
+	// when a request in flight commits the session again
+	require.NoError(b.Commit("foo", []byte("resurrected"), expiry))
+
+	// then the commit is dropped
+	details, err = b.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.Equal([]byte("bar"), details.Data)
+
+	// when the entry expires
+	now = expiry.Add(time.Second)
+	svc.Expire(now)
+
+	// then the denylist is empty
+	require.Equal(0, svc.Len("scs.denylist"))
+}
+
+func TestDenylistCacheTTL(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	svc.AddTable("scs.denylist", "TokenHash")
+	opts := []dynamostore.Option{
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithDenylist(dynamostore.DenylistConfig{
+			Table:    "scs.denylist",
+			CacheTTL: time.Minute,
+		}),
+	}
+	a := dynamostore.New(svc, opts...)
+	b := dynamostore.New(svc, opts...)
+
+	// given a token b has checked recently
+	expiry := now.Add(time.Hour)
+	require.NoError(a.Commit("foo", []byte("bar"), expiry))
+	_, exists, err := b.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+
+	// when another store denies it
+	require.NoError(a.Deny(ctx, "foo", expiry))
+
+	// then b accepts it until its cached lookup expires
+	_, exists, err = b.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+
+	now = now.Add(time.Minute)
+	_, exists, err = b.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+}
+
+func TestDenylistWithWriteBehind(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	store := dynamostore.New(svc,
+		dynamostore.WithoutSleep(),
+		dynamostore.WithWriteBehind(1, nil),
+		dynamostore.WithDenylist(dynamostore.DenylistConfig{Table: "scs.denylist"}),
+	)
+	require.NoError(store.Validate())
+	require.NoError(store.CreateDenylistTable(ctx))
+
+	// given a denied token
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(store.Deny(ctx, "foo", expiry))
+
+	// when a request in flight commits the session again
+	require.NoError(store.Commit("foo", []byte("resurrected"), expiry))
+	require.NoError(store.Close(ctx))
+
+	// then the queued write is dropped
+	details, err := store.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.Nil(details)
+}
+
+func TestDenylistRequiresConfig(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New(dynamostore.WithDenylist(dynamostore.DenylistConfig{}))
+	require.EqualError(store.Validate(),
+		"invalid DynamoStore configuration: WithDenylist requires a table and a cache ttl of zero or more")
+
+	plain := fake.New()
+	require.EqualError(plain.Deny(context.Background(), "foo", time.Now()), "requires WithDenylist")
+}
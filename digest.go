@@ -0,0 +1,95 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// tokenDigestAttribute stores the keyed hash of a session's token, when
+// WithTokenDigest is used.
+const tokenDigestAttribute = "TokenDigest"
+
+var errNoTokenDigest = errors.New("requires WithTokenDigest")
+
+// errStopScan stops FindByTokenDigest's scan once the session is found.
+var errStopScan = errors.New("stop scan")
+
+// WithTokenDigest stores the hash returned by HashToken with each session,
+// in the TokenDigest attribute, so security tooling that only knows the
+// hash of a leaked token can find its session with FindByTokenDigest, or
+// with a global secondary index on the attribute, without handling the
+// token itself. The hash is keyed, so it requires WithTokenPepper, and the
+// digest can't be used to recover or guess tokens.
+//
+// The table is still keyed by the token, since Find is only given the token,
+// so the digest doesn't keep tokens out of the table, and access to the
+// table must still be restricted. Sessions committed before the option is
+// enabled don't have a digest until they are committed again.
+func WithTokenDigest() Option {
+	return func(s *DynamoStore) {
+		s.tokenDigest = true
+	}
+}
+
+// digestItem adds the digest of token to av, if WithTokenDigest is used.
+func (s *DynamoStore) digestItem(av map[string]types.AttributeValue, token string) error {
+	if !s.tokenDigest {
+		return nil
+	}
+	digest, err := s.HashToken(context.Background(), token)
+	if err != nil {
+		return err
+	}
+	av[tokenDigestAttribute] = &types.AttributeValueMemberS{Value: digest}
+	return nil
+}
+
+// FindByTokenDigest returns the metadata of the stored session with the
+// given digest, even if it has expired, as returned by GetMetadata. Neither
+// the token nor the data of the session is returned. It returns nil if no
+// session has the digest.
+//
+// The table is scanned, so use WithCapacityLimit to limit the impact on other
+// users of the table, or query an index on the TokenDigest attribute instead.
+func (s *DynamoStore) FindByTokenDigest(ctx context.Context, digest string) (*SessionMetadata, error) {
+	_, av, err := s.findByTokenDigest(ctx, digest)
+	if err != nil || av == nil {
+		return nil, err
+	}
+	return s.sessionMetadata(digest, av)
+}
+
+// findByTokenDigest returns the token and item of the session with the given
+// digest, or a nil item if no session has the digest.
+func (s *DynamoStore) findByTokenDigest(
+	ctx context.Context, digest string,
+) (string, map[string]types.AttributeValue, error) {
+	if !s.tokenDigest {
+		return "", nil, errNoTokenDigest
+	}
+	var token string
+	var found map[string]types.AttributeValue
+	err := s.scan(ctx, &dynamodb.ScanInput{
+		FilterExpression:         aws.String("#digest = :digest"),
+		ExpressionAttributeNames: map[string]string{"#digest": tokenDigestAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":digest": &types.AttributeValueMemberS{Value: digest},
+		},
+	}, func(av map[string]types.AttributeValue) error {
+		if v, ok := av[tokenDigestAttribute].(*types.AttributeValueMemberS); !ok || v.Value != digest {
+			return nil
+		}
+		if v, ok := av["token"].(*types.AttributeValueMemberS); ok {
+			token, found = v.Value, av
+		}
+		return errStopScan
+	})
+	if err == errStopScan {
+		err = nil
+	}
+	return token, found, err
+}
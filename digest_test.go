@@ -0,0 +1,63 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenDigest(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// given
+	pepper := WithTokenPepper(PepperProviderFunc(func(context.Context) ([]byte, error) {
+		return []byte("pepper"), nil
+	}))
+	store := newStore(newFakeClient(), DefaultTableName, []Option{pepper, WithTokenDigest()})
+	require.NoError(store.Validate())
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	for _, token := range []string{"foo", "bar", "baz"} {
+		require.NoError(store.Commit(token, []byte(token), expiry))
+	}
+	digest, err := store.HashToken(ctx, "bar")
+	require.NoError(err)
+
+	// when
+	details, err := store.FindByTokenDigest(ctx, digest)
+
+	// then the session is described without its token or data
+	require.NoError(err)
+	require.NotNil(details)
+	require.Equal(digest, details.TokenHash)
+	require.Equal(expiry, details.Expiry)
+	require.Equal(
+		&types.AttributeValueMemberS{Value: digest},
+		details.Attributes[tokenDigestAttribute],
+	)
+	require.NotContains(details.Attributes, "token")
+	require.NotContains(details.Attributes, "Data")
+
+	// when
+	details, err = store.FindByTokenDigest(ctx, TokenHash("bar"))
+
+	// then
+	require.NoError(err)
+	require.Nil(details)
+}
+
+func TestTokenDigestRequiresPepper(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, []Option{WithTokenDigest()})
+	require.EqualError(store.Validate(),
+		"invalid DynamoStore configuration: WithTokenDigest requires WithTokenPepper")
+
+	plain := newStore(newFakeClient(), DefaultTableName, nil)
+	_, err := plain.FindByTokenDigest(context.Background(), "digest")
+	require.Equal(errNoTokenDigest, err)
+}
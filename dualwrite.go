@@ -0,0 +1,73 @@
+package dynamostore
+
+import (
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+var _ scs.Store = &DualWriteStore{}
+
+// DualWriteStore writes sessions to both a primary and a secondary store,
+// and reads them from the primary, falling back to the secondary. It is
+// intended for migrating between session stores without logging users out:
+// wrap a DynamoStore as the primary and the old store as the secondary until
+// every live session has been written to both.
+type DualWriteStore struct {
+	primary   scs.Store
+	secondary scs.Store
+	codec     scs.Codec
+}
+
+// NewDualWriteStore creates a DualWriteStore instance.
+//
+// If codec is not nil, sessions that are only found in the secondary store
+// are copied into the primary store, using the codec to decode their
+// expiry. It should match the codec used by the scs.SessionManager.
+func NewDualWriteStore(primary, secondary scs.Store, codec scs.Codec) *DualWriteStore {
+	return &DualWriteStore{
+		primary:   primary,
+		secondary: secondary,
+		codec:     codec,
+	}
+}
+
+// Find returns the data for a given session token from the primary store,
+// or from the secondary store if the primary doesn't contain the session.
+func (s *DualWriteStore) Find(token string) (b []byte, exists bool, err error) {
+	b, exists, err = s.primary.Find(token)
+	if err != nil || exists {
+		return b, exists, err
+	}
+
+	b, exists, err = s.secondary.Find(token)
+	if err != nil || !exists {
+		return b, exists, err
+	}
+
+	if s.codec != nil {
+		if deadline, _, err := s.codec.Decode(b); err == nil {
+			// Failing to copy the session isn't fatal, the copy
+			// will be attempted again the next time it is read.
+			_ = s.primary.Commit(token, b, deadline)
+		}
+	}
+	return b, true, nil
+}
+
+// Commit adds a session token and data to both stores with the given expiry
+// time.
+func (s *DualWriteStore) Commit(token string, b []byte, expiry time.Time) error {
+	if err := s.primary.Commit(token, b, expiry); err != nil {
+		return err
+	}
+	return s.secondary.Commit(token, b, expiry)
+}
+
+// Delete removes a session token and corresponding data from both stores.
+func (s *DualWriteStore) Delete(token string) error {
+	if err := s.primary.Delete(token); err != nil {
+		return err
+	}
+	return s.secondary.Delete(token)
+}
@@ -0,0 +1,61 @@
+package dynamostore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func TestDualWriteStore(t *testing.T) {
+	require := require.New(t)
+
+	primary := memstore.NewWithCleanupInterval(0)
+	secondary := memstore.NewWithCleanupInterval(0)
+	store := dynamostore.NewDualWriteStore(primary, secondary, scs.GobCodec{})
+
+	expiry := time.Now().Add(time.Minute)
+	data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"foo": "bar"})
+	require.NoError(err)
+
+	// given a session that was saved before the migration started
+	err = secondary.Commit("old", data, expiry)
+	require.NoError(err)
+	// when the session is read
+	actual, exists, err := store.Find("old")
+	// then it should be found in the secondary store
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(data, actual)
+	// and copied into the primary store
+	actual, exists, err = primary.Find("old")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(data, actual)
+
+	// given a new session
+	// when it is saved
+	err = store.Commit("new", data, expiry)
+	require.NoError(err)
+	// then it should be written to both stores
+	for _, s := range []scs.Store{primary, secondary} {
+		_, exists, err = s.Find("new")
+		require.NoError(err)
+		require.True(exists)
+	}
+
+	// given a saved session
+	// when it is deleted
+	err = store.Delete("new")
+	require.NoError(err)
+	// then it should be removed from both stores
+	for _, s := range []scs.Store{primary, secondary} {
+		_, exists, err = s.Find("new")
+		require.NoError(err)
+		require.False(exists)
+	}
+}
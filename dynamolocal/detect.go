@@ -0,0 +1,66 @@
+package dynamolocal
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// defaultEdgePort is LocalStack's default port for every service.
+const defaultEdgePort = "4566"
+
+// DetectEndpoint returns the URL of a local DynamoDB API configured by the
+// environment, or an empty string if none is configured. The following
+// variables are checked, in order:
+//
+//   - DYNAMOSTORE_ENDPOINT, see EndpointEnv
+//   - AWS_ENDPOINT_URL
+//   - LOCALSTACK_HOSTNAME, set by LocalStack for Lambda functions it runs,
+//     with the port from EDGE_PORT (default 4566)
+func DetectEndpoint() string {
+	for _, name := range []string{EndpointEnv, "AWS_ENDPOINT_URL"} {
+		if endpoint := os.Getenv(name); endpoint != "" {
+			return endpoint
+		}
+	}
+	if host := os.Getenv("LOCALSTACK_HOSTNAME"); host != "" {
+		port := os.Getenv("EDGE_PORT")
+		if port == "" {
+			port = defaultEdgePort
+		}
+		return "http://" + net.JoinHostPort(host, port)
+	}
+	return ""
+}
+
+// NewStore returns a DynamoStore for local development. If DetectEndpoint
+// finds a local endpoint, the store uses it with dummy credentials, unless
+// credentials are set in the environment. Otherwise, the store uses the
+// default AWS configuration.
+func NewStore(ctx context.Context, table string, opts ...dynamostore.Option) (*dynamostore.DynamoStore, error) {
+	endpoint := DetectEndpoint()
+
+	var loadOpts []func(*config.LoadOptions) error
+	if endpoint != "" && os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("test", "test", ""),
+		))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	if endpoint != "" {
+		opts = append([]dynamostore.Option{dynamostore.WithEndpoint(endpoint)}, opts...)
+	}
+	return dynamostore.NewFromConfigWithTableName(cfg, table, opts...), nil
+}
@@ -0,0 +1,93 @@
+// Package dynamolocal runs DynamoDB Local for integration tests.
+//
+// Start launches the amazon/dynamodb-local container using testcontainers,
+// unless the environment points at an instance that is already running,
+// such as the one started by docker-compose or LocalStack. See
+// DetectEndpoint.
+package dynamolocal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// EndpointEnv is the environment variable checked first by DetectEndpoint.
+const EndpointEnv = "DYNAMOSTORE_ENDPOINT"
+
+// Image is the container image started by Start.
+const Image = "amazon/dynamodb-local"
+
+const port = "8000/tcp"
+
+// Instance is a running DynamoDB Local.
+type Instance struct {
+	// Endpoint is the URL of the DynamoDB API.
+	Endpoint string
+
+	container testcontainers.Container
+}
+
+// Start returns a running DynamoDB Local. If DetectEndpoint finds an
+// endpoint, it is used. Otherwise, a new container is started, which must be
+// stopped by calling Terminate.
+func Start(ctx context.Context) (*Instance, error) {
+	if endpoint := DetectEndpoint(); endpoint != "" {
+		return &Instance{Endpoint: endpoint}, nil
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        Image,
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForListeningPort(port),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to start %s: %w", Image, err)
+	}
+
+	endpoint, err := container.PortEndpoint(ctx, port, "http")
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	return &Instance{
+		Endpoint:  endpoint,
+		container: container,
+	}, nil
+}
+
+// Client returns a DynamoDB client configured for the instance.
+func (i *Instance) Client() *dynamodb.Client {
+	return NewClient(i.Endpoint)
+}
+
+// Terminate stops the container started by Start, if any.
+func (i *Instance) Terminate(ctx context.Context) error {
+	if i.container == nil {
+		return nil
+	}
+	return i.container.Terminate(ctx)
+}
+
+// NewClient returns a DynamoDB client for the DynamoDB Local instance at
+// endpoint, using dummy credentials.
+func NewClient(endpoint string) *dynamodb.Client {
+	creds := credentials.NewStaticCredentialsProvider("id", "secret", "token")
+	return dynamodb.NewFromConfig(
+		aws.Config{
+			Credentials: creds,
+			Region:      "us-west-2",
+		},
+		func(o *dynamodb.Options) {
+			o.BaseEndpoint = aws.String(endpoint)
+		},
+	)
+}
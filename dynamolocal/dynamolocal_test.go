@@ -0,0 +1,109 @@
+package dynamolocal_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/dynamolocal"
+)
+
+var endpointVars = []string{
+	dynamolocal.EndpointEnv,
+	"AWS_ENDPOINT_URL",
+	"LOCALSTACK_HOSTNAME",
+	"EDGE_PORT",
+}
+
+// setenv replaces the variables checked by DetectEndpoint, and returns a
+// function that restores them.
+func setenv(vars map[string]string) func() {
+	orig := map[string]*string{}
+	for _, name := range endpointVars {
+		if value, ok := os.LookupEnv(name); ok {
+			orig[name] = &value
+		} else {
+			orig[name] = nil
+		}
+		if value, ok := vars[name]; ok {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+	return func() {
+		for name, value := range orig {
+			if value != nil {
+				os.Setenv(name, *value)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+func TestDetectEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		vars     map[string]string
+		expected string
+	}{{
+		vars:     map[string]string{},
+		expected: "",
+	}, {
+		vars: map[string]string{
+			dynamolocal.EndpointEnv: "http://dynamodb:8000",
+			"AWS_ENDPOINT_URL":      "http://localhost:4566",
+		},
+		expected: "http://dynamodb:8000",
+	}, {
+		vars: map[string]string{
+			"AWS_ENDPOINT_URL":    "http://localhost:4566",
+			"LOCALSTACK_HOSTNAME": "localstack",
+		},
+		expected: "http://localhost:4566",
+	}, {
+		vars: map[string]string{
+			"LOCALSTACK_HOSTNAME": "localstack",
+		},
+		expected: "http://localstack:4566",
+	}, {
+		vars: map[string]string{
+			"LOCALSTACK_HOSTNAME": "localstack",
+			"EDGE_PORT":           "4567",
+		},
+		expected: "http://localstack:4567",
+	}} {
+		restore := setenv(tc.vars)
+		actual := dynamolocal.DetectEndpoint()
+		restore()
+		require.Equal(t, tc.expected, actual, tc.vars)
+	}
+}
+
+func TestStartWithEndpoint(t *testing.T) {
+	require := require.New(t)
+
+	defer setenv(map[string]string{
+		dynamolocal.EndpointEnv: "http://dynamodb:8000",
+	})()
+
+	local, err := dynamolocal.Start(context.Background())
+	require.NoError(err)
+	require.Equal("http://dynamodb:8000", local.Endpoint)
+	require.NotNil(local.Client())
+	require.NoError(local.Terminate(context.Background()))
+}
+
+func TestNewStore(t *testing.T) {
+	require := require.New(t)
+
+	defer setenv(map[string]string{
+		"LOCALSTACK_HOSTNAME": "localstack",
+	})()
+
+	store, err := dynamolocal.NewStore(context.Background(), "sessions")
+	require.NoError(err)
+	require.NotNil(store)
+}
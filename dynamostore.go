@@ -3,6 +3,9 @@ package dynamostore
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
@@ -26,89 +29,557 @@ var ErrCreateTimedOut = errors.New("timed out waiting for table creation")
 
 // DynamoStore represents the session store.
 type DynamoStore struct {
-	svc   *dynamodb.Client
-	table *string
+	stats storeStats
+
+	svc    Client
+	reader ItemReader
+	optFns []func(*dynamodb.Options)
+	table  *string
+	codec  scs.Codec
+	now    func() time.Time
+	// sleep waits between polls of the table's status. Tests replace it to
+	// avoid waiting.
+	sleep func(time.Duration)
+
+	consistentRead    bool
+	ttlAttribute      string
+	slidingExpiration time.Duration
+	gracePeriod       time.Duration
+	skipExpiryCheck   bool
+	versioned         bool
+	monotonicExpiry   bool
+	expiryOnlyUpdates bool
+	retention         time.Duration
+	encryption        *encryption
+	dbesdk            bool
+	pepper            *tokenPepper
+	tokenDigest       bool
+	sinks             []eventSink
+	invalidation      *invalidation
+	denylist          *denylist
+	revocationTTL     time.Duration
+	subjectKey        string
+	beforeWrite       PayloadHook
+	afterRead         PayloadHook
+	billing           billing
+	tags              map[string]string
+
+	endpoint        string
+	endpointVariant endpointVariant
+	httpClient      bool
+	retry           retryOptions
+	failover        *regionFailover
+
+	limiter      *capacityLimiter
+	writeBehind  *writeBehind
+	lazyDelete   *lazyDeleter
+	unchanged    *unchangedCache
+	cache        *sessionCache
+	recentWrites *recentWrites
+	coalescer    *commitCoalescer
+	hotTokens    *hotTokens
+	audit        *AuditConfig
+	adaptive     *adaptiveConsistency
+	projection   *projection
+	cursors      cursorKey
+	expiryBucket time.Duration
+	creationTime bool
+
+	attributeIndexes []*attributeIndex
+	subjectIndex     bool
+	replicas         []string
+	global           *GlobalTableConfig
+	absent           *absentTokens
+	autoScaling      AutoScaler
+	resourcePolicy   string
+
+	contributorInsights bool
+
+	// problems found while applying options, reported by Validate.
+	problems []string
+
+	closeOnce sync.Once
+	closeErr  error
+	closers   []func(context.Context) error
+}
+
+// Option configures optional DynamoStore behavior.
+type Option func(*DynamoStore)
+
+// WithCodec sets the codec used to decode session data when the store
+// needs to inspect it, such as reading the expiry of imported sessions. It
+// should match the codec used by the scs.SessionManager. The default is
+// scs.GobCodec.
+func WithCodec(codec scs.Codec) Option {
+	return func(s *DynamoStore) {
+		s.codec = codec
+	}
+}
+
+// WithClock sets the function used to get the current time when deciding
+// whether sessions have expired. It is intended for testing expiry without
+// waiting. The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *DynamoStore) {
+		s.now = now
+	}
+}
+
+// WithExpiryGracePeriod keeps returning sessions for the given duration
+// after they expire, so small differences between the clocks of application
+// servers don't end sessions early. DeleteExpired and Export also allow for
+// the grace period. The default is zero.
+func WithExpiryGracePeriod(d time.Duration) Option {
+	return func(s *DynamoStore) {
+		if d < 0 {
+			s.invalid("WithExpiryGracePeriod requires a duration of zero or more")
+			return
+		}
+		s.gracePeriod = d
+	}
 }
 
+// WithoutExpiryCheck makes Find return expired sessions that DynamoDB
+// hasn't removed yet, along with their expiry, for applications that check
+// expiry themselves. By default, Find ignores expired sessions, because
+// DynamoDB can take days to remove them.
+//
+// Export still skips expired sessions, and DeleteExpired still deletes them.
+func WithoutExpiryCheck() Option {
+	return func(s *DynamoStore) {
+		s.skipExpiryCheck = true
+	}
+}
+
+// hideExpired returns true if Find should ignore a session with the given
+// expiry at now.
+func (s *DynamoStore) hideExpired(expiry, now time.Time) bool {
+	return !s.skipExpiryCheck && s.expiredAt(expiry, now)
+}
+
+// expiredAt returns true if a session with the given expiry has expired at
+// now, allowing for the grace period.
+func (s *DynamoStore) expiredAt(expiry, now time.Time) bool {
+	return expiry.Add(s.gracePeriod).Before(now)
+}
+
+// defaultTTLAttribute must match the name used by sessionItem.
+const defaultTTLAttribute = "ttl"
+
 type sessionItem struct {
-	Token string `dynamodbav:"token,string"`
-	Data  []byte
-	TTL   time.Time `dynamodbav:"ttl,unixtime"`
+	Token    string `dynamodbav:"token,string"`
+	Data     []byte
+	TTL      time.Time `dynamodbav:"ttl,unixtime"`
+	DataHash []byte    `dynamodbav:",omitempty"`
+	Version  int64     `dynamodbav:",omitempty"`
+	Revoked  int64     `dynamodbav:",omitempty"`
+	KeyID    string    `dynamodbav:",omitempty"`
 }
 
 // New creates a DynamoStore instance using default values.
-func New(svc *dynamodb.Client) *DynamoStore {
-	return NewWithTableName(svc, DefaultTableName)
+func New(svc Client, opts ...Option) *DynamoStore {
+	return NewWithTableName(svc, DefaultTableName, opts...)
 }
 
 // NewWithTableName create a DynamoStore instance, overriding the default
 // table name.
-func NewWithTableName(svc *dynamodb.Client, table string) *DynamoStore {
-	return &DynamoStore{
-		svc:   svc,
-		table: aws.String(table),
+func NewWithTableName(svc Client, table string, opts ...Option) *DynamoStore {
+	return newStore(svc, table, opts)
+}
+
+// NewFromConfig creates a DynamoStore instance using default values, and a
+// DynamoDB client created from cfg, such as the result of
+// config.LoadDefaultConfig.
+func NewFromConfig(cfg aws.Config, opts ...Option) *DynamoStore {
+	return NewFromConfigWithTableName(cfg, DefaultTableName, opts...)
+}
+
+// NewFromConfigWithTableName creates a DynamoStore instance using a DynamoDB
+// client created from cfg, overriding the default table name.
+func NewFromConfigWithTableName(cfg aws.Config, table string, opts ...Option) *DynamoStore {
+	return newStore(dynamodb.NewFromConfig(cfg), table, opts)
+}
+
+func newStore(svc Client, table string, opts []Option) *DynamoStore {
+	s := &DynamoStore{
+		svc:    svc,
+		reader: svc,
+		table:  aws.String(table),
+		codec:  scs.GobCodec{},
+		now:    time.Now,
+		sleep:  time.Sleep,
+
+		consistentRead: true,
+		ttlAttribute:   defaultTTLAttribute,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.projection = s.newProjection()
+	if s.retry.enabled() {
+		s.optFns = append(s.optFns, s.retry.apply)
 	}
+	if s.failover != nil {
+		s.optFns = append(s.optFns, s.failover.apply)
+	}
+	if s.endpointVariant.enabled() {
+		s.optFns = append(s.optFns, s.endpointVariant.apply)
+	}
+	if s.writeBehind != nil {
+		s.writeBehind.start(s)
+		s.onClose(s.writeBehind.close)
+	}
+	if s.lazyDelete != nil {
+		s.lazyDelete.start(s)
+		s.onClose(s.lazyDelete.close)
+	}
+	if s.coalescer != nil {
+		s.coalescer.store = s
+		s.onClose(s.coalescer.close)
+	}
+	if s.invalidation != nil {
+		s.invalidation.start(s)
+		s.onClose(s.invalidation.close)
+	}
+	return s
 }
 
 // Find returns the data for a given session token from the DynamoStore instance.
 // If the session token is not found or is expired, the returned exists flag
 // will be set to false.
 func (s *DynamoStore) Find(token string) (b []byte, exists bool, err error) {
-	ctx := context.Background()
-	item, err := s.getItem(ctx, token)
-	switch {
-	case err != nil:
+	return s.FindCtx(context.Background(), token)
+}
+
+// FindCtx is the same as Find, except it takes a context.Context. It is
+// used instead of Find by scs.SessionManager.
+func (s *DynamoStore) FindCtx(ctx context.Context, token string) (b []byte, exists bool, err error) {
+	item, err := s.find(ctx, token)
+	if err != nil || item == nil {
 		return nil, false, err
+	}
+	data, err := s.readHook(ctx, item.Data)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// FindWithExpiry is like Find, but also returns the time the session
+// expires, without decoding the session data.
+func (s *DynamoStore) FindWithExpiry(ctx context.Context, token string) (
+	b []byte, expiry time.Time, exists bool, err error,
+) {
+	item, err := s.find(ctx, token)
+	if err != nil || item == nil {
+		return nil, time.Time{}, false, err
+	}
+	data, err := s.readHook(ctx, item.Data)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	return data, item.TTL, true, nil
+}
+
+// find returns the session with the given token, including changes queued
+// by write-behind, or nil if it doesn't exist or has expired.
+func (s *DynamoStore) find(ctx context.Context, token string) (*sessionItem, error) {
+	atomic.AddInt64(&s.stats.finds, 1)
+	s.usedToken(token, false)
+	if s.writeBehind != nil {
+		if item, ok := s.writeBehind.find(token); ok {
+			return s.unlessDenied(ctx, item)
+		}
+	}
+	item := s.cached(token)
+	cached := item != nil
+	if cached {
+		atomic.AddInt64(&s.stats.cacheHits, 1)
+	} else {
+		var err error
+		consistent := s.readConsistency()
+		item, err = s.readItem(ctx, token, consistent)
+		if err == nil && !consistent && s.recentWrites != nil && s.recentWrites.stale(token, item, s.now()) {
+			s.staleRead()
+			item, err = s.readItem(ctx, token, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.trackVersion(ctx, token, item)
+	now := s.now()
+	switch {
 	case item.Token == "":
-		return nil, false, nil
-	case item.TTL.Before(time.Now()):
-		return nil, false, nil
+		s.forgetUnchanged(token)
+		return nil, nil
+	case item.Revoked != 0:
+		s.forgetUnchanged(token)
+		s.forgetCached(token)
+		return nil, nil
+	case s.hideExpired(item.TTL, now):
+		s.forgetUnchanged(token)
+		s.forgetCached(token)
+		if s.lazyDelete != nil {
+			s.lazyDelete.delete(item.Token, now.Add(-s.gracePeriod))
+		}
+		return nil, nil
+	}
+	if denied, err := s.denied(ctx, token); err != nil || denied {
+		s.forgetCached(token)
+		return nil, err
+	}
+	if s.slidingExpiration > 0 {
+		if err := s.extendExpiry(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+	if s.unchanged != nil {
+		hash := item.DataHash
+		if len(hash) < 1 {
+			hash = hashData(item.Data)
+		}
+		s.unchanged.remember(token, hash, item.TTL)
 	}
-	return item.Data, true, nil
+	if !cached {
+		s.cacheItem(item)
+	}
+	return item, nil
 }
 
 // Commit adds a session token and data to the DynamoStore instance with the
 // given expiry time. If the session token already exists then the data and
 // expiry time are updated.
+//
+// When write-behind is enabled, the write is queued and Commit returns
+// before the item has been saved.
 func (s *DynamoStore) Commit(token string, data []byte, expiry time.Time) error {
-	ctx := context.Background()
-	return s.setItem(ctx, token, data, expiry)
+	return s.CommitCtx(context.Background(), token, data, expiry)
+}
+
+// CommitCtx is the same as Commit, except it takes a context.Context. It is
+// used instead of Commit by scs.SessionManager.
+func (s *DynamoStore) CommitCtx(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	atomic.AddInt64(&s.stats.commits, 1)
+	s.usedToken(token, true)
+	data, err := s.writeHook(ctx, data)
+	if err != nil {
+		return err
+	}
+	if s.writeBehind != nil && s.writeBehind.enqueue(token, &writeOp{
+		item: &sessionItem{Token: token, Data: data, TTL: expiry},
+	}) {
+		return nil
+	}
+	if s.coalescer != nil {
+		return s.coalescer.commit(ctx, token, data, expiry)
+	}
+	return s.commit(ctx, token, data, expiry)
+}
+
+// commit saves a session, unless WithSkipUnchanged finds it unchanged.
+func (s *DynamoStore) commit(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	if s.unchanged == nil {
+		_, err := s.write(ctx, token, data, expiry, types.ReturnValueNone)
+		return ignoreDroppedCommit(err)
+	}
+
+	hash := hashData(data)
+	if s.unchanged.unchanged(token, hash, expiry) {
+		atomic.AddInt64(&s.stats.skippedCommits, 1)
+		return nil
+	}
+	if s.expiryOnlyUpdates && s.unchanged.sameData(token, hash) {
+		updated, err := s.updateExpiry(ctx, token, hash, expiry)
+		if err != nil {
+			return err
+		}
+		if updated {
+			s.unchanged.remember(token, hash, expiry)
+			return nil
+		}
+	}
+	s.unchanged.forget(token)
+	if _, err := s.write(ctx, token, data, expiry, types.ReturnValueNone); err != nil {
+		return ignoreDroppedCommit(err)
+	}
+	s.unchanged.remember(token, hash, expiry)
+	return nil
+}
+
+// CommitAndReturnPrevious is like CommitCtx, but also returns the data and
+// expiry of the session it replaced, without a separate read. If there was
+// no session, or it had expired, existed is false.
+//
+// The write is never skipped by WithSkipUnchanged, and it can't be queued,
+// so CommitAndReturnPrevious fails when write-behind is enabled. If the
+// commit is dropped by WithMonotonicExpiry or WithRetention, existed is
+// false.
+func (s *DynamoStore) CommitAndReturnPrevious(ctx context.Context, token string, data []byte, expiry time.Time) (
+	prevData []byte, prevExpiry time.Time, existed bool, err error,
+) {
+	if s.writeBehind != nil {
+		return nil, time.Time{}, false, errWriteBehind
+	}
+	data, err = s.writeHook(ctx, data)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	s.forgetUnchanged(token)
+	old, err := s.write(ctx, token, data, expiry, types.ReturnValueAllOld)
+	if err != nil {
+		return nil, time.Time{}, false, ignoreDroppedCommit(err)
+	}
+	if s.unchanged != nil {
+		s.unchanged.remember(token, hashData(data), expiry)
+	}
+	if len(old) < 1 {
+		return nil, time.Time{}, false, nil
+	}
+	item, err := s.unmarshalItem(old)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	if s.expiredAt(item.TTL, s.now()) {
+		return nil, time.Time{}, false, nil
+	}
+	prevData, err = s.readHook(ctx, item.Data)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	return prevData, item.TTL, true, nil
+}
+
+// write saves a session, checking its version if optimistic locking is
+// enabled, and returns errRevoked if the token is on the denylist. The
+// attributes of the replaced item are returned when returnValues is ALL_OLD.
+func (s *DynamoStore) write(
+	ctx context.Context, token string, data []byte, expiry time.Time, returnValues types.ReturnValue,
+) (map[string]types.AttributeValue, error) {
+	if denied, err := s.denied(ctx, token); err != nil {
+		return nil, err
+	} else if denied {
+		return nil, errRevoked
+	}
+	if s.versioned {
+		return s.setVersionedItem(ctx, token, data, expiry, returnValues)
+	}
+	return s.setItem(ctx, token, data, expiry, returnValues)
 }
 
 // Delete removes a session token and corresponding data from the DynamoStore
 // instance.
+//
+// When write-behind is enabled, the delete is queued with any pending
+// writes for the same token and Delete returns before it has been applied.
 func (s *DynamoStore) Delete(token string) error {
-	ctx := context.Background()
+	return s.DeleteCtx(context.Background(), token)
+}
+
+// DeleteCtx is the same as Delete, except it takes a context.Context. It is
+// used instead of Delete by scs.SessionManager.
+func (s *DynamoStore) DeleteCtx(ctx context.Context, token string) error {
 	if token == "" {
 		return nil
 	}
-	return s.deleteItem(ctx, token)
+	s.usedToken(token, true)
+	s.forgetUnchanged(token)
+	if s.writeBehind != nil && s.writeBehind.enqueue(token, &writeOp{}) {
+		return nil
+	}
+	if s.coalescer != nil {
+		s.coalescer.cancel(token)
+	}
+	_, err := s.deleteItem(ctx, token, types.ReturnValueNone)
+	return err
+}
+
+// DeleteAndCheck is like DeleteCtx, but also reports whether a session was
+// deleted. If there was no session, or it had expired, existed is false.
+//
+// The delete can't be queued, so DeleteAndCheck fails when write-behind is
+// enabled.
+func (s *DynamoStore) DeleteAndCheck(ctx context.Context, token string) (existed bool, err error) {
+	if token == "" {
+		return false, nil
+	}
+	if s.writeBehind != nil {
+		return false, errWriteBehind
+	}
+	s.usedToken(token, true)
+	s.forgetUnchanged(token)
+	old, err := s.deleteItem(ctx, token, types.ReturnValueAllOld)
+	if err != nil || len(old) < 1 {
+		return false, err
+	}
+	item, err := s.unmarshalItem(old)
+	if err != nil {
+		return false, err
+	}
+	return !s.expiredAt(item.TTL, s.now()), nil
 }
 
 // CreateTable creates the session store table, if it doesn't already exist.
 // This is only intended as a convenience function to make development and
 // testing easier. It is not intended for use in production.
+//
+// When WithAutoScaling is used, new tables are registered with Application
+// Auto Scaling. When WithReplicas is used, CreateTable also adds the
+// replicas, as AddReplicas does, even if the table already exists.
+//
+// CreateTable is safe to call from several instances at once. When another
+// caller creates the table first, CreateTable waits for it to become active,
+// and then configures it, as that caller does.
 func (s *DynamoStore) CreateTable() error {
 	ctx := context.Background()
 	if ok, err := s.checkForTable(ctx); err != nil {
 		return err
-	} else if ok {
-		return nil
+	} else if !ok {
+		if err := s.createNewTable(ctx); err != nil {
+			return err
+		}
 	}
+	if len(s.replicas) > 0 {
+		return s.AddReplicas(ctx)
+	}
+	return nil
+}
+
+// createNewTable creates and configures the table. If another caller created
+// the table after checkForTable, it waits for that table to become active,
+// and configures it too, in case that caller fails before configuring it.
+// Each setting is applied in a way that is safe to repeat.
+func (s *DynamoStore) createNewTable(ctx context.Context) error {
 	if err := s.createTable(ctx); err != nil {
+		var inUseErr *types.ResourceInUseException
+		if !errors.As(err, &inUseErr) {
+			return err
+		}
+		if err := s.waitForActiveTable(ctx, s.table); err != nil {
+			return err
+		}
+	} else if err := s.waitForTable(ctx, s.table); err != nil {
 		return err
 	}
-	if err := s.waitForTable(ctx); err != nil {
+	if err := s.enableTTL(ctx, s.table, s.ttlAttribute); err != nil {
 		return err
 	}
-	return s.updateTTL(ctx)
+	if s.contributorInsights {
+		if err := s.enableContributorInsights(ctx); err != nil {
+			return err
+		}
+	}
+	if s.autoScaling != nil {
+		return s.ConfigureAutoScaling(ctx)
+	}
+	return nil
 }
 
 func (s *DynamoStore) checkForTable(ctx context.Context) (bool, error) {
 	describeTable := &dynamodb.DescribeTableInput{
 		TableName: s.table,
 	}
-	result, err := s.svc.DescribeTable(ctx, describeTable)
+	result, err := s.svc.DescribeTable(ctx, describeTable, s.optFns...)
 	if err != nil {
 		var notFoundErr *types.ResourceNotFoundException
 		if errors.As(err, &notFoundErr) {
@@ -118,7 +589,7 @@ func (s *DynamoStore) checkForTable(ctx context.Context) (bool, error) {
 	}
 	switch status := result.Table.TableStatus; status {
 	case types.TableStatusCreating:
-		return true, s.waitForTable(ctx)
+		return true, s.waitForTable(ctx, s.table)
 	case types.TableStatusDeleting:
 		return false, ErrDeleteInProgress
 	case types.TableStatusActive, types.TableStatusUpdating:
@@ -129,9 +600,18 @@ func (s *DynamoStore) checkForTable(ctx context.Context) (bool, error) {
 }
 
 func (s *DynamoStore) createTable(ctx context.Context) error {
-	createTable := &dynamodb.CreateTableInput{
-		BillingMode: types.BillingModePayPerRequest,
-		TableName:   s.table,
+	_, err := s.svc.CreateTable(ctx, s.createTableInput(), s.optFns...)
+	return err
+}
+
+func (s *DynamoStore) createTableInput() *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		BillingMode:           s.billing.mode(),
+		ProvisionedThroughput: s.billing.throughput(),
+		OnDemandThroughput:    s.billing.onDemandThroughput(false),
+		WarmThroughput:        s.billing.warmThroughput(),
+		Tags:                  s.tableTags(),
+		TableName:             s.table,
 		KeySchema: []types.KeySchemaElement{
 			{
 				AttributeName: aws.String("token"),
@@ -145,81 +625,399 @@ func (s *DynamoStore) createTable(ctx context.Context) error {
 			},
 		},
 	}
-	_, err := s.svc.CreateTable(ctx, createTable)
-	return err
+	if s.expiryBucket > 0 {
+		input.AttributeDefinitions = append(input.AttributeDefinitions,
+			types.AttributeDefinition{
+				AttributeName: aws.String(expiryBucketAttribute),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
+			types.AttributeDefinition{
+				AttributeName: aws.String(s.ttlAttribute),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
+		)
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, s.expiryIndex())
+	}
+	for _, idx := range s.attributeIndexes {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: aws.String(idx.attribute),
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, s.attributeIndex(idx))
+	}
+	if s.subjectIndex {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: aws.String(subjectAttribute),
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, s.subjectIndexDefinition())
+	}
+	if s.resourcePolicy != "" {
+		input.ResourcePolicy = aws.String(s.resourcePolicy)
+	}
+	return input
 }
 
-func (s *DynamoStore) deleteItem(ctx context.Context, token string) error {
-	_, err := s.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: s.table,
-		Key: map[string]types.AttributeValue{
-			"token": &types.AttributeValueMemberS{
-				Value: token,
-			},
-		},
-	})
-	return err
+func (s *DynamoStore) deleteItem(
+	ctx context.Context, token string, returnValues types.ReturnValue,
+) (map[string]types.AttributeValue, error) {
+	units, err := s.limiter.waitWrite(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	returnValues = s.eventReturnValues(returnValues, types.ReturnValueAllOld)
+	var old map[string]types.AttributeValue
+	if s.retention > 0 {
+		result, err := s.revokeItem(ctx, token, returnValues)
+		if err != nil {
+			return nil, err
+		}
+		s.limiter.consumedWrite(units, result.ConsumedCapacity)
+		old = result.Attributes
+	} else {
+		key := newItemKey(token)
+		result, err := s.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:              s.table,
+			Key:                    key.av,
+			ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+			ReturnValues:           returnValues,
+		}, s.optFns...)
+		key.release()
+		if err != nil {
+			return nil, err
+		}
+		s.limiter.consumedWrite(units, result.ConsumedCapacity)
+		old = result.Attributes
+	}
+	s.rememberDelete(token)
+	s.forgetCached(token)
+	s.rememberDeleted(token)
+	s.publishInvalidation(ctx, token)
+	if s.recordsEvents() {
+		var data []byte
+		if item, err := s.unmarshalItem(old); err == nil {
+			data = item.Data
+		}
+		s.recordEvent(ctx, AuditDelete, token, data)
+	}
+	return old, nil
 }
 
 func (s *DynamoStore) getItem(ctx context.Context, token string) (*sessionItem, error) {
-	result, err := s.svc.GetItem(ctx, &dynamodb.GetItemInput{
-		ConsistentRead: aws.Bool(true),
-		TableName:      s.table,
-		Key: map[string]types.AttributeValue{
-			"token": &types.AttributeValueMemberS{
-				Value: token,
-			},
-		},
-	})
+	return s.readItem(ctx, token, s.consistentRead)
+}
+
+func (s *DynamoStore) readItem(ctx context.Context, token string, consistent bool) (*sessionItem, error) {
+	units, err := s.limiter.waitRead(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	item := &sessionItem{}
-	err = attributevalue.UnmarshalMap(result.Item, item)
+	key := newItemKey(token)
+	defer key.release()
+	input := &dynamodb.GetItemInput{
+		ConsistentRead:           aws.Bool(consistent),
+		TableName:                s.table,
+		Key:                      key.av,
+		ProjectionExpression:     s.projection.expr,
+		ExpressionAttributeNames: s.projection.names,
+		ReturnConsumedCapacity:   s.limiter.returnConsumedCapacity(),
+	}
+	result, err := s.reader.GetItem(ctx, input, s.optFns...)
 	if err != nil {
 		return nil, err
 	}
+	s.limiter.consumedRead(units, result.ConsumedCapacity)
+	s.stats.read(result.Item)
+
+	return s.unmarshalItem(s.readRemote(ctx, input, result.Item))
+}
 
+// marshalItem builds the item for a session. It produces the same item as
+// attributevalue.MarshalMap would for a sessionItem, without reflection, and
+// then encrypts the data if WithEncryption is used.
+func (s *DynamoStore) marshalItem(
+	token string, data []byte, expiry time.Time,
+) (map[string]types.AttributeValue, error) {
+	av := newItemMap()
+	av["token"] = &types.AttributeValueMemberS{Value: token}
+	if data == nil {
+		av["Data"] = &types.AttributeValueMemberNULL{Value: true}
+	} else {
+		av["Data"] = &types.AttributeValueMemberB{Value: data}
+	}
+	av[s.ttlAttribute] = &types.AttributeValueMemberN{
+		Value: strconv.FormatInt(expiry.Unix(), 10),
+	}
+	if s.unchanged != nil {
+		av[dataHashAttribute] = &types.AttributeValueMemberB{Value: hashData(data)}
+	}
+	s.bucketItem(av, expiry)
+	s.indexItem(av, data)
+	s.subjectItem(av, data)
+	s.regionItem(av)
+	if err := s.digestItem(av, token); err != nil {
+		return nil, err
+	}
+	if err := s.encryptItem(av, token, data); err != nil {
+		return nil, err
+	}
+	return av, nil
+}
+
+// unmarshalItem decodes a session. Items with the attribute types written by
+// marshalItem are decoded directly, and anything else is left to
+// attributevalue.UnmarshalMap.
+func (s *DynamoStore) unmarshalItem(av map[string]types.AttributeValue) (*sessionItem, error) {
+	item, ok := s.decodeItem(av)
+	if !ok {
+		var err error
+		if item, err = s.unmarshalItemSlow(av); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.decryptItem(item); err != nil {
+		return nil, err
+	}
 	return item, nil
 }
 
-func (s *DynamoStore) setItem(ctx context.Context, token string, data []byte, expiry time.Time) error {
-	av, err := attributevalue.MarshalMap(&sessionItem{
-		Token: token,
-		Data:  data,
-		TTL:   expiry,
-	})
+// unmarshalItemSlow decodes a session with attributevalue.UnmarshalMap.
+func (s *DynamoStore) unmarshalItemSlow(av map[string]types.AttributeValue) (*sessionItem, error) {
+	if ttl, ok := av[s.ttlAttribute]; ok && s.ttlAttribute != defaultTTLAttribute {
+		renamed := make(map[string]types.AttributeValue, len(av))
+		for k, v := range av {
+			renamed[k] = v
+		}
+		delete(renamed, s.ttlAttribute)
+		renamed[defaultTTLAttribute] = ttl
+		av = renamed
+	}
+
+	item := &sessionItem{}
+	if err := attributevalue.UnmarshalMap(av, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// decodeItem decodes items written by marshalItem without reflection. It
+// returns false if the item has any other shape.
+func (s *DynamoStore) decodeItem(av map[string]types.AttributeValue) (*sessionItem, bool) {
+	item := &sessionItem{}
+	if len(av) < 1 {
+		return item, true
+	}
+
+	token, ok := av["token"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, false
+	}
+	item.Token = token.Value
+
+	switch data := av["Data"].(type) {
+	case *types.AttributeValueMemberB:
+		item.Data = data.Value
+	case *types.AttributeValueMemberNULL, nil:
+	default:
+		return nil, false
+	}
+
+	ttl, ok := av[s.ttlAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, false
+	}
+	seconds, err := strconv.ParseInt(ttl.Value, 10, 64)
 	if err != nil {
-		return err
+		return nil, false
 	}
+	item.TTL = time.Unix(seconds, 0)
 
-	_, err = s.svc.PutItem(ctx, &dynamodb.PutItemInput{
-		Item:      av,
-		TableName: s.table,
-	})
-	return err
+	switch hash := av[dataHashAttribute].(type) {
+	case *types.AttributeValueMemberB:
+		item.DataHash = hash.Value
+	case nil:
+	default:
+		return nil, false
+	}
+
+	switch version := av[versionAttribute].(type) {
+	case *types.AttributeValueMemberN:
+		if item.Version, err = strconv.ParseInt(version.Value, 10, 64); err != nil {
+			return nil, false
+		}
+	case nil:
+	default:
+		return nil, false
+	}
+
+	switch revoked := av[revokedAttribute].(type) {
+	case *types.AttributeValueMemberN:
+		if item.Revoked, err = strconv.ParseInt(revoked.Value, 10, 64); err != nil {
+			return nil, false
+		}
+	case nil:
+	default:
+		return nil, false
+	}
+
+	switch keyID := av[keyIDAttribute].(type) {
+	case *types.AttributeValueMemberS:
+		item.KeyID = keyID.Value
+	case nil:
+	default:
+		return nil, false
+	}
+	return item, true
 }
 
-func (s *DynamoStore) updateTTL(ctx context.Context) error {
+func (s *DynamoStore) setItem(
+	ctx context.Context, token string, data []byte, expiry time.Time, returnValues types.ReturnValue,
+) (map[string]types.AttributeValue, error) {
+	av, err := s.marshalItem(token, data, expiry)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseItemMap(av)
+
+	units, err := s.limiter.waitWrite(ctx, itemSize(av))
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.PutItemInput{
+		Item:                   av,
+		TableName:              s.table,
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		ReturnValues:           s.eventReturnValues(returnValues, types.ReturnValueAllOld),
+	}
+	if s.monotonicExpiry {
+		s.requireLaterExpiry(input)
+	}
+	if s.tombstones() {
+		s.requireNotRevoked(input)
+	}
+	var result *dynamodb.PutItemOutput
+	if s.creationTime {
+		result, err = s.putKeepingCreation(ctx, input)
+	} else {
+		result, err = s.svc.PutItem(ctx, input, s.optFns...)
+	}
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		switch {
+		case !errors.As(err, &conditionErr):
+		case s.monotonicExpiry:
+			return nil, errShorterExpiry
+		case s.tombstones():
+			return nil, errRevoked
+		}
+		return nil, err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.rememberWrite(token, expiry)
+	s.stats.wrote(data, av)
+	s.cacheItem(&sessionItem{Token: token, Data: data, TTL: time.Unix(expiry.Unix(), 0)})
+	s.publishInvalidation(ctx, token)
+	s.recordWrite(ctx, token, data, result.Attributes)
+	return result.Attributes, nil
+}
+
+func (s *DynamoStore) updateTTL(ctx context.Context, table *string, attribute string) error {
 	updateTTL := &dynamodb.UpdateTimeToLiveInput{
-		TableName: s.table,
+		TableName: table,
 		TimeToLiveSpecification: &types.TimeToLiveSpecification{
-			AttributeName: aws.String("ttl"),
+			AttributeName: aws.String(attribute),
 			Enabled:       aws.Bool(true),
 		},
 	}
-	_, err := s.svc.UpdateTimeToLive(ctx, updateTTL)
+	_, err := s.svc.UpdateTimeToLive(ctx, updateTTL, s.optFns...)
+	return err
+}
+
+// enableTTL enables TTL on a table, unless it is already enabled for
+// attribute, such as by another caller of CreateTable.
+func (s *DynamoStore) enableTTL(ctx context.Context, table *string, attribute string) error {
+	if enabled, err := s.ttlEnabled(ctx, table, attribute); err != nil || enabled {
+		return err
+	}
+	err := s.updateTTL(ctx, table, attribute)
+	if err != nil {
+		// Another caller may have enabled TTL since it was checked.
+		if enabled, _ := s.ttlEnabled(ctx, table, attribute); enabled {
+			return nil
+		}
+	}
 	return err
 }
 
-func (s *DynamoStore) waitForTable(ctx context.Context) error {
+// ttlEnabled reports whether TTL is enabled, or being enabled, for
+// attribute.
+func (s *DynamoStore) ttlEnabled(ctx context.Context, table *string, attribute string) (bool, error) {
+	result, err := s.svc.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: table,
+	}, s.optFns...)
+	if err != nil {
+		return false, err
+	}
+	desc := result.TimeToLiveDescription
+	if desc == nil || aws.ToString(desc.AttributeName) != attribute {
+		return false, nil
+	}
+	switch desc.TimeToLiveStatus {
+	case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+		return true, nil
+	}
+	return false, nil
+}
+
+// waitForActiveTable waits for a table being created by another caller to
+// become active. Unlike waitForTable, it fails if the table doesn't exist,
+// such as when the other caller deleted it.
+func (s *DynamoStore) waitForActiveTable(ctx context.Context, table *string) error {
 	describeTable := &dynamodb.DescribeTableInput{
-		TableName: s.table,
+		TableName: table,
+	}
+	for i := 0; i < 60; i++ {
+		s.sleep(1 * time.Second)
+		result, err := s.svc.DescribeTable(ctx, describeTable, s.optFns...)
+		if err != nil {
+			return err
+		}
+		switch result.Table.TableStatus {
+		case types.TableStatusActive:
+			return nil
+		case types.TableStatusDeleting:
+			return ErrDeleteInProgress
+		}
+	}
+	return ErrCreateTimedOut
+}
+
+// createTTLTable creates a table used alongside the session table, such as
+// the audit or denylist table, and enables TTL on it. Like CreateTable, if another caller
+// created the table first, it waits for that table to become active, and
+// enables TTL in case that caller didn't.
+func (s *DynamoStore) createTTLTable(ctx context.Context, input *dynamodb.CreateTableInput) error {
+	_, err := s.svc.CreateTable(ctx, input, s.optFns...)
+	var inUseErr *types.ResourceInUseException
+	switch {
+	case err == nil:
+		err = s.waitForTable(ctx, input.TableName)
+	case errors.As(err, &inUseErr):
+		err = s.waitForActiveTable(ctx, input.TableName)
+	}
+	if err != nil {
+		return err
+	}
+	return s.enableTTL(ctx, input.TableName, defaultTTLAttribute)
+}
+
+func (s *DynamoStore) waitForTable(ctx context.Context, table *string) error {
+	describeTable := &dynamodb.DescribeTableInput{
+		TableName: table,
 	}
 	for i := 0; i < 60; i++ {
-		time.Sleep(1 * time.Second)
-		result, err := s.svc.DescribeTable(ctx, describeTable)
+		s.sleep(1 * time.Second)
+		result, err := s.svc.DescribeTable(ctx, describeTable, s.optFns...)
 		if err != nil {
 			var notFoundErr *types.ResourceNotFoundException
 			if errors.As(err, &notFoundErr) {
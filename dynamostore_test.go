@@ -7,3 +7,4 @@ import (
 )
 
 var _ scs.Store = dynamostore.New(nil)
+var _ scs.CtxStore = dynamostore.New(nil)
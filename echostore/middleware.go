@@ -0,0 +1,19 @@
+// Package echostore adapts dynamostore for use with echo's session
+// middleware.
+package echostore
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+
+	"github.com/sjansen/dynamostore/gorillastore"
+)
+
+// Middleware returns echo middleware that makes sessions backed by store,
+// usually a DynamoStore, available through session.Get().
+//
+// See gorillastore.New() for a description of the keyPairs parameter.
+func Middleware(store scs.Store, keyPairs ...[]byte) echo.MiddlewareFunc {
+	return session.Middleware(gorillastore.New(store, keyPairs...))
+}
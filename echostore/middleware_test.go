@@ -0,0 +1,54 @@
+package echostore_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/echostore"
+)
+
+func TestMiddleware(t *testing.T) {
+	require := require.New(t)
+
+	e := echo.New()
+	e.Use(echostore.Middleware(memstore.NewWithCleanupInterval(0), []byte("secret")))
+	e.GET("/login", func(c echo.Context) error {
+		sess, err := session.Get("session", c)
+		if err != nil {
+			return err
+		}
+		sess.Values["user"] = "alice"
+		if err := sess.Save(c.Request(), c.Response()); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/whoami", func(c echo.Context) error {
+		sess, err := session.Get("session", c)
+		if err != nil {
+			return err
+		}
+		user, _ := sess.Values["user"].(string)
+		return c.String(http.StatusOK, user)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/login", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(http.StatusNoContent, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(cookies, 1)
+
+	req = httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(http.StatusOK, rec.Code)
+	require.Equal("alice", rec.Body.String())
+}
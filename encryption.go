@@ -0,0 +1,287 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// keyIDAttribute stores the ID of the key used to encrypt a session, when
+// WithEncryption is used.
+const keyIDAttribute = "KeyID"
+
+var errNoEncryption = errors.New("requires WithEncryption")
+
+// Keyring holds the keys used by WithEncryption.
+type Keyring struct {
+	// Current identifies the key used to encrypt sessions.
+	Current string `json:"current"`
+	// Keys maps key IDs to AES keys, which must be 16, 24, or 32 bytes
+	// long. Keys other than the current key are only used to decrypt
+	// sessions written before the current key was introduced.
+	Keys map[string][]byte `json:"keys"`
+}
+
+// WithEncryption encrypts session data with AES-GCM before it is written to
+// the table, using the current key in keys. The ID of the key is stored with
+// each session, so keys can be rotated by adding a new key, making it
+// current, and keeping the old keys until every session encrypted with them
+// has been rewritten or has expired. Sessions are re-encrypted with the
+// current key whenever they are committed, and RotateEncryption re-encrypts
+// the rest.
+//
+// Sessions written without encryption can still be read, so encryption can
+// be enabled on an existing table. The token is authenticated along with the
+// data, so encrypted data can't be moved to another session. Stores that
+// don't use WithEncryption return encrypted data as is, so every instance
+// sharing the table must use it. WithSkipUnchanged stores an unkeyed hash of
+// the unencrypted data.
+func WithEncryption(keys *Keyring) Option {
+	return func(s *DynamoStore) {
+		ks, err := newKeyset(keys)
+		if err != nil {
+			s.invalid("WithEncryption %s", err)
+			return
+		}
+		s.encryption = &encryption{keys: ks}
+	}
+}
+
+// encryption holds the keys used to encrypt and decrypt sessions, and
+// refreshes them from provider, if there is one.
+type encryption struct {
+	provider KeyProvider
+	refresh  time.Duration
+
+	mu      sync.Mutex
+	fetched time.Time
+	keys    *keyset
+}
+
+// keyset returns the current keys, fetching them from the provider first if
+// they are missing or older than the refresh interval. If a refresh fails,
+// the previous keys are used until the next interval.
+func (e *encryption) keyset(now time.Time) (*keyset, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.provider == nil || (e.keys != nil && now.Sub(e.fetched) < e.refresh) {
+		return e.keys, nil
+	}
+	keys, err := e.provider.Keyring(context.Background())
+	if err == nil {
+		var ks *keyset
+		if ks, err = newKeyset(keys); err == nil {
+			e.keys = ks
+		}
+	}
+	if err != nil && e.keys == nil {
+		return nil, fmt.Errorf("dynamostore: fetching encryption keys: %w", err)
+	}
+	e.fetched = now
+	return e.keys, nil
+}
+
+// additionalData holds buffers for the token authenticated along with each
+// session's data. AES-GCM doesn't retain it, so the buffers can be reused.
+var additionalData = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// keyset is a Keyring, ready for use.
+type keyset struct {
+	current string
+	ciphers map[string]cipher.AEAD
+}
+
+func newKeyset(keys *Keyring) (*keyset, error) {
+	if keys == nil || keys.Current == "" {
+		return nil, errors.New("requires a current key")
+	}
+	ciphers := make(map[string]cipher.AEAD, len(keys.Keys))
+	for id, key := range keys.Keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %s", id, err)
+		}
+		if ciphers[id], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("key %q: %s", id, err)
+		}
+	}
+	if _, ok := ciphers[keys.Current]; !ok {
+		return nil, fmt.Errorf("current key %q is missing", keys.Current)
+	}
+	return &keyset{current: keys.Current, ciphers: ciphers}, nil
+}
+
+// seal encrypts data with the current key, returning the key ID and the
+// nonce followed by the ciphertext.
+func (ks *keyset) seal(token string, data []byte) (string, []byte, error) {
+	aead := ks.ciphers[ks.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+	ad := additionalData.Get().(*[]byte)
+	*ad = append((*ad)[:0], token...)
+	sealed := aead.Seal(nonce, nonce, data, *ad)
+	additionalData.Put(ad)
+	return ks.current, sealed, nil
+}
+
+// open decrypts data sealed with the given key.
+func (ks *keyset) open(keyID, token string, sealed []byte) ([]byte, error) {
+	aead, ok := ks.ciphers[keyID]
+	if !ok {
+		return nil, fmt.Errorf("dynamostore: unknown encryption key %q", keyID)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("dynamostore: encrypted session is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	ad := additionalData.Get().(*[]byte)
+	*ad = append((*ad)[:0], token...)
+	data, err := aead.Open(nil, nonce, ciphertext, *ad)
+	additionalData.Put(ad)
+	if err != nil {
+		return nil, fmt.Errorf("dynamostore: decrypting session: %w", err)
+	}
+	return data, nil
+}
+
+// encryptItem replaces the data in av with its encrypted form, if
+// encryption is enabled.
+func (s *DynamoStore) encryptItem(av map[string]types.AttributeValue, token string, data []byte) error {
+	if s.encryption == nil || data == nil {
+		return nil
+	}
+	ks, err := s.encryption.keyset(s.now())
+	if err != nil {
+		return err
+	}
+	keyID, sealed, err := ks.seal(token, data)
+	if err != nil {
+		return err
+	}
+	av["Data"] = &types.AttributeValueMemberB{Value: sealed}
+	av[keyIDAttribute] = &types.AttributeValueMemberS{Value: keyID}
+	return nil
+}
+
+// decryptItem replaces the data in item with its decrypted form, if it was
+// encrypted.
+func (s *DynamoStore) decryptItem(item *sessionItem) error {
+	if item.KeyID == "" {
+		return nil
+	}
+	if s.encryption == nil {
+		return errors.New("dynamostore: session is encrypted, but WithEncryption isn't used")
+	}
+	ks, err := s.encryption.keyset(s.now())
+	if err != nil {
+		return err
+	}
+	data, err := ks.open(item.KeyID, item.Token, item.Data)
+	if err != nil {
+		return err
+	}
+	item.Data = data
+	return nil
+}
+
+// RotateEncryption re-encrypts every unexpired session that isn't encrypted
+// with the current key, including sessions written before encryption was
+// enabled, and returns the number of sessions rewritten. Each update is
+// conditional on the stored data not having changed since it was read, so
+// concurrent commits are never lost. Once it returns without an error, keys
+// other than the current key are no longer needed to read unexpired
+// sessions.
+//
+// The table is scanned, so use WithCapacityLimit to limit the impact on other
+// users of the table.
+func (s *DynamoStore) RotateEncryption(ctx context.Context) (int, error) {
+	if s.encryption == nil {
+		return 0, errNoEncryption
+	}
+	now := s.now()
+	ks, err := s.encryption.keyset(now)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = s.scan(ctx, &dynamodb.ScanInput{
+		FilterExpression:         aws.String("attribute_not_exists(#keyid) OR #keyid <> :current"),
+		ExpressionAttributeNames: map[string]string{"#keyid": keyIDAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":current": &types.AttributeValueMemberS{Value: ks.current},
+		},
+	}, func(av map[string]types.AttributeValue) error {
+		item, err := s.unmarshalItem(av)
+		if err != nil {
+			return err
+		}
+		if item.KeyID == ks.current || item.Data == nil || s.expiredAt(item.TTL, now) {
+			return nil
+		}
+		rotated, err := s.reencrypt(ctx, item, av["Data"])
+		if rotated {
+			n++
+		}
+		return err
+	})
+	return n, err
+}
+
+// reencrypt rewrites the data of item with the current key, if the stored
+// data is still old. It returns false if the session has changed.
+func (s *DynamoStore) reencrypt(ctx context.Context, item *sessionItem, old types.AttributeValue) (bool, error) {
+	av := map[string]types.AttributeValue{}
+	if err := s.encryptItem(av, item.Token, item.Data); err != nil {
+		return false, err
+	}
+
+	units, err := s.limiter.waitWrite(ctx, itemSize(av))
+	if err != nil {
+		return false, err
+	}
+	result, err := s.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: item.Token,
+			},
+		},
+		UpdateExpression:    aws.String("SET #data = :data, #keyid = :keyid"),
+		ConditionExpression: aws.String("#data = :old"),
+		ExpressionAttributeNames: map[string]string{
+			"#data":  "Data",
+			"#keyid": keyIDAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":data":  av["Data"],
+			":keyid": av[keyIDAttribute],
+			":old":   old,
+		},
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// The session was committed or deleted after it was read.
+			return false, nil
+		}
+		return false, err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.forgetCached(item.Token)
+	return true, nil
+}
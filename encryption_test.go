@@ -0,0 +1,93 @@
+package dynamostore_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithEncryption(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	svc := fake.NewClient()
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	expiry := time.Now().Add(time.Hour)
+
+	// given sessions written without encryption and with an old key
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	plain := dynamostore.New(svc)
+	require.NoError(plain.Commit("plain", []byte("foo"), expiry))
+	v1 := dynamostore.New(svc, dynamostore.WithEncryption(&dynamostore.Keyring{
+		Current: "v1",
+		Keys:    map[string][]byte{"v1": oldKey},
+	}))
+	require.NoError(v1.Validate())
+	require.NoError(v1.Commit("old", []byte("bar"), expiry))
+
+	// then the data is encrypted
+	details, err := v1.Inspect(ctx, "old")
+	require.NoError(err)
+	require.Equal([]byte("bar"), details.Data)
+	require.NotContains(string(details.Attributes["Data"].(*types.AttributeValueMemberB).Value), "bar")
+	require.Equal(&types.AttributeValueMemberS{Value: "v1"}, details.Attributes["KeyID"])
+
+	// when the key is rotated
+	v2 := dynamostore.New(svc, dynamostore.WithEncryption(&dynamostore.Keyring{
+		Current: "v2",
+		Keys:    map[string][]byte{"v1": oldKey, "v2": newKey},
+	}))
+	require.NoError(v2.Commit("new", []byte("qux"), expiry))
+
+	// then every session can be read
+	for token, expected := range map[string]string{"plain": "foo", "old": "bar", "new": "qux"} {
+		data, exists, err := v2.Find(token)
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte(expected), data)
+	}
+
+	// when existing sessions are re-encrypted
+	n, err := v2.RotateEncryption(ctx)
+
+	// then the old key is no longer needed
+	require.NoError(err)
+	require.Equal(2, n)
+	v2only := dynamostore.New(svc, dynamostore.WithEncryption(&dynamostore.Keyring{
+		Current: "v2",
+		Keys:    map[string][]byte{"v2": newKey},
+	}))
+	for token, expected := range map[string]string{"plain": "foo", "old": "bar", "new": "qux"} {
+		data, exists, err := v2only.Find(token)
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte(expected), data)
+	}
+	n, err = v2.RotateEncryption(ctx)
+	require.NoError(err)
+	require.Zero(n)
+}
+
+func TestWithEncryptionValidation(t *testing.T) {
+	for name, keys := range map[string]*dynamostore.Keyring{
+		"nil":         nil,
+		"no current":  {Keys: map[string][]byte{"v1": make([]byte, 32)}},
+		"missing":     {Current: "v2", Keys: map[string][]byte{"v1": make([]byte, 32)}},
+		"bad key":     {Current: "v1", Keys: map[string][]byte{"v1": make([]byte, 7)}},
+		"bad old key": {Current: "v1", Keys: map[string][]byte{"v0": nil, "v1": make([]byte, 32)}},
+	} {
+		keys := keys
+		t.Run(name, func(t *testing.T) {
+			store := fake.New(dynamostore.WithEncryption(keys))
+			require.Error(t, store.Validate())
+		})
+	}
+}
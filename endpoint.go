@@ -0,0 +1,109 @@
+package dynamostore
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// endpointVariant selects alternative forms of the regional DynamoDB
+// endpoint.
+type endpointVariant struct {
+	fips      bool
+	dualStack bool
+}
+
+// WithFIPSEndpoint sends every request the store makes to the FIPS 140-2
+// validated DynamoDB endpoint for the client's region, such as
+// dynamodb-fips.us-east-1.amazonaws.com. The standard endpoints in the AWS
+// GovCloud (US) regions are already FIPS validated, and are used as is.
+// Requests fail in regions without a FIPS endpoint.
+func WithFIPSEndpoint() Option {
+	return func(s *DynamoStore) {
+		s.endpointVariant.fips = true
+	}
+}
+
+// WithDualStackEndpoint sends every request the store makes to the
+// dual-stack DynamoDB endpoint for the client's region, such as
+// dynamodb.us-east-1.api.aws, which accepts both IPv4 and IPv6 connections.
+func WithDualStackEndpoint() Option {
+	return func(s *DynamoStore) {
+		s.endpointVariant.dualStack = true
+	}
+}
+
+// enabled returns true if a non-standard endpoint was requested.
+func (v endpointVariant) enabled() bool {
+	return v.fips || v.dualStack
+}
+
+// apply wraps the client's endpoint resolver. It must be the last of the
+// store's per-request options, so endpoints set by other options are seen.
+// Base endpoints, such as the one set by WithEndpoint, are left unchanged.
+func (v endpointVariant) apply(o *dynamodb.Options) {
+	if o.BaseEndpoint != nil {
+		return
+	}
+	resolver := o.EndpointResolver
+	if resolver == nil {
+		resolver = dynamodb.NewDefaultEndpointResolver()
+	}
+	o.EndpointResolver = dynamodb.EndpointResolverFunc(
+		func(region string, options dynamodb.EndpointResolverOptions) (aws.Endpoint, error) {
+			endpoint, err := resolver.ResolveEndpoint(region, options)
+			if err != nil {
+				return endpoint, err
+			}
+			return v.rewrite(endpoint)
+		},
+	)
+}
+
+// rewrite converts a standard regional endpoint to the requested variant.
+// Custom endpoints, such as DynamoDB Local, are returned unchanged.
+func (v endpointVariant) rewrite(endpoint aws.Endpoint) (aws.Endpoint, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return endpoint, err
+	}
+
+	host := u.Hostname()
+	var suffix, dualStackSuffix string
+	switch {
+	case !strings.HasPrefix(host, "dynamodb."):
+		return endpoint, nil
+	case strings.HasSuffix(host, ".amazonaws.com"):
+		suffix, dualStackSuffix = ".amazonaws.com", ".api.aws"
+	case strings.HasSuffix(host, ".amazonaws.com.cn"):
+		suffix, dualStackSuffix = ".amazonaws.com.cn", ".api.amazonwebservices.com.cn"
+	default:
+		return endpoint, nil
+	}
+
+	region := strings.TrimSuffix(strings.TrimPrefix(host, "dynamodb."), suffix)
+	prefix := "dynamodb."
+	if v.fips {
+		switch {
+		case strings.HasPrefix(region, "us-gov-"):
+		case strings.HasPrefix(region, "cn-"):
+			return endpoint, fmt.Errorf("no FIPS endpoint for DynamoDB in %s", region)
+		default:
+			prefix = "dynamodb-fips."
+		}
+	}
+	if v.dualStack {
+		suffix = dualStackSuffix
+	}
+
+	port := u.Port()
+	u.Host = prefix + region + suffix
+	if port != "" {
+		u.Host += ":" + port
+	}
+	endpoint.URL = u.String()
+	return endpoint, nil
+}
@@ -0,0 +1,74 @@
+package dynamostore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointVariantRewrite(t *testing.T) {
+	for _, tc := range []struct {
+		url       string
+		fips      bool
+		dualStack bool
+		expected  string
+		fails     bool
+	}{{
+		url:      "https://dynamodb.us-east-1.amazonaws.com",
+		fips:     true,
+		expected: "https://dynamodb-fips.us-east-1.amazonaws.com",
+	}, {
+		url:       "https://dynamodb.us-east-1.amazonaws.com",
+		dualStack: true,
+		expected:  "https://dynamodb.us-east-1.api.aws",
+	}, {
+		url:       "https://dynamodb.ca-central-1.amazonaws.com",
+		fips:      true,
+		dualStack: true,
+		expected:  "https://dynamodb-fips.ca-central-1.api.aws",
+	}, {
+		url:      "https://dynamodb.us-gov-west-1.amazonaws.com",
+		fips:     true,
+		expected: "https://dynamodb.us-gov-west-1.amazonaws.com",
+	}, {
+		url:       "https://dynamodb.cn-north-1.amazonaws.com.cn",
+		dualStack: true,
+		expected:  "https://dynamodb.cn-north-1.api.amazonwebservices.com.cn",
+	}, {
+		url:   "https://dynamodb.cn-north-1.amazonaws.com.cn",
+		fips:  true,
+		fails: true,
+	}, {
+		url:       "http://localhost:8000",
+		fips:      true,
+		dualStack: true,
+		expected:  "http://localhost:8000",
+	}} {
+		v := endpointVariant{fips: tc.fips, dualStack: tc.dualStack}
+		actual, err := v.rewrite(aws.Endpoint{URL: tc.url})
+		if tc.fails {
+			require.Error(t, err, tc.url)
+			continue
+		}
+		require.NoError(t, err, tc.url)
+		require.Equal(t, tc.expected, actual.URL, tc.url)
+	}
+}
+
+func TestWithFIPSEndpoint(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfig(stubConfig(httpClient), WithFIPSEndpoint(), WithDualStackEndpoint())
+
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	require.Equal("dynamodb-fips.us-west-2.api.aws", httpClient.last().URL.Host)
+
+	// Endpoints set explicitly are never rewritten.
+	store = NewFromConfig(stubConfig(httpClient), WithFIPSEndpoint(), WithEndpoint("http://localhost:8000"))
+	_, _, err = store.Find("foo")
+	require.NoError(err)
+	require.Equal("localhost:8000", httpClient.last().URL.Host)
+}
@@ -0,0 +1,108 @@
+// Package eventbridgesink publishes session lifecycle events to Amazon
+// EventBridge, so other systems can react without polling the session table.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the EventBridge client.
+package eventbridgesink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// DefaultSource is the source of the events published by a Sink, unless
+// Config.Source is set.
+const DefaultSource = "dynamostore"
+
+// Client is the subset of the EventBridge API used by a Sink. It is
+// implemented by *eventbridge.Client.
+type Client interface {
+	PutEvents(
+		context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options),
+	) (*eventbridge.PutEventsOutput, error)
+}
+
+// Config configures a Sink.
+type Config struct {
+	Client Client
+	// EventBus is the name or ARN of the event bus. The default is the
+	// account's default event bus.
+	EventBus string
+	// Source is the source of each event. The default is DefaultSource.
+	Source string
+}
+
+// Sink is a dynamostore.SessionEventSink that publishes an EventBridge event
+// when a session is created or deleted. The detail type is
+// dynamostore.EventSessionCreated or dynamostore.EventSessionRevoked, and
+// the detail is a dynamostore.EventDetail. Refreshes and expirations aren't
+// published. Use it with dynamostore.WithEventSink, or wrap it to publish
+// other events or filter them.
+type Sink struct {
+	cfg Config
+}
+
+// New returns a Sink, or an error if cfg is invalid.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("eventbridgesink: missing client")
+	}
+	if cfg.Source == "" {
+		cfg.Source = DefaultSource
+	}
+	return &Sink{cfg: cfg}, nil
+}
+
+// Created publishes a dynamostore.EventSessionCreated event.
+func (e *Sink) Created(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return e.publish(ctx, dynamostore.EventSessionCreated, event)
+}
+
+// Refreshed does nothing.
+func (e *Sink) Refreshed(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return nil
+}
+
+// Revoked publishes a dynamostore.EventSessionRevoked event.
+func (e *Sink) Revoked(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return e.publish(ctx, dynamostore.EventSessionRevoked, event)
+}
+
+// Expired does nothing.
+func (e *Sink) Expired(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return nil
+}
+
+func (e *Sink) publish(ctx context.Context, detailType string, event *dynamostore.AuditEvent) error {
+	detail, err := json.Marshal(dynamostore.NewEventDetail(event))
+	if err != nil {
+		return err
+	}
+
+	entry := ebtypes.PutEventsRequestEntry{
+		Source:     aws.String(e.cfg.Source),
+		DetailType: aws.String(detailType),
+		Detail:     aws.String(string(detail)),
+		Time:       aws.Time(event.Time),
+	}
+	if e.cfg.EventBus != "" {
+		entry.EventBusName = aws.String(e.cfg.EventBus)
+	}
+	result, err := e.cfg.Client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{entry},
+	})
+	if err != nil {
+		return err
+	}
+	if result.FailedEntryCount > 0 && len(result.Entries) > 0 && result.Entries[0].ErrorMessage != nil {
+		return errors.New("eventbridgesink: publishing event: " + *result.Entries[0].ErrorMessage)
+	}
+	return nil
+}
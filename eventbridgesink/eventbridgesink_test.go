@@ -0,0 +1,96 @@
+package eventbridgesink_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/eventbridgesink"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+type fakeEventBridge struct {
+	entries []*eventbridge.PutEventsInput
+	err     error
+}
+
+func (f *fakeEventBridge) PutEvents(
+	ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options),
+) (*eventbridge.PutEventsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.entries = append(f.entries, params)
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+func TestSink(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	events := &fakeEventBridge{}
+	sink, err := eventbridgesink.New(eventbridgesink.Config{Client: events, EventBus: "sessions"})
+	require.NoError(err)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithEventSink(sink, nil),
+	)
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	require.NoError(store.Commit("foo", []byte("baz"), now.Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+
+	// then
+	require.Len(events.entries, 2)
+	created := events.entries[0].Entries[0]
+	require.Equal(dynamostore.EventSessionCreated, aws.ToString(created.DetailType))
+	require.Equal(eventbridgesink.DefaultSource, aws.ToString(created.Source))
+	require.Equal("sessions", aws.ToString(created.EventBusName))
+
+	var detail dynamostore.EventDetail
+	require.NoError(json.Unmarshal([]byte(aws.ToString(created.Detail)), &detail))
+	require.Equal(dynamostore.TokenHash("foo"), detail.TokenHash)
+	require.True(now.Equal(detail.Time))
+
+	revoked := events.entries[1].Entries[0]
+	require.Equal(dynamostore.EventSessionRevoked, aws.ToString(revoked.DetailType))
+}
+
+func TestSinkErrors(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var failed []*dynamostore.AuditEvent
+	sink, err := eventbridgesink.New(eventbridgesink.Config{
+		Client: &fakeEventBridge{err: errors.New("throttled")},
+	})
+	require.NoError(err)
+	store := fake.New(dynamostore.WithEventSink(sink, func(event *dynamostore.AuditEvent, err error) {
+		require.EqualError(err, "throttled")
+		failed = append(failed, event)
+	}))
+
+	// when
+	err = store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+
+	// then
+	require.NoError(err)
+	require.Len(failed, 1)
+	require.Equal(dynamostore.AuditCreate, failed[0].Type)
+
+	// when
+	_, err = eventbridgesink.New(eventbridgesink.Config{})
+
+	// then
+	require.EqualError(err, "eventbridgesink: missing client")
+}
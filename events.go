@@ -0,0 +1,219 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SessionEventSink receives session lifecycle events. WithAudit is
+// implemented as a sink, and WithEventSink adds others, such as those in the
+// eventbridgesink, snssink, kinesissink, and firehosesink packages. Sinks are
+// called synchronously, after the session operation succeeds, and an error
+// returned by a sink never fails the operation.
+//
+// Telling a create from a refresh requires the replaced item, so writes
+// return it from DynamoDB when any sink is used. Bulk operations such as
+// Import and DeleteAll aren't reported.
+type SessionEventSink interface {
+	// Created is called when a session is committed, and there was no
+	// unexpired session with the same token.
+	Created(context.Context, *AuditEvent) error
+	// Refreshed is called when an existing session is committed or its
+	// expiry is extended.
+	Refreshed(context.Context, *AuditEvent) error
+	// Revoked is called when a session is deleted or revoked.
+	Revoked(context.Context, *AuditEvent) error
+	// Expired is called when an expired session is deleted by
+	// WithLazyDelete or DeleteExpired.
+	Expired(context.Context, *AuditEvent) error
+}
+
+// SessionEventFuncs adapts functions to the SessionEventSink interface.
+// Functions that are nil are skipped.
+type SessionEventFuncs struct {
+	OnCreated   func(context.Context, *AuditEvent) error
+	OnRefreshed func(context.Context, *AuditEvent) error
+	OnRevoked   func(context.Context, *AuditEvent) error
+	OnExpired   func(context.Context, *AuditEvent) error
+}
+
+// Created calls f.OnCreated.
+func (f *SessionEventFuncs) Created(ctx context.Context, event *AuditEvent) error {
+	return callEventFunc(f.OnCreated, ctx, event)
+}
+
+// Refreshed calls f.OnRefreshed.
+func (f *SessionEventFuncs) Refreshed(ctx context.Context, event *AuditEvent) error {
+	return callEventFunc(f.OnRefreshed, ctx, event)
+}
+
+// Revoked calls f.OnRevoked.
+func (f *SessionEventFuncs) Revoked(ctx context.Context, event *AuditEvent) error {
+	return callEventFunc(f.OnRevoked, ctx, event)
+}
+
+// Expired calls f.OnExpired.
+func (f *SessionEventFuncs) Expired(ctx context.Context, event *AuditEvent) error {
+	return callEventFunc(f.OnExpired, ctx, event)
+}
+
+func callEventFunc(fn func(context.Context, *AuditEvent) error, ctx context.Context, event *AuditEvent) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(ctx, event)
+}
+
+// WithEventSink passes session lifecycle events to sink, in addition to any
+// other sinks. If onError is not nil, it is called with the errors sink
+// returns.
+func WithEventSink(sink SessionEventSink, onError func(*AuditEvent, error)) Option {
+	return func(s *DynamoStore) {
+		if sink == nil {
+			s.invalid("WithEventSink requires a sink")
+			return
+		}
+		s.sinks = append(s.sinks, eventSink{sink: sink, onError: onError})
+	}
+}
+
+type eventSink struct {
+	sink    SessionEventSink
+	onError func(*AuditEvent, error)
+}
+
+// recordsEvents returns true if any sink receives session lifecycle events.
+func (s *DynamoStore) recordsEvents() bool {
+	return len(s.sinks) > 0
+}
+
+// eventReturnValues returns the ReturnValues needed by recordWrite, if
+// events are recorded and returnValues won't return the replaced item.
+func (s *DynamoStore) eventReturnValues(returnValues, needed types.ReturnValue) types.ReturnValue {
+	if s.recordsEvents() && (returnValues == "" || returnValues == types.ReturnValueNone) {
+		return needed
+	}
+	return returnValues
+}
+
+// recordWrite records the commit of a session, given the attributes of the
+// item it replaced. Only the expiry of the replaced item is used, so old may
+// contain just the updated attributes.
+func (s *DynamoStore) recordWrite(ctx context.Context, token string, data []byte, old map[string]types.AttributeValue) {
+	if !s.recordsEvents() {
+		return
+	}
+	eventType := AuditCreate
+	if n, ok := old[s.ttlAttribute].(*types.AttributeValueMemberN); ok {
+		ttl, err := strconv.ParseInt(n.Value, 10, 64)
+		if err == nil && !s.expiredAt(time.Unix(ttl, 0), s.now()) {
+			eventType = AuditRefresh
+		}
+	}
+	s.recordEvent(ctx, eventType, token, data)
+}
+
+// recordEvent passes an event to every sink, if events are enabled. The
+// subject is read from data, if it is not nil.
+func (s *DynamoStore) recordEvent(ctx context.Context, eventType AuditEventType, token string, data []byte) {
+	if !s.recordsEvents() {
+		return
+	}
+	event := &AuditEvent{
+		Type: eventType,
+		Time: s.now(),
+	}
+	if data != nil && s.subjectKey != "" {
+		event.Subject = s.subjectOf(data)
+	}
+	if s.audit != nil && s.audit.Source != nil {
+		event.Source = s.audit.Source(ctx)
+	}
+	hash, hashErr := s.HashToken(ctx, token)
+	event.TokenHash = hash
+
+	for _, sink := range s.sinks {
+		err := hashErr
+		if err == nil {
+			err = dispatchEvent(ctx, sink.sink, event)
+		}
+		if err != nil && sink.onError != nil {
+			sink.onError(event, err)
+		}
+	}
+}
+
+// dispatchEvent calls the method of sink for the type of event.
+func dispatchEvent(ctx context.Context, sink SessionEventSink, event *AuditEvent) error {
+	switch event.Type {
+	case AuditCreate:
+		return sink.Created(ctx, event)
+	case AuditRefresh:
+		return sink.Refreshed(ctx, event)
+	case AuditDelete:
+		return sink.Revoked(ctx, event)
+	case AuditExpire:
+		return sink.Expired(ctx, event)
+	}
+	return nil
+}
+
+// Detail types of the events published by the eventbridgesink package, also
+// used as the "type" message attribute by the snssink package.
+const (
+	EventSessionCreated = "session.created"
+	EventSessionRevoked = "session.revoked"
+)
+
+// EventDetail is the detail of the events published by the eventbridgesink
+// package, and the message published by the snssink package. It identifies
+// the session by the hash returned by HashToken, so tokens are never sent to
+// subscribers.
+type EventDetail struct {
+	TokenHash string    `json:"tokenHash"`
+	Time      time.Time `json:"time"`
+	Subject   string    `json:"subject,omitempty"`
+	Source    string    `json:"source,omitempty"`
+}
+
+// NewEventDetail returns the EventDetail of event.
+func NewEventDetail(event *AuditEvent) *EventDetail {
+	return &EventDetail{
+		TokenHash: event.TokenHash,
+		Time:      event.Time.UTC(),
+		Subject:   event.Subject,
+		Source:    event.Source,
+	}
+}
+
+// EventRecord is the record exported by the kinesissink and firehosesink
+// packages for each session lifecycle event. Like EventDetail, it identifies
+// the session by the hash returned by HashToken, so the duration of a session
+// can be found by joining its create and delete or expire records.
+type EventRecord struct {
+	Type      AuditEventType `json:"type"`
+	TokenHash string         `json:"tokenHash"`
+	Time      time.Time      `json:"time"`
+	Subject   string         `json:"subject,omitempty"`
+	Source    string         `json:"source,omitempty"`
+}
+
+// MarshalEventRecord returns event as an EventRecord in JSON, followed by a
+// newline so records concatenated by Firehose can be split.
+func MarshalEventRecord(event *AuditEvent) ([]byte, error) {
+	data, err := json.Marshal(&EventRecord{
+		Type:      event.Type,
+		TokenHash: event.TokenHash,
+		Time:      event.Time.UTC(),
+		Subject:   event.Subject,
+		Source:    event.Source,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
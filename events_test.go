@@ -0,0 +1,125 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []AuditEventType
+	err    error
+}
+
+func (r *recordingSink) record(eventType AuditEventType) error {
+	r.events = append(r.events, eventType)
+	return r.err
+}
+
+func (r *recordingSink) Created(ctx context.Context, event *AuditEvent) error {
+	return r.record(AuditCreate)
+}
+
+func (r *recordingSink) Refreshed(ctx context.Context, event *AuditEvent) error {
+	return r.record(AuditRefresh)
+}
+
+func (r *recordingSink) Revoked(ctx context.Context, event *AuditEvent) error {
+	return r.record(AuditDelete)
+}
+
+func (r *recordingSink) Expired(ctx context.Context, event *AuditEvent) error {
+	return r.record(AuditExpire)
+}
+
+func TestEventSink(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	sink := &recordingSink{}
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithEventSink(sink, nil),
+	})
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	require.NoError(store.Commit("foo", []byte("baz"), now.Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+
+	// then
+	require.Equal([]AuditEventType{AuditCreate, AuditRefresh, AuditDelete}, sink.events)
+}
+
+func TestEventSinkErrors(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var failed []*AuditEvent
+	other := &recordingSink{}
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithEventSink(&recordingSink{err: errors.New("unavailable")}, func(event *AuditEvent, err error) {
+			require.EqualError(err, "unavailable")
+			failed = append(failed, event)
+		}),
+		WithEventSink(other, nil),
+	})
+
+	// when
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+
+	// then
+	require.NoError(err)
+	require.Len(failed, 1)
+	require.Equal(AuditCreate, failed[0].Type)
+	require.Equal([]AuditEventType{AuditCreate}, other.events)
+}
+
+func TestSessionEventFuncs(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var revoked []string
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithEventSink(&SessionEventFuncs{
+			OnRevoked: func(ctx context.Context, event *AuditEvent) error {
+				revoked = append(revoked, event.TokenHash)
+				return nil
+			},
+		}, nil),
+	})
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+
+	// then
+	require.Equal([]string{TokenHash("foo")}, revoked)
+}
+
+func TestMarshalEventRecord(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	data, err := MarshalEventRecord(&AuditEvent{
+		Type:      AuditDelete,
+		TokenHash: TokenHash("foo"),
+		Time:      now,
+		Subject:   "alice",
+	})
+	require.NoError(err)
+	require.Equal(byte('\n'), data[len(data)-1])
+
+	var record EventRecord
+	require.NoError(json.Unmarshal(data, &record))
+	require.Equal(AuditDelete, record.Type)
+	require.Equal(TokenHash("foo"), record.TokenHash)
+	require.Equal("alice", record.Subject)
+	require.True(now.Equal(record.Time))
+}
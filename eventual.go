@@ -0,0 +1,121 @@
+package dynamostore
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentWrites limits the number of writes remembered by
+// WithEventualReads. Once reached, writes older than the window are
+// dropped.
+const maxRecentWrites = 10000
+
+// WithEventualReads makes Find use eventually consistent reads, which cost
+// half as much as strongly consistent reads. To avoid missing its own
+// writes, the store remembers sessions it has written or deleted within
+// window. If an eventually consistent read of one of those sessions finds
+// nothing, or an expiry earlier than the one written, or finds a session
+// that was deleted, Find reads it again with a strongly consistent read.
+//
+// Writes by other instances of the application aren't tracked, so requests
+// for the same session that are handled by different instances may still
+// briefly see stale data. WithEventualReads can't be used with
+// WithOptimisticLocking, which needs the current version of each session.
+func WithEventualReads(window time.Duration) Option {
+	return func(s *DynamoStore) {
+		if window <= 0 {
+			s.invalid("WithEventualReads requires a positive window")
+			return
+		}
+		if s.recentWrites != nil {
+			s.invalid(repeatedEventualReads)
+			return
+		}
+		s.consistentRead = false
+		s.recentWrites = newRecentWrites(window)
+	}
+}
+
+// repeatedEventualReads is reported when more than one of WithEventualReads
+// and WithAdaptiveConsistency is used.
+const repeatedEventualReads = "WithEventualReads and WithAdaptiveConsistency can't be combined or repeated"
+
+// recentWrites remembers when sessions were last written by this store.
+type recentWrites struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	writes map[string]recentWrite
+}
+
+func newRecentWrites(window time.Duration) *recentWrites {
+	return &recentWrites{
+		window: window,
+		writes: map[string]recentWrite{},
+	}
+}
+
+type recentWrite struct {
+	at      time.Time
+	expiry  time.Time
+	deleted bool
+}
+
+// rememberWrite records that a session was written with the given expiry,
+// if WithEventualReads is enabled.
+func (s *DynamoStore) rememberWrite(token string, expiry time.Time) {
+	if s.recentWrites != nil {
+		s.recentWrites.remember(token, recentWrite{at: s.now(), expiry: expiry})
+	}
+}
+
+// rememberDelete records that a session was deleted or revoked, if
+// WithEventualReads is enabled.
+func (s *DynamoStore) rememberDelete(token string) {
+	if s.recentWrites != nil {
+		s.recentWrites.remember(token, recentWrite{at: s.now(), deleted: true})
+	}
+}
+
+func (w *recentWrites) remember(token string, write recentWrite) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.writes) >= maxRecentWrites {
+		w.prune(write.at)
+	}
+	if len(w.writes) < maxRecentWrites {
+		w.writes[token] = write
+	} else if write.deleted {
+		// The deleted session mustn't be checked against an older write.
+		delete(w.writes, token)
+	}
+}
+
+// prune removes writes older than the window.
+func (w *recentWrites) prune(now time.Time) {
+	for token, write := range w.writes {
+		if now.Sub(write.at) > w.window {
+			delete(w.writes, token)
+		}
+	}
+}
+
+// stale returns true if item may be older than a write made within the
+// window.
+func (w *recentWrites) stale(token string, item *sessionItem, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	write, ok := w.writes[token]
+	switch {
+	case !ok:
+		return false
+	case now.Sub(write.at) > w.window:
+		delete(w.writes, token)
+		return false
+	case write.deleted:
+		return item.Token != ""
+	case item.Token == "":
+		return true
+	}
+	return item.TTL.Unix() < write.expiry.Unix()
+}
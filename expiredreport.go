@@ -0,0 +1,66 @@
+package dynamostore
+
+import (
+	"context"
+	"time"
+)
+
+// ExpiredReport describes the sessions that have expired, but haven't been
+// removed by DynamoDB's TTL process yet, as returned by ReportExpired.
+type ExpiredReport struct {
+	// ItemCount is the number of items in the table, as reported by
+	// DynamoDB, which updates it approximately every six hours.
+	ItemCount int64
+	// Scanned is the number of items read, and Complete is true if every
+	// item in the table was read.
+	Scanned  int
+	Complete bool
+
+	// Expired is the number of scanned items that DeleteExpired would
+	// remove, and ExpiredBytes is their total size, as DynamoDB measures it.
+	Expired      int
+	ExpiredBytes int64
+	// OldestExpiry is the earliest expiry of the expired items, which shows
+	// how far behind the TTL process is. It is zero if nothing has expired.
+	OldestExpiry time.Time
+}
+
+// EstimatedExpired estimates the number of expired items in the whole table.
+func (r *ExpiredReport) EstimatedExpired() int64 {
+	if r.Complete || r.Scanned < 1 {
+		return int64(r.Expired)
+	}
+	return int64(float64(r.Expired) / float64(r.Scanned) * float64(r.ItemCount))
+}
+
+// EstimatedExpiredBytes estimates the total size of the expired items in the
+// whole table.
+func (r *ExpiredReport) EstimatedExpiredBytes() int64 {
+	if r.Complete || r.Scanned < 1 {
+		return r.ExpiredBytes
+	}
+	return int64(float64(r.ExpiredBytes) / float64(r.Scanned) * float64(r.ItemCount))
+}
+
+// ReportExpired reads up to sample items, or the whole table if sample is
+// zero or less, and reports how many have expired but are still stored, so
+// operators can decide whether to run DeleteExpired, and with how much
+// parallelism. The table is sampled like TableStats, so items count as
+// expired when DeleteExpired would remove them, after WithExpiryGracePeriod,
+// and aren't decrypted.
+//
+// Use WithCapacityLimit to limit the impact on other users of the table.
+func (s *DynamoStore) ReportExpired(ctx context.Context, sample int) (*ExpiredReport, error) {
+	t, err := s.sampleTable(ctx, sample)
+	if err != nil {
+		return nil, err
+	}
+	return &ExpiredReport{
+		ItemCount:    t.itemCount,
+		Scanned:      t.sampled,
+		Complete:     t.complete,
+		Expired:      t.expired,
+		ExpiredBytes: t.expiredBytes,
+		OldestExpiry: t.oldestExpiry,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package dynamostore_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestReportExpired(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithExpiryGracePeriod(time.Minute),
+	)
+
+	// given two expired sessions, one within the grace period, and five
+	// unexpired sessions
+	require.NoError(store.Commit("old", []byte("data"), now.Add(-24*time.Hour)))
+	require.NoError(store.Commit("stale", []byte("data"), now.Add(-time.Hour)))
+	require.NoError(store.Commit("grace", []byte("data"), now.Add(-time.Second)))
+	for i := 0; i < 5; i++ {
+		require.NoError(store.Commit("token"+strconv.Itoa(i), []byte("data"), now.Add(time.Hour)))
+	}
+
+	// when the whole table is scanned
+	report, err := store.ReportExpired(ctx, 0)
+
+	// then
+	require.NoError(err)
+	require.Equal(int64(8), report.ItemCount)
+	require.Equal(8, report.Scanned)
+	require.True(report.Complete)
+	require.Equal(2, report.Expired)
+	require.Greater(report.ExpiredBytes, int64(0))
+	require.True(now.Add(-24 * time.Hour).Equal(report.OldestExpiry))
+	require.Equal(int64(2), report.EstimatedExpired())
+	require.Equal(report.ExpiredBytes, report.EstimatedExpiredBytes())
+
+	// when only a sample is scanned
+	report, err = store.ReportExpired(ctx, 4)
+
+	// then the rest of the table is estimated
+	require.NoError(err)
+	require.Equal(4, report.Scanned)
+	require.False(report.Complete)
+	require.Equal(int64(report.Expired*2), report.EstimatedExpired())
+	require.Equal(report.ExpiredBytes*2, report.EstimatedExpiredBytes())
+
+	// when the expired sessions are deleted
+	n, err := store.DeleteExpired(ctx, 1)
+	require.NoError(err)
+	require.Equal(2, n)
+	report, err = store.ReportExpired(ctx, 0)
+
+	// then none are reported
+	require.NoError(err)
+	require.Zero(report.Expired)
+	require.True(report.OldestExpiry.IsZero())
+}
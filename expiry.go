@@ -0,0 +1,47 @@
+package dynamostore
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// errShorterExpiry is returned by setItem when WithMonotonicExpiry is used,
+// and the session has a later expiry than the one being committed.
+var errShorterExpiry = errors.New("session has a later expiry")
+
+// WithMonotonicExpiry makes Commit conditional on the new expiry being no
+// earlier than the stored expiry. Concurrent requests for the same session
+// can finish in any order, so a slow request could otherwise replace a
+// later expiry with an earlier one, and end the session early. Commits that
+// would shorten the expiry are dropped without an error, including any
+// changes to the session data.
+//
+// Sessions are still removed by Delete, and WithMonotonicExpiry can't be
+// used with WithOptimisticLocking, which already rejects stale commits.
+func WithMonotonicExpiry() Option {
+	return func(s *DynamoStore) {
+		s.monotonicExpiry = true
+	}
+}
+
+// requireLaterExpiry makes input conditional on the stored expiry not being
+// later than the expiry in input.Item.
+func (s *DynamoStore) requireLaterExpiry(input *dynamodb.PutItemInput) {
+	input.ConditionExpression = aws.String("attribute_not_exists(#ttl) OR #ttl <= :ttl")
+	input.ExpressionAttributeNames = map[string]string{"#ttl": s.ttlAttribute}
+	input.ExpressionAttributeValues = map[string]types.AttributeValue{
+		":ttl": input.Item[s.ttlAttribute],
+	}
+}
+
+// ignoreDroppedCommit hides errShorterExpiry and errRevoked, since dropping
+// the commit is the intended result.
+func ignoreDroppedCommit(err error) error {
+	if err == errShorterExpiry || err == errRevoked {
+		return nil
+	}
+	return err
+}
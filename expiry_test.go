@@ -0,0 +1,53 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithMonotonicExpiry(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New(dynamostore.WithMonotonicExpiry())
+	require.NoError(store.Validate())
+	later := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	earlier := later.Add(-time.Hour)
+
+	// given a session with a later expiry
+	require.NoError(store.Commit("foo", []byte("later"), later))
+
+	// when a commit would shorten the expiry
+	err := store.Commit("foo", []byte("earlier"), earlier)
+
+	// then it is dropped without an error
+	require.NoError(err)
+	data, expiry, exists, err := store.FindWithExpiry(context.Background(), "foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("later"), data)
+	require.True(later.Equal(expiry))
+
+	// when a commit keeps or extends the expiry
+	require.NoError(store.Commit("foo", []byte("same"), later))
+	data, _, err = store.Find("foo")
+	require.NoError(err)
+	require.Equal([]byte("same"), data)
+	require.NoError(store.Commit("foo", []byte("extended"), later.Add(time.Hour)))
+
+	// then it is written
+	data, _, err = store.Find("foo")
+	require.NoError(err)
+	require.Equal([]byte("extended"), data)
+
+	// when combined with optimistic locking
+	store = fake.New(dynamostore.WithMonotonicExpiry(), dynamostore.WithOptimisticLocking())
+
+	// then the configuration is rejected
+	require.Error(store.Validate())
+}
@@ -0,0 +1,174 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ExpiryIndexName is the name of the global secondary index created by
+// CreateTable when WithExpiryIndex is used.
+const ExpiryIndexName = "ExpiryIndex"
+
+// DefaultExpiryBucket is the width of the buckets used by WithExpiryIndex,
+// unless another is given.
+const DefaultExpiryBucket = time.Hour
+
+// expiryBucketAttribute stores the start of the bucket containing a
+// session's expiry, as seconds since the Unix epoch.
+const expiryBucketAttribute = "ExpiryBucket"
+
+var errNoExpiryIndex = errors.New("requires WithExpiryIndex")
+
+// WithExpiryIndex stores the bucket containing each session's expiry, so
+// ForEachExpiring can find sessions expiring within a window by querying
+// ExpiryIndexName, a global secondary index partitioned by bucket and sorted
+// by expiry, instead of scanning the table. Narrower buckets spread writes
+// across more partitions, but a window spanning many buckets takes more
+// queries. The width must be a whole number of minutes, and is
+// DefaultExpiryBucket if zero.
+//
+// CreateTable, CloudFormationTemplate, and TerraformConfig include the
+// index. It must be added to existing tables, and sessions committed before
+// the option is enabled aren't indexed until they are committed again.
+func WithExpiryIndex(bucket time.Duration) Option {
+	return func(s *DynamoStore) {
+		if bucket == 0 {
+			bucket = DefaultExpiryBucket
+		}
+		if bucket < time.Minute || bucket%time.Minute != 0 {
+			s.invalid("WithExpiryIndex requires a bucket of a whole number of minutes")
+			return
+		}
+		s.expiryBucket = bucket
+	}
+}
+
+// bucketOf returns the start of the bucket containing expiry.
+func (s *DynamoStore) bucketOf(expiry time.Time) int64 {
+	width := int64(s.expiryBucket / time.Second)
+	unix := expiry.Unix()
+	bucket := unix - unix%width
+	if unix < 0 && bucket != unix {
+		bucket -= width
+	}
+	return bucket
+}
+
+// bucketItem adds the expiry bucket to av, if WithExpiryIndex is used.
+func (s *DynamoStore) bucketItem(av map[string]types.AttributeValue, expiry time.Time) {
+	if s.expiryBucket <= 0 {
+		return
+	}
+	av[expiryBucketAttribute] = &types.AttributeValueMemberN{
+		Value: strconv.FormatInt(s.bucketOf(expiry), 10),
+	}
+}
+
+// setExpiry returns an update expression that sets the expiry to the value
+// named ":ttl", adding the expiry bucket to names and values if
+// WithExpiryIndex is used.
+func (s *DynamoStore) setExpiry(
+	names map[string]string, values map[string]types.AttributeValue, expiry time.Time,
+) string {
+	names["#ttl"] = s.ttlAttribute
+	values[":ttl"] = &types.AttributeValueMemberN{
+		Value: strconv.FormatInt(expiry.Unix(), 10),
+	}
+	if s.expiryBucket <= 0 {
+		return "SET #ttl = :ttl"
+	}
+	names["#bucket"] = expiryBucketAttribute
+	values[":bucket"] = &types.AttributeValueMemberN{
+		Value: strconv.FormatInt(s.bucketOf(expiry), 10),
+	}
+	return "SET #ttl = :ttl, #bucket = :bucket"
+}
+
+// expiryIndex returns the index created for WithExpiryIndex.
+func (s *DynamoStore) expiryIndex() types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(ExpiryIndexName),
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String(expiryBucketAttribute),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String(s.ttlAttribute),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		Projection: &types.Projection{
+			ProjectionType:   types.ProjectionTypeInclude,
+			NonKeyAttributes: []string{revokedAttribute},
+		},
+		ProvisionedThroughput: s.billing.throughput(),
+	}
+}
+
+// ExpiringSession is a session found by ForEachExpiring.
+type ExpiringSession struct {
+	Token  string
+	Expiry time.Time
+}
+
+// ForEachExpiring calls fn, in order of expiry, for every session expiring
+// between start and end, inclusive, so applications can refresh sessions or
+// warn users before they expire. Revoked sessions are skipped. Iteration
+// stops at the first error returned by fn. It requires WithExpiryIndex.
+//
+// The index is eventually consistent, so recently committed sessions may be
+// missed, and sessions may have been extended or deleted since they were
+// indexed.
+func (s *DynamoStore) ForEachExpiring(
+	ctx context.Context, start, end time.Time, fn func(*ExpiringSession) error,
+) error {
+	if s.expiryBucket <= 0 {
+		return errNoExpiryIndex
+	}
+	width := int64(s.expiryBucket / time.Second)
+	for bucket := s.bucketOf(start); bucket <= end.Unix(); bucket += width {
+		if err := s.queryExpiryBucket(ctx, bucket, start, end, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryExpiryBucket calls fn for the sessions in bucket expiring between
+// start and end.
+func (s *DynamoStore) queryExpiryBucket(
+	ctx context.Context, bucket int64, start, end time.Time, fn func(*ExpiringSession) error,
+) error {
+	return s.query(ctx, &dynamodb.QueryInput{
+		IndexName:              aws.String(ExpiryIndexName),
+		KeyConditionExpression: aws.String("#bucket = :bucket AND #ttl BETWEEN :start AND :end"),
+		FilterExpression:       aws.String("attribute_not_exists(#revoked)"),
+		ExpressionAttributeNames: map[string]string{
+			"#bucket":  expiryBucketAttribute,
+			"#ttl":     s.ttlAttribute,
+			"#revoked": revokedAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":bucket": &types.AttributeValueMemberN{Value: strconv.FormatInt(bucket, 10)},
+			":start":  &types.AttributeValueMemberN{Value: strconv.FormatInt(start.Unix(), 10)},
+			":end":    &types.AttributeValueMemberN{Value: strconv.FormatInt(end.Unix(), 10)},
+		},
+	}, func(av map[string]types.AttributeValue) error {
+		token, ok := av["token"].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil
+		}
+		expiry, ok := unixAttribute(av, s.ttlAttribute)
+		if !ok {
+			return nil
+		}
+		return fn(&ExpiringSession{Token: token.Value, Expiry: expiry})
+	})
+}
@@ -0,0 +1,74 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestForEachExpiring(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC)
+	store := dynamostore.New(fake.NewClient(),
+		dynamostore.WithoutSleep(),
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithExpiryIndex(0),
+		dynamostore.WithRetention(time.Hour),
+	)
+	require.NoError(store.Validate())
+	require.NoError(store.CreateTable())
+
+	// given sessions expiring in and around the next hour
+	require.NoError(store.Commit("soon", []byte("data"), now.Add(10*time.Minute)))
+	require.NoError(store.Commit("later", []byte("data"), now.Add(50*time.Minute)))
+	require.NoError(store.Commit("tomorrow", []byte("data"), now.Add(24*time.Hour)))
+	require.NoError(store.Commit("revoked", []byte("data"), now.Add(20*time.Minute)))
+	require.NoError(store.Delete("revoked"))
+
+	// when sessions expiring in the next hour are listed
+	var found []*dynamostore.ExpiringSession
+	err := store.ForEachExpiring(ctx, now, now.Add(time.Hour), func(session *dynamostore.ExpiringSession) error {
+		found = append(found, session)
+		return nil
+	})
+
+	// then only unrevoked sessions in the window are found, in order
+	require.NoError(err)
+	require.Len(found, 2)
+	require.Equal("soon", found[0].Token)
+	require.True(now.Add(10 * time.Minute).Equal(found[0].Expiry))
+	require.Equal("later", found[1].Token)
+
+	// when a session is extended out of the window
+	require.NoError(store.Commit("soon", []byte("data"), now.Add(48*time.Hour)))
+	found = nil
+	err = store.ForEachExpiring(ctx, now, now.Add(time.Hour), func(session *dynamostore.ExpiringSession) error {
+		found = append(found, session)
+		return nil
+	})
+
+	// then its new bucket is used
+	require.NoError(err)
+	require.Len(found, 1)
+	require.Equal("later", found[0].Token)
+}
+
+func TestForEachExpiringRequiresIndex(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New()
+	err := store.ForEachExpiring(context.Background(), time.Now(), time.Now().Add(time.Hour),
+		func(*dynamostore.ExpiringSession) error { return nil },
+	)
+	require.Error(err)
+
+	store = fake.New(dynamostore.WithExpiryIndex(90 * time.Second))
+	require.Error(store.Validate())
+}
@@ -0,0 +1,83 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WithExpiryOnlyUpdates makes Commit update only the expiry of a session
+// when its data is the same as when it was last read or committed by this
+// store, instead of rewriting the whole item. It requires WithSkipUnchanged,
+// which tracks the data, and is most useful with scs's IdleTimeout, which
+// moves the expiry on every request.
+//
+// DynamoDB charges for an update by the size of the whole item, so this
+// doesn't reduce the write capacity consumed. It does avoid sending the
+// session data with every commit, which matters for large sessions. If the
+// stored data has been changed by another request, the whole item is
+// written as usual.
+func WithExpiryOnlyUpdates() Option {
+	return func(s *DynamoStore) {
+		s.expiryOnlyUpdates = true
+	}
+}
+
+// updateExpiry sets the expiry of a session, if its stored data still has
+// the given hash. It returns false if the session has changed.
+func (s *DynamoStore) updateExpiry(ctx context.Context, token string, hash []byte, expiry time.Time) (bool, error) {
+	condition := "#hash = :hash"
+	if s.monotonicExpiry {
+		condition += " AND #ttl <= :ttl"
+	}
+	names := map[string]string{
+		"#hash": dataHashAttribute,
+	}
+	values := map[string]types.AttributeValue{
+		":hash": &types.AttributeValueMemberB{
+			Value: hash,
+		},
+	}
+	update := s.setExpiry(names, values, expiry)
+	if s.tombstones() {
+		condition += " AND attribute_not_exists(#revoked)"
+		names["#revoked"] = revokedAttribute
+	}
+
+	units, err := s.limiter.waitWrite(ctx, 0)
+	if err != nil {
+		return false, err
+	}
+	result, err := s.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnConsumedCapacity:    s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.rememberWrite(token, expiry)
+	atomic.AddInt64(&s.stats.expiryUpdates, 1)
+	s.forgetCached(token)
+	s.publishInvalidation(ctx, token)
+	s.recordEvent(ctx, AuditRefresh, token, nil)
+	return true, nil
+}
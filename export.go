@@ -0,0 +1,58 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ExportRecord is a single session, as written by Export. Records are
+// encoded as JSON, one per line, with Data encoded as base64.
+//
+// Token is required to restore a session, and grants access to it. Exports
+// should be protected accordingly. TokenHash is the hash of the token, as
+// returned by HashToken, for correlating records without exposing the token
+// itself.
+type ExportRecord struct {
+	Token     string    `json:"token"`
+	TokenHash string    `json:"token_hash"`
+	Expiry    time.Time `json:"expiry"`
+	Data      []byte    `json:"data"`
+}
+
+// Export writes every unexpired session to w as JSON lines, and returns the
+// number of sessions written.
+func (s *DynamoStore) Export(ctx context.Context, w io.Writer) (int, error) {
+	now := s.now()
+	enc := json.NewEncoder(w)
+	n := 0
+	err := s.scanItems(ctx, func(item *sessionItem) error {
+		if s.expiredAt(item.TTL, now) || item.Revoked != 0 {
+			return nil
+		}
+		hash, err := s.HashToken(ctx, item.Token)
+		if err != nil {
+			return err
+		}
+		data, err := s.readHook(ctx, item.Data)
+		if err != nil {
+			return err
+		}
+		n++
+		return enc.Encode(&ExportRecord{
+			Token:     item.Token,
+			TokenHash: hash,
+			Expiry:    item.TTL,
+			Data:      data,
+		})
+	})
+	return n, err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	svc.pageSize = 2
+	store := newStore(svc, DefaultTableName, nil)
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	for _, token := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(store.Commit(token, []byte(token+token), expiry))
+	}
+	require.NoError(store.Commit("expired", []byte("x"), time.Now().Add(-time.Hour)))
+
+	var buf bytes.Buffer
+	n, err := store.Export(context.Background(), &buf)
+	require.NoError(err)
+	require.Equal(5, n)
+	require.Equal(3, svc.count("Scan"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(lines, 5)
+
+	var record ExportRecord
+	require.NoError(json.Unmarshal([]byte(lines[0]), &record))
+	require.Equal("a", record.Token)
+	require.Equal(hashToken("a"), record.TokenHash)
+	require.Equal([]byte("aa"), record.Data)
+	require.True(expiry.Equal(record.Expiry))
+	require.Contains(lines[0], `"data":"YWE="`)
+}
@@ -0,0 +1,177 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// DefaultFailoverThreshold is the number of consecutive failed requests
+// that trigger a region failover, if FailoverConfig.Threshold isn't set.
+const DefaultFailoverThreshold = 5
+
+// FailoverConfig configures WithRegionFailover.
+type FailoverConfig struct {
+	// Regions lists the regions containing a replica of the session table,
+	// in order of preference. The first region is the primary.
+	Regions []string
+	// Threshold is the number of consecutive failed requests to a region
+	// before switching to the next one. Errors caused by the request, such
+	// as throttling or failed conditions, aren't counted. The default is
+	// DefaultFailoverThreshold.
+	Threshold int
+	// RetryPrimaryAfter is how long to wait after failing over before
+	// switching back to the primary region. If zero, the store keeps using
+	// the new region until it fails too.
+	RetryPrimaryAfter time.Duration
+	// OnFailover, if not nil, is called when the store switches regions.
+	// err is the last error returned by the previous region, or nil when
+	// returning to the primary region.
+	OnFailover func(from, to string, err error)
+}
+
+// WithRegionFailover sends requests to the first of several regions, and
+// switches to the next region when requests keep failing. It is intended for
+// tables replicated by DynamoDB global tables. Sessions committed shortly
+// before a failover may not have been replicated yet, and will be missing or
+// stale until they are.
+//
+// Failover changes the region of each request, so it requires a client that
+// honors per-request options, such as *dynamodb.Client, and has no effect
+// with WithEndpoint.
+func WithRegionFailover(cfg *FailoverConfig) Option {
+	return func(s *DynamoStore) {
+		if cfg == nil || len(cfg.Regions) < 1 {
+			s.invalid("WithRegionFailover requires at least 1 region")
+			return
+		}
+		threshold := cfg.Threshold
+		if threshold < 1 {
+			threshold = DefaultFailoverThreshold
+		}
+		s.failover = &regionFailover{
+			regions:    append([]string(nil), cfg.Regions...),
+			threshold:  threshold,
+			retryAfter: cfg.RetryPrimaryAfter,
+			onFailover: cfg.OnFailover,
+			now:        func() time.Time { return s.now() },
+		}
+	}
+}
+
+// ActiveRegion returns the region requests are currently sent to when
+// WithRegionFailover is used, or "" otherwise.
+func (s *DynamoStore) ActiveRegion() string {
+	if s.failover == nil {
+		return ""
+	}
+	return s.failover.region()
+}
+
+type regionFailover struct {
+	regions    []string
+	threshold  int
+	retryAfter time.Duration
+	onFailover func(from, to string, err error)
+	now        func() time.Time
+
+	mu       sync.Mutex
+	current  int
+	failures int
+	switched time.Time
+}
+
+func (f *regionFailover) region() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.regions[f.current]
+}
+
+// apply sends the request to the active region, and records the outcome.
+func (f *regionFailover) apply(o *dynamodb.Options) {
+	idx, from := f.choose()
+	if from != "" && f.onFailover != nil {
+		f.onFailover(from, f.regions[idx], nil)
+	}
+	o.Region = f.regions[idx]
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(
+			"RegionFailover",
+			func(
+				ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+			) (middleware.InitializeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				f.record(idx, err)
+				return out, metadata, err
+			},
+		), middleware.Before)
+	})
+}
+
+// choose returns the index of the region to use, switching back to the
+// primary region if it is time to retry it. If it switched, from names the
+// previous region.
+func (f *regionFailover) choose() (idx int, from string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.current != 0 && f.retryAfter > 0 && f.now().Sub(f.switched) >= f.retryAfter {
+		from = f.regions[f.current]
+		f.current = 0
+		f.failures = 0
+		f.switched = f.now()
+	}
+	return f.current, from
+}
+
+// record counts consecutive failures of the region at idx, and fails over
+// to the next region when the threshold is reached.
+func (f *regionFailover) record(idx int, err error) {
+	if !regionalFailure(err) {
+		f.mu.Lock()
+		if idx == f.current {
+			f.failures = 0
+		}
+		f.mu.Unlock()
+		return
+	}
+
+	f.mu.Lock()
+	if idx != f.current {
+		// The store already switched away from this region.
+		f.mu.Unlock()
+		return
+	}
+	f.failures++
+	if f.failures < f.threshold || len(f.regions) < 2 {
+		f.mu.Unlock()
+		return
+	}
+	from := f.regions[f.current]
+	f.current = (f.current + 1) % len(f.regions)
+	f.failures = 0
+	f.switched = f.now()
+	to := f.regions[f.current]
+	f.mu.Unlock()
+
+	if f.onFailover != nil {
+		f.onFailover(from, to, err)
+	}
+}
+
+// regionalFailure returns true if err may be caused by a problem with the
+// region, rather than the request.
+func regionalFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorFault() != smithy.FaultClient
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package dynamostore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+// regionalHTTPClient fails every request sent to an unhealthy region.
+type regionalHTTPClient struct {
+	stubHTTPClient
+	failing failingHTTPClient
+
+	unhealthy string
+}
+
+func (c *regionalHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Host, c.unhealthy) {
+		return c.failing.Do(req)
+	}
+	return c.stubHTTPClient.Do(req)
+}
+
+func TestWithRegionFailover(t *testing.T) {
+	require := require.New(t)
+
+	type event struct {
+		from, to string
+		failed   bool
+	}
+	var events []event
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	httpClient := &regionalHTTPClient{unhealthy: "us-west-2"}
+	store := NewFromConfig(stubConfig(httpClient),
+		WithClock(func() time.Time { return now }),
+		WithRetryer(aws.NopRetryer{}),
+		WithRegionFailover(&FailoverConfig{
+			Regions:           []string{"us-west-2", "us-east-1"},
+			Threshold:         2,
+			RetryPrimaryAfter: time.Minute,
+			OnFailover: func(from, to string, err error) {
+				events = append(events, event{from, to, err != nil})
+			},
+		}),
+	)
+	require.NoError(store.Validate())
+	require.Equal("us-west-2", store.ActiveRegion())
+
+	// given a failing primary region
+	for i := 0; i < 2; i++ {
+		_, _, err := store.Find("foo")
+		require.Error(err)
+	}
+
+	// when the threshold is reached
+	_, _, err := store.Find("foo")
+
+	// then requests are sent to the next region
+	require.NoError(err)
+	require.Equal("us-east-1", store.ActiveRegion())
+	require.Equal("dynamodb.us-east-1.amazonaws.com", httpClient.last().URL.Host)
+	require.Equal([]event{{"us-west-2", "us-east-1", true}}, events)
+
+	// and the primary region is retried later
+	now = now.Add(time.Minute)
+	_, _, err = store.Find("foo")
+	require.Error(err)
+	require.Equal("us-west-2", store.ActiveRegion())
+	require.Equal([]event{
+		{"us-west-2", "us-east-1", true},
+		{"us-east-1", "us-west-2", false},
+	}, events)
+}
+
+func TestRegionalFailure(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfig(stubConfig(httpClient),
+		WithRegionFailover(&FailoverConfig{
+			Regions:   []string{"us-west-2", "us-east-1"},
+			Threshold: 1,
+		}),
+	)
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Minute))
+	require.NoError(err)
+	require.Equal("us-west-2", store.ActiveRegion())
+
+	require.False(regionalFailure(nil))
+	require.False(regionalFailure(&types.ConditionalCheckFailedException{}))
+	require.True(regionalFailure(&types.InternalServerError{}))
+	require.True(regionalFailure(failingError{}))
+}
+
+func TestWithRegionFailoverValidation(t *testing.T) {
+	require := require.New(t)
+
+	for _, cfg := range []*FailoverConfig{nil, {}} {
+		store := newStore(newFakeClient(), DefaultTableName, []Option{
+			WithRegionFailover(cfg),
+		})
+		require.EqualError(store.Validate(),
+			"invalid DynamoStore configuration: WithRegionFailover requires at least 1 region")
+	}
+}
+
+type failingError struct{}
+
+func (failingError) Error() string { return "connection reset" }
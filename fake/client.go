@@ -0,0 +1,1286 @@
+// Package fake provides an in-memory stand-in for DynamoDB, so applications
+// can unit test code that uses dynamostore without running DynamoDB Local.
+//
+// Rather than reimplementing the store, the package implements the
+// dynamostore.Client interface. Every DynamoStore method runs unchanged
+// against it, so sessions expire, conflict, and fail the same way they do
+// against DynamoDB, including the typed errors returned by the AWS SDK.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/sjansen/dynamostore"
+)
+
+var _ dynamostore.Client = &Client{}
+
+// region is the region of every table, as reported in its ARN.
+const region = "us-east-1"
+
+// maxBatchGetItems is the most keys DynamoDB accepts in a batch read.
+const maxBatchGetItems = 100
+
+// maxBatchWriteItems is the most requests DynamoDB accepts in a batch write.
+const maxBatchWriteItems = 25
+
+// New returns a DynamoStore backed by a new in-memory client, with the
+// default table already created.
+func New(opts ...dynamostore.Option) *dynamostore.DynamoStore {
+	return NewWithTableName(dynamostore.DefaultTableName, opts...)
+}
+
+// NewWithTableName returns a DynamoStore backed by a new in-memory client,
+// with the named table already created.
+func NewWithTableName(table string, opts ...dynamostore.Option) *dynamostore.DynamoStore {
+	c := NewClient()
+	c.AddTable(table, "token")
+	return dynamostore.NewWithTableName(c, table, opts...)
+}
+
+// Client is an in-memory implementation of dynamostore.Client. It is safe
+// for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+type table struct {
+	name         string
+	key          string
+	ttlAttribute string
+	created      time.Time
+	tags         []types.Tag
+	billing      types.BillingMode
+	throughput   *types.ProvisionedThroughput
+	warm         *types.WarmThroughput
+	onDemand     types.OnDemandThroughput
+	policy       *resourcePolicy
+	indexes      map[string]index
+	replicas     []string
+	insights     bool
+	items        map[string]map[string]types.AttributeValue
+}
+
+// resourcePolicy is a table's resource-based policy.
+type resourcePolicy struct {
+	document string
+	revision int
+}
+
+// index is a global secondary index. Every attribute of an item is
+// projected, whatever the index's projection.
+type index struct {
+	hash  string
+	rng   string
+	input types.GlobalSecondaryIndex
+}
+
+// NewClient returns a Client without any tables.
+func NewClient() *Client {
+	return &Client{
+		tables: map[string]*table{},
+	}
+}
+
+// AddTable creates an active table with the given hash key, without waiting.
+func (c *Client) AddTable(name, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables[name] = &table{
+		name:    name,
+		key:     key,
+		created: time.Now(),
+		billing: types.BillingModePayPerRequest,
+		items:   map[string]map[string]types.AttributeValue{},
+	}
+}
+
+// Expire deletes items whose TTL attribute is earlier than now, as
+// DynamoDB's TTL process eventually does, and returns the number deleted.
+// Only tables with TTL enabled are affected.
+func (c *Client) Expire(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.tables {
+		if t.ttlAttribute == "" {
+			continue
+		}
+		for key, item := range t.items {
+			ttl, ok := item[t.ttlAttribute].(*types.AttributeValueMemberN)
+			if !ok {
+				continue
+			}
+			sec, err := strconv.ParseInt(ttl.Value, 10, 64)
+			if err == nil && time.Unix(sec, 0).Before(now) {
+				delete(t.items, key)
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Len returns the number of items in a table, including expired items.
+func (c *Client) Len(table string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.tables[table]; ok {
+		return len(t.items)
+	}
+	return 0
+}
+
+func notFound(name *string) error {
+	return &types.ResourceNotFoundException{
+		Message: aws.String("Requested resource not found: Table: " + aws.ToString(name) + " not found"),
+	}
+}
+
+func validation(msg string) error {
+	return &smithy.GenericAPIError{
+		Code:    "ValidationException",
+		Message: msg,
+	}
+}
+
+// table returns the named table. The caller must hold c.mu.
+func (c *Client) table(name *string) (*table, error) {
+	t, ok := c.tables[aws.ToString(name)]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return t, nil
+}
+
+// keyOf returns the hash key of an item or key.
+func (t *table) keyOf(item map[string]types.AttributeValue) (string, error) {
+	key, ok := item[t.key].(*types.AttributeValueMemberS)
+	if !ok || key.Value == "" {
+		return "", validation("missing or invalid key attribute: " + t.key)
+	}
+	return key.Value, nil
+}
+
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	result := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		result[k] = v
+	}
+	return result
+}
+
+// check evaluates a condition expression against an item.
+func check(
+	cond *string, names map[string]string, values map[string]types.AttributeValue,
+	item map[string]types.AttributeValue,
+) error {
+	if cond == nil {
+		return nil
+	}
+	e := &expression{names: names, values: values}
+	fn, err := e.condition(*cond)
+	if err != nil {
+		return validation(err.Error())
+	}
+	if !fn(item) {
+		return &types.ConditionalCheckFailedException{
+			Message: aws.String("The conditional request failed"),
+		}
+	}
+	return nil
+}
+
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, av := range item {
+		size += len(name)
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			size += len(v.Value)
+		case *types.AttributeValueMemberN:
+			size += len(v.Value)
+		case *types.AttributeValueMemberB:
+			size += len(v.Value)
+		default:
+			size++
+		}
+	}
+	return size
+}
+
+// capacity returns the consumed capacity for a request, if requested.
+func capacity(rcc types.ReturnConsumedCapacity, table string, units float64) *types.ConsumedCapacity {
+	if rcc == "" || rcc == types.ReturnConsumedCapacityNone {
+		return nil
+	}
+	return &types.ConsumedCapacity{
+		TableName:     aws.String(table),
+		CapacityUnits: aws.Float64(units),
+	}
+}
+
+func readUnits(size int, consistent bool) float64 {
+	units := math.Max(1, math.Ceil(float64(size)/4096))
+	if !consistent {
+		units /= 2
+	}
+	return units
+}
+
+func writeUnits(size int) float64 {
+	return math.Max(1, math.Ceil(float64(size)/1024))
+}
+
+// BatchGetItem implements dynamostore.Client. Every key is processed.
+func (c *Client) BatchGetItem(
+	ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for name, request := range params.RequestItems {
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range request.Keys {
+			count++
+			if _, err := t.keyOf(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if count < 1 || count > maxBatchGetItems {
+		msg := fmt.Sprintf("batch read must contain between 1 and %d keys", maxBatchGetItems)
+		return nil, validation(msg)
+	}
+
+	result := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+	for name, request := range params.RequestItems {
+		t := c.tables[name]
+		e := &expression{names: request.ExpressionAttributeNames}
+		units := 0.0
+		for _, k := range request.Keys {
+			key, _ := t.keyOf(k)
+			item := copyItem(t.items[key])
+			if item == nil {
+				continue
+			}
+			units += readUnits(itemSize(item), aws.ToBool(request.ConsistentRead))
+			if request.ProjectionExpression != nil {
+				var err error
+				if item, err = project(e, *request.ProjectionExpression, item); err != nil {
+					return nil, err
+				}
+			}
+			result.Responses[name] = append(result.Responses[name], item)
+		}
+		if cc := capacity(params.ReturnConsumedCapacity, name, units); cc != nil {
+			result.ConsumedCapacity = append(result.ConsumedCapacity, *cc)
+		}
+	}
+	return result, nil
+}
+
+// BatchWriteItem implements dynamostore.Client.
+func (c *Client) BatchWriteItem(
+	ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for name, requests := range params.RequestItems {
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range requests {
+			count++
+			item, err := requestItem(r)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.keyOf(item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if count < 1 || count > maxBatchWriteItems {
+		msg := fmt.Sprintf("batch write must contain between 1 and %d requests", maxBatchWriteItems)
+		return nil, validation(msg)
+	}
+
+	for name, requests := range params.RequestItems {
+		t := c.tables[name]
+		for _, r := range requests {
+			item, _ := requestItem(r)
+			key, _ := t.keyOf(item)
+			if r.PutRequest != nil {
+				t.items[key] = copyItem(r.PutRequest.Item)
+			} else {
+				delete(t.items, key)
+			}
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// requestItem returns the item or key of a batch write request.
+func requestItem(r types.WriteRequest) (map[string]types.AttributeValue, error) {
+	switch {
+	case r.PutRequest != nil:
+		return r.PutRequest.Item, nil
+	case r.DeleteRequest != nil:
+		return r.DeleteRequest.Key, nil
+	}
+	return nil, validation("write request must contain a put or delete")
+}
+
+// CreateTable implements dynamostore.Client. New tables are active
+// immediately.
+func (c *Client) CreateTable(
+	ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := aws.ToString(params.TableName)
+	if _, ok := c.tables[name]; ok {
+		return nil, &types.ResourceInUseException{
+			Message: aws.String("Table already exists: " + name),
+		}
+	}
+	var key string
+	for _, k := range params.KeySchema {
+		if k.KeyType == types.KeyTypeHash {
+			key = aws.ToString(k.AttributeName)
+		}
+	}
+	if key == "" {
+		return nil, validation("missing hash key")
+	}
+
+	indexes := make(map[string]index, len(params.GlobalSecondaryIndexes))
+	for _, gsi := range params.GlobalSecondaryIndexes {
+		idx := index{input: gsi}
+		for _, k := range gsi.KeySchema {
+			switch k.KeyType {
+			case types.KeyTypeHash:
+				idx.hash = aws.ToString(k.AttributeName)
+			case types.KeyTypeRange:
+				idx.rng = aws.ToString(k.AttributeName)
+			}
+		}
+		if idx.hash == "" {
+			return nil, validation("missing index hash key")
+		}
+		indexes[aws.ToString(gsi.IndexName)] = idx
+	}
+
+	billing := params.BillingMode
+	if billing == "" {
+		billing = types.BillingModeProvisioned
+	}
+	t := &table{
+		name:       name,
+		key:        key,
+		created:    time.Now(),
+		tags:       params.Tags,
+		billing:    billing,
+		throughput: params.ProvisionedThroughput,
+		warm:       params.WarmThroughput,
+		indexes:    indexes,
+		items:      map[string]map[string]types.AttributeValue{},
+	}
+	if err := t.limitOnDemand(params.OnDemandThroughput); err != nil {
+		return nil, err
+	}
+	if params.ResourcePolicy != nil {
+		if !json.Valid([]byte(aws.ToString(params.ResourcePolicy))) {
+			return nil, validation("invalid resource policy")
+		}
+		t.policy = &resourcePolicy{document: aws.ToString(params.ResourcePolicy), revision: 1}
+	}
+	c.tables[name] = t
+	return &dynamodb.CreateTableOutput{
+		TableDescription: t.describe(),
+	}, nil
+}
+
+// DeleteItem implements dynamostore.Client.
+func (c *Client) DeleteItem(
+	ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.keyOf(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[key]
+	err = check(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old)
+	if err != nil {
+		return nil, err
+	}
+	delete(t.items, key)
+
+	result := &dynamodb.DeleteItemOutput{
+		ConsumedCapacity: capacity(params.ReturnConsumedCapacity, t.name, writeUnits(itemSize(old))),
+	}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		result.Attributes = copyItem(old)
+	}
+	return result, nil
+}
+
+// DeleteTable implements dynamostore.Client. Tables are deleted
+// immediately.
+func (c *Client) DeleteTable(
+	ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.tables, t.name)
+	desc := t.describe()
+	desc.TableStatus = types.TableStatusDeleting
+	return &dynamodb.DeleteTableOutput{
+		TableDescription: desc,
+	}, nil
+}
+
+func (t *table) describe() *types.TableDescription {
+	size := 0
+	for _, item := range t.items {
+		size += itemSize(item)
+	}
+	desc := &types.TableDescription{
+		TableName:        aws.String(t.name),
+		TableArn:         aws.String(dynamostore.TableARN(region, "000000000000", t.name)),
+		TableStatus:      types.TableStatusActive,
+		CreationDateTime: aws.Time(t.created),
+		ItemCount:        aws.Int64(int64(len(t.items))),
+		TableSizeBytes:   aws.Int64(int64(size)),
+		BillingModeSummary: &types.BillingModeSummary{
+			BillingMode: t.billing,
+		},
+		KeySchema: []types.KeySchemaElement{{
+			AttributeName: aws.String(t.key),
+			KeyType:       types.KeyTypeHash,
+		}},
+		AttributeDefinitions: []types.AttributeDefinition{{
+			AttributeName: aws.String(t.key),
+			AttributeType: types.ScalarAttributeTypeS,
+		}},
+	}
+	if pt := t.throughput; pt != nil {
+		desc.ProvisionedThroughput = &types.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  pt.ReadCapacityUnits,
+			WriteCapacityUnits: pt.WriteCapacityUnits,
+		}
+	}
+	if warm := t.warm; warm != nil {
+		desc.WarmThroughput = &types.TableWarmThroughputDescription{
+			ReadUnitsPerSecond:  warm.ReadUnitsPerSecond,
+			WriteUnitsPerSecond: warm.WriteUnitsPerSecond,
+			Status:              types.TableStatusActive,
+		}
+	}
+	if limits := t.onDemand; limits.MaxReadRequestUnits != nil || limits.MaxWriteRequestUnits != nil {
+		desc.OnDemandThroughput = &types.OnDemandThroughput{
+			MaxReadRequestUnits:  limits.MaxReadRequestUnits,
+			MaxWriteRequestUnits: limits.MaxWriteRequestUnits,
+		}
+	}
+	if len(t.replicas) > 0 {
+		desc.StreamSpecification = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeNewAndOldImages,
+		}
+		for _, r := range append([]string{region}, t.replicas...) {
+			desc.Replicas = append(desc.Replicas, types.ReplicaDescription{
+				RegionName:    aws.String(r),
+				ReplicaStatus: types.ReplicaStatusActive,
+			})
+		}
+	}
+	names := make([]string, 0, len(t.indexes))
+	for name := range t.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		gsi := t.indexes[name].input
+		desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   gsi.IndexName,
+			IndexStatus: types.IndexStatusActive,
+			KeySchema:   gsi.KeySchema,
+			Projection:  gsi.Projection,
+		})
+	}
+	return desc
+}
+
+// DescribeTable implements dynamostore.Client.
+func (c *Client) DescribeTable(
+	ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{
+		Table: t.describe(),
+	}, nil
+}
+
+// DescribeContributorInsights reports whether contributor insights are
+// enabled for a table. It isn't used by dynamostore, but lets tests check
+// WithContributorInsights.
+func (c *Client) DescribeContributorInsights(
+	ctx context.Context, params *dynamodb.DescribeContributorInsightsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeContributorInsightsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	status := types.ContributorInsightsStatusDisabled
+	if t.insights {
+		status = types.ContributorInsightsStatusEnabled
+	}
+	return &dynamodb.DescribeContributorInsightsOutput{
+		TableName:                 params.TableName,
+		ContributorInsightsStatus: status,
+	}, nil
+}
+
+// DescribeTimeToLive implements dynamostore.Client.
+func (c *Client) DescribeTimeToLive(
+	ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	desc := &types.TimeToLiveDescription{
+		TimeToLiveStatus: types.TimeToLiveStatusDisabled,
+	}
+	if t.ttlAttribute != "" {
+		desc.AttributeName = aws.String(t.ttlAttribute)
+		desc.TimeToLiveStatus = types.TimeToLiveStatusEnabled
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{
+		TimeToLiveDescription: desc,
+	}, nil
+}
+
+// GetItem implements dynamostore.Client.
+func (c *Client) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.keyOf(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	item := copyItem(t.items[key])
+	if item != nil && params.ProjectionExpression != nil {
+		e := &expression{names: params.ExpressionAttributeNames}
+		if item, err = project(e, *params.ProjectionExpression, item); err != nil {
+			return nil, err
+		}
+	}
+	units := readUnits(itemSize(t.items[key]), aws.ToBool(params.ConsistentRead))
+	return &dynamodb.GetItemOutput{
+		Item:             item,
+		ConsumedCapacity: capacity(params.ReturnConsumedCapacity, t.name, units),
+	}, nil
+}
+
+// project returns the attributes of item named by a projection expression.
+func project(
+	e *expression, expr string, item map[string]types.AttributeValue,
+) (map[string]types.AttributeValue, error) {
+	names, err := e.projection(expr)
+	if err != nil {
+		return nil, validation(err.Error())
+	}
+	result := make(map[string]types.AttributeValue, len(names))
+	for _, name := range names {
+		if v, ok := item[name]; ok {
+			result[name] = v
+		}
+	}
+	return result, nil
+}
+
+// PutItem implements dynamostore.Client.
+func (c *Client) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.keyOf(params.Item)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[key]
+	err = check(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old)
+	if err != nil {
+		return nil, err
+	}
+	t.items[key] = copyItem(params.Item)
+
+	result := &dynamodb.PutItemOutput{
+		ConsumedCapacity: capacity(params.ReturnConsumedCapacity, t.name, writeUnits(itemSize(params.Item))),
+	}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		result.Attributes = copyItem(old)
+	}
+	return result, nil
+}
+
+// PutResourcePolicy implements dynamostore.Client, for tables.
+func (c *Client) PutResourcePolicy(
+	ctx context.Context, params *dynamodb.PutResourcePolicyInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutResourcePolicyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.tableByARN(params.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	document := aws.ToString(params.Policy)
+	if !json.Valid([]byte(document)) {
+		return nil, validation("invalid resource policy")
+	}
+	revision := 0
+	if t.policy != nil {
+		revision = t.policy.revision
+	}
+	if expected := params.ExpectedRevisionId; expected != nil && *expected != strconv.Itoa(revision) {
+		return nil, &types.PolicyNotFoundException{
+			Message: aws.String("Resource policy revision doesn't match: " + *expected),
+		}
+	}
+	t.policy = &resourcePolicy{document: document, revision: revision + 1}
+	return &dynamodb.PutResourcePolicyOutput{
+		RevisionId: aws.String(strconv.Itoa(t.policy.revision)),
+	}, nil
+}
+
+// GetResourcePolicy returns the resource-based policy of a table. It isn't
+// used by dynamostore, but lets tests check WithResourcePolicy.
+func (c *Client) GetResourcePolicy(
+	ctx context.Context, params *dynamodb.GetResourcePolicyInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetResourcePolicyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.tableByARN(params.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	if t.policy == nil {
+		return nil, &types.PolicyNotFoundException{
+			Message: aws.String("Resource policy not found: " + aws.ToString(params.ResourceArn)),
+		}
+	}
+	return &dynamodb.GetResourcePolicyOutput{
+		Policy:     aws.String(t.policy.document),
+		RevisionId: aws.String(strconv.Itoa(t.policy.revision)),
+	}, nil
+}
+
+// tableByARN returns the table with the given ARN. The caller must hold c.mu.
+func (c *Client) tableByARN(arn *string) (*table, error) {
+	for _, t := range c.tables {
+		if dynamostore.TableARN(region, "000000000000", t.name) == aws.ToString(arn) {
+			return t, nil
+		}
+	}
+	return nil, &types.ResourceNotFoundException{
+		Message: aws.String("Requested resource not found: " + aws.ToString(arn)),
+	}
+}
+
+// Query implements dynamostore.Client, for the table or one of its global
+// secondary indexes. Items are returned in order of the range key, if there
+// is one, and then of the table's hash key.
+func (c *Client) Query(
+	ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	hash, rng := t.key, ""
+	if params.IndexName != nil {
+		idx, ok := t.indexes[*params.IndexName]
+		if !ok {
+			return nil, validation("The table does not have the specified index: " + *params.IndexName)
+		}
+		hash, rng = idx.hash, idx.rng
+	}
+	if params.KeyConditionExpression == nil {
+		return nil, validation("missing key condition expression")
+	}
+	e := &expression{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	match, err := e.condition(*params.KeyConditionExpression)
+	if err != nil {
+		return nil, validation(err.Error())
+	}
+	var filter condition
+	if params.FilterExpression != nil {
+		if filter, err = e.condition(*params.FilterExpression); err != nil {
+			return nil, validation(err.Error())
+		}
+	}
+
+	var keys []string
+	for key, item := range t.items {
+		if _, ok := item[hash]; !ok {
+			continue
+		}
+		if _, ok := item[rng]; rng != "" && !ok {
+			continue
+		}
+		if match(item) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if rng != "" {
+			c, ok := compare(t.items[keys[i]][rng], t.items[keys[j]][rng])
+			if ok && c != 0 {
+				return c < 0
+			}
+		}
+		return keys[i] < keys[j]
+	})
+	if params.ScanIndexForward != nil && !*params.ScanIndexForward {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	if params.ExclusiveStartKey != nil {
+		start, err := t.keyOf(params.ExclusiveStartKey)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
+			if key == start {
+				keys = keys[i+1:]
+				break
+			}
+		}
+	}
+
+	result := &dynamodb.QueryOutput{}
+	size := 0
+	limit := int(aws.ToInt32(params.Limit))
+	for i, key := range keys {
+		item := t.items[key]
+		size += itemSize(item)
+		result.ScannedCount++
+		if filter == nil || filter(item) {
+			item = copyItem(item)
+			if params.ProjectionExpression != nil {
+				if item, err = project(e, *params.ProjectionExpression, item); err != nil {
+					return nil, err
+				}
+			}
+			result.Items = append(result.Items, item)
+			result.Count++
+		}
+		if limit > 0 && int(result.ScannedCount) >= limit && i < len(keys)-1 {
+			last := map[string]types.AttributeValue{
+				t.key: &types.AttributeValueMemberS{Value: key},
+				hash:  t.items[key][hash],
+			}
+			if rng != "" {
+				last[rng] = t.items[key][rng]
+			}
+			result.LastEvaluatedKey = last
+			break
+		}
+	}
+	if params.Select == types.SelectCount {
+		result.Items = nil
+	}
+	units := readUnits(size, aws.ToBool(params.ConsistentRead))
+	result.ConsumedCapacity = capacity(params.ReturnConsumedCapacity, t.name, units)
+	return result, nil
+}
+
+// Scan implements dynamostore.Client. Items are returned in a stable order,
+// and parallel scans split the table by a hash of each item's key.
+func (c *Client) Scan(
+	ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	e := &expression{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	var filter condition
+	if params.FilterExpression != nil {
+		if filter, err = e.condition(*params.FilterExpression); err != nil {
+			return nil, validation(err.Error())
+		}
+	}
+
+	keys := make([]string, 0, len(t.items))
+	for key := range t.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if params.ExclusiveStartKey != nil {
+		start, err := t.keyOf(params.ExclusiveStartKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = keys[sort.SearchStrings(keys, start+"\x00"):]
+	}
+	if params.TotalSegments != nil {
+		segment := uint32(aws.ToInt32(params.Segment))
+		total := uint32(aws.ToInt32(params.TotalSegments))
+		if total < 1 || segment >= total {
+			return nil, validation("invalid segment")
+		}
+		filtered := keys[:0:0]
+		for _, key := range keys {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(key))
+			if h.Sum32()%total == segment {
+				filtered = append(filtered, key)
+			}
+		}
+		keys = filtered
+	}
+
+	result := &dynamodb.ScanOutput{}
+	size := 0
+	limit := int(aws.ToInt32(params.Limit))
+	for i, key := range keys {
+		item := t.items[key]
+		size += itemSize(item)
+		result.ScannedCount++
+		if filter == nil || filter(item) {
+			item = copyItem(item)
+			if params.ProjectionExpression != nil {
+				if item, err = project(e, *params.ProjectionExpression, item); err != nil {
+					return nil, err
+				}
+			}
+			result.Items = append(result.Items, item)
+			result.Count++
+		}
+		if limit > 0 && int(result.ScannedCount) >= limit && i < len(keys)-1 {
+			result.LastEvaluatedKey = map[string]types.AttributeValue{
+				t.key: &types.AttributeValueMemberS{Value: key},
+			}
+			break
+		}
+	}
+	units := readUnits(size, aws.ToBool(params.ConsistentRead))
+	result.ConsumedCapacity = capacity(params.ReturnConsumedCapacity, t.name, units)
+	return result, nil
+}
+
+// TransactWriteItems implements dynamostore.Client. Every condition is
+// checked before any change is made, and the changes are made together.
+func (c *Client) TransactWriteItems(
+	ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type change struct {
+		t    *table
+		key  string
+		item map[string]types.AttributeValue
+	}
+	changes := make([]change, 0, len(params.TransactItems))
+	reasons := make([]types.CancellationReason, len(params.TransactItems))
+	seen := map[string]bool{}
+	failed := false
+	units := map[string]float64{}
+	for i, op := range params.TransactItems {
+		var (
+			tableName *string
+			key       map[string]types.AttributeValue
+			cond      *string
+			names     map[string]string
+			values    map[string]types.AttributeValue
+		)
+		switch {
+		case op.ConditionCheck != nil:
+			tableName, key = op.ConditionCheck.TableName, op.ConditionCheck.Key
+			cond, names, values = op.ConditionCheck.ConditionExpression,
+				op.ConditionCheck.ExpressionAttributeNames, op.ConditionCheck.ExpressionAttributeValues
+		case op.Delete != nil:
+			tableName, key = op.Delete.TableName, op.Delete.Key
+			cond, names, values = op.Delete.ConditionExpression,
+				op.Delete.ExpressionAttributeNames, op.Delete.ExpressionAttributeValues
+		case op.Put != nil:
+			tableName, key = op.Put.TableName, op.Put.Item
+			cond, names, values = op.Put.ConditionExpression,
+				op.Put.ExpressionAttributeNames, op.Put.ExpressionAttributeValues
+		case op.Update != nil:
+			tableName, key = op.Update.TableName, op.Update.Key
+			cond, names, values = op.Update.ConditionExpression,
+				op.Update.ExpressionAttributeNames, op.Update.ExpressionAttributeValues
+		default:
+			return nil, validation("missing TransactWriteItem operation")
+		}
+
+		t, err := c.table(tableName)
+		if err != nil {
+			return nil, err
+		}
+		k, err := t.keyOf(key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[t.name+"/"+k] {
+			return nil, validation("Transaction request cannot include multiple operations on one item")
+		}
+		seen[t.name+"/"+k] = true
+
+		old := t.items[k]
+		reasons[i].Code = aws.String("None")
+		if err := check(cond, names, values, old); err != nil {
+			var conditionErr *types.ConditionalCheckFailedException
+			if !errors.As(err, &conditionErr) {
+				return nil, err
+			}
+			reasons[i].Code = aws.String("ConditionalCheckFailed")
+			reasons[i].Message = conditionErr.Message
+			failed = true
+			continue
+		}
+
+		switch {
+		case op.Delete != nil:
+			changes = append(changes, change{t: t, key: k})
+			units[t.name] += 2 * writeUnits(itemSize(old))
+		case op.Put != nil:
+			changes = append(changes, change{t: t, key: k, item: copyItem(op.Put.Item)})
+			units[t.name] += 2 * writeUnits(itemSize(op.Put.Item))
+		case op.Update != nil:
+			if op.Update.UpdateExpression == nil {
+				return nil, validation("missing UpdateExpression")
+			}
+			e := &expression{names: names, values: values}
+			fn, err := e.update(*op.Update.UpdateExpression)
+			if err != nil {
+				return nil, validation(err.Error())
+			}
+			item := copyItem(old)
+			if item == nil {
+				item = copyItem(key)
+			}
+			if err := fn(item); err != nil {
+				return nil, validation(err.Error())
+			}
+			changes = append(changes, change{t: t, key: k, item: item})
+			units[t.name] += 2 * writeUnits(itemSize(item))
+		}
+	}
+	if failed {
+		return nil, &types.TransactionCanceledException{
+			Message: aws.String(
+				"Transaction cancelled, please refer cancellation reasons for specific reasons",
+			),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, change := range changes {
+		if change.item == nil {
+			delete(change.t.items, change.key)
+		} else {
+			change.t.items[change.key] = change.item
+		}
+	}
+	result := &dynamodb.TransactWriteItemsOutput{}
+	if params.ReturnConsumedCapacity != "" && params.ReturnConsumedCapacity != types.ReturnConsumedCapacityNone {
+		names := make([]string, 0, len(units))
+		for name := range units {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result.ConsumedCapacity = append(result.ConsumedCapacity,
+				*capacity(params.ReturnConsumedCapacity, name, units[name]))
+		}
+	}
+	return result, nil
+}
+
+// UpdateContributorInsights implements dynamostore.Client. Changes take
+// effect immediately, but nothing is reported to CloudWatch.
+func (c *Client) UpdateContributorInsights(
+	ctx context.Context, params *dynamodb.UpdateContributorInsightsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateContributorInsightsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if params.IndexName != nil {
+		return nil, validation("contributor insights for indexes aren't supported")
+	}
+	status := types.ContributorInsightsStatusDisabled
+	switch params.ContributorInsightsAction {
+	case types.ContributorInsightsActionEnable:
+		status = types.ContributorInsightsStatusEnabled
+		t.insights = true
+	case types.ContributorInsightsActionDisable:
+		t.insights = false
+	default:
+		return nil, validation("invalid ContributorInsightsAction")
+	}
+	return &dynamodb.UpdateContributorInsightsOutput{
+		TableName:                 params.TableName,
+		ContributorInsightsStatus: status,
+	}, nil
+}
+
+// UpdateItem implements dynamostore.Client. Items that don't exist are
+// created, unless the condition expression fails.
+func (c *Client) UpdateItem(
+	ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.keyOf(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if params.UpdateExpression == nil {
+		return nil, validation("missing UpdateExpression")
+	}
+	e := &expression{names: params.ExpressionAttributeNames, values: params.ExpressionAttributeValues}
+	fn, err := e.update(*params.UpdateExpression)
+	if err != nil {
+		return nil, validation(err.Error())
+	}
+	old := t.items[key]
+	err = check(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, old)
+	if err != nil {
+		return nil, err
+	}
+
+	item := copyItem(old)
+	if item == nil {
+		item = copyItem(params.Key)
+	}
+	if err := fn(item); err != nil {
+		return nil, validation(err.Error())
+	}
+	if !equal(item[t.key], params.Key[t.key]) {
+		return nil, validation("cannot update attribute " + t.key + ": this attribute is part of the key")
+	}
+	t.items[key] = item
+
+	size := itemSize(item)
+	if oldSize := itemSize(old); oldSize > size {
+		size = oldSize
+	}
+	result := &dynamodb.UpdateItemOutput{
+		ConsumedCapacity: capacity(params.ReturnConsumedCapacity, t.name, writeUnits(size)),
+	}
+	switch params.ReturnValues {
+	case "", types.ReturnValueNone:
+	case types.ReturnValueAllOld:
+		result.Attributes = copyItem(old)
+	case types.ReturnValueAllNew:
+		result.Attributes = copyItem(item)
+	default:
+		return nil, validation("unsupported ReturnValues: " + string(params.ReturnValues))
+	}
+	return result, nil
+}
+
+// UpdateTable implements dynamostore.Client. Only replica updates take
+// effect, and new replicas are active immediately. Other updates, including
+// parameters added to the request by middleware, are accepted and ignored.
+func (c *Client) UpdateTable(
+	ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	replicas := append([]string{}, t.replicas...)
+	for _, update := range params.ReplicaUpdates {
+		switch {
+		case update.Create != nil:
+			r := aws.ToString(update.Create.RegionName)
+			if r == region || indexOf(replicas, r) >= 0 {
+				return nil, validation("replica already exists in region: " + r)
+			}
+			replicas = append(replicas, r)
+		case update.Delete != nil:
+			r := aws.ToString(update.Delete.RegionName)
+			i := indexOf(replicas, r)
+			if i < 0 {
+				return nil, validation("no replica in region: " + r)
+			}
+			replicas = append(replicas[:i], replicas[i+1:]...)
+		default:
+			return nil, validation("unsupported replica update")
+		}
+	}
+	if err := t.limitOnDemand(params.OnDemandThroughput); err != nil {
+		return nil, err
+	}
+	t.replicas = replicas
+	return &dynamodb.UpdateTableOutput{
+		TableDescription: t.describe(),
+	}, nil
+}
+
+// limitOnDemand applies the OnDemandThroughput parameter of CreateTable or
+// UpdateTable. A limit of -1 removes it, and one that's omitted is unchanged.
+func (t *table) limitOnDemand(limits *types.OnDemandThroughput) error {
+	if limits == nil {
+		return nil
+	}
+	if t.billing != types.BillingModePayPerRequest {
+		return validation("on-demand throughput requires PAY_PER_REQUEST billing")
+	}
+	reads, err := onDemandLimit(t.onDemand.MaxReadRequestUnits, limits.MaxReadRequestUnits)
+	if err != nil {
+		return err
+	}
+	writes, err := onDemandLimit(t.onDemand.MaxWriteRequestUnits, limits.MaxWriteRequestUnits)
+	if err != nil {
+		return err
+	}
+	t.onDemand.MaxReadRequestUnits = reads
+	t.onDemand.MaxWriteRequestUnits = writes
+	return nil
+}
+
+func onDemandLimit(current, update *int64) (*int64, error) {
+	switch n := aws.ToInt64(update); {
+	case update == nil:
+		return current, nil
+	case n == -1:
+		return nil, nil
+	case n > 0:
+		return aws.Int64(n), nil
+	default:
+		return nil, validation("invalid on-demand throughput")
+	}
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// UpdateTimeToLive implements dynamostore.Client. Changes take effect
+// immediately.
+func (c *Client) UpdateTimeToLive(
+	ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	spec := params.TimeToLiveSpecification
+	if spec == nil {
+		return nil, validation("missing TimeToLiveSpecification")
+	}
+	if aws.ToBool(spec.Enabled) {
+		if t.ttlAttribute != "" {
+			return nil, validation("TimeToLive is already enabled")
+		}
+		t.ttlAttribute = aws.ToString(spec.AttributeName)
+	} else {
+		t.ttlAttribute = ""
+	}
+	return &dynamodb.UpdateTimeToLiveOutput{
+		TimeToLiveSpecification: spec,
+	}, nil
+}
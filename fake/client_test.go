@@ -0,0 +1,488 @@
+package fake_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	store := fake.New()
+	expiry := time.Now().Add(time.Hour)
+
+	// when
+	err := store.Commit("foo", []byte("bar"), expiry)
+	require.NoError(err)
+	err = store.Commit("expired", []byte("baz"), time.Now().Add(-time.Hour))
+	require.NoError(err)
+
+	// then
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+
+	_, exists, err = store.Find("expired")
+	require.NoError(err)
+	require.False(exists)
+
+	n, err := store.DeleteExpired(context.Background(), 2)
+	require.NoError(err)
+	require.Equal(1, n)
+
+	var buf bytes.Buffer
+	n, err = store.Export(context.Background(), &buf)
+	require.NoError(err)
+	require.Equal(1, n)
+
+	require.NoError(store.Delete("foo"))
+	_, exists, err = store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+}
+
+func TestMissingTable(t *testing.T) {
+	require := require.New(t)
+
+	store := dynamostore.New(fake.NewClient())
+	_, _, err := store.Find("foo")
+
+	var notFound *types.ResourceNotFoundException
+	require.True(errors.As(err, &notFound))
+}
+
+func TestExpire(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	client := fake.NewClient()
+	_, err := client.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+		TableName: aws.String("sessions"),
+		KeySchema: []types.KeySchemaElement{{
+			AttributeName: aws.String("token"),
+			KeyType:       types.KeyTypeHash,
+		}},
+	})
+	require.NoError(err)
+	store := dynamostore.NewWithTableName(client, "sessions")
+	require.NoError(store.Commit("foo", []byte("bar"), time.Now().Add(-time.Minute)))
+
+	// when TTL is disabled
+	require.Equal(0, client.Expire(time.Now()))
+
+	// when TTL is enabled
+	_, err = client.UpdateTimeToLive(context.Background(), &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String("sessions"),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String("ttl"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	require.NoError(err)
+	require.Equal(1, client.Expire(time.Now()))
+	require.Equal(0, client.Len("sessions"))
+}
+
+func TestConditions(t *testing.T) {
+	require := require.New(t)
+
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	put := func(cond string, values map[string]types.AttributeValue) error {
+		_, err := client.PutItem(context.Background(), &dynamodb.PutItemInput{
+			TableName: aws.String("sessions"),
+			Item: map[string]types.AttributeValue{
+				"token": &types.AttributeValueMemberS{Value: "foo"},
+				"ttl":   &types.AttributeValueMemberN{Value: "100"},
+			},
+			ConditionExpression:       aws.String(cond),
+			ExpressionAttributeNames:  map[string]string{"#t": "token", "#ttl": "ttl"},
+			ExpressionAttributeValues: values,
+		})
+		return err
+	}
+	n := func(v string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{":v": &types.AttributeValueMemberN{Value: v}}
+	}
+
+	var conditionErr *types.ConditionalCheckFailedException
+	require.NoError(put("attribute_not_exists(#t)", nil))
+	require.True(errors.As(put("attribute_not_exists(#t)", nil), &conditionErr))
+	require.NoError(put("#ttl < :v AND attribute_exists(#t)", n("200")))
+	require.True(errors.As(put("#ttl >= :v", n("1000")), &conditionErr))
+	require.NoError(put("NOT (#ttl = :v) OR #ttl <> :v", n("99")))
+	require.Error(put("#ttl <", nil))
+}
+
+func TestTransactWriteItems(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	ctx := context.Background()
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	key := func(token string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"token": &types.AttributeValueMemberS{Value: token}}
+	}
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("sessions"), Item: key("foo")})
+	require.NoError(err)
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("sessions"), Item: key("taken")})
+	require.NoError(err)
+	rename := func(to string) error {
+		_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{
+					TableName:                aws.String("sessions"),
+					Item:                     key(to),
+					ConditionExpression:      aws.String("attribute_not_exists(#t)"),
+					ExpressionAttributeNames: map[string]string{"#t": "token"},
+				}},
+				{Delete: &types.Delete{
+					TableName:                aws.String("sessions"),
+					Key:                      key("foo"),
+					ConditionExpression:      aws.String("attribute_exists(#t)"),
+					ExpressionAttributeNames: map[string]string{"#t": "token"},
+				}},
+			},
+		})
+		return err
+	}
+
+	// when a condition fails
+	err = rename("taken")
+
+	// then nothing changes
+	var canceledErr *types.TransactionCanceledException
+	require.True(errors.As(err, &canceledErr))
+	require.Len(canceledErr.CancellationReasons, 2)
+	require.Equal("ConditionalCheckFailed", aws.ToString(canceledErr.CancellationReasons[0].Code))
+	require.Equal("None", aws.ToString(canceledErr.CancellationReasons[1].Code))
+	require.Equal(2, client.Len("sessions"))
+
+	// when every condition passes
+	err = rename("bar")
+
+	// then every change is made
+	require.NoError(err)
+	require.Equal(2, client.Len("sessions"))
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("sessions"), Key: key("foo")})
+	require.NoError(err)
+	require.Empty(result.Item)
+	result, err = client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("sessions"), Key: key("bar")})
+	require.NoError(err)
+	require.NotEmpty(result.Item)
+
+	// when an item is used twice
+	_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String("sessions"), Item: key("bar")}},
+			{Delete: &types.Delete{TableName: aws.String("sessions"), Key: key("bar")}},
+		},
+	})
+
+	// then the request is rejected
+	require.Error(err)
+}
+
+func TestUpdateItem(t *testing.T) {
+	require := require.New(t)
+
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	update := func(expr string, cond *string) (map[string]types.AttributeValue, error) {
+		result, err := client.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+			TableName: aws.String("sessions"),
+			Key: map[string]types.AttributeValue{
+				"token": &types.AttributeValueMemberS{Value: "foo"},
+			},
+			UpdateExpression:         aws.String(expr),
+			ConditionExpression:      cond,
+			ExpressionAttributeNames: map[string]string{"#t": "token", "#v": "version"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":one":  &types.AttributeValueMemberN{Value: "1"},
+				":zero": &types.AttributeValueMemberN{Value: "0"},
+				":ttl":  &types.AttributeValueMemberN{Value: "100"},
+			},
+			ReturnValues: types.ReturnValueAllNew,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Attributes, nil
+	}
+
+	var conditionErr *types.ConditionalCheckFailedException
+	_, err := update("SET ttl = :ttl", aws.String("attribute_exists(#t)"))
+	require.True(errors.As(err, &conditionErr))
+	require.Equal(0, client.Len("sessions"))
+
+	item, err := update("SET #v = if_not_exists(#v, :zero) + :one, ttl = :ttl", nil)
+	require.NoError(err)
+	require.Equal(&types.AttributeValueMemberN{Value: "1"}, item["version"])
+	require.Equal(&types.AttributeValueMemberN{Value: "100"}, item["ttl"])
+
+	item, err = update("SET #v = #v + :one REMOVE ttl", aws.String("attribute_exists(#t)"))
+	require.NoError(err)
+	require.Equal(&types.AttributeValueMemberN{Value: "2"}, item["version"])
+	require.NotContains(item, "ttl")
+
+	_, err = update("SET #v = missing - :one", nil)
+	require.Error(err)
+	_, err = update("SET #t = :one", nil)
+	require.Error(err)
+	_, err = update("ADD #v :one", nil)
+	require.Error(err)
+}
+
+func TestScanPagination(t *testing.T) {
+	require := require.New(t)
+
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	for _, token := range []string{"a", "b", "c"} {
+		_, err := client.PutItem(context.Background(), &dynamodb.PutItemInput{
+			TableName: aws.String("sessions"),
+			Item: map[string]types.AttributeValue{
+				"token": &types.AttributeValueMemberS{Value: token},
+			},
+		})
+		require.NoError(err)
+	}
+
+	result, err := client.Scan(context.Background(), &dynamodb.ScanInput{
+		TableName: aws.String("sessions"),
+		Limit:     aws.Int32(2),
+	})
+	require.NoError(err)
+	require.Len(result.Items, 2)
+	require.NotNil(result.LastEvaluatedKey)
+
+	result, err = client.Scan(context.Background(), &dynamodb.ScanInput{
+		TableName:         aws.String("sessions"),
+		ExclusiveStartKey: result.LastEvaluatedKey,
+		Limit:             aws.Int32(2),
+	})
+	require.NoError(err)
+	require.Len(result.Items, 1)
+	require.Nil(result.LastEvaluatedKey)
+}
+
+func TestQueryIndex(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	client := fake.NewClient()
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("sessions"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("token"), KeyType: types.KeyTypeHash},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName: aws.String("byGroup"),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("group"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("rank"), KeyType: types.KeyTypeRange},
+			},
+		}},
+	})
+	require.NoError(err)
+	items := []struct{ token, group, rank string }{
+		{"a", "x", "3"}, {"b", "x", "1"}, {"c", "x", "2"}, {"d", "y", "1"}, {"e", "", ""},
+	}
+	for _, i := range items {
+		item := map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{Value: i.token},
+		}
+		if i.group != "" {
+			item["group"] = &types.AttributeValueMemberS{Value: i.group}
+			item["rank"] = &types.AttributeValueMemberN{Value: i.rank}
+		}
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("sessions"), Item: item})
+		require.NoError(err)
+	}
+
+	query := &dynamodb.QueryInput{
+		TableName:              aws.String("sessions"),
+		IndexName:              aws.String("byGroup"),
+		KeyConditionExpression: aws.String("#group = :group AND #rank BETWEEN :low AND :high"),
+		ExpressionAttributeNames: map[string]string{
+			"#group": "group",
+			"#rank":  "rank",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":group": &types.AttributeValueMemberS{Value: "x"},
+			":low":   &types.AttributeValueMemberN{Value: "1"},
+			":high":  &types.AttributeValueMemberN{Value: "2"},
+		},
+		Limit: aws.Int32(1),
+	}
+	result, err := client.Query(ctx, query)
+	require.NoError(err)
+	require.Len(result.Items, 1)
+	require.Equal("b", result.Items[0]["token"].(*types.AttributeValueMemberS).Value)
+	require.NotNil(result.LastEvaluatedKey)
+
+	query.ExclusiveStartKey = result.LastEvaluatedKey
+	result, err = client.Query(ctx, query)
+	require.NoError(err)
+	require.Len(result.Items, 1)
+	require.Equal("c", result.Items[0]["token"].(*types.AttributeValueMemberS).Value)
+	require.Nil(result.LastEvaluatedKey)
+
+	query.IndexName = aws.String("missing")
+	_, err = client.Query(ctx, query)
+	require.Error(err)
+}
+
+func TestBatchGetItem(t *testing.T) {
+	require := require.New(t)
+
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	for _, token := range []string{"a", "b"} {
+		_, err := client.PutItem(context.Background(), &dynamodb.PutItemInput{
+			TableName: aws.String("sessions"),
+			Item: map[string]types.AttributeValue{
+				"token": &types.AttributeValueMemberS{Value: token},
+				"Data":  &types.AttributeValueMemberB{Value: []byte(token)},
+			},
+		})
+		require.NoError(err)
+	}
+	key := func(token string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{Value: token},
+		}
+	}
+
+	result, err := client.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"sessions": {
+				Keys:                     []map[string]types.AttributeValue{key("a"), key("b"), key("c")},
+				ProjectionExpression:     aws.String("#token"),
+				ExpressionAttributeNames: map[string]string{"#token": "token"},
+			},
+		},
+	})
+	require.NoError(err)
+	require.ElementsMatch(
+		[]map[string]types.AttributeValue{key("a"), key("b")},
+		result.Responses["sessions"],
+	)
+
+	_, err = client.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"sessions": {},
+		},
+	})
+	require.Error(err)
+}
+
+func TestResourcePolicy(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	client := fake.NewClient()
+	client.AddTable("sessions", "token")
+	arn := aws.String(dynamostore.TableARN("us-east-1", "000000000000", "sessions"))
+
+	_, err := client.GetResourcePolicy(ctx, &dynamodb.GetResourcePolicyInput{ResourceArn: arn})
+	var notFoundErr *types.PolicyNotFoundException
+	require.True(errors.As(err, &notFoundErr))
+
+	result, err := client.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn: arn,
+		Policy:      aws.String(`{"Version": "2012-10-17"}`),
+	})
+	require.NoError(err)
+	revision := result.RevisionId
+
+	_, err = client.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn:        arn,
+		Policy:             aws.String(`{"Version": "2012-10-17"}`),
+		ExpectedRevisionId: aws.String("stale"),
+	})
+	require.True(errors.As(err, &notFoundErr))
+
+	_, err = client.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn:        arn,
+		Policy:             aws.String(`{"Version": "2012-10-17", "Statement": []}`),
+		ExpectedRevisionId: revision,
+	})
+	require.NoError(err)
+
+	policy, err := client.GetResourcePolicy(ctx, &dynamodb.GetResourcePolicyInput{ResourceArn: arn})
+	require.NoError(err)
+	require.JSONEq(`{"Version": "2012-10-17", "Statement": []}`, aws.ToString(policy.Policy))
+
+	_, err = client.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn: arn,
+		Policy:      aws.String("{"),
+	})
+	require.Error(err)
+
+	_, err = client.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn: aws.String(dynamostore.TableARN("us-east-1", "000000000000", "missing")),
+		Policy:      aws.String(`{"Version": "2012-10-17"}`),
+	})
+	var missingErr *types.ResourceNotFoundException
+	require.True(errors.As(err, &missingErr))
+}
+
+func TestOnDemandThroughput(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	client := fake.NewClient()
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("provisioned"),
+		KeySchema: []types.KeySchemaElement{{
+			AttributeName: aws.String("token"),
+			KeyType:       types.KeyTypeHash,
+		}},
+		OnDemandThroughput: &types.OnDemandThroughput{MaxReadRequestUnits: aws.Int64(100)},
+	})
+	require.Error(err)
+
+	_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String("sessions"),
+		BillingMode: types.BillingModePayPerRequest,
+		KeySchema: []types.KeySchemaElement{{
+			AttributeName: aws.String("token"),
+			KeyType:       types.KeyTypeHash,
+		}},
+		OnDemandThroughput: &types.OnDemandThroughput{MaxReadRequestUnits: aws.Int64(100)},
+	})
+	require.NoError(err)
+
+	result, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String("sessions"),
+		OnDemandThroughput: &types.OnDemandThroughput{
+			MaxReadRequestUnits:  aws.Int64(-1),
+			MaxWriteRequestUnits: aws.Int64(50),
+		},
+	})
+	require.NoError(err)
+	limits := result.TableDescription.OnDemandThroughput
+	require.Nil(limits.MaxReadRequestUnits)
+	require.Equal(int64(50), aws.ToInt64(limits.MaxWriteRequestUnits))
+
+	_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:          aws.String("sessions"),
+		OnDemandThroughput: &types.OnDemandThroughput{MaxWriteRequestUnits: aws.Int64(0)},
+	})
+	require.Error(err)
+}
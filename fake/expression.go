@@ -0,0 +1,342 @@
+package fake
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// condition is a parsed condition or filter expression.
+type condition func(item map[string]types.AttributeValue) bool
+
+// expression holds the placeholders shared by the expressions of a request.
+type expression struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+// name resolves an attribute name, which may be a placeholder.
+func (e *expression) name(token string) (string, error) {
+	if !strings.HasPrefix(token, "#") {
+		return token, nil
+	}
+	name, ok := e.names[token]
+	if !ok {
+		return "", fmt.Errorf("undefined expression attribute name: %s", token)
+	}
+	return name, nil
+}
+
+// projection parses a projection expression into attribute names.
+func (e *expression) projection(expr string) ([]string, error) {
+	var names []string
+	for _, part := range strings.Split(expr, ",") {
+		name, err := e.name(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// condition parses the subset of the condition expression syntax used by
+// dynamostore: comparisons, AND, OR, NOT, parentheses, attribute_exists,
+// attribute_not_exists, and begins_with.
+func (e *expression) condition(expr string) (condition, error) {
+	p := &parser{expr: e, tokens: tokenize(expr)}
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token in expression: %s", p.tokens[p.pos])
+	}
+	return cond, nil
+}
+
+// isSeparator returns true if r ends a name or placeholder in an expression.
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("(),+-<>=", r)
+}
+
+func tokenize(expr string) []string {
+	var tokens []string
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("(),+-", r):
+			tokens = append(tokens, string(r))
+			i++
+		case strings.ContainsRune("<>=", r):
+			j := i + 1
+			if j < len(runes) && strings.ContainsRune("<>=", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !isSeparator(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	expr   *expression
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *parser) expect(token string) error {
+	if actual := p.next(); actual != token {
+		return fmt.Errorf("expected %q in expression, got %q", token, actual)
+	}
+	return nil
+}
+
+func (p *parser) or() (condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item map[string]types.AttributeValue) bool {
+			return l(item) || right(item)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item map[string]types.AttributeValue) bool {
+			return l(item) && right(item)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (condition, error) {
+	if !strings.EqualFold(p.peek(), "NOT") {
+		return p.primary()
+	}
+	p.next()
+	cond, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) bool {
+		return !cond(item)
+	}, nil
+}
+
+func (p *parser) primary() (condition, error) {
+	if p.peek() == "(" {
+		p.next()
+		cond, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(")")
+	}
+
+	switch fn := p.peek(); fn {
+	case "attribute_exists", "attribute_not_exists":
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		name, err := p.expr.name(p.next())
+		if err != nil {
+			return nil, err
+		}
+		exists := fn == "attribute_exists"
+		return func(item map[string]types.AttributeValue) bool {
+			_, ok := item[name]
+			return ok == exists
+		}, p.expect(")")
+	case "begins_with":
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		left, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) bool {
+			a, ok := left(item).(*types.AttributeValueMemberS)
+			b, ok2 := right(item).(*types.AttributeValueMemberS)
+			return ok && ok2 && strings.HasPrefix(a.Value, b.Value)
+		}, p.expect(")")
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if strings.EqualFold(op, "BETWEEN") {
+		return p.between(left)
+	}
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	var test func(int) bool
+	switch op {
+	case "=":
+		return func(item map[string]types.AttributeValue) bool {
+			return equal(left(item), right(item))
+		}, nil
+	case "<>":
+		return func(item map[string]types.AttributeValue) bool {
+			return !equal(left(item), right(item))
+		}, nil
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	case ">=":
+		test = func(c int) bool { return c >= 0 }
+	default:
+		return nil, fmt.Errorf("unsupported comparator in expression: %q", op)
+	}
+	return func(item map[string]types.AttributeValue) bool {
+		c, ok := compare(left(item), right(item))
+		return ok && test(c)
+	}, nil
+}
+
+// between parses the bounds of a BETWEEN comparison, which are inclusive.
+func (p *parser) between(
+	value func(map[string]types.AttributeValue) types.AttributeValue,
+) (condition, error) {
+	low, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.next(); !strings.EqualFold(op, "AND") {
+		return nil, fmt.Errorf("expected AND in BETWEEN expression, got %q", op)
+	}
+	high, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) bool {
+		v := value(item)
+		c1, ok1 := compare(low(item), v)
+		c2, ok2 := compare(v, high(item))
+		return ok1 && ok2 && c1 <= 0 && c2 <= 0
+	}, nil
+}
+
+// operand returns a function that evaluates an attribute or value.
+func (p *parser) operand() (func(map[string]types.AttributeValue) types.AttributeValue, error) {
+	token := p.next()
+	if strings.HasPrefix(token, ":") {
+		value, ok := p.expr.values[token]
+		if !ok {
+			return nil, fmt.Errorf("undefined expression attribute value: %s", token)
+		}
+		return func(map[string]types.AttributeValue) types.AttributeValue {
+			return value
+		}, nil
+	}
+	if token == "" || strings.ContainsAny(token, "(),+-<>=") {
+		return nil, fmt.Errorf("expected operand in expression, got %q", token)
+	}
+	name, err := p.expr.name(token)
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) types.AttributeValue {
+		return item[name]
+	}, nil
+}
+
+func equal(a, b types.AttributeValue) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders two scalar values of the same type. The ok flag is false if
+// the values can't be compared.
+func compare(a, b types.AttributeValue) (int, bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			x, ok1 := new(big.Float).SetString(a.Value)
+			y, ok2 := new(big.Float).SetString(b.Value)
+			if ok1 && ok2 {
+				return x.Cmp(y), true
+			}
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value), true
+		}
+	}
+	return 0, false
+}
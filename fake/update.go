@@ -0,0 +1,169 @@
+package fake
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// update is a parsed update expression.
+type update func(item map[string]types.AttributeValue) error
+
+// update parses the subset of the update expression syntax used by
+// dynamostore: SET with values, attributes, if_not_exists, and addition or
+// subtraction of numbers, and REMOVE.
+func (e *expression) update(expr string) (update, error) {
+	p := &parser{expr: e, tokens: tokenize(expr)}
+	var actions []update
+	for p.pos < len(p.tokens) {
+		clause := strings.ToUpper(p.next())
+		for {
+			var action update
+			var err error
+			switch clause {
+			case "SET":
+				action, err = p.set()
+			case "REMOVE":
+				action, err = p.remove()
+			default:
+				err = fmt.Errorf("unsupported update clause: %s", clause)
+			}
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+	}
+	if len(actions) < 1 {
+		return nil, fmt.Errorf("empty update expression")
+	}
+	return func(item map[string]types.AttributeValue) error {
+		// Every action sees the item as it was before the update.
+		old := copyItem(item)
+		for _, action := range actions {
+			if err := action(old); err != nil {
+				return err
+			}
+		}
+		for k := range item {
+			delete(item, k)
+		}
+		for k, v := range old {
+			item[k] = v
+		}
+		return nil
+	}, nil
+}
+
+func (p *parser) path() (string, error) {
+	token := p.next()
+	if token == "" || strings.HasPrefix(token, ":") || strings.ContainsAny(token, "(),<>=+-") {
+		return "", fmt.Errorf("expected attribute in expression, got %q", token)
+	}
+	return p.expr.name(token)
+}
+
+func (p *parser) remove() (update, error) {
+	name, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) error {
+		delete(item, name)
+		return nil
+	}, nil
+}
+
+func (p *parser) set() (update, error) {
+	name, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	left, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op != "+" && op != "-" {
+		return func(item map[string]types.AttributeValue) error {
+			v := left(item)
+			if v == nil {
+				return fmt.Errorf("SET refers to an attribute that doesn't exist")
+			}
+			item[name] = v
+			return nil
+		}, nil
+	}
+	p.next()
+	right, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) error {
+		v, err := arithmetic(left(item), op, right(item))
+		if err != nil {
+			return err
+		}
+		item[name] = v
+		return nil
+	}, nil
+}
+
+// value parses an operand of SET, which may use if_not_exists.
+func (p *parser) value() (func(map[string]types.AttributeValue) types.AttributeValue, error) {
+	if p.peek() != "if_not_exists" {
+		return p.operand()
+	}
+	p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	name, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	fallback, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) types.AttributeValue {
+		if v, ok := item[name]; ok {
+			return v
+		}
+		return fallback(item)
+	}, nil
+}
+
+func arithmetic(a types.AttributeValue, op string, b types.AttributeValue) (types.AttributeValue, error) {
+	x, ok1 := a.(*types.AttributeValueMemberN)
+	y, ok2 := b.(*types.AttributeValueMemberN)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+	}
+	m, ok1 := new(big.Float).SetString(x.Value)
+	n, ok2 := new(big.Float).SetString(y.Value)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("invalid number in update expression")
+	}
+	if op == "+" {
+		m.Add(m, n)
+	} else {
+		m.Sub(m, n)
+	}
+	return &types.AttributeValueMemberN{Value: m.Text('f', -1)}, nil
+}
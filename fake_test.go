@@ -0,0 +1,369 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var _ Client = &fakeClient{}
+
+// fakeClient is a minimal in-memory stand-in for DynamoDB, keyed on the
+// "token" attribute.
+type fakeClient struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+	calls map[string]int
+	errs  map[string]error
+
+	// pageSize limits the number of items returned by each Scan.
+	pageSize int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		items: map[string]map[string]types.AttributeValue{},
+		calls: map[string]int{},
+		errs:  map[string]error{},
+	}
+}
+
+// call records a call to op and returns the error op should fail with.
+// The caller must hold c.mu.
+func (c *fakeClient) call(op string) error {
+	c.calls[op]++
+	return c.errs[op]
+}
+
+func (c *fakeClient) failWith(op string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs[op] = err
+}
+
+func (c *fakeClient) count(op string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[op]
+}
+
+func tokenOf(key map[string]types.AttributeValue) string {
+	if av, ok := key["token"].(*types.AttributeValueMemberS); ok {
+		return av.Value
+	}
+	return ""
+}
+
+// BatchGetItem ignores projections.
+func (c *fakeClient) BatchGetItem(
+	ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("BatchGetItem"); err != nil {
+		return nil, err
+	}
+	result := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+	for table, request := range params.RequestItems {
+		for _, key := range request.Keys {
+			if item, ok := c.items[tokenOf(key)]; ok {
+				result.Responses[table] = append(result.Responses[table], item)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeClient) BatchWriteItem(
+	ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("BatchWriteItem"); err != nil {
+		return nil, err
+	}
+	for _, requests := range params.RequestItems {
+		for _, r := range requests {
+			switch {
+			case r.PutRequest != nil:
+				c.items[tokenOf(r.PutRequest.Item)] = r.PutRequest.Item
+			case r.DeleteRequest != nil:
+				delete(c.items, tokenOf(r.DeleteRequest.Key))
+			}
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *fakeClient) CreateTable(
+	ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("CreateTable"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (c *fakeClient) DeleteItem(
+	ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("DeleteItem"); err != nil {
+		return nil, err
+	}
+	token := tokenOf(params.Key)
+	result := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		result.Attributes = c.items[token]
+	}
+	delete(c.items, token)
+	return result, nil
+}
+
+func (c *fakeClient) DeleteTable(
+	ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DeleteTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("DeleteTable"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTimeToLive(
+	ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("DescribeTimeToLive"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTable(
+	ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("DescribeTable"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName:   params.TableName,
+			TableStatus: types.TableStatusActive,
+		},
+	}, nil
+}
+
+func (c *fakeClient) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetItem"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{
+		Item: c.items[tokenOf(params.Key)],
+	}, nil
+}
+
+func (c *fakeClient) PutItem(
+	ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("PutItem"); err != nil {
+		return nil, err
+	}
+	token := tokenOf(params.Item)
+	result := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		result.Attributes = c.items[token]
+	}
+	item := make(map[string]types.AttributeValue, len(params.Item))
+	for k, v := range params.Item {
+		item[k] = v
+	}
+	c.items[token] = item
+	return result, nil
+}
+
+func (c *fakeClient) PutResourcePolicy(
+	ctx context.Context, params *dynamodb.PutResourcePolicyInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.PutResourcePolicyOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("PutResourcePolicy"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.PutResourcePolicyOutput{}, nil
+}
+
+// Query isn't supported. Tests that query indexes use the fake package.
+func (c *fakeClient) Query(
+	ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("Query"); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("fakeClient doesn't support Query")
+}
+
+func (c *fakeClient) Scan(
+	ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("Scan"); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, 0, len(c.items))
+	for token := range c.items {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	if params.ExclusiveStartKey != nil {
+		start := tokenOf(params.ExclusiveStartKey)
+		tokens = tokens[sort.SearchStrings(tokens, start+"\x00"):]
+	}
+
+	if params.TotalSegments != nil {
+		segment := uint32(aws.ToInt32(params.Segment))
+		total := uint32(aws.ToInt32(params.TotalSegments))
+		filtered := tokens[:0]
+		for _, token := range tokens {
+			h := fnv.New32a()
+			h.Write([]byte(token))
+			if h.Sum32()%total == segment {
+				filtered = append(filtered, token)
+			}
+		}
+		tokens = filtered
+	}
+
+	result := &dynamodb.ScanOutput{}
+	for i, token := range tokens {
+		result.Items = append(result.Items, c.items[token])
+		if c.pageSize > 0 && len(result.Items) >= c.pageSize && i < len(tokens)-1 {
+			result.LastEvaluatedKey = map[string]types.AttributeValue{
+				"token": &types.AttributeValueMemberS{Value: token},
+			}
+			break
+		}
+	}
+	return result, nil
+}
+
+// UpdateItem only supports update expressions of the form
+// "SET name = :value, ...", and ignores conditions.
+func (c *fakeClient) UpdateItem(
+	ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdateItem"); err != nil {
+		return nil, err
+	}
+
+	token := tokenOf(params.Key)
+	old := c.items[token]
+	item := make(map[string]types.AttributeValue, len(old)+1)
+	for k, v := range old {
+		item[k] = v
+	}
+	item["token"] = params.Key["token"]
+	expr := strings.TrimPrefix(aws.ToString(params.UpdateExpression), "SET ")
+	for _, action := range strings.Split(expr, ",") {
+		parts := strings.SplitN(action, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if n, ok := params.ExpressionAttributeNames[name]; ok {
+			name = n
+		}
+		item[name] = params.ExpressionAttributeValues[strings.TrimSpace(parts[1])]
+	}
+	c.items[token] = item
+
+	result := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		result.Attributes = old
+	}
+	return result, nil
+}
+
+// TransactWriteItems applies puts and deletes, ignoring conditions.
+func (c *fakeClient) TransactWriteItems(
+	ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("TransactWriteItems"); err != nil {
+		return nil, err
+	}
+	for _, op := range params.TransactItems {
+		switch {
+		case op.Put != nil:
+			c.items[tokenOf(op.Put.Item)] = op.Put.Item
+		case op.Delete != nil:
+			delete(c.items, tokenOf(op.Delete.Key))
+		default:
+			return nil, errors.New("unsupported TransactWriteItem")
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *fakeClient) UpdateContributorInsights(
+	ctx context.Context, params *dynamodb.UpdateContributorInsightsInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateContributorInsightsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdateContributorInsights"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.UpdateContributorInsightsOutput{
+		TableName:                 params.TableName,
+		ContributorInsightsStatus: types.ContributorInsightsStatusEnabled,
+	}, nil
+}
+
+// UpdateTable isn't supported. Tests that update tables use the fake package.
+func (c *fakeClient) UpdateTable(
+	ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdateTable"); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("fakeClient doesn't support UpdateTable")
+}
+
+func (c *fakeClient) UpdateTimeToLive(
+	ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdateTimeToLive"); err != nil {
+		return nil, err
+	}
+	return &dynamodb.UpdateTimeToLiveOutput{}, nil
+}
@@ -0,0 +1,71 @@
+// +build integration
+
+package fiberstore_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/dynamolocal"
+	"github.com/sjansen/dynamostore/fiberstore"
+)
+
+var local *dynamolocal.Instance
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	var err error
+	local, err = dynamolocal.Start(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if err := local.Terminate(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+func createClient() *dynamodb.Client {
+	return local.Client()
+}
+
+func TestStorage(t *testing.T) {
+	require := require.New(t)
+
+	store := dynamostore.NewWithTableName(createClient(), "fiberstore.test")
+	require.NoError(store.CreateTable())
+
+	storage := fiberstore.New(store)
+
+	err := storage.Set("foo", []byte("bar"), 0)
+	require.NoError(err)
+	err = storage.Set("baz", []byte("qux"), time.Minute)
+	require.NoError(err)
+
+	actual, err := storage.Get("foo")
+	require.NoError(err)
+	require.Equal([]byte("bar"), actual)
+
+	err = storage.Delete("foo")
+	require.NoError(err)
+	actual, err = storage.Get("foo")
+	require.NoError(err)
+	require.Nil(actual)
+
+	err = storage.Reset()
+	require.NoError(err)
+	actual, err = storage.Get("baz")
+	require.NoError(err)
+	require.Nil(actual)
+
+	require.NoError(storage.Close())
+}
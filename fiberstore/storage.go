@@ -0,0 +1,69 @@
+// Package fiberstore implements the gofiber Storage interface using
+// dynamostore, so Fiber applications can use the same DynamoDB table layout,
+// TTL handling, and table management as scs applications.
+package fiberstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// noExpiry is used when a key is set without an expiration. DynamoDB's TTL
+// process ignores timestamps this far in the future.
+const noExpiry = 100 * 365 * 24 * time.Hour
+
+// Storage implements fiber.Storage.
+type Storage struct {
+	store *dynamostore.DynamoStore
+}
+
+// New creates a Storage instance backed by store.
+func New(store *dynamostore.DynamoStore) *Storage {
+	return &Storage{store: store}
+}
+
+// Get returns the value for the given key, or nil if the key doesn't exist
+// or has expired.
+func (s *Storage) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+	b, exists, err := s.store.Find(key)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Set stores the value for the given key. A zero exp means the key never
+// expires.
+func (s *Storage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) < 1 {
+		return nil
+	}
+	if exp <= 0 {
+		exp = noExpiry
+	}
+	return s.store.Commit(key, val, time.Now().Add(exp))
+}
+
+// Delete removes the value for the given key.
+func (s *Storage) Delete(key string) error {
+	if key == "" {
+		return nil
+	}
+	return s.store.Delete(key)
+}
+
+// Reset removes every key from the table.
+func (s *Storage) Reset() error {
+	_, err := s.store.DeleteAll(context.Background())
+	return err
+}
+
+// Close releases resources held by the underlying store.
+func (s *Storage) Close() error {
+	return s.store.Close(context.Background())
+}
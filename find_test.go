@@ -0,0 +1,363 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindWithExpiry(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	expiry := now.Add(time.Hour)
+	for _, opts := range [][]Option{
+		{},
+		{WithWriteBehind(1, nil)},
+	} {
+		opts = append(opts, WithClock(func() time.Time { return now }))
+		store := newStore(newFakeClient(), DefaultTableName, opts)
+
+		// given
+		require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+		// when
+		data, actual, exists, err := store.FindWithExpiry(ctx, "foo")
+		// then
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte("bar"), data)
+		require.True(expiry.Equal(actual), actual)
+
+		// when
+		data, actual, exists, err = store.FindWithExpiry(ctx, "missing")
+		// then
+		require.NoError(err)
+		require.False(exists)
+		require.Nil(data)
+		require.True(actual.IsZero())
+
+		require.NoError(store.Close(ctx))
+	}
+}
+
+func TestWithSlidingExpiration(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithSlidingExpiration(30 * time.Minute),
+	})
+	require.NoError(store.Validate())
+
+	// given a session that expires soon
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Minute)))
+
+	// when it is found
+	_, expiry, exists, err := store.FindWithExpiry(ctx, "foo")
+	// then its expiry is extended
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(now.Add(30*time.Minute).Unix(), expiry.Unix())
+	require.Equal(1, svc.count("UpdateItem"))
+
+	// when it is found again shortly after
+	now = now.Add(time.Minute)
+	_, exists, err = store.Find("foo")
+	// then the small extension is skipped
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(1, svc.count("UpdateItem"))
+
+	// when it is found before the extended expiry
+	now = now.Add(25 * time.Minute)
+	_, expiry, exists, err = store.FindWithExpiry(ctx, "foo")
+	// then it is extended again
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(now.Add(30*time.Minute).Unix(), expiry.Unix())
+	require.Equal(2, svc.count("UpdateItem"))
+
+	// when it is idle for too long
+	now = now.Add(31 * time.Minute)
+	_, exists, err = store.Find("foo")
+	// then it has expired
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(2, svc.count("UpdateItem"))
+}
+
+func TestWithSlidingExpirationDeadline(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithSlidingExpiration(time.Hour),
+	})
+
+	// given a session whose lifetime ends before the idle window
+	deadline := now.Add(20 * time.Minute)
+	data, err := scs.GobCodec{}.Encode(deadline, map[string]interface{}{"user": "alice"})
+	require.NoError(err)
+	require.NoError(store.Commit("foo", data, now.Add(time.Minute)))
+
+	// when it is found
+	_, expiry, exists, err := store.FindWithExpiry(ctx, "foo")
+
+	// then it is only extended to the deadline
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(deadline.Unix(), expiry.Unix())
+}
+
+func TestWithExpiryGracePeriod(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithExpiryGracePeriod(30 * time.Second),
+	})
+
+	// given an expired session
+	require.NoError(store.Commit("foo", []byte("bar"), now))
+	now = now.Add(20 * time.Second)
+
+	// when it is within the grace period
+	_, exists, err := store.Find("foo")
+	// then it is still found
+	require.NoError(err)
+	require.True(exists)
+	n, err := store.DeleteExpired(ctx, 1)
+	require.NoError(err)
+	require.Equal(0, n)
+
+	// when the grace period has passed
+	now = now.Add(11 * time.Second)
+	_, exists, err = store.Find("foo")
+	// then it has expired
+	require.NoError(err)
+	require.False(exists)
+	n, err = store.DeleteExpired(ctx, 1)
+	require.NoError(err)
+	require.Equal(1, n)
+}
+
+func TestWithoutExpiryCheck(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, opts := range [][]Option{
+		{},
+		{WithWriteBehind(1, nil)},
+	} {
+		opts = append(opts,
+			WithClock(func() time.Time { return now }),
+			WithoutExpiryCheck(),
+		)
+		store := newStore(newFakeClient(), DefaultTableName, opts)
+		require.NoError(store.Validate())
+
+		// given an expired session
+		expiry := now.Add(-time.Hour)
+		require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+		// when it is found
+		data, actual, exists, err := store.FindWithExpiry(ctx, "foo")
+		// then it is returned with its expiry
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte("bar"), data)
+		require.True(expiry.Equal(actual), actual)
+
+		require.NoError(store.Close(ctx))
+	}
+
+	// when combined with options that depend on the expiry check
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithoutExpiryCheck(),
+		WithLazyDelete(nil),
+		WithSlidingExpiration(time.Hour),
+	})
+	// then the configuration is rejected
+	err := store.Validate()
+	require.Error(err)
+	require.Len(err.(*ConfigError).Problems, 2)
+	require.NoError(store.Close(ctx))
+}
+
+// laggingReader returns nothing for eventually consistent reads, as if
+// replication hadn't caught up with any writes.
+type laggingReader struct {
+	*fakeClient
+	consistent []bool
+}
+
+func (c *laggingReader) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.consistent = append(c.consistent, aws.ToBool(params.ConsistentRead))
+	if !aws.ToBool(params.ConsistentRead) {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return c.fakeClient.GetItem(ctx, params, optFns...)
+}
+
+// staleReader returns the items it was given for eventually consistent
+// reads, as if replication hadn't caught up with later changes.
+type staleReader struct {
+	*fakeClient
+	items      map[string]map[string]types.AttributeValue
+	consistent []bool
+}
+
+func (c *staleReader) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.consistent = append(c.consistent, aws.ToBool(params.ConsistentRead))
+	if !aws.ToBool(params.ConsistentRead) {
+		return &dynamodb.GetItemOutput{Item: c.items[tokenOf(params.Key)]}, nil
+	}
+	return c.fakeClient.GetItem(ctx, params, optFns...)
+}
+
+func TestWithEventualReads(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	reader := &laggingReader{fakeClient: svc}
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithEventualReads(time.Second),
+	})
+	store.reader = reader
+	require.NoError(store.Validate())
+
+	// when an untracked session is missed
+	_, exists, err := store.Find("other")
+	// then it isn't read again
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]bool{false}, reader.consistent)
+
+	// when a session committed by this store is missed
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	reader.consistent = nil
+	data, exists, err := store.Find("foo")
+	// then it is read again with a consistent read
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+	require.Equal([]bool{false, true}, reader.consistent)
+
+	// when the window has passed
+	now = now.Add(2 * time.Second)
+	reader.consistent = nil
+	_, exists, err = store.Find("foo")
+	// then it isn't read again
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]bool{false}, reader.consistent)
+
+	// when a session was deleted by this store
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+	reader.consistent = nil
+	_, exists, err = store.Find("foo")
+	// then it isn't read again
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]bool{false}, reader.consistent)
+
+	// when an eventually consistent read still finds a deleted session
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	stale := &staleReader{fakeClient: svc, items: map[string]map[string]types.AttributeValue{
+		"foo": svc.items["foo"],
+	}}
+	store.reader = stale
+	require.NoError(store.Delete("foo"))
+	_, exists, err = store.Find("foo")
+	// then it is read again with a consistent read
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]bool{false, true}, stale.consistent)
+
+	// when combined with optimistic locking
+	store = newStore(svc, DefaultTableName, []Option{
+		WithEventualReads(time.Second),
+		WithOptimisticLocking(),
+	})
+	// then the configuration is rejected
+	require.Error(store.Validate())
+}
+
+func TestWithAdaptiveConsistency(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	svc := newFakeClient()
+	reader := &laggingReader{fakeClient: svc}
+	var changes []bool
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithAdaptiveConsistency(&AdaptiveConsistencyConfig{
+			Threshold: 2,
+			Cooldown:  time.Minute,
+			OnChange: func(consistent bool) {
+				changes = append(changes, consistent)
+			},
+		}),
+	})
+	store.reader = reader
+	require.NoError(store.Validate())
+	require.False(store.ConsistentReads())
+
+	// given a session committed by this store
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+
+	// when stale reads are common
+	for i := 0; i < 2; i++ {
+		_, exists, err := store.Find("foo")
+		require.NoError(err)
+		require.True(exists)
+	}
+	// then the store switches to consistent reads
+	require.Equal([]bool{false, true, false, true}, reader.consistent)
+	require.True(store.ConsistentReads())
+	require.Equal([]bool{true}, changes)
+	reader.consistent = nil
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]bool{true}, reader.consistent)
+
+	// when the cooldown has passed
+	now = now.Add(2 * time.Minute)
+	// then it switches back
+	require.False(store.ConsistentReads())
+	require.Equal([]bool{true, false}, changes)
+
+	// when combined with WithEventualReads
+	store = newStore(svc, DefaultTableName, []Option{
+		WithEventualReads(time.Second),
+		WithAdaptiveConsistency(&AdaptiveConsistencyConfig{}),
+	})
+	// then the configuration is rejected
+	require.Error(store.Validate())
+}
@@ -0,0 +1,80 @@
+// Package firehosesink exports session lifecycle events to a Kinesis Data
+// Firehose delivery stream, so the records Firehose delivers to S3 or
+// elsewhere can be queried directly.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the Firehose client.
+package firehosesink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	fhtypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the Kinesis Data Firehose API used by a Sink. It
+// is implemented by *firehose.Client.
+type Client interface {
+	PutRecord(
+		context.Context, *firehose.PutRecordInput, ...func(*firehose.Options),
+	) (*firehose.PutRecordOutput, error)
+}
+
+// Config configures a Sink.
+type Config struct {
+	Client Client
+	// DeliveryStream is the name of the delivery stream.
+	DeliveryStream string
+}
+
+// Sink is a dynamostore.SessionEventSink that exports every event as a
+// dynamostore.EventRecord in JSON followed by a newline. Use it with
+// dynamostore.WithEventSink.
+type Sink struct {
+	cfg Config
+}
+
+// New returns a Sink, or an error if cfg is invalid.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Client == nil || cfg.DeliveryStream == "" {
+		return nil, errors.New("firehosesink: requires a client and a delivery stream")
+	}
+	return &Sink{cfg: cfg}, nil
+}
+
+// Created exports event.
+func (f *Sink) Created(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return f.put(ctx, event)
+}
+
+// Refreshed exports event.
+func (f *Sink) Refreshed(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return f.put(ctx, event)
+}
+
+// Revoked exports event.
+func (f *Sink) Revoked(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return f.put(ctx, event)
+}
+
+// Expired exports event.
+func (f *Sink) Expired(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return f.put(ctx, event)
+}
+
+func (f *Sink) put(ctx context.Context, event *dynamostore.AuditEvent) error {
+	data, err := dynamostore.MarshalEventRecord(event)
+	if err != nil {
+		return err
+	}
+	_, err = f.cfg.Client.PutRecord(ctx, &firehose.PutRecordInput{
+		DeliveryStreamName: aws.String(f.cfg.DeliveryStream),
+		Record:             &fhtypes.Record{Data: data},
+	})
+	return err
+}
@@ -0,0 +1,59 @@
+package firehosesink_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/firehosesink"
+)
+
+type fakeFirehose struct {
+	records []*firehose.PutRecordInput
+}
+
+func (f *fakeFirehose) PutRecord(
+	ctx context.Context, params *firehose.PutRecordInput, optFns ...func(*firehose.Options),
+) (*firehose.PutRecordOutput, error) {
+	f.records = append(f.records, params)
+	return &firehose.PutRecordOutput{}, nil
+}
+
+func TestSink(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	stream := &fakeFirehose{}
+	sink, err := firehosesink.New(firehosesink.Config{Client: stream, DeliveryStream: "sessions"})
+	require.NoError(err)
+	store := fake.New(dynamostore.WithEventSink(sink, nil))
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+
+	// then
+	require.Len(stream.records, 2)
+	record := stream.records[1]
+	require.Equal("sessions", aws.ToString(record.DeliveryStreamName))
+	require.True(bytes.HasSuffix(record.Record.Data, []byte("\n")))
+
+	var event dynamostore.EventRecord
+	require.NoError(json.Unmarshal(record.Record.Data, &event))
+	require.Equal(dynamostore.AuditDelete, event.Type)
+
+	// when
+	_, err = firehosesink.New(firehosesink.Config{Client: stream})
+
+	// then
+	require.Error(err)
+}
@@ -0,0 +1,34 @@
+module github.com/sjansen/dynamostore/ginstore
+
+go 1.24
+
+require (
+	github.com/alexedwards/scs/v2 v2.5.0
+	github.com/gin-contrib/sessions v0.0.3
+	github.com/sjansen/dynamostore/gorillastore v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/gin-gonic/gin v1.6.3 // indirect
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/go-playground/validator/v10 v10.2.0 // indirect
+	github.com/golang/protobuf v1.3.3 // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/gorilla/securecookie v1.1.1 // indirect
+	github.com/gorilla/sessions v1.2.1 // indirect
+	github.com/json-iterator/go v1.1.9 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.1 // indirect
+	github.com/ugorji/go/codec v1.1.7 // indirect
+	golang.org/x/sys v0.0.0-20200930185726-fdedc70b468f // indirect
+	gopkg.in/yaml.v2 v2.3.0 // indirect
+)
+
+replace (
+	github.com/sjansen/dynamostore => ..
+	github.com/sjansen/dynamostore/gorillastore => ../gorillastore
+)
@@ -0,0 +1,28 @@
+// Package ginstore adapts dynamostore for use with gin-contrib/sessions.
+package ginstore
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"github.com/gin-contrib/sessions"
+
+	"github.com/sjansen/dynamostore/gorillastore"
+)
+
+var _ sessions.Store = &Store{}
+
+// Store implements gin-contrib/sessions.Store using gorillastore.
+type Store struct {
+	*gorillastore.Store
+}
+
+// New returns a new Store backed by store, usually a DynamoStore.
+//
+// See gorillastore.New() for a description of the keyPairs parameter.
+func New(store scs.Store, keyPairs ...[]byte) *Store {
+	return &Store{gorillastore.New(store, keyPairs...)}
+}
+
+// Options sets the default configuration for new sessions.
+func (s *Store) Options(options sessions.Options) {
+	s.Store.Options = options.ToGorillaOptions()
+}
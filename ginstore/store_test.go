@@ -0,0 +1,35 @@
+package ginstore_test
+
+import (
+	"testing"
+
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/tester"
+
+	"github.com/sjansen/dynamostore/ginstore"
+)
+
+var newStore = func(_ *testing.T) sessions.Store {
+	return ginstore.New(memstore.NewWithCleanupInterval(0), []byte("secret"))
+}
+
+func TestGetSet(t *testing.T) {
+	tester.GetSet(t, newStore)
+}
+
+func TestDeleteKey(t *testing.T) {
+	tester.DeleteKey(t, newStore)
+}
+
+func TestFlashes(t *testing.T) {
+	tester.Flashes(t, newStore)
+}
+
+func TestClear(t *testing.T) {
+	tester.Clear(t, newStore)
+}
+
+func TestOptions(t *testing.T) {
+	tester.Options(t, newStore)
+}
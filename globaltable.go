@@ -0,0 +1,259 @@
+package dynamostore
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultReplicationWindow is how long after a session is committed in
+// another region ReadWriterRegion reads it from that region, by default.
+// Global tables usually replicate writes within a second.
+const DefaultReplicationWindow = 5 * time.Second
+
+const (
+	writerRegionAttribute = "WriterRegion"
+	committedAtAttribute  = "CommittedAt"
+)
+
+// maxAbsentTokens limits the number of sessions remembered as deleted or
+// missing by WithGlobalTable. Once reached, entries older than the window
+// are dropped.
+const maxAbsentTokens = 10000
+
+// ReadStrategy controls how a store using WithGlobalTable deals with
+// sessions that haven't been replicated to its region yet.
+type ReadStrategy int
+
+const (
+	// ReadLocal only reads the local replica. A session committed in
+	// another region may briefly appear stale or missing.
+	ReadLocal ReadStrategy = iota
+	// ReadRemoteOnMiss reads the other regions when a session isn't found
+	// in the local replica, so a session created in another region, such as
+	// by a login, is found before it has been replicated. Sessions deleted
+	// by the store, and sessions no region had, aren't read from the other
+	// regions again within GlobalTableConfig.Window, so a logout isn't
+	// undone by a replica that hasn't received the delete yet, and a stale
+	// cookie doesn't cost a cross-region read on every request.
+	ReadRemoteOnMiss
+	// ReadWriterRegion works like ReadRemoteOnMiss, and also reads a
+	// session from the region that last committed it, if that was another
+	// region within GlobalTableConfig.Window. Sessions bouncing between
+	// regions see their latest data, at the cost of a cross-region read.
+	ReadWriterRegion
+)
+
+// GlobalTableConfig configures WithGlobalTable.
+type GlobalTableConfig struct {
+	// Region is the region of the store's DynamoDB client. It is recorded
+	// with each session the store commits.
+	Region string
+	// Remotes maps the other regions of the global table to clients in
+	// those regions, which are used to read sessions the local replica
+	// doesn't have yet. They are only needed by ReadRemoteOnMiss and
+	// ReadWriterRegion.
+	Remotes map[string]ItemReader
+	// Strategy is how sessions are read. The default is ReadLocal.
+	Strategy ReadStrategy
+	// Window is how long after a session is committed in another region
+	// ReadWriterRegion reads it from that region, and how long a deleted or
+	// missing session isn't read from other regions. The default is
+	// DefaultReplicationWindow.
+	Window time.Duration
+	// OnError, if not nil, is called when a session can't be read from
+	// another region. The local replica's copy of the session is used
+	// instead, so an outage in one region doesn't fail Find in the others.
+	OnError func(region string, err error)
+}
+
+// WithGlobalTable makes the store aware of DynamoDB global tables, for
+// active-active deployments that commit sessions in every region. Sessions
+// are always written to the local replica, along with the region that wrote
+// them and when. Replication is asynchronous, so a request that reaches
+// another region right after a commit may not see it. The read strategy
+// decides whether Find falls back to reading other regions. Remote reads are
+// strongly consistent.
+//
+// Commits that only extend the expiry, such as by WithExpiryOnlyUpdates or
+// WithSlidingExpiration, don't change the recorded region.
+func WithGlobalTable(cfg GlobalTableConfig) Option {
+	return func(s *DynamoStore) {
+		if cfg.Region == "" || cfg.Window < 0 {
+			s.invalid("WithGlobalTable requires a region and a window of zero or more")
+			return
+		}
+		if _, ok := cfg.Remotes[cfg.Region]; ok {
+			s.invalid("WithGlobalTable remotes can't include the local region")
+			return
+		}
+		if cfg.Strategy != ReadLocal && len(cfg.Remotes) < 1 {
+			s.invalid("WithGlobalTable requires remotes to read other regions")
+			return
+		}
+		if cfg.Window == 0 {
+			cfg.Window = DefaultReplicationWindow
+		}
+		s.global = &cfg
+		if cfg.Strategy != ReadLocal {
+			s.absent = newAbsentTokens(cfg.Window)
+		}
+	}
+}
+
+// regionItem records the region committing av, if WithGlobalTable is used.
+func (s *DynamoStore) regionItem(av map[string]types.AttributeValue) {
+	if s.global == nil {
+		return
+	}
+	av[writerRegionAttribute] = &types.AttributeValueMemberS{Value: s.global.Region}
+	av[committedAtAttribute] = &types.AttributeValueMemberN{
+		Value: strconv.FormatInt(s.now().UnixNano()/int64(time.Millisecond), 10),
+	}
+}
+
+// readRemote applies the read strategy to a session read from the local
+// replica, and returns the item that should be used instead, if any.
+func (s *DynamoStore) readRemote(
+	ctx context.Context, input *dynamodb.GetItemInput, local map[string]types.AttributeValue,
+) map[string]types.AttributeValue {
+	if s.global == nil || s.global.Strategy == ReadLocal {
+		return local
+	}
+	if len(local) < 1 {
+		token := keyToken(input.Key)
+		if s.absent.contains(token, s.now()) {
+			return local
+		}
+		regions := make([]string, 0, len(s.global.Remotes))
+		for region := range s.global.Remotes {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+		missing := true
+		for _, region := range regions {
+			item, ok := s.readRegion(ctx, region, input)
+			if len(item) > 0 {
+				return item
+			}
+			missing = missing && ok
+		}
+		if missing {
+			s.absent.add(token, s.now())
+		}
+		return local
+	}
+	if s.global.Strategy != ReadWriterRegion {
+		return local
+	}
+	writer, ok := local[writerRegionAttribute].(*types.AttributeValueMemberS)
+	if !ok || writer.Value == s.global.Region {
+		return local
+	}
+	committed, ok := local[committedAtAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return local
+	}
+	ms, err := strconv.ParseInt(committed.Value, 10, 64)
+	if err != nil || s.now().Sub(time.Unix(0, ms*int64(time.Millisecond))) > s.global.Window {
+		return local
+	}
+	// The session may have been deleted in the writer region, so its
+	// answer is used even if it doesn't have the session.
+	if item, ok := s.readRegion(ctx, writer.Value, input); ok {
+		return item
+	}
+	return local
+}
+
+// readRegion reads a session from the replica in region. It returns false
+// if there isn't a client for the region, or the read fails.
+func (s *DynamoStore) readRegion(
+	ctx context.Context, region string, input *dynamodb.GetItemInput,
+) (map[string]types.AttributeValue, bool) {
+	reader, ok := s.global.Remotes[region]
+	if !ok {
+		return nil, false
+	}
+	remote := *input
+	remote.ConsistentRead = aws.Bool(true)
+	remote.ReturnConsumedCapacity = ""
+	result, err := reader.GetItem(ctx, &remote, s.optFns...)
+	if err != nil {
+		if s.global.OnError != nil {
+			s.global.OnError(region, err)
+		}
+		return nil, false
+	}
+	return result.Item, true
+}
+
+// keyToken returns the token of a session's key.
+func keyToken(key map[string]types.AttributeValue) string {
+	if token, ok := key["token"].(*types.AttributeValueMemberS); ok {
+		return token.Value
+	}
+	return ""
+}
+
+// rememberDeleted records that a session was deleted, so ReadRemoteOnMiss
+// and ReadWriterRegion don't read it from a replica that hasn't received the
+// delete yet.
+func (s *DynamoStore) rememberDeleted(token string) {
+	s.absent.add(token, s.now())
+}
+
+// absentTokens remembers sessions that were deleted by the store, or that
+// weren't found in any region, within the replication window.
+type absentTokens struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]time.Time
+}
+
+func newAbsentTokens(window time.Duration) *absentTokens {
+	return &absentTokens{
+		window: window,
+		tokens: map[string]time.Time{},
+	}
+}
+
+func (a *absentTokens) add(token string, now time.Time) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.tokens) >= maxAbsentTokens {
+		for t, at := range a.tokens {
+			if now.Sub(at) > a.window {
+				delete(a.tokens, t)
+			}
+		}
+	}
+	if len(a.tokens) < maxAbsentTokens {
+		a.tokens[token] = now
+	}
+}
+
+// contains returns true if token was absent within the window.
+func (a *absentTokens) contains(token string, now time.Time) bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	at, ok := a.tokens[token]
+	if ok && now.Sub(at) > a.window {
+		delete(a.tokens, token)
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,214 @@
+package dynamostore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+// replicate copies a session between clients, as a global table eventually
+// does.
+func replicate(t *testing.T, from, to *fake.Client, token string) {
+	ctx := context.Background()
+	key := map[string]types.AttributeValue{"token": &types.AttributeValueMemberS{Value: token}}
+	result, err := from.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(dynamostore.DefaultTableName),
+		Key:       key,
+	})
+	require.NoError(t, err)
+	_, err = to.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(dynamostore.DefaultTableName),
+		Item:      result.Item,
+	})
+	require.NoError(t, err)
+}
+
+type failingReader struct{}
+
+// countingReader counts the reads of another region.
+type countingReader struct {
+	reader dynamostore.ItemReader
+	reads  int
+}
+
+func (r *countingReader) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	r.reads++
+	return r.reader.GetItem(ctx, params, optFns...)
+}
+
+func (failingReader) GetItem(
+	context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	return nil, errors.New("region unavailable")
+}
+
+func TestGlobalTable(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	clock := dynamostore.WithClock(func() time.Time { return now })
+	east, west := fake.NewClient(), fake.NewClient()
+	east.AddTable(dynamostore.DefaultTableName, "token")
+	west.AddTable(dynamostore.DefaultTableName, "token")
+	writer := dynamostore.New(east, clock, dynamostore.WithGlobalTable(dynamostore.GlobalTableConfig{
+		Region: "us-east-1",
+	}))
+	newReader := func(strategy dynamostore.ReadStrategy) *dynamostore.DynamoStore {
+		store := dynamostore.New(west, clock, dynamostore.WithGlobalTable(dynamostore.GlobalTableConfig{
+			Region:   "us-west-2",
+			Remotes:  map[string]dynamostore.ItemReader{"us-east-1": east},
+			Strategy: strategy,
+		}))
+		require.NoError(store.Validate())
+		return store
+	}
+	local := newReader(dynamostore.ReadLocal)
+	onMiss := newReader(dynamostore.ReadRemoteOnMiss)
+	fromWriter := newReader(dynamostore.ReadWriterRegion)
+	expiry := now.Add(time.Hour)
+
+	// given a session that hasn't been replicated yet
+	require.NoError(writer.Commit("token", []byte("v1"), expiry))
+
+	// when it is read locally
+	_, exists, err := local.Find("token")
+
+	// then it is missing
+	require.NoError(err)
+	require.False(exists)
+
+	// when the other regions are read on a miss
+	data, exists, err := onMiss.Find("token")
+
+	// then it is found
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("v1"), data)
+
+	// given a change that hasn't been replicated yet
+	replicate(t, east, west, "token")
+	require.NoError(writer.Commit("token", []byte("v2"), expiry))
+
+	// when it is read with ReadRemoteOnMiss
+	data, _, err = onMiss.Find("token")
+
+	// then the replicated version is found
+	require.NoError(err)
+	require.Equal([]byte("v1"), data)
+
+	// when it is read from the region that committed it
+	data, _, err = fromWriter.Find("token")
+
+	// then the latest version is found
+	require.NoError(err)
+	require.Equal([]byte("v2"), data)
+
+	// when the replication window has passed
+	now = now.Add(time.Minute)
+	data, _, err = fromWriter.Find("token")
+
+	// then the local replica is trusted
+	require.NoError(err)
+	require.Equal([]byte("v1"), data)
+}
+
+func TestGlobalTableRemoteError(t *testing.T) {
+	require := require.New(t)
+
+	var failed []string
+	store := fake.New(dynamostore.WithGlobalTable(dynamostore.GlobalTableConfig{
+		Region:   "us-west-2",
+		Remotes:  map[string]dynamostore.ItemReader{"us-east-1": failingReader{}},
+		Strategy: dynamostore.ReadRemoteOnMiss,
+		OnError: func(region string, err error) {
+			failed = append(failed, region)
+		},
+	}))
+
+	// when the other region can't be read
+	_, exists, err := store.Find("token")
+
+	// then the local replica's answer is used
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]string{"us-east-1"}, failed)
+
+	// when it is read again
+	_, exists, err = store.Find("token")
+
+	// then the other region is read again
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]string{"us-east-1", "us-east-1"}, failed)
+}
+
+func TestGlobalTableDelete(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	clock := dynamostore.WithClock(func() time.Time { return now })
+	east, west := fake.NewClient(), fake.NewClient()
+	east.AddTable(dynamostore.DefaultTableName, "token")
+	west.AddTable(dynamostore.DefaultTableName, "token")
+	remote := &countingReader{reader: east}
+	store := dynamostore.New(west, clock, dynamostore.WithGlobalTable(dynamostore.GlobalTableConfig{
+		Region:   "us-west-2",
+		Remotes:  map[string]dynamostore.ItemReader{"us-east-1": remote},
+		Strategy: dynamostore.ReadRemoteOnMiss,
+	}))
+	require.NoError(store.Validate())
+
+	// given a session in both regions
+	require.NoError(store.Commit("token", []byte("data"), now.Add(time.Hour)))
+	replicate(t, west, east, "token")
+
+	// when it is deleted, before the delete is replicated
+	require.NoError(store.Delete("token"))
+	_, exists, err := store.Find("token")
+
+	// then it stays deleted
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(0, remote.reads)
+
+	// when an unknown session is read twice
+	for i := 0; i < 2; i++ {
+		_, exists, err = store.Find("unknown")
+		require.NoError(err)
+		require.False(exists)
+	}
+
+	// then the other region is only read once
+	require.Equal(1, remote.reads)
+
+	// when the replication window has passed
+	now = now.Add(time.Minute)
+	_, _, err = store.Find("unknown")
+
+	// then the other region is read again
+	require.NoError(err)
+	require.Equal(2, remote.reads)
+}
+
+func TestWithGlobalTableValidation(t *testing.T) {
+	for _, cfg := range []dynamostore.GlobalTableConfig{
+		{},
+		{Region: "us-east-1", Window: -time.Second},
+		{Region: "us-east-1", Strategy: dynamostore.ReadRemoteOnMiss},
+		{Region: "us-east-1", Remotes: map[string]dynamostore.ItemReader{"us-east-1": failingReader{}}},
+	} {
+		store := fake.New(dynamostore.WithGlobalTable(cfg))
+		require.Error(t, store.Validate(), cfg)
+	}
+}
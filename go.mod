@@ -1,14 +1,26 @@
 module github.com/sjansen/dynamostore
 
-go 1.12
+go 1.24
 
 require (
-	github.com/alexedwards/scs/v2 v2.4.0
-	github.com/aws/aws-sdk-go-v2 v1.2.1
-	github.com/aws/aws-sdk-go-v2/credentials v1.1.2
-	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.0.3
-	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.1.2
+	github.com/alexedwards/scs/v2 v2.5.0
+	github.com/aws/aws-sdk-go-v2 v1.47.1
+	github.com/aws/aws-sdk-go-v2/credentials v1.20.6
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.21.8
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.70.0
+	github.com/aws/smithy-go v1.28.2
+	github.com/stretchr/testify v1.6.1
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.5.4 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.8.4 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.43.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/accept-encoding v1.13.19 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/endpoint-discovery v1.13.4 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/stretchr/testify v1.5.1
-	gopkg.in/yaml.v2 v2.3.0 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,171 @@
+// Package gorillastore implements a gorilla/sessions store backed by
+// DynamoDB, using the same item layout as dynamostore so that gorilla and
+// scs applications can share a session table.
+package gorillastore
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+var _ sessions.Store = &Store{}
+
+// DefaultLifetime is how long sessions are stored when Options.MaxAge is 0.
+// It matches the default lifetime used by scs.
+const DefaultLifetime = 24 * time.Hour
+
+// Store stores gorilla sessions in an scs.Store, usually a DynamoStore.
+//
+// Session values are encoded with an scs.Codec, so keys must be strings.
+// The session ID is signed, and optionally encrypted, using securecookie
+// before being sent to the browser.
+type Store struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options // default configuration
+	Codec   scs.Codec
+
+	store scs.Store
+}
+
+// New returns a new Store.
+//
+// See sessions.NewCookieStore() for a description of the keyPairs
+// parameter.
+func New(store scs.Store, keyPairs ...[]byte) *Store {
+	s := &Store{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		Codec: scs.GobCodec{},
+		store: store,
+	}
+
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// Get returns a session for the given name after adding it to the registry.
+//
+// See sessions.CookieStore.Get().
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+//
+// See sessions.CookieStore.New().
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+	var err error
+	if c, errCookie := r.Cookie(name); errCookie == nil {
+		err = securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...)
+		if err == nil {
+			var found bool
+			found, err = s.load(session)
+			if err == nil && found {
+				session.IsNew = false
+			}
+		}
+	}
+	return session, err
+}
+
+// Save adds a single session to the response.
+//
+// If the Options.MaxAge of the session is < 0 then the session is deleted
+// from the store. If it is 0, the cookie expires when the browser is closed
+// and the stored session expires after DefaultLifetime.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.store.Delete(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		// Match the format of tokens generated by scs.
+		session.ID = base64.RawURLEncoding.EncodeToString(
+			securecookie.GenerateRandomKey(32),
+		)
+	}
+	if err := s.save(session); err != nil {
+		return err
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// MaxAge sets the maximum age for the store and the underlying cookie
+// implementation. Individual sessions can be deleted by setting Options.MaxAge
+// = -1 for that session.
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+
+	// Set the maxAge for each securecookie instance.
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+func (s *Store) save(session *sessions.Session) error {
+	values := make(map[string]interface{}, len(session.Values))
+	for k, v := range session.Values {
+		key, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("unsupported session key type: %T", k)
+		}
+		values[key] = v
+	}
+
+	lifetime := DefaultLifetime
+	if session.Options.MaxAge > 0 {
+		lifetime = time.Duration(session.Options.MaxAge) * time.Second
+	}
+	deadline := time.Now().Add(lifetime)
+	b, err := s.Codec.Encode(deadline, values)
+	if err != nil {
+		return err
+	}
+	return s.store.Commit(session.ID, b, deadline)
+}
+
+func (s *Store) load(session *sessions.Session) (bool, error) {
+	b, found, err := s.store.Find(session.ID)
+	if err != nil || !found {
+		return false, err
+	}
+
+	_, values, err := s.Codec.Decode(b)
+	if err != nil {
+		return false, err
+	}
+	if values == nil {
+		return false, errors.New("invalid session data")
+	}
+	for k, v := range values {
+		session.Values[k] = v
+	}
+	return true, nil
+}
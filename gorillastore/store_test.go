@@ -0,0 +1,99 @@
+package gorillastore_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/gorillastore"
+)
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+
+	backend := memstore.NewWithCleanupInterval(0)
+	store := gorillastore.New(backend, []byte("secret-key"))
+
+	// given a new session
+	r := httptest.NewRequest("GET", "/", nil)
+	session, err := store.Get(r, "session")
+	require.NoError(err)
+	require.True(session.IsNew)
+	// when it is saved
+	session.Values["user"] = "alice"
+	w := httptest.NewRecorder()
+	err = session.Save(r, w)
+	require.NoError(err)
+	// then it should be readable by scs
+	b, found, err := backend.Find(session.ID)
+	require.NoError(err)
+	require.True(found)
+	_, values, err := scs.GobCodec{}.Decode(b)
+	require.NoError(err)
+	require.Equal("alice", values["user"])
+
+	// given a saved session
+	cookies := w.Result().Cookies()
+	require.Len(cookies, 1)
+	// when the cookie is sent with a later request
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookies[0])
+	session, err = store.Get(r, "session")
+	// then the session should be loaded
+	require.NoError(err)
+	require.False(session.IsNew)
+	require.Equal("alice", session.Values["user"])
+
+	// given a saved session
+	// when it is deleted
+	session.Options.MaxAge = -1
+	w = httptest.NewRecorder()
+	err = session.Save(r, w)
+	require.NoError(err)
+	// then it should be removed from the store
+	_, found, err = backend.Find(session.ID)
+	require.NoError(err)
+	require.False(found)
+	// and the cookie should be cleared
+	cookies = w.Result().Cookies()
+	require.Len(cookies, 1)
+	require.Equal("", cookies[0].Value)
+	require.True(cookies[0].MaxAge < 0)
+
+	// given a session with a key scs can't represent
+	r = httptest.NewRequest("GET", "/", nil)
+	session, err = store.New(r, "session")
+	require.NoError(err)
+	session.Values[42] = "answer"
+	// when it is saved
+	err = store.Save(r, httptest.NewRecorder(), session)
+	// then there should be an error
+	require.Error(err)
+}
+
+func TestBrowserSessionCookie(t *testing.T) {
+	require := require.New(t)
+
+	backend := memstore.NewWithCleanupInterval(0)
+	store := gorillastore.New(backend, []byte("secret-key"))
+	store.Options.MaxAge = 0
+
+	r := httptest.NewRequest("GET", "/", nil)
+	session, err := store.Get(r, "session")
+	require.NoError(err)
+	session.Values["user"] = "alice"
+	w := httptest.NewRecorder()
+	require.NoError(session.Save(r, w))
+
+	cookies := w.Result().Cookies()
+	require.Len(cookies, 1)
+	require.NotEmpty(cookies[0].Value)
+	require.Equal(0, cookies[0].MaxAge)
+
+	_, found, err := backend.Find(session.ID)
+	require.NoError(err)
+	require.True(found)
+}
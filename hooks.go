@@ -0,0 +1,39 @@
+package dynamostore
+
+import "context"
+
+// PayloadHook inspects or transforms session data. The data it returns must
+// still be readable by the scs.SessionManager's codec.
+type PayloadHook func(ctx context.Context, data []byte) ([]byte, error)
+
+// WithPayloadHooks passes session data through beforeWrite when it is
+// committed, and through afterRead when it is found, so policies such as
+// stripping fields that shouldn't be persisted can be enforced in one place.
+// Either hook may be nil. An error returned by a hook fails the commit or
+// find.
+//
+// Sessions written by ImportFrom and Import pass through beforeWrite, and
+// sessions read by Export and ExportBySubject pass through afterRead, so
+// exports contain the same data as Find returns, and can be imported again.
+func WithPayloadHooks(beforeWrite, afterRead PayloadHook) Option {
+	return func(s *DynamoStore) {
+		s.beforeWrite = beforeWrite
+		s.afterRead = afterRead
+	}
+}
+
+// writeHook passes data through the beforeWrite hook, if there is one.
+func (s *DynamoStore) writeHook(ctx context.Context, data []byte) ([]byte, error) {
+	if s.beforeWrite == nil {
+		return data, nil
+	}
+	return s.beforeWrite(ctx, data)
+}
+
+// readHook passes data through the afterRead hook, if there is one.
+func (s *DynamoStore) readHook(ctx context.Context, data []byte) ([]byte, error) {
+	if s.afterRead == nil {
+		return data, nil
+	}
+	return s.afterRead(ctx, data)
+}
@@ -0,0 +1,116 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPayloadHooks(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithPayloadHooks(
+			func(ctx context.Context, data []byte) ([]byte, error) {
+				if bytes.Contains(data, []byte("fail")) {
+					return nil, errors.New("rejected")
+				}
+				return bytes.Replace(data, []byte("555-0100"), []byte("XXX"), -1), nil
+			},
+			func(ctx context.Context, data []byte) ([]byte, error) {
+				return append([]byte("read:"), data...), nil
+			},
+		),
+	})
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour)
+
+	// when
+	require.NoError(store.Commit("foo", []byte("phone=555-0100"), expiry))
+
+	// then the transformed data is stored
+	item, err := store.getItem(ctx, "foo")
+	require.NoError(err)
+	require.Equal([]byte("phone=XXX"), item.Data)
+
+	// and transformed again when found
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("read:phone=XXX"), data)
+	data, _, _, err = store.FindWithExpiry(ctx, "foo")
+	require.NoError(err)
+	require.Equal([]byte("read:phone=XXX"), data)
+	prev, _, existed, err := store.CommitAndReturnPrevious(ctx, "foo", []byte("phone=555-0100 again"), expiry)
+	require.NoError(err)
+	require.True(existed)
+	require.Equal([]byte("read:phone=XXX"), prev)
+
+	// when a hook fails
+	err = store.Commit("foo", []byte("fail"), expiry)
+
+	// then nothing is written
+	require.EqualError(err, "rejected")
+	item, err = store.getItem(ctx, "foo")
+	require.NoError(err)
+	require.Equal([]byte("phone=XXX again"), item.Data)
+}
+
+func TestPayloadHooksWithImport(t *testing.T) {
+	require := require.New(t)
+
+	// given hooks that can only read data they wrote
+	hooks := WithPayloadHooks(
+		func(ctx context.Context, data []byte) ([]byte, error) {
+			return append([]byte("sealed:"), data...), nil
+		},
+		func(ctx context.Context, data []byte) ([]byte, error) {
+			if !bytes.HasPrefix(data, []byte("sealed:")) {
+				return nil, errors.New("not sealed")
+			}
+			return bytes.TrimPrefix(data, []byte("sealed:")), nil
+		},
+	)
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"user": "alice"})
+	require.NoError(err)
+	src := memstore.NewWithCleanupInterval(0)
+	require.NoError(src.Commit("foo", data, expiry))
+
+	// when
+	store := newStore(newFakeClient(), DefaultTableName, []Option{hooks})
+	n, err := store.ImportFrom(ctx, src)
+
+	// then imported sessions pass through beforeWrite
+	require.NoError(err)
+	require.Equal(1, n)
+	actual, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(data, actual)
+
+	// when
+	var buf bytes.Buffer
+	_, err = store.Export(ctx, &buf)
+	require.NoError(err)
+	dst := newStore(newFakeClient(), DefaultTableName, []Option{hooks})
+	result, err := dst.Import(ctx, &buf)
+
+	// then exports can be imported by another store with the same hooks
+	require.NoError(err)
+	require.Equal(1, result.Imported)
+	require.Empty(result.Failures)
+	actual, exists, err = dst.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(data, actual)
+}
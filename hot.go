@@ -0,0 +1,134 @@
+package dynamostore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxHotTokens limits the number of tokens counted in each interval of
+// WithHotTokens. Once reached, tokens not already counted are ignored until
+// the next interval.
+const maxHotTokens = 10000
+
+// TokenCount reports how often a session was used. The token is identified
+// by a hash, as returned by HashToken, so it can be logged safely.
+type TokenCount struct {
+	Hash   string
+	Reads  int64
+	Writes int64
+}
+
+// TokenHash returns the SHA-256 digest of a token, which identifies it in
+// Stats, exports, and audit events. Stores that use WithTokenPepper use an
+// HMAC instead, see HashToken.
+func TokenHash(token string) string {
+	return hashToken(token)
+}
+
+// WithHotTokens makes Stats report the n sessions that were found,
+// committed, or deleted most often during the last interval to two
+// intervals. A session used far more often than others can point to a
+// misbehaving client, and can exceed the throughput DynamoDB allows for a
+// single item.
+func WithHotTokens(n int, interval time.Duration) Option {
+	return func(s *DynamoStore) {
+		if n < 1 || interval <= 0 {
+			s.invalid("WithHotTokens requires a positive count and interval")
+			return
+		}
+		s.hotTokens = &hotTokens{
+			n:        n,
+			interval: interval,
+			current:  map[string]*TokenCount{},
+		}
+	}
+}
+
+// hotTokens counts token use in the current and previous intervals.
+type hotTokens struct {
+	n        int
+	interval time.Duration
+
+	mu       sync.Mutex
+	started  time.Time
+	current  map[string]*TokenCount
+	previous map[string]*TokenCount
+}
+
+// usedToken counts a read or write of a session, if WithHotTokens is
+// enabled.
+func (s *DynamoStore) usedToken(token string, write bool) {
+	if s.hotTokens == nil || token == "" {
+		return
+	}
+	if hash, err := s.HashToken(context.Background(), token); err == nil {
+		s.hotTokens.count(hash, write, s.now())
+	}
+}
+
+func (h *hotTokens) rotate(now time.Time) {
+	switch elapsed := now.Sub(h.started); {
+	case elapsed < h.interval:
+		return
+	case elapsed < 2*h.interval:
+		h.previous = h.current
+	default:
+		h.previous = nil
+	}
+	h.current = map[string]*TokenCount{}
+	h.started = now
+}
+
+func (h *hotTokens) count(hash string, write bool, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rotate(now)
+	c, ok := h.current[hash]
+	if !ok {
+		if len(h.current) >= maxHotTokens {
+			return
+		}
+		c = &TokenCount{Hash: hash}
+		h.current[hash] = c
+	}
+	if write {
+		c.Writes++
+	} else {
+		c.Reads++
+	}
+}
+
+// top returns the most used tokens, most used first.
+func (h *hotTokens) top(now time.Time) []TokenCount {
+	h.mu.Lock()
+	h.rotate(now)
+	totals := make(map[string]TokenCount, len(h.current)+len(h.previous))
+	for _, counts := range []map[string]*TokenCount{h.previous, h.current} {
+		for hash, c := range counts {
+			total := totals[hash]
+			total.Hash = hash
+			total.Reads += c.Reads
+			total.Writes += c.Writes
+			totals[hash] = total
+		}
+	}
+	h.mu.Unlock()
+
+	result := make([]TokenCount, 0, len(totals))
+	for _, c := range totals {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Reads+result[i].Writes, result[j].Reads+result[j].Writes
+		if a != b {
+			return a > b
+		}
+		return result[i].Hash < result[j].Hash
+	})
+	if len(result) > h.n {
+		result = result[:h.n]
+	}
+	return result
+}
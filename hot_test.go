@@ -0,0 +1,55 @@
+package dynamostore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotTokens(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	expiry := now.Add(time.Hour)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithHotTokens(2, time.Minute),
+	})
+	require.Empty(store.Stats().HotTokens)
+
+	// when sessions are used
+	for i := 0; i < 3; i++ {
+		_, _, err := store.Find("foo")
+		require.NoError(err)
+	}
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.NoError(store.Commit("baz", []byte("qux"), expiry))
+	require.NoError(store.Commit("baz", []byte("qux"), expiry))
+	require.NoError(store.Delete("quux"))
+
+	// then the most used are reported by hash
+	require.Equal([]TokenCount{
+		{Hash: TokenHash("foo"), Reads: 3, Writes: 1},
+		{Hash: TokenHash("baz"), Writes: 2},
+	}, store.Stats().HotTokens)
+	require.NotContains(TokenHash("foo"), "foo")
+
+	// when the next interval starts
+	now = now.Add(90 * time.Second)
+	for i := 0; i < 3; i++ {
+		require.NoError(store.Commit("baz", []byte("qux"), expiry))
+	}
+
+	// then the previous interval is still counted
+	require.Equal([]TokenCount{
+		{Hash: TokenHash("baz"), Writes: 5},
+		{Hash: TokenHash("foo"), Reads: 3, Writes: 1},
+	}, store.Stats().HotTokens)
+
+	// when two intervals pass
+	now = now.Add(2 * time.Minute)
+
+	// then nothing is reported
+	require.Empty(store.Stats().HotTokens)
+}
@@ -0,0 +1,84 @@
+package dynamostore
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// WithHTTPClient sends every request the store makes using client, such as
+// an *http.Client, instead of the DynamoDB client's HTTP client.
+func WithHTTPClient(client aws.HTTPClient) Option {
+	return func(s *DynamoStore) {
+		switch {
+		case client == nil:
+			s.invalid("WithHTTPClient requires a client")
+			return
+		case s.httpClient:
+			s.invalid("the HTTP client is set more than once by WithHTTPClient or WithHTTPSettings")
+		}
+		s.httpClient = true
+		s.optFns = append(s.optFns, func(o *dynamodb.Options) {
+			o.HTTPClient = client
+		})
+	}
+}
+
+// HTTPSettings tunes the HTTP client used by WithHTTPSettings. Zero values
+// keep the AWS SDK's defaults.
+type HTTPSettings struct {
+	// Timeout limits the total time of each HTTP request, including
+	// reading the response.
+	Timeout time.Duration
+	// DialTimeout limits the time spent establishing a connection.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout limits the time spent on the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// IdleConnTimeout is how long idle keep-alive connections are kept.
+	IdleConnTimeout time.Duration
+	// MaxIdleConnsPerHost limits the idle keep-alive connections kept for
+	// the DynamoDB endpoint.
+	MaxIdleConnsPerHost int
+	// DisableKeepAlives creates a new connection for every request.
+	DisableKeepAlives bool
+	// Proxy selects the proxy for each request, see http.Transport.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// WithHTTPSettings sends every request the store makes using a new HTTP
+// client, based on the AWS SDK's default client and tuned by settings.
+func WithHTTPSettings(settings *HTTPSettings) Option {
+	return WithHTTPClient(newHTTPClient(settings))
+}
+
+func newHTTPClient(settings *HTTPSettings) *awshttp.BuildableClient {
+	client := awshttp.NewBuildableClient()
+	if settings.Timeout > 0 {
+		client = client.WithTimeout(settings.Timeout)
+	}
+	if settings.DialTimeout > 0 {
+		client = client.WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = settings.DialTimeout
+		})
+	}
+	return client.WithTransportOptions(func(t *http.Transport) {
+		if settings.TLSHandshakeTimeout > 0 {
+			t.TLSHandshakeTimeout = settings.TLSHandshakeTimeout
+		}
+		if settings.IdleConnTimeout > 0 {
+			t.IdleConnTimeout = settings.IdleConnTimeout
+		}
+		if settings.MaxIdleConnsPerHost > 0 {
+			t.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+		}
+		if settings.Proxy != nil {
+			t.Proxy = settings.Proxy
+		}
+		t.DisableKeepAlives = settings.DisableKeepAlives
+	})
+}
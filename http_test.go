@@ -0,0 +1,48 @@
+package dynamostore
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHTTPClient(t *testing.T) {
+	require := require.New(t)
+
+	unused := &stubHTTPClient{}
+	httpClient := &stubHTTPClient{}
+	store := NewFromConfig(stubConfig(unused), WithHTTPClient(httpClient))
+
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	require.Len(httpClient.requests, 1)
+	require.Empty(unused.requests)
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	require := require.New(t)
+
+	proxy := http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})
+	client := newHTTPClient(&HTTPSettings{
+		Timeout:             5 * time.Second,
+		DialTimeout:         time.Second,
+		IdleConnTimeout:     time.Minute,
+		MaxIdleConnsPerHost: 50,
+		Proxy:               proxy,
+	})
+
+	require.Equal(5*time.Second, client.GetTimeout())
+	require.Equal(time.Second, client.GetDialer().Timeout)
+	transport := client.GetTransport()
+	require.Equal(time.Minute, transport.IdleConnTimeout)
+	require.Equal(50, transport.MaxIdleConnsPerHost)
+	require.False(transport.DisableKeepAlives)
+	require.NotNil(transport.Proxy)
+
+	defaults := newHTTPClient(&HTTPSettings{})
+	require.Equal(time.Duration(0), defaults.GetTimeout())
+	require.NotZero(defaults.GetTransport().TLSHandshakeTimeout)
+}
@@ -0,0 +1,219 @@
+package dynamostore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ImportFrom copies every session in src into the DynamoStore instance,
+// preserving each session's expiry, and returns the number of sessions
+// imported. Expiry is decoded from the session data using the store's codec,
+// see WithCodec. Sessions that have already expired are skipped, and so are
+// sessions revoked in the table, when WithRetention or WithRevocationMarkers
+// is used.
+//
+// If src also implements scs.IterableCtxStore, ctx is passed to AllCtx.
+func (s *DynamoStore) ImportFrom(ctx context.Context, src scs.IterableStore) (int, error) {
+	var sessions map[string][]byte
+	var err error
+	if ctxStore, ok := src.(scs.IterableCtxStore); ok {
+		sessions, err = ctxStore.AllCtx(ctx)
+	} else {
+		sessions, err = src.All()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	items := make([]map[string]types.AttributeValue, 0, len(sessions))
+	for token, data := range sessions {
+		expiry, _, err := s.codec.Decode(data)
+		if err != nil {
+			return 0, fmt.Errorf("unable to decode session expiry: %w", err)
+		}
+		if s.expiredAt(expiry, now) {
+			continue
+		}
+		data, err := s.writeHook(ctx, data)
+		if err != nil {
+			return 0, err
+		}
+		item, err := s.marshalItem(token, data, expiry)
+		if err != nil {
+			return 0, err
+		}
+		items = append(items, item)
+	}
+
+	imported, _, err := s.importItems(ctx, items)
+	return imported, err
+}
+
+// importItems writes imported items, and returns the number written and the
+// number skipped. Items are written in batches, unless revoked sessions are
+// kept in the table, in which case each item is written with a condition, so
+// a revoked session isn't overwritten and brought back.
+func (s *DynamoStore) importItems(
+	ctx context.Context, items []map[string]types.AttributeValue,
+) (int, int, error) {
+	if !s.tombstones() {
+		if err := s.batchPut(ctx, items); err != nil {
+			return 0, 0, err
+		}
+		return len(items), 0, nil
+	}
+	imported, revoked := 0, 0
+	for _, item := range items {
+		units, err := s.limiter.waitWrite(ctx, itemSize(item))
+		if err != nil {
+			return imported, revoked, err
+		}
+		input := &dynamodb.PutItemInput{
+			Item:                   item,
+			TableName:              s.table,
+			ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		}
+		s.requireNotRevoked(input)
+		result, err := s.svc.PutItem(ctx, input, s.optFns...)
+		var conditionErr *types.ConditionalCheckFailedException
+		switch {
+		case errors.As(err, &conditionErr):
+			revoked++
+			continue
+		case err != nil:
+			return imported, revoked, err
+		}
+		s.limiter.consumedWrite(units, result.ConsumedCapacity)
+		if token, ok := item["token"].(*types.AttributeValueMemberS); ok {
+			s.forgetCached(token.Value)
+		}
+		imported++
+	}
+	return imported, revoked, nil
+}
+
+// ImportFailure describes an export record that couldn't be imported.
+type ImportFailure struct {
+	Line int
+	Err  error
+}
+
+// ImportResult summarizes the outcome of Import.
+type ImportResult struct {
+	Imported int
+	Expired  int
+	// Revoked is the number of sessions skipped because they were revoked
+	// in the table, when WithRetention or WithRevocationMarkers is used.
+	Revoked  int
+	Failures []ImportFailure
+}
+
+// Import reads sessions in the format written by Export and writes them into
+// the DynamoStore instance. Sessions that have already expired are skipped,
+// and so are sessions revoked in the table, when WithRetention or
+// WithRevocationMarkers is used. Records that are malformed or fail validation are reported in the result's
+// Failures and don't stop the import; an error is only returned if reading
+// from r or writing to DynamoDB fails.
+func (s *DynamoStore) Import(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	return s.importRecords(ctx, r, false)
+}
+
+// CheckImport reads sessions in the format written by Export and reports
+// what Import would do with them, without writing anything to DynamoDB.
+func (s *DynamoStore) CheckImport(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	return s.importRecords(ctx, r, true)
+}
+
+func (s *DynamoStore) importRecords(ctx context.Context, r io.Reader, dryRun bool) (*ImportResult, error) {
+	result := &ImportResult{}
+	batch := make([]map[string]types.AttributeValue, 0, maxBatchWriteItems)
+	flush := func() error {
+		if dryRun {
+			result.Imported += len(batch)
+			batch = batch[:0]
+			return nil
+		}
+		imported, revoked, err := s.importItems(ctx, batch)
+		result.Imported += imported
+		result.Revoked += revoked
+		batch = batch[:0]
+		return err
+	}
+
+	now := s.now()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxExportLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) < 1 {
+			continue
+		}
+
+		var record ExportRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err == nil {
+			err = record.validate()
+		}
+		if err == nil && record.TokenHash != "" {
+			var hash string
+			if hash, err = s.HashToken(ctx, record.Token); err == nil && hash != record.TokenHash {
+				err = errors.New("token hash mismatch")
+			}
+		}
+		if err != nil {
+			result.Failures = append(result.Failures, ImportFailure{Line: line, Err: err})
+			continue
+		}
+		if s.expiredAt(record.Expiry, now) {
+			result.Expired++
+			continue
+		}
+
+		data, err := s.writeHook(ctx, record.Data)
+		if err != nil {
+			result.Failures = append(result.Failures, ImportFailure{Line: line, Err: err})
+			continue
+		}
+		item, err := s.marshalItem(record.Token, data, record.Expiry)
+		if err != nil {
+			result.Failures = append(result.Failures, ImportFailure{Line: line, Err: err})
+			continue
+		}
+		batch = append(batch, item)
+		if len(batch) >= maxBatchWriteItems {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// maxExportLineSize is large enough for a record containing the largest item
+// DynamoDB can store, after base64 encoding.
+const maxExportLineSize = 1024 * 1024
+
+func (r *ExportRecord) validate() error {
+	switch {
+	case r.Token == "":
+		return errors.New("missing token")
+	case r.Expiry.IsZero():
+		return errors.New("missing expiry")
+	}
+	return nil
+}
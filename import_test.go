@@ -0,0 +1,114 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFrom(t *testing.T) {
+	require := require.New(t)
+
+	src := memstore.NewWithCleanupInterval(0)
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	for i := 0; i < 30; i++ {
+		data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"i": i})
+		require.NoError(err)
+		require.NoError(src.Commit(string(rune('A'+i)), data, expiry))
+	}
+
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, nil)
+
+	n, err := store.ImportFrom(context.Background(), src)
+	require.NoError(err)
+	require.Equal(30, n)
+	require.Equal(2, svc.count("BatchWriteItem"))
+
+	item, err := store.getItem(context.Background(), "B")
+	require.NoError(err)
+	require.Equal(expiry.Unix(), item.TTL.Unix())
+	_, values, err := scs.GobCodec{}.Decode(item.Data)
+	require.NoError(err)
+	require.Equal(1, values["i"])
+}
+
+func TestImportFromWithGracePeriod(t *testing.T) {
+	require := require.New(t)
+
+	// given a session that expired within the grace period
+	src := memstore.NewWithCleanupInterval(0)
+	expiry := time.Now().Add(-time.Minute)
+	data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{})
+	require.NoError(err)
+	require.NoError(src.Commit("foo", data, time.Now().Add(time.Hour)))
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithExpiryGracePeriod(5 * time.Minute),
+	})
+
+	// when
+	n, err := store.ImportFrom(context.Background(), src)
+
+	// then it is still imported
+	require.NoError(err)
+	require.Equal(1, n)
+}
+
+func TestImport(t *testing.T) {
+	require := require.New(t)
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	src := newStore(newFakeClient(), DefaultTableName, nil)
+	for i := 0; i < 30; i++ {
+		require.NoError(src.Commit(string(rune('A'+i)), []byte{byte(i)}, expiry))
+	}
+	require.NoError(src.Commit("expired", []byte("x"), time.Now().Add(-time.Hour)))
+
+	var buf bytes.Buffer
+	_, err := src.Export(context.Background(), &buf)
+	require.NoError(err)
+	buf.WriteString(`{"token":"old","expiry":"2001-02-03T04:05:06Z","data":""}` + "\n")
+	buf.WriteString("\n")
+	buf.WriteString(`{"token":"bad","token_hash":"1234","expiry":"2101-02-03T04:05:06Z"}` + "\n")
+	buf.WriteString(`{"token":` + "\n")
+
+	svc := newFakeClient()
+	dst := newStore(svc, DefaultTableName, nil)
+	result, err := dst.Import(context.Background(), &buf)
+	require.NoError(err)
+	require.Equal(30, result.Imported)
+	require.Equal(1, result.Expired)
+	require.Len(result.Failures, 2)
+	require.Equal(33, result.Failures[0].Line)
+	require.EqualError(result.Failures[0].Err, "token hash mismatch")
+	require.Equal(34, result.Failures[1].Line)
+	require.Equal(2, svc.count("BatchWriteItem"))
+
+	actual, exists, err := dst.Find("B")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte{1}, actual)
+}
+
+func TestCheckImport(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"token":"new","expiry":"2101-02-03T04:05:06Z","data":"eA=="}` + "\n")
+	buf.WriteString(`{"token":"old","expiry":"2001-02-03T04:05:06Z","data":""}` + "\n")
+	buf.WriteString(`{"token":"bad","token_hash":"1234","expiry":"2101-02-03T04:05:06Z"}` + "\n")
+
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, nil)
+	result, err := store.CheckImport(context.Background(), &buf)
+	require.NoError(err)
+	require.Equal(1, result.Imported)
+	require.Equal(1, result.Expired)
+	require.Len(result.Failures, 1)
+	require.Equal(0, svc.count("BatchWriteItem"))
+}
@@ -0,0 +1,210 @@
+package dynamostore
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SessionInfo describes a stored session without exposing its token or
+// data.
+type SessionInfo struct {
+	TokenHash string
+	Expiry    time.Time
+	Size      int
+
+	// Subject is the subject of the session, if WithSubjectKey is used.
+	Subject string
+	// Revoked is true if the session was revoked, but is still retained,
+	// see WithRetention and WithRevocationMarkers.
+	Revoked bool
+}
+
+// SessionDetails is a stored session, as returned by Inspect.
+type SessionDetails struct {
+	Token  string
+	Expiry time.Time
+	Data   []byte
+
+	// Attributes contains every attribute of the stored item.
+	Attributes map[string]types.AttributeValue
+}
+
+// ForEachSession calls fn for every session in the table, including expired
+// sessions that DynamoDB hasn't removed yet. Iteration stops at the first
+// error returned by fn.
+func (s *DynamoStore) ForEachSession(ctx context.Context, fn func(*SessionInfo) error) error {
+	return s.scan(ctx, &dynamodb.ScanInput{}, func(av map[string]types.AttributeValue) error {
+		info, err := s.sessionInfo(ctx, av)
+		if err != nil {
+			return err
+		}
+		return fn(info)
+	})
+}
+
+// sessionInfo describes a stored item.
+func (s *DynamoStore) sessionInfo(ctx context.Context, av map[string]types.AttributeValue) (*SessionInfo, error) {
+	item, err := s.unmarshalItem(av)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := s.HashToken(ctx, item.Token)
+	if err != nil {
+		return nil, err
+	}
+	info := &SessionInfo{
+		TokenHash: hash,
+		Expiry:    item.TTL,
+		Size:      itemSize(av),
+		Revoked:   item.Revoked != 0,
+	}
+	if s.subjectKey != "" && item.Data != nil {
+		info.Subject = s.subjectOf(item.Data)
+	}
+	return info, nil
+}
+
+// Inspect returns a stored session, even if it has expired. It returns nil if
+// the session doesn't exist. Inspect is intended for support tooling, and
+// doesn't consult write-behind queues or other caches.
+func (s *DynamoStore) Inspect(ctx context.Context, token string) (*SessionDetails, error) {
+	result, err := s.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+	}, s.optFns...)
+	if err != nil || len(result.Item) < 1 {
+		return nil, err
+	}
+
+	item, err := s.unmarshalItem(result.Item)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionDetails{
+		Token:      item.Token,
+		Expiry:     item.TTL,
+		Data:       item.Data,
+		Attributes: result.Item,
+	}, nil
+}
+
+// SessionMetadata describes a stored session without its data, as returned
+// by GetMetadata.
+type SessionMetadata struct {
+	TokenHash string
+	Expiry    time.Time
+	// Size is the size of the session's metadata attributes, as DynamoDB
+	// measures them. The data isn't read, so it isn't included.
+	Size int
+	// Version is the session's version, if WithOptimisticLocking is used.
+	Version int64
+	// KeyID identifies the key that encrypted the data, if WithEncryption
+	// is used.
+	KeyID string
+	// CreatedAt is when the session was first committed, if
+	// WithCreationTime is used.
+	CreatedAt time.Time
+	// RevokedAt is when the session was revoked, if it is retained or
+	// marked after revocation.
+	RevokedAt time.Time
+
+	// Attributes contains the metadata attributes written by the store,
+	// such as the expiry and the attributes indexed by WithAttributeIndex.
+	// The token, the data, and the hash of the data aren't included.
+	Attributes map[string]types.AttributeValue
+}
+
+// GetMetadata returns the metadata of a stored session, even if it has
+// expired, without returning or decrypting its data, for support tooling
+// that shouldn't see session contents. It returns nil if the session doesn't
+// exist. Only the metadata attributes are read. The creation time is only
+// recorded by WithCreationTime, and the store doesn't record when sessions
+// were last used.
+func (s *DynamoStore) GetMetadata(ctx context.Context, token string) (*SessionMetadata, error) {
+	expr, names := s.metadataProjection()
+	result, err := s.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+		ProjectionExpression:     expr,
+		ExpressionAttributeNames: names,
+	}, s.optFns...)
+	if err != nil || len(result.Item) < 1 {
+		return nil, err
+	}
+
+	hash, err := s.HashToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessionMetadata(hash, result.Item)
+}
+
+// metadataProjection returns a projection of the attributes written by the
+// store, except the token, the data, and the hash of the data.
+func (s *DynamoStore) metadataProjection() (*string, map[string]string) {
+	var attrs []string
+	for _, attr := range s.reservedAttributes() {
+		if attr != "token" && attr != "Data" && attr != dataHashAttribute {
+			attrs = append(attrs, attr)
+		}
+	}
+	for _, idx := range s.attributeIndexes {
+		attrs = append(attrs, idx.attribute)
+	}
+	names := make(map[string]string, len(attrs))
+	placeholders := make([]string, len(attrs))
+	for i, attr := range attrs {
+		placeholders[i] = "#m" + strconv.Itoa(i)
+		names[placeholders[i]] = attr
+	}
+	return aws.String(strings.Join(placeholders, ", ")), names
+}
+
+// sessionMetadata describes a stored item, without decrypting its data.
+func (s *DynamoStore) sessionMetadata(
+	hash string, av map[string]types.AttributeValue,
+) (*SessionMetadata, error) {
+	item, ok := s.decodeItem(av)
+	if !ok {
+		var err error
+		if item, err = s.unmarshalItemSlow(av); err != nil {
+			return nil, err
+		}
+	}
+	metadata := &SessionMetadata{
+		TokenHash:  hash,
+		Expiry:     item.TTL,
+		Version:    item.Version,
+		KeyID:      item.KeyID,
+		Attributes: make(map[string]types.AttributeValue, len(av)),
+	}
+	if created, ok := unixAttribute(av, createdAtAttribute); ok {
+		metadata.CreatedAt = created
+	}
+	if item.Revoked != 0 {
+		metadata.RevokedAt = time.Unix(item.Revoked, 0)
+	}
+	for name, v := range av {
+		if name != "token" && name != "Data" && name != dataHashAttribute {
+			metadata.Attributes[name] = v
+		}
+	}
+	metadata.Size = itemSize(metadata.Attributes)
+	return metadata, nil
+}
@@ -0,0 +1,89 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, nil)
+	expiry := time.Now().Add(-time.Minute).Round(time.Second)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	details, err := store.Inspect(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal("foo", details.Token)
+	require.Equal([]byte("bar"), details.Data)
+	require.True(expiry.Equal(details.Expiry))
+	require.Contains(details.Attributes, "ttl")
+
+	details, err = store.Inspect(context.Background(), "missing")
+	require.NoError(err)
+	require.Nil(details)
+
+	var infos []*SessionInfo
+	err = store.ForEachSession(context.Background(), func(info *SessionInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+	require.NoError(err)
+	require.Len(infos, 1)
+	require.Equal(hashToken("foo"), infos[0].TokenHash)
+	require.True(expiry.Equal(infos[0].Expiry))
+	require.Greater(infos[0].Size, 3)
+}
+
+func TestGetMetadata(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	svc := &getItemRecorder{fakeClient: newFakeClient()}
+	store := newStore(svc, DefaultTableName, []Option{
+		WithEncryption(&Keyring{
+			Current: "k1",
+			Keys:    map[string][]byte{"k1": make([]byte, 32)},
+		}),
+		WithSkipUnchanged(0),
+	})
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	metadata, err := store.GetMetadata(ctx, "foo")
+	require.NoError(err)
+	require.NotNil(svc.input.ProjectionExpression)
+	for _, attr := range svc.input.ExpressionAttributeNames {
+		require.NotContains([]string{"token", "Data", "DataHash"}, attr)
+	}
+	require.Equal(hashToken("foo"), metadata.TokenHash)
+	require.True(expiry.Equal(metadata.Expiry))
+	require.Greater(metadata.Size, 3)
+	require.Equal("k1", metadata.KeyID)
+	require.True(metadata.RevokedAt.IsZero())
+	require.NotContains(metadata.Attributes, "Data")
+	require.NotContains(metadata.Attributes, "token")
+	require.NotContains(metadata.Attributes, "DataHash")
+	require.Contains(metadata.Attributes, "ttl")
+
+	metadata, err = store.GetMetadata(ctx, "missing")
+	require.NoError(err)
+	require.Nil(metadata)
+}
+
+// getItemRecorder records the last GetItem request.
+type getItemRecorder struct {
+	*fakeClient
+	input *dynamodb.GetItemInput
+}
+
+func (c *getItemRecorder) GetItem(
+	ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	c.input = params
+	return c.fakeClient.GetItem(ctx, params, optFns...)
+}
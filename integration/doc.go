@@ -0,0 +1,8 @@
+// Package integration tests DynamoStore against DynamoDB Local, see
+// dynamolocal:
+//
+//	go test -tags integration ./...
+//
+// It is a separate module so the root module doesn't depend on
+// testcontainers.
+package integration
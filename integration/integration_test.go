@@ -1,44 +1,43 @@
 // +build integration
 
-package dynamostore_test
+package integration_test
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"os"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/stretchr/testify/require"
 
 	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/dynamolocal"
+	"github.com/sjansen/dynamostore/sessiontest"
+	"github.com/sjansen/dynamostore/storetest"
 )
 
-func createClient() *dynamodb.Client {
-	endpoint := os.Getenv("DYNAMOSTORE_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "http://localhost:8000"
+var local *dynamolocal.Instance
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	var err error
+	local, err = dynamolocal.Start(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if err := local.Terminate(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 	}
+	os.Exit(code)
+}
 
-	creds := credentials.NewStaticCredentialsProvider("id", "secret", "token")
-	client := dynamodb.NewFromConfig(
-		aws.Config{
-			Credentials: creds,
-			Region:      "us-west-2",
-		},
-		dynamodb.WithEndpointResolver(
-			dynamodb.EndpointResolverFromURL(
-				endpoint,
-				func(e *aws.Endpoint) {
-					e.HostnameImmutable = true
-				},
-			),
-		),
-	)
-	return client
+func createClient() *dynamodb.Client {
+	return local.Client()
 }
 
 func randomString() string {
@@ -129,3 +128,31 @@ func TestStore(t *testing.T) {
 	require.Equal(false, exists)
 	require.Nil(actual)
 }
+
+func TestConformance(t *testing.T) {
+	require := require.New(t)
+
+	store := dynamostore.New(createClient())
+	require.NoError(store.CreateTable())
+
+	storetest.Run(t, store)
+}
+
+func TestSessionFlow(t *testing.T) {
+	require := require.New(t)
+
+	store := dynamostore.New(createClient())
+	require.NoError(store.CreateTable())
+
+	h := sessiontest.New(store)
+	defer h.Close()
+
+	c := h.NewClient()
+	require.NoError(c.Login("alice"))
+	user, err := c.WhoAmI()
+	require.NoError(err)
+	require.Equal("alice", user)
+	require.NoError(c.Logout())
+	_, err = c.WhoAmI()
+	require.Equal(sessiontest.ErrUnauthorized, err)
+}
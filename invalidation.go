@@ -0,0 +1,153 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Invalidation tells the instances of an application sharing a table to
+// drop a session from their caches.
+type Invalidation struct {
+	// Origin identifies the store that published the invalidation, so
+	// stores can ignore their own.
+	Origin string `json:"origin"`
+	// TokenHash identifies the session, as returned by HashToken.
+	TokenHash string `json:"tokenHash"`
+}
+
+// InvalidationBus carries invalidations between the instances of an
+// application, such as over SNS, Redis pub/sub, or a consumer of the table's
+// stream. Publish and the function passed to Subscribe may be called
+// concurrently.
+//
+// The package only provides MemoryBus, which can't reach other processes.
+// Applications with more than one instance must implement InvalidationBus
+// over a transport they already run.
+type InvalidationBus interface {
+	// Publish sends an invalidation to every subscriber, including the
+	// publisher's own.
+	Publish(ctx context.Context, inv *Invalidation) error
+	// Subscribe calls fn for every invalidation published, until the
+	// returned function is called.
+	Subscribe(fn func(*Invalidation)) (unsubscribe func())
+}
+
+// WithCacheInvalidation keeps the caches enabled by WithCache consistent
+// across instances. Whenever a session is committed, deleted, or has its
+// expiry updated, an invalidation is published to bus, and every other
+// store subscribed to it drops the session from its cache. Sessions are
+// identified by the hash returned by HashToken, so tokens are never sent
+// over the bus.
+//
+// Invalidations are best effort. Publishing is synchronous, failures are
+// counted in Stats, and the cache's ttl still limits how long a stale
+// session can be returned. Subscribers that implement their own transport
+// can also call Invalidate directly.
+func WithCacheInvalidation(bus InvalidationBus) Option {
+	return func(s *DynamoStore) {
+		if bus == nil {
+			s.invalid("WithCacheInvalidation requires a bus")
+			return
+		}
+		id := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, id); err != nil {
+			s.invalid("WithCacheInvalidation couldn't generate an origin: %s", err)
+			return
+		}
+		s.invalidation = &invalidation{bus: bus, origin: hex.EncodeToString(id)}
+	}
+}
+
+type invalidation struct {
+	bus         InvalidationBus
+	origin      string
+	unsubscribe func()
+}
+
+func (inv *invalidation) start(s *DynamoStore) {
+	inv.unsubscribe = inv.bus.Subscribe(func(msg *Invalidation) {
+		if msg.Origin != inv.origin {
+			s.Invalidate(msg.TokenHash)
+		}
+	})
+}
+
+func (inv *invalidation) close(ctx context.Context) error {
+	inv.unsubscribe()
+	return nil
+}
+
+// publishInvalidation tells other instances that a session has changed, if
+// WithCacheInvalidation is used.
+func (s *DynamoStore) publishInvalidation(ctx context.Context, token string) {
+	if s.invalidation == nil {
+		return
+	}
+	hash, err := s.HashToken(ctx, token)
+	if err == nil {
+		err = s.invalidation.bus.Publish(ctx, &Invalidation{
+			Origin:    s.invalidation.origin,
+			TokenHash: hash,
+		})
+	}
+	if err != nil {
+		atomic.AddInt64(&s.stats.invalidationErrors, 1)
+	}
+}
+
+// Invalidate drops the session whose token has the given hash, as returned
+// by HashToken, from the cache enabled by WithCache. It is called for
+// invalidations received by WithCacheInvalidation, and can be called by
+// other transports, such as a handler for the table's stream.
+func (s *DynamoStore) Invalidate(tokenHash string) {
+	if s.cache == nil {
+		return
+	}
+	atomic.AddInt64(&s.stats.invalidations, 1)
+	s.cache.forgetHash(tokenHash)
+}
+
+// MemoryBus is an InvalidationBus for stores in the same process, such as
+// in tests.
+type MemoryBus struct {
+	mu          sync.Mutex
+	next        int
+	subscribers map[int]func(*Invalidation)
+}
+
+// NewMemoryBus returns an empty MemoryBus.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{subscribers: map[int]func(*Invalidation){}}
+}
+
+// Publish calls every subscriber.
+func (b *MemoryBus) Publish(ctx context.Context, inv *Invalidation) error {
+	b.mu.Lock()
+	subscribers := make([]func(*Invalidation), 0, len(b.subscribers))
+	for _, fn := range b.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	b.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(inv)
+	}
+	return nil
+}
+
+// Subscribe adds a subscriber.
+func (b *MemoryBus) Subscribe(fn func(*Invalidation)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
@@ -0,0 +1,120 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingBus struct {
+	*MemoryBus
+}
+
+func (b failingBus) Publish(ctx context.Context, inv *Invalidation) error {
+	return errors.New("unavailable")
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := newFakeClient()
+	bus := NewMemoryBus()
+	opts := []Option{
+		WithCache(10, time.Hour),
+		WithCacheInvalidation(bus),
+	}
+	a := newStore(svc, DefaultTableName, opts)
+	b := newStore(svc, DefaultTableName, opts)
+	require.NoError(a.Validate())
+
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(a.Commit("foo", []byte("bar"), expiry))
+	_, _, err := b.Find("foo")
+	require.NoError(err)
+
+	// when
+	require.NoError(a.Commit("foo", []byte("baz"), expiry))
+	actual, exists, err := b.Find("foo")
+
+	// then
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("baz"), actual)
+	require.Equal(int64(0), a.Stats().Invalidations)
+	require.Equal(int64(2), b.Stats().Invalidations)
+	require.Equal(int64(0), b.Stats().CacheHits)
+
+	// when
+	_, _, err = a.Find("foo")
+	require.NoError(err)
+	require.NoError(b.Delete("foo"))
+	_, exists, err = a.Find("foo")
+
+	// then
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(int64(1), a.Stats().CacheHits)
+
+	// when
+	require.NoError(b.Close(context.Background()))
+	require.NoError(a.Commit("foo", []byte("qux"), expiry))
+
+	// then
+	require.Equal(int64(2), b.Stats().Invalidations)
+}
+
+func TestCacheInvalidationErrors(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithCache(10, time.Hour),
+		WithCacheInvalidation(failingBus{NewMemoryBus()}),
+	})
+
+	// when
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+
+	// then
+	require.NoError(err)
+	require.Equal(int64(1), store.Stats().InvalidationErrors)
+
+	// when
+	invalid := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithCacheInvalidation(NewMemoryBus()),
+	})
+
+	// then
+	require.EqualError(invalid.Validate(),
+		"invalid DynamoStore configuration: WithCacheInvalidation requires WithCache")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no entropy")
+}
+
+func TestCacheInvalidationOriginError(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	reader := rand.Reader
+	rand.Reader = failingReader{}
+	defer func() { rand.Reader = reader }()
+
+	// when
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithCache(10, time.Minute),
+		WithCacheInvalidation(NewMemoryBus()),
+	})
+
+	// then
+	require.EqualError(store.Validate(), "invalid DynamoStore configuration: "+
+		"WithCacheInvalidation couldn't generate an origin: no entropy")
+}
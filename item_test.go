@@ -0,0 +1,96 @@
+package dynamostore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalItem(t *testing.T) {
+	expiry := time.Date(2021, 2, 3, 4, 5, 6, 7, time.UTC)
+	for name, tc := range map[string]struct {
+		opts []Option
+		data []byte
+	}{
+		"data":          {data: []byte("bar")},
+		"empty data":    {data: []byte{}},
+		"nil data":      {data: nil},
+		"renamed ttl":   {opts: []Option{WithTTLAttribute("expires")}, data: []byte("bar")},
+		"data hash":     {opts: []Option{WithSkipUnchanged(0)}, data: []byte("bar")},
+		"nil data hash": {opts: []Option{WithSkipUnchanged(0)}, data: nil},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			store := newStore(newFakeClient(), DefaultTableName, tc.opts)
+
+			// given
+			item := &sessionItem{Token: "foo", Data: tc.data, TTL: expiry}
+			if store.unchanged != nil {
+				item.DataHash = hashData(tc.data)
+			}
+			expected, err := attributevalue.MarshalMap(item)
+			require.NoError(err)
+			if store.ttlAttribute != defaultTTLAttribute {
+				expected[store.ttlAttribute] = expected[defaultTTLAttribute]
+				delete(expected, defaultTTLAttribute)
+			}
+
+			// when
+			av, err := store.marshalItem("foo", tc.data, expiry)
+
+			// then
+			require.NoError(err)
+			require.Equal(expected, av)
+			decoded, ok := store.decodeItem(av)
+			require.True(ok)
+			require.Equal("foo", decoded.Token)
+			require.Equal(expiry.Unix(), decoded.TTL.Unix())
+			require.Equal(item.DataHash, decoded.DataHash)
+			if len(tc.data) > 0 {
+				require.Equal(tc.data, decoded.Data)
+			}
+		})
+	}
+}
+
+func TestUnmarshalItem(t *testing.T) {
+	require := require.New(t)
+	store := newStore(newFakeClient(), DefaultTableName, nil)
+
+	// when an item was written by marshalItem
+	item, err := store.unmarshalItem(map[string]types.AttributeValue{
+		"token":   &types.AttributeValueMemberS{Value: "foo"},
+		"Data":    &types.AttributeValueMemberB{Value: []byte("bar")},
+		"ttl":     &types.AttributeValueMemberN{Value: "1612325106"},
+		"Version": &types.AttributeValueMemberN{Value: "3"},
+		"Other":   &types.AttributeValueMemberS{Value: "ignored"},
+	})
+	// then it is decoded directly
+	require.NoError(err)
+	require.Equal(&sessionItem{
+		Token:   "foo",
+		Data:    []byte("bar"),
+		TTL:     time.Unix(1612325106, 0),
+		Version: 3,
+	}, item)
+
+	// when the item is missing
+	item, err = store.unmarshalItem(nil)
+	// then it is empty
+	require.NoError(err)
+	require.Equal(&sessionItem{}, item)
+
+	// when an attribute has an unexpected type
+	item, err = store.unmarshalItem(map[string]types.AttributeValue{
+		"token": &types.AttributeValueMemberS{Value: "foo"},
+		"Data":  &types.AttributeValueMemberB{Value: []byte("bar")},
+		"ttl":   &types.AttributeValueMemberS{Value: "soon"},
+	})
+	// then it is left to attributevalue, which rejects it
+	require.Error(err)
+	require.Nil(item)
+}
@@ -0,0 +1,60 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DefaultKeyRefresh is how often WithKeyProvider fetches keys by default.
+const DefaultKeyRefresh = 5 * time.Minute
+
+// KeyProvider supplies the keys used to encrypt sessions.
+type KeyProvider interface {
+	Keyring(ctx context.Context) (*Keyring, error)
+}
+
+// KeyProviderFunc adapts a function to the KeyProvider interface.
+type KeyProviderFunc func(ctx context.Context) (*Keyring, error)
+
+// Keyring calls f.
+func (f KeyProviderFunc) Keyring(ctx context.Context) (*Keyring, error) {
+	return f(ctx)
+}
+
+// WithKeyProvider is like WithEncryption, but fetches keys from provider
+// when they are first needed, and again every refresh interval, so keys can
+// be rotated without restarting the application. If a refresh fails, the
+// previous keys are used until the next interval. The default interval is
+// DefaultKeyRefresh.
+func WithKeyProvider(provider KeyProvider, refresh time.Duration) Option {
+	return func(s *DynamoStore) {
+		if provider == nil || refresh < 0 {
+			s.invalid("WithKeyProvider requires a provider and a refresh interval of zero or more")
+			return
+		}
+		if refresh == 0 {
+			refresh = DefaultKeyRefresh
+		}
+		s.encryption = &encryption{provider: provider, refresh: refresh}
+	}
+}
+
+// ParseKeyring decodes a keyring stored as JSON, with keys encoded as
+// base64:
+//
+//	{"current": "v2", "keys": {"v1": "...", "v2": "..."}}
+//
+// It is the format expected by the key providers in the secretsmanagerkeys
+// and parameterstorekeys packages.
+func ParseKeyring(b []byte) (*Keyring, error) {
+	keys := &Keyring{}
+	if err := json.Unmarshal(b, keys); err != nil {
+		return nil, err
+	}
+	if _, err := newKeyset(keys); err != nil {
+		return nil, errors.New("dynamostore: keyring " + err.Error())
+	}
+	return keys, nil
+}
@@ -0,0 +1,95 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeyProvider parses value as a keyring, or fails with err.
+type fakeKeyProvider struct {
+	value string
+	err   error
+	calls int
+}
+
+func (f *fakeKeyProvider) Keyring(ctx context.Context) (*Keyring, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return ParseKeyring([]byte(f.value))
+}
+
+func keyringJSON(current string, ids ...string) string {
+	b := &bytes.Buffer{}
+	b.WriteString(`{"current": "` + current + `", "keys": {`)
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		key := bytes.Repeat([]byte(id[len(id)-1:]), 32)
+		b.WriteString(`"` + id + `": "` + base64.StdEncoding.EncodeToString(key) + `"`)
+	}
+	b.WriteString("}}")
+	return b.String()
+}
+
+func TestWithKeyProvider(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	secrets := &fakeKeyProvider{err: errors.New("unavailable")}
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithKeyProvider(secrets, time.Minute),
+	})
+	require.NoError(store.Validate())
+	expiry := now.Add(time.Hour)
+
+	// when the keys can't be fetched
+	err := store.Commit("foo", []byte("bar"), expiry)
+
+	// then nothing is written
+	require.Error(err)
+	require.Empty(svc.items)
+
+	// when the keys can be fetched
+	secrets.err = nil
+	secrets.value = keyringJSON("v1", "v1")
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.NoError(store.Commit("bar", []byte("baz"), expiry))
+
+	// then they are cached until the refresh interval
+	require.Equal(2, secrets.calls)
+	require.Equal("v1", svc.items["foo"][keyIDAttribute].(*types.AttributeValueMemberS).Value)
+
+	// when a new key is added and a refresh fails
+	secrets.value = keyringJSON("v2", "v1", "v2")
+	secrets.err = errors.New("unavailable")
+	now = now.Add(2 * time.Minute)
+
+	// then the previous keys are used
+	require.NoError(store.Commit("bar", []byte("baz"), expiry))
+	require.Equal("v1", svc.items["bar"][keyIDAttribute].(*types.AttributeValueMemberS).Value)
+
+	// when the refresh succeeds
+	secrets.err = nil
+	now = now.Add(2 * time.Minute)
+	require.NoError(store.Commit("bar", []byte("baz"), expiry))
+
+	// then the new key is used, and the old key still works
+	require.Equal("v2", svc.items["bar"][keyIDAttribute].(*types.AttributeValueMemberS).Value)
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+}
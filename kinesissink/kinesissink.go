@@ -0,0 +1,78 @@
+// Package kinesissink exports session lifecycle events to a Kinesis data
+// stream, for analytics of login patterns and session duration.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the Kinesis client.
+package kinesissink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the Kinesis Data Streams API used by a Sink. It is
+// implemented by *kinesis.Client.
+type Client interface {
+	PutRecord(context.Context, *kinesis.PutRecordInput, ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+}
+
+// Config configures a Sink.
+type Config struct {
+	Client Client
+	// Stream is the name of the data stream.
+	Stream string
+}
+
+// Sink is a dynamostore.SessionEventSink that exports every event as a
+// dynamostore.EventRecord in JSON. Records are partitioned by token hash, so
+// the events of a session are read in order. Use it with
+// dynamostore.WithEventSink.
+type Sink struct {
+	cfg Config
+}
+
+// New returns a Sink, or an error if cfg is invalid.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Client == nil || cfg.Stream == "" {
+		return nil, errors.New("kinesissink: requires a client and a stream")
+	}
+	return &Sink{cfg: cfg}, nil
+}
+
+// Created exports event.
+func (k *Sink) Created(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return k.put(ctx, event)
+}
+
+// Refreshed exports event.
+func (k *Sink) Refreshed(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return k.put(ctx, event)
+}
+
+// Revoked exports event.
+func (k *Sink) Revoked(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return k.put(ctx, event)
+}
+
+// Expired exports event.
+func (k *Sink) Expired(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return k.put(ctx, event)
+}
+
+func (k *Sink) put(ctx context.Context, event *dynamostore.AuditEvent) error {
+	data, err := dynamostore.MarshalEventRecord(event)
+	if err != nil {
+		return err
+	}
+	_, err = k.cfg.Client.PutRecord(ctx, &kinesis.PutRecordInput{
+		StreamName:   aws.String(k.cfg.Stream),
+		PartitionKey: aws.String(event.TokenHash),
+		Data:         data,
+	})
+	return err
+}
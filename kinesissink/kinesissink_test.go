@@ -0,0 +1,99 @@
+package kinesissink_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/kinesissink"
+)
+
+type fakeKinesis struct {
+	records []*kinesis.PutRecordInput
+	err     error
+}
+
+func (f *fakeKinesis) PutRecord(
+	ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options),
+) (*kinesis.PutRecordOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.records = append(f.records, params)
+	return &kinesis.PutRecordOutput{}, nil
+}
+
+func TestSink(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	stream := &fakeKinesis{}
+	sink, err := kinesissink.New(kinesissink.Config{Client: stream, Stream: "sessions"})
+	require.NoError(err)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithEventSink(sink, nil),
+	)
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.Commit("foo", []byte("bar"), now.Add(time.Hour)))
+	require.NoError(store.Commit("foo", []byte("baz"), now.Add(time.Hour)))
+	require.NoError(store.Delete("foo"))
+
+	// then
+	require.Len(stream.records, 3)
+	var types []dynamostore.AuditEventType
+	for _, record := range stream.records {
+		require.Equal("sessions", aws.ToString(record.StreamName))
+		require.Equal(dynamostore.TokenHash("foo"), aws.ToString(record.PartitionKey))
+
+		var event dynamostore.EventRecord
+		require.NoError(json.Unmarshal(record.Data, &event))
+		require.Equal(dynamostore.TokenHash("foo"), event.TokenHash)
+		require.True(now.Equal(event.Time))
+		types = append(types, event.Type)
+	}
+	require.Equal([]dynamostore.AuditEventType{
+		dynamostore.AuditCreate, dynamostore.AuditRefresh, dynamostore.AuditDelete,
+	}, types)
+}
+
+func TestSinkErrors(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var failed []*dynamostore.AuditEvent
+	sink, err := kinesissink.New(kinesissink.Config{
+		Client: &fakeKinesis{err: errors.New("throttled")},
+		Stream: "sessions",
+	})
+	require.NoError(err)
+	store := fake.New(dynamostore.WithEventSink(sink, func(event *dynamostore.AuditEvent, err error) {
+		require.EqualError(err, "throttled")
+		failed = append(failed, event)
+	}))
+
+	// when
+	err = store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+
+	// then
+	require.NoError(err)
+	require.Len(failed, 1)
+	require.Equal(dynamostore.AuditCreate, failed[0].Type)
+
+	// when
+	_, err = kinesissink.New(kinesissink.Config{Client: &fakeKinesis{}})
+
+	// then
+	require.Error(err)
+}
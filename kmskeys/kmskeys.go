@@ -0,0 +1,36 @@
+// Package kmskeys decrypts token peppers with AWS KMS, for use with
+// dynamostore.WithTokenPepper.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the KMS client.
+package kmskeys
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the KMS API used by Pepper. It is implemented by
+// *kms.Client.
+type Client interface {
+	Decrypt(context.Context, *kms.DecryptInput, ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Pepper returns a dynamostore.PepperProvider that decrypts ciphertext with
+// KMS. The ciphertext is a pepper encrypted under a KMS key, such as the
+// CiphertextBlob returned by GenerateDataKey, so it can be stored in
+// configuration without revealing the pepper.
+func Pepper(client Client, ciphertext []byte) dynamostore.PepperProvider {
+	return dynamostore.PepperProviderFunc(func(ctx context.Context) ([]byte, error) {
+		result, err := client.Decrypt(ctx, &kms.DecryptInput{
+			CiphertextBlob: ciphertext,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Plaintext, nil
+	})
+}
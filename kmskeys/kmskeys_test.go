@@ -0,0 +1,55 @@
+package kmskeys_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/kmskeys"
+)
+
+type fakeKMS struct {
+	plaintext []byte
+}
+
+func (f *fakeKMS) Decrypt(
+	ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options),
+) (*kms.DecryptOutput, error) {
+	if string(params.CiphertextBlob) != "encrypted" {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kms.DecryptOutput{Plaintext: f.plaintext}, nil
+}
+
+func TestPepper(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// given
+	client := &fakeKMS{plaintext: []byte("pepper")}
+	store := fake.New(dynamostore.WithTokenPepper(kmskeys.Pepper(client, []byte("encrypted"))))
+	plain := fake.New(dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(
+		func(context.Context) ([]byte, error) { return []byte("pepper"), nil },
+	)))
+
+	// when
+	hash, err := store.HashToken(ctx, "foo")
+
+	// then the decrypted pepper is used
+	require.NoError(err)
+	expected, err := plain.HashToken(ctx, "foo")
+	require.NoError(err)
+	require.Equal(expected, hash)
+
+	// when the ciphertext can't be decrypted
+	store = fake.New(dynamostore.WithTokenPepper(kmskeys.Pepper(client, []byte("garbage"))))
+	_, err = store.HashToken(ctx, "foo")
+
+	// then
+	require.EqualError(err, "invalid ciphertext")
+}
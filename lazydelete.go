@@ -0,0 +1,92 @@
+package dynamostore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxLazyDeletes limits the number of expired sessions being deleted in the
+// background at once. Find skips deleting sessions when the limit is reached,
+// rather than waiting.
+const maxLazyDeletes = 16
+
+// WithLazyDelete makes Find delete the expired sessions it encounters in the
+// background, so they don't linger until DynamoDB's TTL process removes
+// them, which can take up to 48 hours. Each deletion is conditional on the
+// session still being expired. Failed deletions are reported to onError, if
+// it is not nil, and otherwise ignored, since TTL will remove the session
+// eventually.
+func WithLazyDelete(onError func(token string, err error)) Option {
+	return func(s *DynamoStore) {
+		s.lazyDelete = &lazyDeleter{
+			onError: onError,
+			sem:     make(chan struct{}, maxLazyDeletes),
+		}
+	}
+}
+
+type lazyDeleter struct {
+	store   *DynamoStore
+	onError func(token string, err error)
+	sem     chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func (d *lazyDeleter) start(s *DynamoStore) {
+	d.store = s
+}
+
+// delete removes the session with the given token if it expired before
+// cutoff. After the deleter is closed, it deletes synchronously.
+func (d *lazyDeleter) delete(token string, cutoff time.Time) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		d.run(token, cutoff)
+		return
+	}
+	select {
+	case d.sem <- struct{}{}:
+	default:
+		d.mu.Unlock()
+		return
+	}
+	d.wg.Add(1)
+	d.mu.Unlock()
+
+	go func() {
+		defer d.wg.Done()
+		defer func() { <-d.sem }()
+		d.run(token, cutoff)
+	}()
+}
+
+func (d *lazyDeleter) run(token string, cutoff time.Time) {
+	_, err := d.store.deleteIfExpired(context.Background(), token, cutoff)
+	if err != nil && d.onError != nil {
+		d.onError(token, err)
+	}
+}
+
+// close waits for background deletions to finish, or ctx to be done.
+func (d *lazyDeleter) close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
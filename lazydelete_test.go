@@ -0,0 +1,55 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLazyDelete(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var failed []string
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithLazyDelete(func(token string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, token)
+		}),
+	})
+
+	// given
+	require.NoError(store.Commit("expired", []byte("bar"), time.Now().Add(-time.Hour)))
+	require.NoError(store.Commit("active", []byte("baz"), time.Now().Add(time.Hour)))
+
+	// when
+	_, exists, err := store.Find("expired")
+	require.NoError(err)
+	require.False(exists)
+	_, exists, err = store.Find("active")
+	require.NoError(err)
+	require.True(exists)
+	require.NoError(store.Close(ctx))
+
+	// then
+	require.Equal(1, svc.count("DeleteItem"))
+	require.NotContains(svc.items, "expired")
+	require.Contains(svc.items, "active")
+
+	// when
+	require.NoError(store.Commit("expired", []byte("bar"), time.Now().Add(-time.Hour)))
+	svc.failWith("DeleteItem", errors.New("oops"))
+	_, exists, err = store.Find("expired")
+
+	// then
+	require.NoError(err)
+	require.False(exists)
+	require.Equal([]string{"expired"}, failed)
+}
@@ -0,0 +1,58 @@
+package dynamostore
+
+import (
+	"context"
+	"strings"
+)
+
+// CloseError is returned by Close when one or more background tasks failed
+// to shut down cleanly.
+type CloseError struct {
+	Errors []error
+}
+
+func (e *CloseError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "dynamostore: close failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the first error, so errors.Is and errors.As can be used to
+// check for common causes such as context.DeadlineExceeded.
+func (e *CloseError) Unwrap() error {
+	if len(e.Errors) < 1 {
+		return nil
+	}
+	return e.Errors[0]
+}
+
+// Close stops background goroutines and flushes pending work, such as
+// queued write-behind commits. It waits until shutdown is complete or ctx is
+// done, whichever happens first, and reports any errors encountered along
+// the way as a *CloseError.
+//
+// The store remains usable after Close, but operations that would normally
+// run in the background are performed synchronously instead. Calling Close
+// more than once returns the result of the first call.
+func (s *DynamoStore) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		var errs []error
+		for i := len(s.closers) - 1; i >= 0; i-- {
+			if err := s.closers[i](ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			s.closeErr = &CloseError{Errors: errs}
+		}
+	})
+	return s.closeErr
+}
+
+// onClose registers fn to be called by Close. Functions are called in the
+// reverse order they were registered.
+func (s *DynamoStore) onClose(fn func(context.Context) error) {
+	s.closers = append(s.closers, fn)
+}
@@ -0,0 +1,39 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	svc.failWith("PutItem", errors.New("throttled"))
+	store := newStore(svc, DefaultTableName, []Option{
+		WithWriteBehind(1, nil),
+	})
+
+	expiry := time.Now().Add(time.Minute)
+	require.NoError(store.Commit("foo", []byte("foo"), expiry))
+	require.NoError(store.Commit("bar", []byte("bar"), expiry))
+
+	err := store.Close(context.Background())
+	require.Error(err)
+	var closeErr *CloseError
+	require.True(errors.As(err, &closeErr))
+	require.Len(closeErr.Errors, 1)
+	require.Contains(err.Error(), "2 writes failed: throttled")
+
+	// repeated calls report the same result
+	require.Equal(err, store.Close(context.Background()))
+}
+
+func TestCloseWithoutBackgroundTasks(t *testing.T) {
+	store := newStore(newFakeClient(), DefaultTableName, nil)
+	require.NoError(t, store.Close(context.Background()))
+}
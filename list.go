@@ -0,0 +1,159 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultListLimit is the number of sessions returned by ListSessions when
+// the limit is zero or less.
+const DefaultListLimit = 100
+
+// ErrInvalidCursor is returned by ListSessions when a cursor wasn't
+// returned by an earlier call, or can no longer be read.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListSessions returns a page of up to limit sessions, including expired
+// sessions that DynamoDB hasn't removed yet, and a cursor for the next page.
+// The first page is returned when cursor is empty, and the cursor returned
+// with the last page is empty. Sessions aren't returned in any useful order,
+// and a page may be short, or even empty, when DynamoDB stops reading early.
+//
+// A cursor identifies the last session read, so it is encrypted to keep the
+// token from admin UIs and logs. When WithTokenPepper is used, the key is
+// derived from the pepper, and cursors can be passed between instances of
+// the application. Otherwise, a cursor can only be used with the DynamoStore
+// that returned it.
+func (s *DynamoStore) ListSessions(ctx context.Context, cursor string, limit int) ([]*SessionInfo, string, error) {
+	if limit < 1 {
+		limit = DefaultListLimit
+	} else if limit > math.MaxInt32 {
+		limit = math.MaxInt32
+	}
+	params := &dynamodb.ScanInput{
+		TableName:              s.table,
+		Limit:                  aws.Int32(int32(limit)),
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}
+	if cursor != "" {
+		token, err := s.openCursor(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		params.ExclusiveStartKey = map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		}
+	}
+
+	units, err := s.limiter.waitRead(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	result, err := s.svc.Scan(ctx, params, s.optFns...)
+	if err != nil {
+		return nil, "", err
+	}
+	s.limiter.consumedRead(units, result.ConsumedCapacity)
+
+	sessions := make([]*SessionInfo, 0, len(result.Items))
+	for _, av := range result.Items {
+		info, err := s.sessionInfo(ctx, av)
+		if err != nil {
+			return nil, "", err
+		}
+		sessions = append(sessions, info)
+	}
+
+	var next string
+	if key, ok := result.LastEvaluatedKey["token"].(*types.AttributeValueMemberS); ok {
+		if next, err = s.sealCursor(ctx, key.Value); err != nil {
+			return nil, "", err
+		}
+	}
+	return sessions, next, nil
+}
+
+// cursorKey is the key used to encrypt the cursors returned by
+// ListSessions.
+type cursorKey struct {
+	mu   sync.Mutex
+	aead cipher.AEAD
+}
+
+// cursorAEAD returns the cipher used for cursors, creating it on first use.
+// If the pepper can't be fetched, the next call tries again.
+func (s *DynamoStore) cursorAEAD(ctx context.Context) (cipher.AEAD, error) {
+	s.cursors.mu.Lock()
+	defer s.cursors.mu.Unlock()
+	if s.cursors.aead != nil {
+		return s.cursors.aead, nil
+	}
+	key := make([]byte, 32)
+	if s.pepper != nil {
+		pepper, err := s.pepper.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha256.New, pepper)
+		mac.Write([]byte("dynamostore cursor"))
+		key = mac.Sum(nil)
+	} else if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.cursors.aead = aead
+	return aead, nil
+}
+
+// sealCursor returns an encrypted cursor identifying token.
+func (s *DynamoStore) sealCursor(ctx context.Context, token string) (string, error) {
+	aead, err := s.cursorAEAD(ctx)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(token), []byte(*s.table))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// openCursor returns the token identified by a cursor from sealCursor.
+func (s *DynamoStore) openCursor(ctx context.Context, cursor string) (string, error) {
+	aead, err := s.cursorAEAD(ctx)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCursor
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	token, err := aead.Open(nil, nonce, sealed, []byte(*s.table))
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return string(token), nil
+}
@@ -0,0 +1,155 @@
+package dynamostore_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestListSessions(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := fake.New()
+	expiry := time.Now().Add(time.Hour)
+
+	// given
+	expected := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		token := "token" + strconv.Itoa(i)
+		require.NoError(store.Commit(token, []byte("data"), expiry))
+		expected[dynamostore.TokenHash(token)] = true
+	}
+
+	// when every page is listed
+	seen := map[string]bool{}
+	pages := 0
+	cursor := ""
+	for {
+		sessions, next, err := store.ListSessions(ctx, cursor, 2)
+		require.NoError(err)
+		require.LessOrEqual(len(sessions), 2)
+		for _, info := range sessions {
+			require.False(seen[info.TokenHash])
+			seen[info.TokenHash] = true
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		require.NotContains(next, "token")
+		cursor = next
+	}
+
+	// then every session is listed once
+	require.Equal(expected, seen)
+	require.Equal(3, pages)
+}
+
+func TestListSessionsInvalidCursor(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := fake.New()
+	other := fake.New()
+	for i := 0; i < 3; i++ {
+		require.NoError(other.Commit("token"+strconv.Itoa(i), []byte("data"), time.Now().Add(time.Hour)))
+	}
+	_, cursor, err := other.ListSessions(ctx, "", 1)
+	require.NoError(err)
+	require.NotEmpty(cursor)
+
+	// when a cursor is corrupt
+	_, _, err = store.ListSessions(ctx, "not a cursor", 1)
+
+	// then it is rejected
+	require.Equal(dynamostore.ErrInvalidCursor, err)
+
+	// when a cursor came from another store without a shared pepper
+	_, _, err = store.ListSessions(ctx, cursor, 1)
+
+	// then it is rejected
+	require.Equal(dynamostore.ErrInvalidCursor, err)
+}
+
+func TestListSessionsWithPepper(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	pepper := dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(
+		func(context.Context) ([]byte, error) { return []byte("pepper"), nil },
+	))
+	client := fake.NewClient()
+	client.AddTable(dynamostore.DefaultTableName, "token")
+	store := dynamostore.New(client, pepper)
+	other := dynamostore.New(client, pepper)
+	for i := 0; i < 3; i++ {
+		require.NoError(store.Commit("token"+strconv.Itoa(i), []byte("data"), time.Now().Add(time.Hour)))
+	}
+
+	// given
+	first, cursor, err := store.ListSessions(ctx, "", 2)
+	require.NoError(err)
+	require.Len(first, 2)
+
+	// when another instance continues from the cursor
+	rest, next, err := other.ListSessions(ctx, cursor, 2)
+
+	// then it returns the remaining session
+	require.NoError(err)
+	require.Len(rest, 1)
+	require.Empty(next)
+	require.NotEqual(first[0].TokenHash, rest[0].TokenHash)
+	require.NotEqual(first[1].TokenHash, rest[0].TokenHash)
+}
+
+func TestListSessionsRetriesPepper(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	fail := true
+	client := fake.NewClient()
+	client.AddTable(dynamostore.DefaultTableName, "token")
+	store := dynamostore.New(client, dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(
+		func(context.Context) ([]byte, error) {
+			if fail {
+				return nil, errors.New("unavailable")
+			}
+			return []byte("pepper"), nil
+		},
+	)))
+	other := dynamostore.New(client, dynamostore.WithTokenPepper(dynamostore.PepperProviderFunc(
+		func(context.Context) ([]byte, error) { return []byte("pepper"), nil },
+	)))
+	for i := 0; i < 3; i++ {
+		require.NoError(other.Commit("token"+strconv.Itoa(i), []byte("data"), time.Now().Add(time.Hour)))
+	}
+	_, cursor, err := other.ListSessions(ctx, "", 2)
+	require.NoError(err)
+
+	// given the pepper couldn't be fetched
+	_, _, err = store.ListSessions(ctx, cursor, 2)
+	require.Error(err)
+
+	// when it can be fetched again
+	fail = false
+	rest, _, err := store.ListSessions(ctx, cursor, 2)
+
+	// then the cursor can be read
+	require.NoError(err)
+	require.Len(rest, 1)
+
+	// when the limit doesn't fit in a Scan request
+	all, _, err := store.ListSessions(ctx, "", int(^uint(0)>>1))
+
+	// then it is capped
+	require.NoError(err)
+	require.Len(all, 3)
+}
@@ -0,0 +1,43 @@
+// Package parameterstorekeys reads session encryption keys from AWS Systems
+// Manager Parameter Store, for use with dynamostore.WithKeyProvider.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the Systems Manager client.
+package parameterstorekeys
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the Systems Manager API used by KeyProvider. It is
+// implemented by *ssm.Client.
+type Client interface {
+	GetParameter(
+		context.Context, *ssm.GetParameterInput, ...func(*ssm.Options),
+	) (*ssm.GetParameterOutput, error)
+}
+
+// KeyProvider returns a dynamostore.KeyProvider that reads a keyring, in the
+// format accepted by dynamostore.ParseKeyring, from a parameter, which should
+// be a SecureString.
+func KeyProvider(client Client, name string) dynamostore.KeyProvider {
+	return dynamostore.KeyProviderFunc(func(ctx context.Context) (*dynamostore.Keyring, error) {
+		result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.Parameter == nil {
+			return nil, errors.New("parameterstorekeys: parameter " + name + " has no value")
+		}
+		return dynamostore.ParseKeyring([]byte(aws.ToString(result.Parameter.Value)))
+	})
+}
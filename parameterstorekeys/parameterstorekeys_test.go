@@ -0,0 +1,50 @@
+package parameterstorekeys_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/parameterstorekeys"
+)
+
+type fakeParameters struct {
+	value string
+}
+
+func (f *fakeParameters) GetParameter(
+	ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options),
+) (*ssm.GetParameterOutput, error) {
+	if !aws.ToBool(params.WithDecryption) {
+		return nil, errors.New("missing WithDecryption")
+	}
+	return &ssm.GetParameterOutput{
+		Parameter: &ssmtypes.Parameter{Value: aws.String(f.value)},
+	}, nil
+}
+
+func keyringJSON(current string) string {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("1"), 32))
+	return `{"current": "` + current + `", "keys": {"v1": "` + key + `"}}`
+}
+
+func TestKeyProvider(t *testing.T) {
+	require := require.New(t)
+
+	provider := parameterstorekeys.KeyProvider(&fakeParameters{value: keyringJSON("v1")}, "/sessions/keys")
+	keys, err := provider.Keyring(context.Background())
+	require.NoError(err)
+	require.Equal("v1", keys.Current)
+	require.Equal(bytes.Repeat([]byte("1"), 32), keys.Keys["v1"])
+
+	provider = parameterstorekeys.KeyProvider(&fakeParameters{value: keyringJSON("v2")}, "/sessions/keys")
+	_, err = provider.Keyring(context.Background())
+	require.Error(err)
+}
@@ -0,0 +1,104 @@
+package dynamostore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"sync"
+)
+
+// PepperProvider supplies the secret used by WithTokenPepper.
+type PepperProvider interface {
+	Pepper(ctx context.Context) ([]byte, error)
+}
+
+// PepperProviderFunc adapts a function to the PepperProvider interface.
+type PepperProviderFunc func(ctx context.Context) ([]byte, error)
+
+// Pepper calls f.
+func (f PepperProviderFunc) Pepper(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// WithTokenPepper derives the token hashes written to exports, audit
+// events, and Stats with HMAC-SHA256, keyed with a secret pepper, instead of
+// SHA-256 alone. Without the pepper, hashes can't be matched to tokens, so
+// a copy of the audit table or an export's hashes can't be correlated with
+// sessions.
+//
+// The pepper is fetched from provider when it is first needed, and kept for
+// the life of the store. It must not change, or hashes recorded before and
+// after the change won't match. Use HashToken, rather than TokenHash, to
+// find the hash of a token.
+func WithTokenPepper(provider PepperProvider) Option {
+	return func(s *DynamoStore) {
+		if provider == nil {
+			s.invalid("WithTokenPepper requires a provider")
+			return
+		}
+		s.pepper = &tokenPepper{provider: provider}
+	}
+}
+
+type tokenPepper struct {
+	provider PepperProvider
+
+	mu     sync.Mutex
+	pepper []byte
+
+	// macs holds *pepperMACs keyed with the pepper, since creating an HMAC
+	// allocates more than hashing a token does.
+	macs sync.Pool
+}
+
+// pepperMAC is an HMAC keyed with the pepper, and a buffer for its input and
+// output.
+type pepperMAC struct {
+	mac hash.Hash
+	buf []byte
+}
+
+// get returns the pepper, fetching it if it hasn't been fetched yet.
+func (p *tokenPepper) get(ctx context.Context) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pepper != nil {
+		return p.pepper, nil
+	}
+	pepper, err := p.provider.Pepper(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pepper) < 1 {
+		return nil, errors.New("dynamostore: token pepper is empty")
+	}
+	p.pepper = pepper
+	return pepper, nil
+}
+
+// HashToken returns the hash used to identify token in exports, audit
+// events, and Stats. It is the same as TokenHash, unless WithTokenPepper is
+// used.
+func (s *DynamoStore) HashToken(ctx context.Context, token string) (string, error) {
+	if s.pepper == nil {
+		return hashToken(token), nil
+	}
+	pepper, err := s.pepper.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	m, ok := s.pepper.macs.Get().(*pepperMAC)
+	if !ok {
+		m = &pepperMAC{mac: hmac.New(sha256.New, pepper)}
+	}
+	m.mac.Reset()
+	m.buf = append(m.buf[:0], token...)
+	m.mac.Write(m.buf)
+	m.buf = m.mac.Sum(m.buf[:0])
+	hash := hex.EncodeToString(m.buf)
+	s.pepper.macs.Put(m)
+	return hash, nil
+}
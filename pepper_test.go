@@ -0,0 +1,115 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakePepper returns value, or fails with err.
+type fakePepper struct {
+	value string
+	err   error
+	calls int
+}
+
+func (f *fakePepper) Pepper(ctx context.Context) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.value), nil
+}
+
+func TestTokenPepper(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// given
+	secrets := &fakePepper{value: "pepper"}
+	peppered := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithTokenPepper(secrets),
+	})
+	other := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithTokenPepper(&fakePepper{value: "pepper"}),
+	})
+	plain := newStore(newFakeClient(), DefaultTableName, nil)
+
+	// when
+	hash, err := peppered.HashToken(ctx, "foo")
+	require.NoError(err)
+	again, err := peppered.HashToken(ctx, "foo")
+	require.NoError(err)
+
+	// then
+	require.Equal(hash, again)
+	require.Equal(1, secrets.calls)
+	require.NotEqual(TokenHash("foo"), hash)
+	require.Len(hash, 64)
+
+	same, err := other.HashToken(ctx, "foo")
+	require.NoError(err)
+	require.Equal(hash, same)
+
+	unpeppered, err := plain.HashToken(ctx, "foo")
+	require.NoError(err)
+	require.Equal(TokenHash("foo"), unpeppered)
+
+	// when
+	failing := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithTokenPepper(&fakePepper{err: errors.New("denied")}),
+	})
+	_, err = failing.HashToken(ctx, "foo")
+
+	// then
+	require.EqualError(err, "denied")
+}
+
+func TestTokenPepperExport(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// given
+	pepper := WithTokenPepper(PepperProviderFunc(func(context.Context) ([]byte, error) {
+		return []byte("pepper"), nil
+	}))
+	src := newStore(newFakeClient(), DefaultTableName, []Option{pepper})
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	require.NoError(src.Commit("foo", []byte("bar"), expiry))
+
+	// when
+	var buf bytes.Buffer
+	_, err := src.Export(ctx, &buf)
+	require.NoError(err)
+
+	// then
+	var record ExportRecord
+	require.NoError(json.Unmarshal(buf.Bytes(), &record))
+	expected, err := src.HashToken(ctx, "foo")
+	require.NoError(err)
+	require.Equal(expected, record.TokenHash)
+
+	// when
+	dst := newStore(newFakeClient(), DefaultTableName, []Option{pepper})
+	result, err := dst.Import(ctx, bytes.NewReader(buf.Bytes()))
+
+	// then
+	require.NoError(err)
+	require.Equal(1, result.Imported)
+	require.Empty(result.Failures)
+
+	// when
+	plain := newStore(newFakeClient(), DefaultTableName, nil)
+	result, err = plain.Import(ctx, bytes.NewReader(buf.Bytes()))
+
+	// then
+	require.NoError(err)
+	require.Equal(0, result.Imported)
+	require.Len(result.Failures, 1)
+	require.EqualError(result.Failures[0].Err, "token hash mismatch")
+}
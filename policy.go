@@ -0,0 +1,200 @@
+package dynamostore
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// PolicyConfig describes how a DynamoStore will be used, so IAMPolicy can
+// grant the permissions it needs and nothing more.
+type PolicyConfig struct {
+	// TableARN identifies the session table, see TableARN.
+	TableARN string
+
+	// CreateTable grants the permissions used by CreateTable.
+	CreateTable bool
+	// DeleteTable grants the permissions used by DeleteTable.
+	DeleteTable bool
+	// Maintenance grants the permissions used by bulk operations such as
+	// Export, Import, DeleteAll, and DeleteExpired.
+	Maintenance bool
+	// QueryIndexes grants the permission used to query the table's global
+	// secondary indexes, such as by ForEachExpiring and FindByAttribute.
+	QueryIndexes bool
+
+	// DAXClusterARN, if set, grants read access through DAX, see WithDAX.
+	DAXClusterARN string
+	// AuditTableARN, if set, grants the permissions used to record events,
+	// see WithAudit. CreateTable also grants the permissions used by
+	// CreateAuditTable.
+	AuditTableARN string
+	// EventBusARN, if set, grants the permission used to publish events,
+	// see the eventbridgesink package.
+	EventBusARN string
+	// TopicARN, if set, grants the permission used to publish revocations,
+	// see the snssink package.
+	TopicARN string
+	// StreamARN, if set, grants the permission used to export events, see
+	// the kinesissink package.
+	StreamARN string
+	// DeliveryStreamARN, if set, grants the permission used to export
+	// events, see the firehosesink package.
+	DeliveryStreamARN string
+	// DenylistTableARN, if set, grants the permissions used to read and
+	// update the denylist, see WithDenylist. CreateTable also grants the
+	// permissions used by CreateDenylistTable.
+	DenylistTableARN string
+}
+
+// TableARN returns the ARN of a DynamoDB table in the standard AWS
+// partition.
+func TableARN(region, accountID, table string) string {
+	return "arn:aws:dynamodb:" + region + ":" + accountID + ":table/" + table
+}
+
+type policyDocument struct {
+	Version   string
+	Statement []*policyStatement
+}
+
+type policyStatement struct {
+	Sid       string
+	Effect    string
+	Principal map[string][]string `json:",omitempty"`
+	Action    []string
+	Resource  string
+}
+
+// IAMPolicy returns an IAM policy document, as indented JSON, granting the
+// permissions DynamoStore needs for the given configuration.
+func IAMPolicy(cfg *PolicyConfig) ([]byte, error) {
+	if cfg.TableARN == "" {
+		return nil, errors.New("missing table ARN")
+	}
+
+	statement := func(sid, resource string, actions ...string) *policyStatement {
+		sort.Strings(actions)
+		return &policyStatement{
+			Sid:      sid,
+			Effect:   "Allow",
+			Action:   actions,
+			Resource: resource,
+		}
+	}
+
+	doc := &policyDocument{
+		Version: "2012-10-17",
+		Statement: []*policyStatement{
+			statement("SessionAccess", cfg.TableARN, sessionActions(cfg.Maintenance)...),
+		},
+	}
+
+	if cfg.CreateTable || cfg.DeleteTable {
+		actions := []string{
+			"dynamodb:DescribeTable",
+		}
+		if cfg.CreateTable {
+			actions = append(actions,
+				"dynamodb:CreateTable",
+				"dynamodb:DescribeTimeToLive",
+				"dynamodb:TagResource",
+				"dynamodb:UpdateTimeToLive",
+			)
+		}
+		if cfg.DeleteTable {
+			actions = append(actions, "dynamodb:DeleteTable")
+		}
+		doc.Statement = append(doc.Statement,
+			statement("TableManagement", cfg.TableARN, actions...),
+		)
+	}
+
+	if cfg.QueryIndexes {
+		doc.Statement = append(doc.Statement,
+			statement("IndexAccess", cfg.TableARN+"/index/*", "dynamodb:Query"),
+		)
+	}
+
+	if cfg.DAXClusterARN != "" {
+		doc.Statement = append(doc.Statement,
+			statement("DAXAccess", cfg.DAXClusterARN, "dax:GetItem"),
+		)
+	}
+
+	if cfg.AuditTableARN != "" {
+		actions := []string{"dynamodb:PutItem"}
+		if cfg.CreateTable {
+			actions = append(actions,
+				"dynamodb:CreateTable",
+				"dynamodb:DescribeTable",
+				"dynamodb:TagResource",
+				"dynamodb:UpdateTimeToLive",
+			)
+		}
+		doc.Statement = append(doc.Statement,
+			statement("AuditAccess", cfg.AuditTableARN, actions...),
+		)
+	}
+
+	if cfg.EventBusARN != "" {
+		doc.Statement = append(doc.Statement,
+			statement("EventBridgeAccess", cfg.EventBusARN, "events:PutEvents"),
+		)
+	}
+
+	if cfg.DenylistTableARN != "" {
+		actions := []string{"dynamodb:GetItem", "dynamodb:PutItem"}
+		if cfg.CreateTable {
+			actions = append(actions,
+				"dynamodb:CreateTable",
+				"dynamodb:DescribeTable",
+				"dynamodb:TagResource",
+				"dynamodb:UpdateTimeToLive",
+			)
+		}
+		doc.Statement = append(doc.Statement,
+			statement("DenylistAccess", cfg.DenylistTableARN, actions...),
+		)
+	}
+
+	if cfg.TopicARN != "" {
+		doc.Statement = append(doc.Statement,
+			statement("SNSAccess", cfg.TopicARN, "sns:Publish"),
+		)
+	}
+
+	if cfg.StreamARN != "" {
+		doc.Statement = append(doc.Statement,
+			statement("KinesisAccess", cfg.StreamARN, "kinesis:PutRecord"),
+		)
+	}
+
+	if cfg.DeliveryStreamARN != "" {
+		doc.Statement = append(doc.Statement,
+			statement("FirehoseAccess", cfg.DeliveryStreamARN, "firehose:PutRecord"),
+		)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// sessionActions returns the actions needed to use sessions, sorted, and
+// the actions used by bulk operations if maintenance is true.
+func sessionActions(maintenance bool) []string {
+	actions := []string{
+		"dynamodb:BatchGetItem",
+		"dynamodb:DeleteItem",
+		"dynamodb:GetItem",
+		"dynamodb:PutItem",
+		"dynamodb:UpdateItem",
+	}
+	if maintenance {
+		actions = append(actions,
+			"dynamodb:BatchWriteItem",
+			"dynamodb:Scan",
+		)
+	}
+	sort.Strings(actions)
+	return actions
+}
@@ -0,0 +1,106 @@
+package dynamostore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIAMPolicy(t *testing.T) {
+	require := require.New(t)
+
+	_, err := IAMPolicy(&PolicyConfig{})
+	require.Error(err)
+
+	arn := TableARN("us-east-1", "123456789012", DefaultTableName)
+	require.Equal("arn:aws:dynamodb:us-east-1:123456789012:table/scs.session", arn)
+
+	b, err := IAMPolicy(&PolicyConfig{TableARN: arn})
+	require.NoError(err)
+	var doc policyDocument
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 1)
+	require.Equal(arn, doc.Statement[0].Resource)
+	require.Equal([]string{
+		"dynamodb:BatchGetItem",
+		"dynamodb:DeleteItem",
+		"dynamodb:GetItem",
+		"dynamodb:PutItem",
+		"dynamodb:UpdateItem",
+	}, doc.Statement[0].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN:      arn,
+		CreateTable:   true,
+		Maintenance:   true,
+		DAXClusterARN: "arn:aws:dax:us-east-1:123456789012:cache/sessions",
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 3)
+	require.Contains(doc.Statement[0].Action, "dynamodb:Scan")
+	require.Contains(doc.Statement[1].Action, "dynamodb:CreateTable")
+	require.NotContains(doc.Statement[1].Action, "dynamodb:DeleteTable")
+	require.Equal([]string{"dax:GetItem"}, doc.Statement[2].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN:      arn,
+		AuditTableARN: TableARN("us-east-1", "123456789012", "scs.audit"),
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 2)
+	require.Equal("AuditAccess", doc.Statement[1].Sid)
+	require.Equal([]string{"dynamodb:PutItem"}, doc.Statement[1].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN:    arn,
+		EventBusARN: "arn:aws:events:us-east-1:123456789012:event-bus/sessions",
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 2)
+	require.Equal("EventBridgeAccess", doc.Statement[1].Sid)
+	require.Equal([]string{"events:PutEvents"}, doc.Statement[1].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN: arn,
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:revocations",
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 2)
+	require.Equal("SNSAccess", doc.Statement[1].Sid)
+	require.Equal([]string{"sns:Publish"}, doc.Statement[1].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN:     arn,
+		QueryIndexes: true,
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 2)
+	require.Equal("IndexAccess", doc.Statement[1].Sid)
+	require.Equal(arn+"/index/*", doc.Statement[1].Resource)
+	require.Equal([]string{"dynamodb:Query"}, doc.Statement[1].Action)
+
+	b, err = IAMPolicy(&PolicyConfig{
+		TableARN:          arn,
+		StreamARN:         "arn:aws:kinesis:us-east-1:123456789012:stream/sessions",
+		DeliveryStreamARN: "arn:aws:firehose:us-east-1:123456789012:deliverystream/sessions",
+	})
+	require.NoError(err)
+	doc = policyDocument{}
+	require.NoError(json.Unmarshal(b, &doc))
+	require.Len(doc.Statement, 3)
+	require.Equal("KinesisAccess", doc.Statement[1].Sid)
+	require.Equal([]string{"kinesis:PutRecord"}, doc.Statement[1].Action)
+	require.Equal("FirehoseAccess", doc.Statement[2].Sid)
+	require.Equal([]string{"firehose:PutRecord"}, doc.Statement[2].Action)
+}
@@ -0,0 +1,58 @@
+package dynamostore
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// itemMaps holds the maps built by marshalItem. A Client doesn't retain the
+// maps in a request, so a map can be reused once the write that sent it has
+// returned.
+var itemMaps = sync.Pool{
+	New: func() interface{} {
+		return make(map[string]types.AttributeValue, 8)
+	},
+}
+
+// newItemMap returns an empty map for marshalItem.
+func newItemMap() map[string]types.AttributeValue {
+	return itemMaps.Get().(map[string]types.AttributeValue)
+}
+
+// releaseItemMap returns a map from newItemMap to the pool. The caller must
+// not use it afterward.
+func releaseItemMap(av map[string]types.AttributeValue) {
+	for k := range av {
+		delete(av, k)
+	}
+	itemMaps.Put(av)
+}
+
+// itemKey is the key of a session, in a map that can be reused once the
+// request that sent it has returned.
+type itemKey struct {
+	av    map[string]types.AttributeValue
+	token types.AttributeValueMemberS
+}
+
+var itemKeys = sync.Pool{
+	New: func() interface{} {
+		k := &itemKey{av: make(map[string]types.AttributeValue, 1)}
+		k.av["token"] = &k.token
+		return k
+	},
+}
+
+// newItemKey returns the key of token.
+func newItemKey(token string) *itemKey {
+	k := itemKeys.Get().(*itemKey)
+	k.token.Value = token
+	return k
+}
+
+// release returns k to the pool. The caller must not use k.av afterward.
+func (k *itemKey) release() {
+	k.token.Value = ""
+	itemKeys.Put(k)
+}
@@ -0,0 +1,59 @@
+package dynamostore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemMapsAreReused(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	svc := newFakeClient()
+	store := New(svc)
+	expiry := time.Now().Add(time.Hour)
+
+	// when
+	for _, token := range []string{"one", "two", "three"} {
+		require.NoError(store.Commit(token, []byte(token), expiry))
+	}
+
+	// then
+	for _, token := range []string{"one", "two", "three"} {
+		data, exists, err := store.Find(token)
+		require.NoError(err)
+		require.True(exists)
+		require.Equal([]byte(token), data)
+	}
+
+	av := newItemMap()
+	av["token"] = nil
+	releaseItemMap(av)
+	require.Empty(newItemMap())
+}
+
+func TestItemKeysAreReused(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	store := New(newFakeClient())
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(store.Commit("one", []byte("one"), expiry))
+
+	// when
+	require.NoError(store.Delete("two"))
+	data, exists, err := store.Find("one")
+
+	// then
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("one"), data)
+
+	key := newItemKey("three")
+	require.Equal("three", key.av["token"].(*types.AttributeValueMemberS).Value)
+	key.release()
+	require.Equal("", key.token.Value)
+}
@@ -0,0 +1,56 @@
+package dynamostore
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// projection limits the attributes returned when finding a session to the
+// ones the store uses. Other attributes, such as ones added by other tools,
+// aren't transferred. DynamoDB still charges for reading the whole item.
+type projection struct {
+	expr  *string
+	names map[string]string
+}
+
+// newProjection returns the projection for the options used by s.
+func (s *DynamoStore) newProjection() *projection {
+	if s.dbesdk {
+		return &projection{}
+	}
+	names := map[string]string{
+		"#token": "token",
+		"#data":  "Data",
+		"#ttl":   s.ttlAttribute,
+	}
+	if s.unchanged != nil {
+		names["#hash"] = dataHashAttribute
+	}
+	if s.versioned {
+		names["#version"] = versionAttribute
+	}
+	if s.tombstones() {
+		names["#revoked"] = revokedAttribute
+	}
+	if s.encryption != nil {
+		names["#keyid"] = keyIDAttribute
+	}
+	if s.global != nil && s.global.Strategy == ReadWriterRegion {
+		names["#writer"] = writerRegionAttribute
+		names["#committed"] = committedAtAttribute
+	}
+	placeholders := []string{
+		"#token", "#data", "#ttl", "#hash", "#version", "#revoked", "#keyid", "#writer", "#committed",
+	}
+	attrs := make([]string, 0, len(placeholders))
+	for _, placeholder := range placeholders {
+		if _, ok := names[placeholder]; ok {
+			attrs = append(attrs, placeholder)
+		}
+	}
+	return &projection{
+		expr:  aws.String(strings.Join(attrs, ", ")),
+		names: names,
+	}
+}
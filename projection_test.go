@@ -0,0 +1,59 @@
+package dynamostore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjection(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts     []Option
+		expected string
+		names    map[string]string
+	}{
+		"default": {
+			expected: "#token, #data, #ttl",
+			names:    map[string]string{"#token": "token", "#data": "Data", "#ttl": "ttl"},
+		},
+		"renamed ttl": {
+			opts:     []Option{WithTTLAttribute("expires")},
+			expected: "#token, #data, #ttl",
+			names:    map[string]string{"#token": "token", "#data": "Data", "#ttl": "expires"},
+		},
+		"skip unchanged": {
+			opts:     []Option{WithSkipUnchanged(0)},
+			expected: "#token, #data, #ttl, #hash",
+			names: map[string]string{
+				"#token": "token", "#data": "Data", "#ttl": "ttl", "#hash": "DataHash",
+			},
+		},
+		"optimistic locking": {
+			opts:     []Option{WithOptimisticLocking()},
+			expected: "#token, #data, #ttl, #version",
+			names: map[string]string{
+				"#token": "token", "#data": "Data", "#ttl": "ttl", "#version": "Version",
+			},
+		},
+		"encryption": {
+			opts: []Option{WithEncryption(&Keyring{
+				Current: "v1",
+				Keys:    map[string][]byte{"v1": make([]byte, 32)},
+			})},
+			expected: "#token, #data, #ttl, #keyid",
+			names: map[string]string{
+				"#token": "token", "#data": "Data", "#ttl": "ttl", "#keyid": "KeyID",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			store := newStore(newFakeClient(), DefaultTableName, tc.opts)
+			require.Equal(tc.expected, aws.ToString(store.projection.expr))
+			require.Equal(tc.names, store.projection.names)
+		})
+	}
+}
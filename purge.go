@@ -0,0 +1,130 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeleteExpired removes sessions that have expired, but haven't been removed
+// by DynamoDB's TTL process yet, and returns the number of sessions removed.
+// DynamoDB typically removes expired items within a few days, so this is only
+// needed to reclaim storage sooner or to keep scans small.
+//
+// The table is scanned in the given number of parallel segments. Each
+// deletion is conditional on the session still being expired, so sessions
+// committed while the scan is in progress are never removed. Use
+// WithCapacityLimit to limit the impact on other users of the table.
+func (s *DynamoStore) DeleteExpired(ctx context.Context, segments int) (int, error) {
+	if segments < 1 {
+		segments = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cutoff := s.now().Add(-s.gracePeriod)
+	var deleted int64
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	wg.Add(segments)
+	for i := 0; i < segments; i++ {
+		go func(segment int) {
+			defer wg.Done()
+			n, err := s.deleteExpiredSegment(ctx, cutoff, segment, segments)
+			atomic.AddInt64(&deleted, int64(n))
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return int(deleted), firstErr
+}
+
+// deleteExpiredSegment removes sessions that expired before cutoff.
+func (s *DynamoStore) deleteExpiredSegment(ctx context.Context, cutoff time.Time, segment, segments int) (int, error) {
+	names := map[string]string{
+		"#token": "token",
+		"#ttl":   s.ttlAttribute,
+	}
+	values := map[string]types.AttributeValue{
+		":cutoff": &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(cutoff.Unix(), 10),
+		},
+	}
+
+	n := 0
+	err := s.scan(ctx, &dynamodb.ScanInput{
+		Segment:                   aws.Int32(int32(segment)),
+		TotalSegments:             aws.Int32(int32(segments)),
+		FilterExpression:          aws.String("#ttl < :cutoff"),
+		ProjectionExpression:      aws.String("#token, #ttl"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}, func(av map[string]types.AttributeValue) error {
+		item, err := s.unmarshalItem(av)
+		if err != nil {
+			return err
+		}
+		if !item.TTL.Before(cutoff) {
+			return nil
+		}
+		deleted, err := s.deleteIfExpired(ctx, item.Token, cutoff)
+		if deleted {
+			n++
+		}
+		return err
+	})
+	return n, err
+}
+
+// deleteIfExpired removes the session with the given token if it expired
+// before cutoff, and returns true if it was removed. The deletion is
+// conditional, so sessions refreshed since they were read are kept.
+func (s *DynamoStore) deleteIfExpired(ctx context.Context, token string, cutoff time.Time) (bool, error) {
+	units, err := s.limiter.waitWrite(ctx, 0)
+	if err != nil {
+		return false, err
+	}
+	result, err := s.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+		ConditionExpression:      aws.String("#ttl < :cutoff"),
+		ExpressionAttributeNames: map[string]string{"#ttl": s.ttlAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":cutoff": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(cutoff.Unix(), 10),
+			},
+		},
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// The session was refreshed after it was read.
+			return false, nil
+		}
+		return false, err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.forgetUnchanged(token)
+	s.forgetCached(token)
+	s.recordEvent(ctx, AuditExpire, token, nil)
+	return true, nil
+}
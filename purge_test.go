@@ -0,0 +1,34 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpired(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	svc.pageSize = 3
+	store := newStore(svc, DefaultTableName, nil)
+
+	live := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+	for i := 0; i < 20; i++ {
+		expiry := live
+		if i%2 == 0 {
+			expiry = expired
+		}
+		require.NoError(store.Commit(string(rune('A'+i)), []byte{byte(i)}, expiry))
+	}
+
+	n, err := store.DeleteExpired(context.Background(), 4)
+	require.NoError(err)
+	require.Equal(10, n)
+	require.Len(svc.items, 10)
+	require.Contains(svc.items, "B")
+	require.NotContains(svc.items, "A")
+}
@@ -0,0 +1,175 @@
+package dynamostore
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WithCapacityLimit bounds the read and write capacity units the store may
+// consume per second. Requests wait for capacity to become available before
+// being sent, and the estimate used to admit them is corrected using the
+// capacity DynamoDB reports as consumed. A limit of zero or less disables
+// limiting for that kind of request.
+//
+// Limits are enforced per DynamoStore instance, so they should be divided
+// among the processes sharing a table.
+func WithCapacityLimit(readUnits, writeUnits float64) Option {
+	return func(s *DynamoStore) {
+		s.limiter = &capacityLimiter{
+			read:  newTokenBucket(readUnits),
+			write: newTokenBucket(writeUnits),
+		}
+	}
+}
+
+type capacityLimiter struct {
+	read  *tokenBucket
+	write *tokenBucket
+}
+
+// waitRead blocks until there is enough capacity for a strongly
+// consistent read of a single item.
+func (l *capacityLimiter) waitRead(ctx context.Context) (float64, error) {
+	if l == nil {
+		return 0, nil
+	}
+	return 1, l.read.wait(ctx, 1)
+}
+
+// waitReads blocks until there is enough capacity for strongly consistent
+// reads of n items.
+func (l *capacityLimiter) waitReads(ctx context.Context, n int) (float64, error) {
+	if l == nil {
+		return 0, nil
+	}
+	units := float64(n)
+	return units, l.read.wait(ctx, units)
+}
+
+// waitWrite blocks until there is enough capacity to write size bytes.
+func (l *capacityLimiter) waitWrite(ctx context.Context, size int) (float64, error) {
+	if l == nil {
+		return 0, nil
+	}
+	units := math.Ceil(float64(size) / 1024)
+	if units < 1 {
+		units = 1
+	}
+	return units, l.write.wait(ctx, units)
+}
+
+// consumedRead corrects the read estimate using the capacity DynamoDB
+// reports.
+func (l *capacityLimiter) consumedRead(estimate float64, cc *types.ConsumedCapacity) {
+	if l != nil && cc != nil && cc.CapacityUnits != nil {
+		l.read.adjust(*cc.CapacityUnits - estimate)
+	}
+}
+
+// consumedWrite corrects the write estimate using the capacity DynamoDB
+// reports.
+func (l *capacityLimiter) consumedWrite(estimate float64, cc *types.ConsumedCapacity) {
+	if l != nil && cc != nil && cc.CapacityUnits != nil {
+		l.write.adjust(*cc.CapacityUnits - estimate)
+	}
+}
+
+func (l *capacityLimiter) returnConsumedCapacity() types.ReturnConsumedCapacity {
+	if l == nil {
+		return ""
+	}
+	return types.ReturnConsumedCapacityTotal
+}
+
+// tokenBucket is a token bucket that allows debt. A request larger than the
+// available tokens is admitted once the bucket has refilled enough to pay
+// for it, so requests larger than the burst size don't block forever.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := math.Max(rate, 1)
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+}
+
+func (b *tokenBucket) wait(ctx context.Context, n float64) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.tokens -= n
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.adjust(-n)
+		return ctx.Err()
+	}
+}
+
+// adjust removes n tokens from the bucket, or returns them if n is negative.
+func (b *tokenBucket) adjust(n float64) {
+	if b == nil || n == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = math.Min(b.burst, b.tokens-n)
+}
+
+// itemSize approximates the size DynamoDB uses to calculate the capacity
+// consumed by writing item.
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, av := range item {
+		size += len(name)
+		switch v := av.(type) {
+		case *types.AttributeValueMemberB:
+			size += len(v.Value)
+		case *types.AttributeValueMemberN:
+			size += len(v.Value)
+		case *types.AttributeValueMemberS:
+			size += len(v.Value)
+		default:
+			size++
+		}
+	}
+	return size
+}
@@ -0,0 +1,62 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	require := require.New(t)
+
+	b := newTokenBucket(100)
+	ctx := context.Background()
+
+	// the initial burst is admitted immediately
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(b.wait(ctx, 1))
+	}
+	require.Less(int64(time.Since(start)), int64(100*time.Millisecond))
+
+	// further requests wait for the bucket to refill
+	start = time.Now()
+	require.NoError(b.wait(ctx, 25))
+	require.NoError(b.wait(ctx, 25))
+	require.GreaterOrEqual(int64(time.Since(start)), int64(400*time.Millisecond))
+
+	// requests are canceled with their context
+	b.adjust(1000)
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Equal(context.Canceled, b.wait(ctx, 1))
+}
+
+func TestCapacityLimiter(t *testing.T) {
+	require := require.New(t)
+
+	var l *capacityLimiter
+	units, err := l.waitWrite(context.Background(), 4096)
+	require.NoError(err)
+	require.Equal(0.0, units)
+	require.Equal(types.ReturnConsumedCapacity(""), l.returnConsumedCapacity())
+
+	l = &capacityLimiter{
+		read:  newTokenBucket(10),
+		write: newTokenBucket(0),
+	}
+	units, err = l.waitWrite(context.Background(), 4097)
+	require.NoError(err)
+	require.Equal(5.0, units)
+	require.Equal(types.ReturnConsumedCapacityTotal, l.returnConsumedCapacity())
+
+	units, err = l.waitRead(context.Background())
+	require.NoError(err)
+	require.Equal(1.0, units)
+	l.consumedRead(units, &types.ConsumedCapacity{CapacityUnits: aws.Float64(4)})
+	require.InDelta(6.0, l.read.tokens, 0.1)
+}
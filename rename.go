@@ -0,0 +1,164 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrSessionNotFound is returned by RenameToken when the session doesn't
+// exist or has expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrTokenInUse is returned by RenameToken when the new token already
+// belongs to a session.
+var ErrTokenInUse = errors.New("token is already in use")
+
+// RenameToken moves a session to a new token, such as when an application
+// rotates the token on login or another change of privilege to prevent
+// session fixation. The new session is written and the old one removed in a
+// single transaction, so the session is never lost or duplicated. The
+// transaction fails with ErrVersionConflict if the session changes after it
+// is read, and with ErrTokenInUse if the new token belongs to an unexpired
+// session.
+//
+// The old session is revoked rather than removed when WithRetention is used.
+// RenameToken fails when write-behind is enabled.
+func (s *DynamoStore) RenameToken(ctx context.Context, oldToken, newToken string) error {
+	if s.writeBehind != nil {
+		return errWriteBehind
+	}
+	units, err := s.limiter.waitRead(ctx)
+	if err != nil {
+		return err
+	}
+	result, err := s.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: oldToken,
+			},
+		},
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		return err
+	}
+	s.limiter.consumedRead(units, result.ConsumedCapacity)
+	s.stats.read(result.Item)
+
+	item, err := s.unmarshalItem(result.Item)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if item.Token == "" || item.Revoked != 0 || s.expiredAt(item.TTL, now) {
+		return ErrSessionNotFound
+	}
+	if denied, err := s.denied(ctx, oldToken); err != nil {
+		return err
+	} else if denied {
+		return ErrSessionNotFound
+	}
+	av, err := s.marshalItem(newToken, item.Data, item.TTL)
+	if err != nil {
+		return err
+	}
+	if created, ok := result.Item[createdAtAttribute]; ok && s.creationTime {
+		av[createdAtAttribute] = created
+	}
+
+	put := &types.Put{
+		TableName:           s.table,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(#token) OR #ttl < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#token": "token",
+			"#ttl":   s.ttlAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(now.Unix(), 10),
+			},
+		},
+	}
+	key := map[string]types.AttributeValue{
+		"token": &types.AttributeValueMemberS{
+			Value: oldToken,
+		},
+	}
+	condition := "#data = :data AND #ttl = :ttl"
+	names := map[string]string{
+		"#data": "Data",
+		"#ttl":  s.ttlAttribute,
+	}
+	values := map[string]types.AttributeValue{
+		":data": result.Item["Data"],
+		":ttl":  result.Item[s.ttlAttribute],
+	}
+	var remove types.TransactWriteItem
+	if s.retention <= 0 {
+		remove.Delete = &types.Delete{
+			TableName:                 s.table,
+			Key:                       key,
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}
+	} else {
+		condition += " AND attribute_not_exists(#revoked)"
+		names["#revoked"] = revokedAttribute
+		values[":now"] = put.ExpressionAttributeValues[":now"]
+		values[":retained"] = &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(now.Add(s.retention).Unix(), 10),
+		}
+		remove.Update = &types.Update{
+			TableName:                 s.table,
+			Key:                       key,
+			UpdateExpression:          aws.String("SET #revoked = :now, #ttl = :retained"),
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}
+	}
+
+	units, err = s.limiter.waitWrite(ctx, 2*itemSize(av))
+	if err != nil {
+		return err
+	}
+	output, err := s.svc.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems:          []types.TransactWriteItem{{Put: put}, remove},
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		var canceledErr *types.TransactionCanceledException
+		if errors.As(err, &canceledErr) {
+			reasons := canceledErr.CancellationReasons
+			if len(reasons) > 0 && aws.ToString(reasons[0].Code) == "ConditionalCheckFailed" {
+				return ErrTokenInUse
+			}
+			if len(reasons) > 1 && aws.ToString(reasons[1].Code) == "ConditionalCheckFailed" {
+				return ErrVersionConflict
+			}
+		}
+		return err
+	}
+	for i := range output.ConsumedCapacity {
+		s.limiter.consumedWrite(units, &output.ConsumedCapacity[i])
+		units = 0
+	}
+
+	s.forgetUnchanged(oldToken)
+	s.rememberDelete(oldToken)
+	s.forgetCached(oldToken)
+	s.rememberDeleted(oldToken)
+	s.publishInvalidation(ctx, oldToken)
+	s.recordEvent(ctx, AuditDelete, oldToken, item.Data)
+	s.recordEvent(ctx, AuditCreate, newToken, item.Data)
+	return nil
+}
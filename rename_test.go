@@ -0,0 +1,100 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestRenameToken(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithEncryption(&dynamostore.Keyring{
+			Current: "k1",
+			Keys:    map[string][]byte{"k1": make([]byte, 32)},
+		}),
+	)
+	expiry := now.Add(time.Hour)
+
+	// given
+	require.NoError(store.Commit("old", []byte("data"), expiry))
+	require.NoError(store.Commit("taken", []byte("other"), expiry))
+	require.NoError(store.Commit("expired", []byte("stale"), now.Add(-time.Hour)))
+
+	// when the session is renamed
+	err := store.RenameToken(ctx, "old", "new")
+
+	// then it is only found by its new token
+	require.NoError(err)
+	_, exists, err := store.Find("old")
+	require.NoError(err)
+	require.False(exists)
+	data, found, exists, err := store.FindWithExpiry(ctx, "new")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("data"), data)
+	require.True(expiry.Equal(found))
+
+	// when the new token is in use
+	err = store.RenameToken(ctx, "new", "taken")
+
+	// then nothing changes
+	require.Equal(dynamostore.ErrTokenInUse, err)
+	data, exists, err = store.Find("taken")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("other"), data)
+
+	// when the new token belongs to an expired session
+	err = store.RenameToken(ctx, "new", "expired")
+
+	// then the expired session is replaced
+	require.NoError(err)
+	data, exists, err = store.Find("expired")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("data"), data)
+
+	// when the old session doesn't exist
+	err = store.RenameToken(ctx, "missing", "another")
+
+	// then
+	require.Equal(dynamostore.ErrSessionNotFound, err)
+}
+
+func TestRenameTokenWithRetention(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithRetention(24*time.Hour),
+	)
+
+	// given
+	require.NoError(store.Commit("old", []byte("data"), now.Add(time.Hour)))
+
+	// when
+	require.NoError(store.RenameToken(ctx, "old", "new"))
+
+	// then the old session is retained, but revoked
+	details, err := store.Inspect(ctx, "old")
+	require.NoError(err)
+	require.NotNil(details)
+	require.Contains(details.Attributes, "Revoked")
+	require.True(now.Add(24 * time.Hour).Equal(details.Expiry))
+	_, exists, err := store.Find("old")
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(dynamostore.ErrSessionNotFound, store.RenameToken(ctx, "old", "other"))
+}
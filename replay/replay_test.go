@@ -0,0 +1,125 @@
+package replay_test
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/replay"
+)
+
+// stub responds the way DynamoDB would to a short sequence of requests.
+type stub struct{}
+
+func (stub) Do(req *http.Request) (*http.Response, error) {
+	body := "{}"
+	status := http.StatusOK
+	switch req.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		b, _ := ioutil.ReadAll(req.Body)
+		if strings.Contains(string(b), "missing") {
+			status = http.StatusBadRequest
+			body = `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException",` +
+				`"message":"not found"}`
+		} else {
+			body = `{"Item":{"token":{"S":"foo"},"Data":{"B":"YmFy"},"ttl":{"N":"4102444800"}}}`
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header: http.Header{
+			"Content-Type": {"application/x-amz-json-1.0"},
+			"X-Amz-Crc32":  {strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(body))), 10)},
+		},
+		Body:    ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		Request: req,
+	}, nil
+}
+
+func exercise(require *require.Assertions, store *dynamostore.DynamoStore) {
+	err := store.Commit("foo", []byte("bar"), time.Now().Add(time.Hour))
+	require.NoError(err)
+
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), data)
+
+	_, _, err = store.Find("missing")
+	var notFound *types.ResourceNotFoundException
+	require.True(errors.As(err, &notFound))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "fixture.json")
+
+	// given a recording
+	rec := replay.NewRecorder(stub{})
+	svc := dynamodb.NewFromConfig(aws.Config{
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", "token"),
+		HTTPClient:  rec,
+		Region:      "us-west-2",
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	})
+	exercise(require, dynamostore.New(svc))
+	require.Len(rec.Interactions(), 3)
+	require.NoError(rec.Save(fixture))
+
+	// when the recording is replayed
+	player, err := replay.Load(fixture)
+	require.NoError(err)
+	exercise(require, dynamostore.New(player.Client()))
+
+	// then every interaction is used
+	require.NoError(player.Done())
+	_, _, err = dynamostore.New(player.Client()).Find("foo")
+	require.Error(err)
+}
+
+func TestStrict(t *testing.T) {
+	require := require.New(t)
+
+	rec := replay.NewRecorder(stub{})
+	player := replay.NewPlayer(nil)
+	player.Strict = true
+
+	svc := dynamodb.NewFromConfig(aws.Config{
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", "token"),
+		HTTPClient:  rec,
+		Region:      "us-west-2",
+	})
+	_, _, err := dynamostore.New(svc).Find("foo")
+	require.NoError(err)
+
+	player = replay.NewPlayer(rec.Interactions())
+	player.Strict = true
+	_, _, err = dynamostore.New(player.Client()).Find("bar")
+	require.Error(err)
+	require.Error(player.Done())
+
+	player = replay.NewPlayer(rec.Interactions())
+	player.Strict = true
+	_, _, err = dynamostore.New(player.Client()).Find("foo")
+	require.NoError(err)
+	require.NoError(player.Done())
+}
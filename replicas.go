@@ -0,0 +1,188 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var errNoReplicas = errors.New("requires WithReplicas")
+
+// replicaPollInterval is how often AddReplicas checks whether a new replica
+// is active. Replicas usually take several minutes to create.
+var replicaPollInterval = 10 * time.Second
+
+// ReplicaError is returned by VerifyReplicas when the table isn't replicated
+// to every region given to WithReplicas.
+type ReplicaError struct {
+	// Missing lists the regions without a replica, and Inactive lists the
+	// regions with a replica that isn't active yet, or has failed.
+	Missing  []string
+	Inactive []string
+}
+
+func (e *ReplicaError) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing in "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Inactive) > 0 {
+		problems = append(problems, "inactive in "+strings.Join(e.Inactive, ", "))
+	}
+	return "table replicas are " + strings.Join(problems, " and ")
+}
+
+// WithReplicas describes the regions a global table is expected to be
+// replicated to, so multi-region deployments can be bootstrapped with
+// AddReplicas and checked with VerifyReplicas. The region of the table itself
+// may be included, and is ignored. CreateTable adds the replicas after
+// creating the table.
+//
+// DynamoDB requires global tables to use on-demand capacity, or provisioned
+// capacity with auto scaling, and enables a stream of new and old images.
+func WithReplicas(regions ...string) Option {
+	return func(s *DynamoStore) {
+		seen := make(map[string]bool, len(regions))
+		for _, region := range regions {
+			if region == "" || seen[region] {
+				s.invalid("WithReplicas requires distinct, non-empty regions")
+				return
+			}
+			seen[region] = true
+		}
+		if len(regions) < 1 {
+			s.invalid("WithReplicas requires at least one region")
+			return
+		}
+		s.replicas = regions
+	}
+}
+
+// AddReplicas replicates the table to each region given to WithReplicas that
+// doesn't already have a replica, one region at a time, and waits for each
+// replica to become active. Use a context with a deadline to limit how long
+// it waits. Existing replicas in other regions are left alone.
+func (s *DynamoStore) AddReplicas(ctx context.Context) error {
+	if len(s.replicas) < 1 {
+		return errNoReplicas
+	}
+	table, err := s.describeReplicas(ctx)
+	if err != nil {
+		return err
+	}
+	local := tableRegion(aws.ToString(table.TableArn))
+	for _, region := range s.replicas {
+		if region == local || findReplica(table, region) != nil {
+			continue
+		}
+		_, err := s.svc.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: s.table,
+			ReplicaUpdates: []types.ReplicationGroupUpdate{{
+				Create: &types.CreateReplicationGroupMemberAction{
+					RegionName: aws.String(region),
+				},
+			}},
+		}, s.optFns...)
+		if err != nil {
+			return err
+		}
+		if table, err = s.waitForReplica(ctx, region); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyReplicas checks that the table has an active replica in each region
+// given to WithReplicas, and returns a *ReplicaError if it doesn't.
+func (s *DynamoStore) VerifyReplicas(ctx context.Context) error {
+	if len(s.replicas) < 1 {
+		return errNoReplicas
+	}
+	table, err := s.describeReplicas(ctx)
+	if err != nil {
+		return err
+	}
+	local := tableRegion(aws.ToString(table.TableArn))
+	replicaErr := &ReplicaError{}
+	for _, region := range s.replicas {
+		if region == local {
+			continue
+		}
+		switch replica := findReplica(table, region); {
+		case replica == nil:
+			replicaErr.Missing = append(replicaErr.Missing, region)
+		case replica.ReplicaStatus != types.ReplicaStatusActive:
+			replicaErr.Inactive = append(replicaErr.Inactive, region)
+		}
+	}
+	if len(replicaErr.Missing) > 0 || len(replicaErr.Inactive) > 0 {
+		return replicaErr
+	}
+	return nil
+}
+
+func (s *DynamoStore) describeReplicas(ctx context.Context) (*types.TableDescription, error) {
+	result, err := s.svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Table, nil
+}
+
+// waitForReplica waits until the replica in region and the table are both
+// active, and returns the table's description.
+func (s *DynamoStore) waitForReplica(ctx context.Context, region string) (*types.TableDescription, error) {
+	for {
+		table, err := s.describeReplicas(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if replica := findReplica(table, region); replica != nil {
+			switch replica.ReplicaStatus {
+			case types.ReplicaStatusCreationFailed:
+				return nil, fmt.Errorf("creating replica in %s failed: %s",
+					region, aws.ToString(replica.ReplicaStatusDescription),
+				)
+			case types.ReplicaStatusActive:
+				if table.TableStatus == types.TableStatusActive {
+					return table, nil
+				}
+			}
+		}
+
+		timer := time.NewTimer(replicaPollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func findReplica(table *types.TableDescription, region string) *types.ReplicaDescription {
+	for i := range table.Replicas {
+		if aws.ToString(table.Replicas[i].RegionName) == region {
+			return &table.Replicas[i]
+		}
+	}
+	return nil
+}
+
+// tableRegion returns the region in a table's ARN, as returned by TableARN.
+func tableRegion(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[3]
+}
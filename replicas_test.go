@@ -0,0 +1,86 @@
+package dynamostore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestReplicas(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	client := fake.NewClient()
+	store := dynamostore.New(client,
+		dynamostore.WithoutSleep(),
+		dynamostore.WithReplicas("us-east-1", "us-west-2", "eu-west-1"),
+	)
+	require.NoError(store.Validate())
+	unreplicated := dynamostore.New(client, dynamostore.WithoutSleep())
+
+	// given a table in us-east-1
+	require.NoError(unreplicated.CreateTable())
+
+	// when it hasn't been replicated
+	err := store.VerifyReplicas(ctx)
+
+	// then every other region is missing
+	var replicaErr *dynamostore.ReplicaError
+	require.True(errors.As(err, &replicaErr))
+	require.Equal([]string{"us-west-2", "eu-west-1"}, replicaErr.Missing)
+	require.Empty(replicaErr.Inactive)
+	require.EqualError(err, "table replicas are missing in us-west-2, eu-west-1")
+
+	// when the replicas are added
+	require.NoError(store.AddReplicas(ctx))
+
+	// then they are active
+	require.NoError(store.VerifyReplicas(ctx))
+	info, err := store.DescribeTable()
+	require.NoError(err)
+	require.Equal(map[string]string{
+		"us-east-1": "ACTIVE",
+		"us-west-2": "ACTIVE",
+		"eu-west-1": "ACTIVE",
+	}, info.Replicas)
+
+	// when the replicas are added again
+	err = store.AddReplicas(ctx)
+
+	// then nothing changes
+	require.NoError(err)
+
+	// when the store doesn't expect replicas
+	// then
+	require.Error(unreplicated.AddReplicas(ctx))
+	require.Error(unreplicated.VerifyReplicas(ctx))
+}
+
+func TestCreateTableWithReplicas(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := dynamostore.New(fake.NewClient(), dynamostore.WithoutSleep(), dynamostore.WithReplicas("eu-west-1"))
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then
+	require.NoError(store.VerifyReplicas(ctx))
+}
+
+func TestWithReplicasValidation(t *testing.T) {
+	for _, regions := range [][]string{
+		{},
+		{""},
+		{"us-west-2", "us-west-2"},
+	} {
+		store := fake.New(dynamostore.WithReplicas(regions...))
+		require.Error(t, store.Validate(), regions)
+	}
+}
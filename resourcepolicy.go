@@ -0,0 +1,72 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var errNoResourcePolicy = errors.New("requires WithResourcePolicy")
+
+// WithResourcePolicy attaches a resource-based policy to the table when
+// CreateTable creates it, so cross-account access to a central session table
+// is provisioned along with the table. The policy is a JSON document, such
+// as one returned by CrossAccountPolicy. CreateTable doesn't change the
+// policy of a table that already exists; use UpdateResourcePolicy. Creating a
+// table with a policy also requires the dynamodb:PutResourcePolicy
+// permission.
+func WithResourcePolicy(policy []byte) Option {
+	return func(s *DynamoStore) {
+		if !json.Valid(policy) {
+			s.invalid("WithResourcePolicy requires a JSON policy document")
+			return
+		}
+		s.resourcePolicy = string(policy)
+	}
+}
+
+// UpdateResourcePolicy attaches the policy set by WithResourcePolicy to the
+// existing table, replacing any policy it already has.
+func (s *DynamoStore) UpdateResourcePolicy(ctx context.Context) error {
+	if s.resourcePolicy == "" {
+		return errNoResourcePolicy
+	}
+	result, err := s.svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutResourcePolicy(ctx, &dynamodb.PutResourcePolicyInput{
+		ResourceArn: result.Table.TableArn,
+		Policy:      aws.String(s.resourcePolicy),
+	}, s.optFns...)
+	return err
+}
+
+// CrossAccountPolicy returns a resource-based policy document, as indented
+// JSON, that lets the given principals use the session table, such as
+// application roles in other accounts. Principals are account IDs or the
+// ARNs of IAM roles or users.
+func CrossAccountPolicy(tableARN string, principals ...string) ([]byte, error) {
+	if tableARN == "" {
+		return nil, errors.New("missing table ARN")
+	}
+	if len(principals) < 1 {
+		return nil, errors.New("missing principals")
+	}
+	doc := &policyDocument{
+		Version: "2012-10-17",
+		Statement: []*policyStatement{{
+			Sid:       "CrossAccountSessionAccess",
+			Effect:    "Allow",
+			Principal: map[string][]string{"AWS": principals},
+			Action:    sessionActions(false),
+			Resource:  tableARN,
+		}},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
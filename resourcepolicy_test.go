@@ -0,0 +1,112 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+var tableARN = dynamostore.TableARN("us-east-1", "000000000000", dynamostore.DefaultTableName)
+
+func TestCrossAccountPolicy(t *testing.T) {
+	require := require.New(t)
+
+	arn := dynamostore.TableARN("us-east-1", "111111111111", "sessions")
+	policy, err := dynamostore.CrossAccountPolicy(arn, "222222222222", "arn:aws:iam::333333333333:role/app")
+	require.NoError(err)
+	require.JSONEq(`{
+	  "Version": "2012-10-17",
+	  "Statement": [{
+	    "Sid": "CrossAccountSessionAccess",
+	    "Effect": "Allow",
+	    "Principal": {"AWS": ["222222222222", "arn:aws:iam::333333333333:role/app"]},
+	    "Action": [
+	      "dynamodb:BatchGetItem",
+	      "dynamodb:DeleteItem",
+	      "dynamodb:GetItem",
+	      "dynamodb:PutItem",
+	      "dynamodb:UpdateItem"
+	    ],
+	    "Resource": "arn:aws:dynamodb:us-east-1:111111111111:table/sessions"
+	  }]
+	}`, string(policy))
+
+	_, err = dynamostore.CrossAccountPolicy("")
+	require.Error(err)
+	_, err = dynamostore.CrossAccountPolicy(arn)
+	require.Error(err)
+}
+
+func TestWithResourcePolicy(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	ctx := context.Background()
+	policy, err := dynamostore.CrossAccountPolicy(tableARN, "222222222222")
+	require.NoError(err)
+	client := fake.NewClient()
+	store := dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithResourcePolicy(policy))
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then the policy is attached to the new table
+	result, err := client.GetResourcePolicy(ctx, &dynamodb.GetResourcePolicyInput{
+		ResourceArn: aws.String(tableARN),
+	})
+	require.NoError(err)
+	require.JSONEq(string(policy), aws.ToString(result.Policy))
+
+	// when the template is rendered
+	template, err := store.CloudFormationTemplate()
+
+	// then it includes the policy
+	require.NoError(err)
+	require.Contains(string(template), `"CrossAccountSessionAccess"`)
+
+	// when the policy isn't JSON
+	// then
+	require.Error(dynamostore.New(client, dynamostore.WithResourcePolicy([]byte("{"))).Validate())
+}
+
+func TestUpdateResourcePolicy(t *testing.T) {
+	require := require.New(t)
+
+	// given a table created without a policy
+	ctx := context.Background()
+	client := fake.NewClient()
+	require.NoError(dynamostore.New(client, dynamostore.WithoutSleep()).CreateTable())
+	policy, err := dynamostore.CrossAccountPolicy(tableARN, "222222222222")
+	require.NoError(err)
+	store := dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithResourcePolicy(policy))
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then CreateTable doesn't attach it
+	_, err = client.GetResourcePolicy(ctx, &dynamodb.GetResourcePolicyInput{
+		ResourceArn: aws.String(tableARN),
+	})
+	require.Error(err)
+
+	// when
+	require.NoError(store.UpdateResourcePolicy(ctx))
+
+	// then
+	result, err := client.GetResourcePolicy(ctx, &dynamodb.GetResourcePolicyInput{
+		ResourceArn: aws.String(tableARN),
+	})
+	require.NoError(err)
+	require.JSONEq(string(policy), aws.ToString(result.Policy))
+
+	// when the option isn't used
+	// then
+	require.Error(dynamostore.New(client).UpdateResourcePolicy(ctx))
+}
@@ -0,0 +1,98 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// revokedAttribute stores when a session was deleted, when WithRetention is
+// used.
+const revokedAttribute = "Revoked"
+
+// errRevoked is returned by write when the session has been revoked, or the
+// token is on the denylist.
+var errRevoked = errors.New("session has been revoked")
+
+// WithRetention makes Delete keep sessions for the given period instead of
+// removing them, for applications that must retain evidence of past
+// sessions. Deleted sessions are marked as revoked, and their expiry is
+// moved to the end of the retention period, so they are removed by
+// DynamoDB's TTL process and by DeleteExpired afterwards. Revoked sessions
+// are never found, and commits to them are dropped without an error, so
+// their data can't be changed.
+//
+// Sessions removed by DeleteBySubject are retained too. Sessions that expire
+// without being deleted are not retained. WithRetention can't be used with
+// WithOptimisticLocking.
+func WithRetention(period time.Duration) Option {
+	return func(s *DynamoStore) {
+		if period <= 0 {
+			s.invalid("WithRetention requires a positive period")
+			return
+		}
+		s.retention = period
+	}
+}
+
+// requireNotRevoked makes input conditional on the stored session not having
+// been revoked, in addition to any existing condition.
+func (s *DynamoStore) requireNotRevoked(input *dynamodb.PutItemInput) {
+	condition := "attribute_not_exists(#revoked)"
+	if input.ConditionExpression != nil {
+		condition = "(" + *input.ConditionExpression + ") AND " + condition
+	}
+	input.ConditionExpression = aws.String(condition)
+	if input.ExpressionAttributeNames == nil {
+		input.ExpressionAttributeNames = map[string]string{}
+	}
+	input.ExpressionAttributeNames["#revoked"] = revokedAttribute
+}
+
+// revokeItem marks a session as revoked, and moves its expiry to the end of
+// the retention period. The attributes of the session before it was revoked
+// are returned when returnValues is ALL_OLD. Sessions that don't exist or
+// were already revoked are left unchanged.
+func (s *DynamoStore) revokeItem(
+	ctx context.Context, token string, returnValues types.ReturnValue,
+) (*dynamodb.UpdateItemOutput, error) {
+	now := s.now()
+	result, err := s.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+		UpdateExpression:    aws.String("SET #revoked = :now, #ttl = :ttl"),
+		ConditionExpression: aws.String("attribute_exists(#token) AND attribute_not_exists(#revoked)"),
+		ExpressionAttributeNames: map[string]string{
+			"#token":   "token",
+			"#revoked": revokedAttribute,
+			"#ttl":     s.ttlAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(now.Unix(), 10),
+			},
+			":ttl": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(now.Add(s.retention).Unix(), 10),
+			},
+		},
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		ReturnValues:           returnValues,
+	}, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package dynamostore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithRetention(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithRetention(30*24*time.Hour),
+	)
+	require.NoError(store.Validate())
+	expiry := now.Add(time.Hour)
+
+	// given
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	// when the session is deleted
+	existed, err := store.DeleteAndCheck(ctx, "foo")
+
+	// then it can't be found, but is retained
+	require.NoError(err)
+	require.True(existed)
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+
+	details, err := store.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.NotNil(details)
+	require.Equal([]byte("bar"), details.Data)
+	require.True(now.Add(30 * 24 * time.Hour).Equal(details.Expiry))
+	require.Contains(details.Attributes, "Revoked")
+	n, err := store.Export(ctx, ioutil.Discard)
+	require.NoError(err)
+	require.Zero(n)
+
+	// when the session is committed or deleted again
+	require.NoError(store.Commit("foo", []byte("changed"), expiry))
+	existed, err = store.DeleteAndCheck(ctx, "foo")
+
+	// then the retained session is unchanged
+	require.NoError(err)
+	require.False(existed)
+	details, err = store.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.Equal([]byte("bar"), details.Data)
+	require.True(now.Add(30 * 24 * time.Hour).Equal(details.Expiry))
+
+	// when a session that doesn't exist is deleted
+	require.NoError(store.Delete("missing"))
+
+	// then nothing is retained
+	details, err = store.Inspect(ctx, "missing")
+	require.NoError(err)
+	require.Nil(details)
+
+	// when combined with optimistic locking
+	store = fake.New(dynamostore.WithRetention(time.Hour), dynamostore.WithOptimisticLocking())
+
+	// then the configuration is rejected
+	require.Error(store.Validate())
+}
@@ -0,0 +1,52 @@
+package dynamostore
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// retryOptions override how the DynamoDB client retries failed requests.
+type retryOptions struct {
+	retryer     aws.Retryer
+	maxAttempts int
+}
+
+// WithRetryer sets the retryer used for every request the store sends,
+// instead of the DynamoDB client's retryer. Use aws.NopRetryer{} to disable
+// retries, such as when the caller already retries failed requests.
+func WithRetryer(retryer aws.Retryer) Option {
+	return func(s *DynamoStore) {
+		s.retry.retryer = retryer
+	}
+}
+
+// WithMaxAttempts limits the number of attempts for each request the store
+// sends, including the first attempt, without otherwise changing the
+// client's retryer or the one set by WithRetryer. The AWS SDK's default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(s *DynamoStore) {
+		if n < 1 {
+			s.invalid("WithMaxAttempts requires at least 1 attempt")
+			return
+		}
+		s.retry.maxAttempts = n
+	}
+}
+
+// enabled returns true if the client's retry behavior should be changed.
+func (r retryOptions) enabled() bool {
+	return r.retryer != nil || r.maxAttempts > 0
+}
+
+func (r retryOptions) apply(o *dynamodb.Options) {
+	if r.retryer != nil {
+		o.Retryer = r.retryer
+	}
+	if r.maxAttempts > 0 {
+		if o.Retryer == nil {
+			o.Retryer = retry.NewStandard()
+		}
+		o.Retryer = retry.AddWithMaxAttempts(o.Retryer, r.maxAttempts)
+	}
+}
@@ -0,0 +1,60 @@
+package dynamostore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// failingHTTPClient responds to every request with an InternalServerError.
+type failingHTTPClient struct {
+	requests int32
+}
+
+func (c *failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	body := []byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"oops"}`)
+	return &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{"Content-Type": {"application/x-amz-json-1.0"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRetryOptions(t *testing.T) {
+	noBackoff := retry.NewStandard(func(o *retry.StandardOptions) {
+		o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) {
+			return 0, nil
+		})
+	})
+
+	for _, tc := range []struct {
+		name     string
+		opts     []Option
+		expected int32
+	}{
+		{"default", []Option{WithRetryer(noBackoff)}, 3},
+		{"disabled", []Option{WithRetryer(aws.NopRetryer{})}, 1},
+		{"max attempts", []Option{WithMaxAttempts(5), WithRetryer(noBackoff)}, 5},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			httpClient := &failingHTTPClient{}
+			store := NewFromConfig(stubConfig(httpClient), tc.opts...)
+
+			_, _, err := store.Find("foo")
+			require.Error(err)
+			require.Equal(tc.expected, atomic.LoadInt32(&httpClient.requests))
+		})
+	}
+}
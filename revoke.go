@@ -0,0 +1,133 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultRevocationTTL is how long the markers written by Revoke are kept,
+// unless WithRevocationMarkers is given another duration.
+const DefaultRevocationTTL = 15 * time.Minute
+
+var errNoRevocation = errors.New("requires WithRevocationMarkers or WithRetention")
+
+// WithRevocationMarkers enables Revoke, which replaces a session with a
+// marker that is kept for ttl. While the marker exists, the session can't
+// be found, and commits to it are dropped without an error, so requests
+// that were in flight when it was revoked can't bring it back. ttl should
+// be longer than any request, and longer than the ttl given to WithCache.
+// Zero uses DefaultRevocationTTL.
+//
+// Commits check for a marker with a condition expression, which doesn't
+// cost any additional capacity. WithRevocationMarkers can't be used with
+// WithOptimisticLocking.
+func WithRevocationMarkers(ttl time.Duration) Option {
+	return func(s *DynamoStore) {
+		if ttl < 0 {
+			s.invalid("WithRevocationMarkers requires a ttl of zero or more")
+			return
+		}
+		if ttl == 0 {
+			ttl = DefaultRevocationTTL
+		}
+		s.revocationTTL = ttl
+	}
+}
+
+// tombstones returns true if revoked sessions are marked instead of
+// removed, so writes must check for them.
+func (s *DynamoStore) tombstones() bool {
+	return s.retention > 0 || s.revocationTTL > 0
+}
+
+// Revoke forces a logout. The session is deleted, and a marker that stops
+// it from being found or committed again is left in its place, so in-flight
+// requests and caches can't resurrect it while the logout propagates. The
+// session is also dropped from the caches of other stores when
+// WithCacheInvalidation is used, and added to the denylist when WithDenylist
+// is used.
+//
+// The marker is kept for the ttl given to WithRevocationMarkers, or, with
+// WithRetention, the retention period. Revoke fails when write-behind is
+// enabled.
+func (s *DynamoStore) Revoke(ctx context.Context, token string) error {
+	if !s.tombstones() {
+		return errNoRevocation
+	}
+	if s.writeBehind != nil {
+		return errWriteBehind
+	}
+	if token == "" {
+		return nil
+	}
+	s.usedToken(token, true)
+	s.forgetUnchanged(token)
+	if s.coalescer != nil {
+		s.coalescer.cancel(token)
+	}
+
+	now := s.now()
+	until := now.Add(s.retention)
+	if s.retention > 0 {
+		if _, err := s.deleteItem(ctx, token, types.ReturnValueNone); err != nil {
+			return err
+		}
+	} else {
+		until = now.Add(s.revocationTTL)
+		if err := s.putRevocationMarker(ctx, token, now, until); err != nil {
+			return err
+		}
+	}
+	if s.denylist != nil {
+		return s.Deny(ctx, token, until)
+	}
+	return nil
+}
+
+// putRevocationMarker replaces a session with a revocation marker.
+func (s *DynamoStore) putRevocationMarker(ctx context.Context, token string, now, until time.Time) error {
+	av := map[string]types.AttributeValue{
+		"token": &types.AttributeValueMemberS{Value: token},
+		"Data":  &types.AttributeValueMemberNULL{Value: true},
+		s.ttlAttribute: &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(until.Unix(), 10),
+		},
+		revokedAttribute: &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(now.Unix(), 10),
+		},
+	}
+	units, err := s.limiter.waitWrite(ctx, itemSize(av))
+	if err != nil {
+		return err
+	}
+	result, err := s.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:                   av,
+		TableName:              s.table,
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+		ReturnValues:           s.eventReturnValues(types.ReturnValueNone, types.ReturnValueAllOld),
+	}, s.optFns...)
+	if err != nil {
+		return err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.rememberDelete(token)
+	s.forgetCached(token)
+	s.rememberDeleted(token)
+	s.publishInvalidation(ctx, token)
+	if len(result.Attributes) > 0 && s.recordsEvents() {
+		var data []byte
+		if item, err := s.unmarshalItem(result.Attributes); err == nil {
+			if item.Revoked != 0 {
+				return nil
+			}
+			data = item.Data
+		}
+		s.recordEvent(ctx, AuditDelete, token, data)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package dynamostore_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestRevoke(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	opts := []dynamostore.Option{
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithRevocationMarkers(0),
+	}
+	store := dynamostore.New(svc, opts...)
+	require.NoError(store.Validate())
+	expiry := now.Add(time.Hour)
+
+	// given
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	// when the session is revoked
+	require.NoError(store.Revoke(ctx, "foo"))
+
+	// then only a marker is left
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+	details, err := store.Inspect(ctx, "foo")
+	require.NoError(err)
+	require.Nil(details.Data)
+	require.Contains(details.Attributes, "Revoked")
+	require.True(now.Add(dynamostore.DefaultRevocationTTL).Equal(details.Expiry))
+
+	// when a request that was in flight commits the session
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	other := dynamostore.New(svc, opts...)
+	_, _, _, err = other.CommitAndReturnPrevious(ctx, "foo", []byte("baz"), expiry)
+	require.NoError(err)
+
+	// then it stays revoked
+	_, exists, err = other.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+
+	// when the marker expires
+	now = now.Add(dynamostore.DefaultRevocationTTL + time.Second)
+	n, err := store.DeleteExpired(ctx, 1)
+	require.NoError(err)
+	require.Equal(1, n)
+
+	// then the token can be used again
+	require.NoError(store.Commit("foo", []byte("qux"), now.Add(time.Hour)))
+	data, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("qux"), data)
+}
+
+func TestImportSkipsRevoked(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	store := fake.New(dynamostore.WithRevocationMarkers(0))
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.NoError(store.Commit("qux", []byte("bar"), expiry))
+	var buf bytes.Buffer
+	_, err := store.Export(ctx, &buf)
+	require.NoError(err)
+
+	// given a revoked session
+	require.NoError(store.Revoke(ctx, "foo"))
+
+	// when an old export is imported
+	result, err := store.Import(ctx, &buf)
+
+	// then the revoked session isn't brought back
+	require.NoError(err)
+	require.Equal(1, result.Imported)
+	require.Equal(1, result.Revoked)
+	_, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+	_, exists, err = store.Find("qux")
+	require.NoError(err)
+	require.True(exists)
+}
+
+func TestRevokeWithCache(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	svc := fake.NewClient()
+	svc.AddTable(dynamostore.DefaultTableName, "token")
+	bus := dynamostore.NewMemoryBus()
+	opts := []dynamostore.Option{
+		dynamostore.WithCache(10, time.Hour),
+		dynamostore.WithCacheInvalidation(bus),
+		dynamostore.WithRevocationMarkers(time.Hour),
+	}
+	a := dynamostore.New(svc, opts...)
+	b := dynamostore.New(svc, opts...)
+
+	// given a session cached by another store
+	require.NoError(a.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)))
+	_, exists, err := b.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+
+	// when
+	require.NoError(a.Revoke(ctx, "foo"))
+
+	// then
+	_, exists, err = b.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+}
+
+func TestRevokeRequiresMarkers(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New()
+	require.EqualError(store.Revoke(context.Background(), "foo"),
+		"requires WithRevocationMarkers or WithRetention")
+
+	store = fake.New(dynamostore.WithRevocationMarkers(0), dynamostore.WithOptimisticLocking())
+	require.EqualError(store.Validate(),
+		"invalid DynamoStore configuration: WithRevocationMarkers can't be used with WithOptimisticLocking")
+}
@@ -0,0 +1,52 @@
+// Package s3export streams session exports to Amazon S3.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the S3 client.
+package s3export
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Export streams an export of store to an S3 object, and returns the number
+// of sessions written. See dynamostore.DynamoStore.Export.
+func Export(
+	ctx context.Context, store *dynamostore.DynamoStore, client manager.UploadAPIClient, bucket, key string,
+) (int, error) {
+	pr, pw := io.Pipe()
+
+	type result struct {
+		n   int
+		err error
+	}
+	exported := make(chan result, 1)
+	go func() {
+		n, err := store.Export(ctx, pw)
+		pw.CloseWithError(err)
+		exported <- result{n, err}
+	}()
+
+	_, err := manager.NewUploader(client).Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        pr,
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	pr.CloseWithError(err)
+
+	r := <-exported
+	if r.err != nil {
+		return 0, r.err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return r.n, nil
+}
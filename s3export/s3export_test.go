@@ -0,0 +1,82 @@
+package s3export_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/s3export"
+)
+
+// fakeS3 records objects uploaded with PutObject. Exports small enough to
+// fit in one part don't use multipart uploads.
+type fakeS3 struct {
+	manager.UploadAPIClient
+	input *s3.PutObjectInput
+	body  []byte
+	err   error
+}
+
+func (f *fakeS3) PutObject(
+	ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options),
+) (*s3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, err := ioutil.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.input = params
+	f.body = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestExport(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	ctx := context.Background()
+	store := fake.New()
+	expiry := time.Now().Add(time.Hour)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.NoError(store.Commit("baz", []byte("qux"), expiry))
+	client := &fakeS3{}
+
+	// when
+	n, err := s3export.Export(ctx, store, client, "backups", "sessions.jsonl")
+
+	// then
+	require.NoError(err)
+	require.Equal(2, n)
+	require.Equal("backups", aws.ToString(client.input.Bucket))
+	require.Equal("sessions.jsonl", aws.ToString(client.input.Key))
+	require.Equal("application/x-ndjson", aws.ToString(client.input.ContentType))
+
+	tokens := map[string]bool{}
+	lines := bufio.NewScanner(bytes.NewReader(client.body))
+	for lines.Scan() {
+		var record dynamostore.ExportRecord
+		require.NoError(json.Unmarshal(lines.Bytes(), &record))
+		tokens[record.Token] = true
+	}
+	require.Equal(map[string]bool{"foo": true, "baz": true}, tokens)
+
+	// when the upload fails
+	_, err = s3export.Export(ctx, store, &fakeS3{err: errors.New("denied")}, "backups", "sessions.jsonl")
+
+	// then
+	require.Error(err)
+}
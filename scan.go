@@ -0,0 +1,85 @@
+package dynamostore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// scanItems calls fn for every item in the table, including expired items
+// that DynamoDB hasn't removed yet.
+func (s *DynamoStore) scanItems(ctx context.Context, fn func(*sessionItem) error) error {
+	input := &dynamodb.ScanInput{}
+	return s.scan(ctx, input, func(av map[string]types.AttributeValue) error {
+		item, err := s.unmarshalItem(av)
+		if err != nil {
+			return err
+		}
+		return fn(item)
+	})
+}
+
+// scan pages through the items matched by input, calling fn for each. The
+// table name, start key, and capacity reporting are managed by scan.
+func (s *DynamoStore) scan(
+	ctx context.Context, input *dynamodb.ScanInput, fn func(map[string]types.AttributeValue) error,
+) error {
+	params := *input
+	params.TableName = s.table
+	params.ReturnConsumedCapacity = s.limiter.returnConsumedCapacity()
+	for {
+		units, err := s.limiter.waitRead(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := s.svc.Scan(ctx, &params, s.optFns...)
+		if err != nil {
+			return err
+		}
+		s.limiter.consumedRead(units, result.ConsumedCapacity)
+
+		for _, av := range result.Items {
+			if err := fn(av); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) < 1 {
+			return nil
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// query pages through the items matched by input, calling fn for each. The
+// table name, start key, and capacity reporting are managed by query.
+func (s *DynamoStore) query(
+	ctx context.Context, input *dynamodb.QueryInput, fn func(map[string]types.AttributeValue) error,
+) error {
+	params := *input
+	params.TableName = s.table
+	params.ReturnConsumedCapacity = s.limiter.returnConsumedCapacity()
+	for {
+		units, err := s.limiter.waitRead(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := s.svc.Query(ctx, &params, s.optFns...)
+		if err != nil {
+			return err
+		}
+		s.limiter.consumedRead(units, result.ConsumedCapacity)
+
+		for _, av := range result.Items {
+			if err := fn(av); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) < 1 {
+			return nil
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
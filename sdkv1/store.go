@@ -0,0 +1,233 @@
+// Package sdkv1 implements the dynamostore API on top of version 1 of the
+// AWS SDK for Go, for applications that haven't migrated to version 2 yet.
+// Sessions are stored using the same item layout as dynamostore, so the two
+// implementations can share a table during a migration.
+package sdkv1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/sjansen/dynamostore"
+)
+
+var _ scs.Store = &DynamoStore{}
+
+// DynamoStore represents the session store.
+type DynamoStore struct {
+	svc   dynamodbiface.DynamoDBAPI
+	table *string
+}
+
+type sessionItem struct {
+	Token string `dynamodbav:"token,string"`
+	Data  []byte
+	TTL   time.Time `dynamodbav:"ttl,unixtime"`
+}
+
+// New creates a DynamoStore instance using default values.
+func New(svc dynamodbiface.DynamoDBAPI) *DynamoStore {
+	return NewWithTableName(svc, dynamostore.DefaultTableName)
+}
+
+// NewWithTableName create a DynamoStore instance, overriding the default
+// table name.
+func NewWithTableName(svc dynamodbiface.DynamoDBAPI, table string) *DynamoStore {
+	return &DynamoStore{
+		svc:   svc,
+		table: aws.String(table),
+	}
+}
+
+// Find returns the data for a given session token from the DynamoStore instance.
+// If the session token is not found or is expired, the returned exists flag
+// will be set to false.
+func (s *DynamoStore) Find(token string) (b []byte, exists bool, err error) {
+	ctx := context.Background()
+	item, err := s.getItem(ctx, token)
+	switch {
+	case err != nil:
+		return nil, false, err
+	case item.Token == "":
+		return nil, false, nil
+	case item.TTL.Before(time.Now()):
+		return nil, false, nil
+	}
+	return item.Data, true, nil
+}
+
+// Commit adds a session token and data to the DynamoStore instance with the
+// given expiry time. If the session token already exists then the data and
+// expiry time are updated.
+func (s *DynamoStore) Commit(token string, data []byte, expiry time.Time) error {
+	ctx := context.Background()
+	return s.setItem(ctx, token, data, expiry)
+}
+
+// Delete removes a session token and corresponding data from the DynamoStore
+// instance.
+func (s *DynamoStore) Delete(token string) error {
+	ctx := context.Background()
+	if token == "" {
+		return nil
+	}
+	return s.deleteItem(ctx, token)
+}
+
+// CreateTable creates the session store table, if it doesn't already exist.
+// This is only intended as a convenience function to make development and
+// testing easier. It is not intended for use in production.
+func (s *DynamoStore) CreateTable() error {
+	ctx := context.Background()
+	if ok, err := s.checkForTable(ctx); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	if err := s.createTable(ctx); err != nil {
+		return err
+	}
+	if err := s.waitForTable(ctx); err != nil {
+		return err
+	}
+	return s.updateTTL(ctx)
+}
+
+func isNotFound(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException
+}
+
+func (s *DynamoStore) checkForTable(ctx context.Context) (bool, error) {
+	result, err := s.svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	switch status := aws.StringValue(result.Table.TableStatus); status {
+	case dynamodb.TableStatusCreating:
+		return true, s.waitForTable(ctx)
+	case dynamodb.TableStatusDeleting:
+		return false, dynamostore.ErrDeleteInProgress
+	case dynamodb.TableStatusActive, dynamodb.TableStatusUpdating:
+		return true, nil
+	default:
+		return false, errors.New("unrecognized table status: " + status)
+	}
+}
+
+func (s *DynamoStore) createTable(ctx context.Context) error {
+	_, err := s.svc.CreateTableWithContext(ctx, &dynamodb.CreateTableInput{
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		TableName:   s.table,
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("token"),
+				KeyType:       aws.String(dynamodb.KeyTypeHash),
+			},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("token"),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			},
+		},
+	})
+	return err
+}
+
+func (s *DynamoStore) deleteItem(ctx context.Context, token string) error {
+	_, err := s.svc.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: s.table,
+		Key: map[string]*dynamodb.AttributeValue{
+			"token": {S: aws.String(token)},
+		},
+	})
+	return err
+}
+
+func (s *DynamoStore) getItem(ctx context.Context, token string) (*sessionItem, error) {
+	result, err := s.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		TableName:      s.table,
+		Key: map[string]*dynamodb.AttributeValue{
+			"token": {S: aws.String(token)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item := &sessionItem{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (s *DynamoStore) setItem(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	av, err := dynamodbattribute.MarshalMap(&sessionItem{
+		Token: token,
+		Data:  data,
+		TTL:   expiry,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		Item:      av,
+		TableName: s.table,
+	})
+	return err
+}
+
+func (s *DynamoStore) updateTTL(ctx context.Context) error {
+	_, err := s.svc.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: s.table,
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String("ttl"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+func (s *DynamoStore) waitForTable(ctx context.Context) error {
+	describeTable := &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}
+	for i := 0; i < 60; i++ {
+		time.Sleep(1 * time.Second)
+		result, err := s.svc.DescribeTableWithContext(ctx, describeTable)
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		switch aws.StringValue(result.Table.TableStatus) {
+		case dynamodb.TableStatusCreating:
+			// continue loop
+		case dynamodb.TableStatusDeleting:
+			return dynamostore.ErrDeleteInProgress
+		case dynamodb.TableStatusActive, dynamodb.TableStatusUpdating:
+			return nil
+		}
+	}
+	return dynamostore.ErrCreateTimedOut
+}
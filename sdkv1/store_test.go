@@ -0,0 +1,70 @@
+package sdkv1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (c *fakeClient) DeleteItemWithContext(
+	ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option,
+) (*dynamodb.DeleteItemOutput, error) {
+	delete(c.items, aws.StringValue(input.Key["token"].S))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (c *fakeClient) GetItemWithContext(
+	ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option,
+) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{
+		Item: c.items[aws.StringValue(input.Key["token"].S)],
+	}, nil
+}
+
+func (c *fakeClient) PutItemWithContext(
+	ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option,
+) (*dynamodb.PutItemOutput, error) {
+	c.items[aws.StringValue(input.Item["token"].S)] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+
+	svc := &fakeClient{items: map[string]map[string]*dynamodb.AttributeValue{}}
+	store := New(svc)
+
+	expiry := time.Now().Add(time.Minute)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	// the item layout matches dynamostore
+	item := svc.items["foo"]
+	require.Equal("foo", aws.StringValue(item["token"].S))
+	require.Equal([]byte("bar"), item["Data"].B)
+	require.NotNil(item["ttl"].N)
+
+	actual, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte("bar"), actual)
+
+	require.NoError(store.Commit("expired", []byte("bar"), time.Now().Add(-time.Minute)))
+	_, exists, err = store.Find("expired")
+	require.NoError(err)
+	require.False(exists)
+
+	require.NoError(store.Delete("foo"))
+	_, exists, err = store.Find("foo")
+	require.NoError(err)
+	require.False(exists)
+}
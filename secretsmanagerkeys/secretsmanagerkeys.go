@@ -0,0 +1,58 @@
+// Package secretsmanagerkeys reads session encryption keys and token
+// peppers from AWS Secrets Manager, for use with dynamostore.WithKeyProvider
+// and dynamostore.WithTokenPepper.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the Secrets Manager client.
+package secretsmanagerkeys
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the Secrets Manager API used by KeyProvider and
+// Pepper. It is implemented by *secretsmanager.Client.
+type Client interface {
+	GetSecretValue(
+		context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options),
+	) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// KeyProvider returns a dynamostore.KeyProvider that reads a keyring, in the
+// format accepted by dynamostore.ParseKeyring, from the current version of a
+// secret.
+func KeyProvider(client Client, secretID string) dynamostore.KeyProvider {
+	return dynamostore.KeyProviderFunc(func(ctx context.Context) (*dynamostore.Keyring, error) {
+		value, err := getSecret(ctx, client, secretID)
+		if err != nil {
+			return nil, err
+		}
+		return dynamostore.ParseKeyring(value)
+	})
+}
+
+// Pepper returns a dynamostore.PepperProvider that reads the pepper from the
+// current version of a secret.
+func Pepper(client Client, secretID string) dynamostore.PepperProvider {
+	return dynamostore.PepperProviderFunc(func(ctx context.Context) ([]byte, error) {
+		return getSecret(ctx, client, secretID)
+	})
+}
+
+func getSecret(ctx context.Context, client Client, secretID string) ([]byte, error) {
+	result, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.SecretString != nil {
+		return []byte(*result.SecretString), nil
+	}
+	return result.SecretBinary, nil
+}
@@ -0,0 +1,78 @@
+package secretsmanagerkeys_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/secretsmanagerkeys"
+)
+
+type fakeSecrets struct {
+	value  *secretsmanager.GetSecretValueOutput
+	err    error
+	secret string
+}
+
+func (f *fakeSecrets) GetSecretValue(
+	ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options),
+) (*secretsmanager.GetSecretValueOutput, error) {
+	f.secret = aws.ToString(params.SecretId)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.value, nil
+}
+
+func TestKeyProvider(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	key := bytes.Repeat([]byte("1"), 32)
+	keyring := `{"current": "v1", "keys": {"v1": "` + base64.StdEncoding.EncodeToString(key) + `"}}`
+
+	// when the secret is a string
+	secrets := &fakeSecrets{value: &secretsmanager.GetSecretValueOutput{SecretString: aws.String(keyring)}}
+	keys, err := secretsmanagerkeys.KeyProvider(secrets, "session-keys").Keyring(ctx)
+
+	// then
+	require.NoError(err)
+	require.Equal("session-keys", secrets.secret)
+	require.Equal("v1", keys.Current)
+	require.Equal(key, keys.Keys["v1"])
+
+	// when the secret is binary
+	secrets = &fakeSecrets{value: &secretsmanager.GetSecretValueOutput{SecretBinary: []byte(keyring)}}
+	keys, err = secretsmanagerkeys.KeyProvider(secrets, "session-keys").Keyring(ctx)
+
+	// then
+	require.NoError(err)
+	require.Equal("v1", keys.Current)
+
+	// when the keyring is invalid
+	secrets = &fakeSecrets{value: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("{}")}}
+	_, err = secretsmanagerkeys.KeyProvider(secrets, "session-keys").Keyring(ctx)
+
+	// then
+	require.Error(err)
+}
+
+func TestPepper(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	secrets := &fakeSecrets{value: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("pepper")}}
+	pepper, err := secretsmanagerkeys.Pepper(secrets, "session-pepper").Pepper(ctx)
+	require.NoError(err)
+	require.Equal("session-pepper", secrets.secret)
+	require.Equal([]byte("pepper"), pepper)
+
+	_, err = secretsmanagerkeys.Pepper(&fakeSecrets{err: errors.New("denied")}, "session-pepper").Pepper(ctx)
+	require.EqualError(err, "denied")
+}
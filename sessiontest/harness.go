@@ -0,0 +1,207 @@
+// Package sessiontest runs realistic session flows against a store, through
+// scs.SessionManager and a real HTTP server. It lets applications check, in
+// their own test suites, that their store configuration works end to end:
+//
+//	h := sessiontest.New(dynamostore.New(svc))
+//	defer h.Close()
+//
+//	c := h.NewClient()
+//	err := c.Login("alice")
+//	user, err := c.WhoAmI()
+//	err = c.Logout()
+package sessiontest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// ErrUnauthorized is returned by Client methods that require a logged in
+// user when there isn't one.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Harness is an HTTP server that stores sessions using scs.SessionManager.
+//
+// It serves the following endpoints:
+//
+//	POST /login?user=name  renews the session token and stores the user
+//	POST /logout           destroys the session
+//	GET  /whoami           returns the logged in user
+//	POST /visit            increments and returns a per-session counter
+type Harness struct {
+	Manager *scs.SessionManager
+	Server  *httptest.Server
+}
+
+// New starts a Harness using store. The SessionManager may be customized
+// before the first request.
+func New(store scs.Store) *Harness {
+	h := &Harness{
+		Manager: scs.New(),
+	}
+	h.Manager.Store = store
+	h.Server = httptest.NewServer(h.Handler())
+	return h
+}
+
+// Close shuts down the server.
+func (h *Harness) Close() {
+	h.Server.Close()
+}
+
+// Handler returns the harness's HTTP handler, wrapped by the
+// SessionManager's LoadAndSave middleware.
+func (h *Harness) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", h.login)
+	mux.HandleFunc("/logout", h.logout)
+	mux.HandleFunc("/whoami", h.whoami)
+	mux.HandleFunc("/visit", h.visit)
+	return h.Manager.LoadAndSave(mux)
+}
+
+func (h *Harness) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "missing user", http.StatusBadRequest)
+		return
+	}
+	if err := h.Manager.RenewToken(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.Manager.Put(r.Context(), "user", user)
+	fmt.Fprint(w, user)
+}
+
+func (h *Harness) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.Manager.Destroy(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Harness) whoami(w http.ResponseWriter, r *http.Request) {
+	user := h.Manager.GetString(r.Context(), "user")
+	if user == "" {
+		http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	fmt.Fprint(w, user)
+}
+
+func (h *Harness) visit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	n := h.Manager.GetInt(r.Context(), "visits") + 1
+	h.Manager.Put(r.Context(), "visits", n)
+	fmt.Fprint(w, n)
+}
+
+// Client is a browser-like client of a Harness, with its own cookie jar.
+type Client struct {
+	harness *Harness
+	http    *http.Client
+}
+
+// NewClient returns a Client with no session.
+func (h *Harness) NewClient() *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		harness: h,
+		http: &http.Client{
+			Jar: jar,
+		},
+	}
+}
+
+// Login starts a new session for user.
+func (c *Client) Login(user string) error {
+	_, err := c.do(http.MethodPost, "/login?user="+url.QueryEscape(user))
+	return err
+}
+
+// Logout destroys the current session.
+func (c *Client) Logout() error {
+	_, err := c.do(http.MethodPost, "/logout")
+	return err
+}
+
+// WhoAmI returns the logged in user, or ErrUnauthorized.
+func (c *Client) WhoAmI() (string, error) {
+	return c.do(http.MethodGet, "/whoami")
+}
+
+// Visit increments the session's visit counter, and returns the new count.
+func (c *Client) Visit() (int, error) {
+	body, err := c.do(http.MethodPost, "/visit")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(body)
+}
+
+// Token returns the current session token, or an empty string if the client
+// has no session cookie.
+func (c *Client) Token() string {
+	u, _ := url.Parse(c.harness.Server.URL)
+	for _, cookie := range c.http.Jar.Cookies(u) {
+		if cookie.Name == c.harness.Manager.Cookie.Name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// SetToken replaces the session cookie, for example to replay a token after
+// logout.
+func (c *Client) SetToken(token string) {
+	u, _ := url.Parse(c.harness.Server.URL)
+	c.http.Jar.SetCookies(u, []*http.Cookie{{
+		Name:  c.harness.Manager.Cookie.Name,
+		Value: token,
+		Path:  "/",
+	}})
+}
+
+func (c *Client) do(method, path string) (string, error) {
+	req, err := http.NewRequest(method, c.harness.Server.URL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "", ErrUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
@@ -0,0 +1,91 @@
+package sessiontest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/sessiontest"
+)
+
+func TestLoginLogout(t *testing.T) {
+	require := require.New(t)
+
+	h := sessiontest.New(fake.New())
+	defer h.Close()
+	c := h.NewClient()
+
+	// given an anonymous visitor
+	_, err := c.WhoAmI()
+	require.Equal(sessiontest.ErrUnauthorized, err)
+	n, err := c.Visit()
+	require.NoError(err)
+	require.Equal(1, n)
+	anonymous := c.Token()
+	require.NotEmpty(anonymous)
+
+	// when the visitor logs in
+	require.NoError(c.Login("alice"))
+
+	// then the token is renewed and the session data is kept
+	user, err := c.WhoAmI()
+	require.NoError(err)
+	require.Equal("alice", user)
+	require.NotEqual(anonymous, c.Token())
+	n, err = c.Visit()
+	require.NoError(err)
+	require.Equal(2, n)
+
+	// when the user logs out
+	loggedIn := c.Token()
+	require.NoError(c.Logout())
+
+	// then the session can't be reused
+	_, err = c.WhoAmI()
+	require.Equal(sessiontest.ErrUnauthorized, err)
+	c.SetToken(loggedIn)
+	_, err = c.WhoAmI()
+	require.Equal(sessiontest.ErrUnauthorized, err)
+}
+
+func TestClientsAreIndependent(t *testing.T) {
+	require := require.New(t)
+
+	h := sessiontest.New(memstore.NewWithCleanupInterval(0))
+	defer h.Close()
+
+	alice, bob := h.NewClient(), h.NewClient()
+	require.NoError(alice.Login("alice"))
+	require.NoError(bob.Login("bob"))
+
+	user, err := alice.WhoAmI()
+	require.NoError(err)
+	require.Equal("alice", user)
+	user, err = bob.WhoAmI()
+	require.NoError(err)
+	require.Equal("bob", user)
+}
+
+func Example() {
+	h := sessiontest.New(fake.New())
+	defer h.Close()
+
+	c := h.NewClient()
+	if err := c.Login("alice"); err != nil {
+		panic(err)
+	}
+	user, _ := c.WhoAmI()
+	fmt.Println(user)
+
+	if err := c.Logout(); err != nil {
+		panic(err)
+	}
+	_, err := c.WhoAmI()
+	fmt.Println(err)
+	// Output:
+	// alice
+	// unauthorized
+}
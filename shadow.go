@@ -0,0 +1,144 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+var _ scs.Store = &ShadowStore{}
+
+// maxShadowReads limits the number of concurrent shadow reads. Reads that
+// would exceed the limit are skipped rather than queued.
+const maxShadowReads = 64
+
+// ShadowMismatch describes a session that was read differently from the
+// primary and shadow stores.
+type ShadowMismatch struct {
+	// TokenHash identifies the session without exposing its token, so
+	// mismatches can be logged safely. It is the hash returned by HashToken
+	// when the primary store is a *DynamoStore, and by TokenHash otherwise.
+	TokenHash string
+
+	Primary       []byte
+	PrimaryExists bool
+
+	Shadow       []byte
+	ShadowExists bool
+
+	// Err is set if reading from the shadow store, or hashing the token,
+	// failed.
+	Err error
+}
+
+// ShadowStore serves sessions from a primary store while asynchronously
+// reading the same sessions from a shadow store and reporting differences.
+// It is intended to build confidence in a new session store before switching
+// to it. Commit and Delete only use the primary store, so the shadow store
+// is usually kept up to date by wrapping a DualWriteStore.
+type ShadowStore struct {
+	primary scs.Store
+	shadow  scs.Store
+	report  func(*ShadowMismatch)
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// NewShadowStore creates a ShadowStore instance. Mismatches and shadow read
+// errors are passed to report, which is called from a background goroutine.
+// If report is nil, they are ignored.
+func NewShadowStore(primary, shadow scs.Store, report func(*ShadowMismatch)) *ShadowStore {
+	if report == nil {
+		report = func(*ShadowMismatch) {}
+	}
+	return &ShadowStore{
+		primary: primary,
+		shadow:  shadow,
+		report:  report,
+		sem:     make(chan struct{}, maxShadowReads),
+	}
+}
+
+// Find returns the data for a given session token from the primary store,
+// and compares it in the background with the data in the shadow store.
+func (s *ShadowStore) Find(token string) (b []byte, exists bool, err error) {
+	b, exists, err = s.primary.Find(token)
+	if err == nil {
+		s.compare(token, b, exists)
+	}
+	return b, exists, err
+}
+
+// Commit adds a session token and data to the primary store with the given
+// expiry time.
+func (s *ShadowStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.primary.Commit(token, b, expiry)
+}
+
+// Delete removes a session token and corresponding data from the primary
+// store.
+func (s *ShadowStore) Delete(token string) error {
+	return s.primary.Delete(token)
+}
+
+// Close waits for in-progress shadow reads to finish, or for ctx to be done,
+// whichever happens first.
+func (s *ShadowStore) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ShadowStore) compare(token string, primary []byte, primaryExists bool) {
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			<-s.sem
+			s.wg.Done()
+		}()
+
+		shadow, shadowExists, err := s.shadow.Find(token)
+		if err == nil && shadowExists == primaryExists && bytes.Equal(shadow, primary) {
+			return
+		}
+		hash, hashErr := s.hashToken(token)
+		if err == nil {
+			err = hashErr
+		}
+		s.report(&ShadowMismatch{
+			TokenHash:     hash,
+			Primary:       primary,
+			PrimaryExists: primaryExists,
+			Shadow:        shadow,
+			ShadowExists:  shadowExists,
+			Err:           err,
+		})
+	}()
+}
+
+// hashToken hashes token the same way as the primary store, if it is a
+// *DynamoStore.
+func (s *ShadowStore) hashToken(token string) (string, error) {
+	if primary, ok := s.primary.(*DynamoStore); ok {
+		return primary.HashToken(context.Background(), token)
+	}
+	return TokenHash(token), nil
+}
@@ -0,0 +1,87 @@
+package dynamostore_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+)
+
+func TestShadowStore(t *testing.T) {
+	require := require.New(t)
+
+	primary := memstore.NewWithCleanupInterval(0)
+	shadow := memstore.NewWithCleanupInterval(0)
+
+	var mu sync.Mutex
+	var mismatches []*dynamostore.ShadowMismatch
+	store := dynamostore.NewShadowStore(primary, shadow, func(m *dynamostore.ShadowMismatch) {
+		mu.Lock()
+		defer mu.Unlock()
+		mismatches = append(mismatches, m)
+	})
+
+	expiry := time.Now().Add(time.Minute)
+	require.NoError(primary.Commit("same", []byte("foo"), expiry))
+	require.NoError(shadow.Commit("same", []byte("foo"), expiry))
+	require.NoError(primary.Commit("changed", []byte("foo"), expiry))
+	require.NoError(shadow.Commit("changed", []byte("bar"), expiry))
+	require.NoError(primary.Commit("missing", []byte("foo"), expiry))
+
+	for _, token := range []string{"same", "changed", "missing", "unknown"} {
+		actual, exists, err := store.Find(token)
+		require.NoError(err)
+		if token == "unknown" {
+			require.False(exists)
+		} else {
+			require.True(exists)
+			require.Equal([]byte("foo"), actual)
+		}
+	}
+
+	// writes only go to the primary store
+	require.NoError(store.Commit("new", []byte("foo"), expiry))
+	_, exists, err := shadow.Find("new")
+	require.NoError(err)
+	require.False(exists)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(store.Close(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(mismatches, 2)
+	byToken := map[string]*dynamostore.ShadowMismatch{}
+	for _, m := range mismatches {
+		byToken[m.TokenHash] = m
+	}
+	changed := dynamostore.TokenHash("changed")
+	require.Equal([]byte("bar"), byToken[changed].Shadow)
+	require.True(byToken[changed].ShadowExists)
+	require.False(byToken[dynamostore.TokenHash("missing")].ShadowExists)
+}
+
+func TestShadowStoreWithoutReport(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	primary := memstore.NewWithCleanupInterval(0)
+	require.NoError(primary.Commit("foo", []byte("bar"), time.Now().Add(time.Minute)))
+	store := dynamostore.NewShadowStore(primary, memstore.NewWithCleanupInterval(0), nil)
+
+	// when
+	_, exists, err := store.Find("foo")
+
+	// then the mismatch is ignored
+	require.NoError(err)
+	require.True(exists)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(store.Close(ctx))
+}
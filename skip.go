@@ -0,0 +1,140 @@
+package dynamostore
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// dataHashAttribute stores a hash of the session data, when
+// WithSkipUnchanged is used.
+const dataHashAttribute = "DataHash"
+
+// defaultUnchangedCacheSize is the number of sessions remembered by
+// WithSkipUnchanged.
+const defaultUnchangedCacheSize = 10000
+
+// WithSkipUnchanged makes Commit skip the write when the session data is
+// the same as when it was last read or committed by this store, and the
+// expiry has moved by no more than tolerance. scs commits sessions after
+// every request that loads them, even when nothing changed, so this can
+// avoid most writes for read-heavy applications. With scs's IdleTimeout,
+// tolerance trades how precisely the idle timeout is enforced for fewer
+// writes.
+//
+// A hash of the data is stored with each session, and the hashes and
+// expiry times of recently used sessions are kept in memory. If another
+// instance of the application changes or deletes a session, the change is
+// kept, since the session hasn't changed as far as this request is
+// concerned. WithSkipUnchanged can't be used with WithWriteBehind.
+func WithSkipUnchanged(tolerance time.Duration) Option {
+	return func(s *DynamoStore) {
+		if tolerance < 0 {
+			s.invalid("WithSkipUnchanged requires a tolerance of zero or more")
+			return
+		}
+		s.unchanged = &unchangedCache{
+			tolerance: tolerance,
+			size:      defaultUnchangedCacheSize,
+			order:     list.New(),
+			entries:   map[string]*list.Element{},
+		}
+	}
+}
+
+// unchangedCache remembers the last known data hash and expiry of sessions.
+type unchangedCache struct {
+	tolerance time.Duration
+	size      int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type unchangedEntry struct {
+	token  string
+	hash   []byte
+	expiry time.Time
+}
+
+func hashData(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// unchanged returns true if a commit of data and expiry can be skipped.
+func (c *unchangedCache) unchanged(token string, hash []byte, expiry time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[token]
+	if !ok {
+		return false
+	}
+	entry := elem.Value.(*unchangedEntry)
+	if !bytes.Equal(entry.hash, hash) {
+		return false
+	}
+	diff := expiry.Sub(entry.expiry)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > c.tolerance {
+		return false
+	}
+	c.order.MoveToFront(elem)
+	return true
+}
+
+// sameData returns true if the session was last known to have data with
+// the given hash.
+func (c *unchangedCache) sameData(token string, hash []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[token]
+	return ok && bytes.Equal(elem.Value.(*unchangedEntry).hash, hash)
+}
+
+// remember records the data hash and expiry of a session, as read from or
+// written to the table.
+func (c *unchangedCache) remember(token string, hash []byte, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[token]; ok {
+		entry := elem.Value.(*unchangedEntry)
+		entry.hash = hash
+		entry.expiry = expiry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[token] = c.order.PushFront(&unchangedEntry{
+		token:  token,
+		hash:   hash,
+		expiry: expiry,
+	})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*unchangedEntry).token)
+	}
+}
+
+// forgetUnchanged removes a session from the cache used by
+// WithSkipUnchanged, if it is enabled.
+func (s *DynamoStore) forgetUnchanged(token string) {
+	if s.unchanged != nil {
+		s.unchanged.forget(token)
+	}
+}
+
+// forget removes a session, so the next commit is always written.
+func (c *unchangedCache) forget(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[token]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, token)
+	}
+}
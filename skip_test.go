@@ -0,0 +1,134 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSkipUnchanged(t *testing.T) {
+	require := require.New(t)
+
+	expiry := time.Now().Add(time.Hour)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithSkipUnchanged(time.Minute),
+	})
+	require.NoError(store.Validate())
+
+	// given a committed session
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.Equal(1, svc.count("PutItem"))
+	require.IsType(&types.AttributeValueMemberB{}, svc.items["foo"][dataHashAttribute])
+
+	// when it is committed again without changes
+	require.NoError(store.Commit("foo", []byte("bar"), expiry.Add(time.Minute)))
+	// then the write is skipped
+	require.Equal(1, svc.count("PutItem"))
+
+	// when the expiry moves by more than the tolerance
+	require.NoError(store.Commit("foo", []byte("bar"), expiry.Add(2*time.Minute)))
+	// then it is written
+	require.Equal(2, svc.count("PutItem"))
+
+	// when the data changes
+	require.NoError(store.Commit("foo", []byte("baz"), expiry.Add(2*time.Minute)))
+	// then it is written
+	require.Equal(3, svc.count("PutItem"))
+
+	// when the session is deleted
+	require.NoError(store.Delete("foo"))
+	require.NoError(store.Commit("foo", []byte("baz"), expiry.Add(2*time.Minute)))
+	// then the next commit is written
+	require.Equal(4, svc.count("PutItem"))
+
+	// when a write fails
+	svc.failWith("PutItem", errors.New("oops"))
+	require.Error(store.Commit("foo", []byte("qux"), expiry))
+	svc.failWith("PutItem", nil)
+	require.NoError(store.Commit("foo", []byte("qux"), expiry))
+	// then the retry is written
+	require.Equal(6, svc.count("PutItem"))
+}
+
+func TestWithSkipUnchangedAfterFind(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	svc := newFakeClient()
+	writer := newStore(svc, DefaultTableName, nil)
+	require.NoError(writer.Commit("foo", []byte("bar"), time.Now().Add(time.Hour)))
+
+	// given a session read by another store
+	store := newStore(svc, DefaultTableName, []Option{WithSkipUnchanged(0)})
+	data, expiry, exists, err := store.FindWithExpiry(ctx, "foo")
+	require.NoError(err)
+	require.True(exists)
+
+	// when it is committed without changes
+	require.NoError(store.Commit("foo", data, expiry))
+
+	// then the write is skipped
+	require.Equal(1, svc.count("PutItem"))
+
+	store = newStore(svc, DefaultTableName, []Option{
+		WithSkipUnchanged(0),
+		WithWriteBehind(1, nil),
+	})
+	defer store.Close(ctx)
+	require.Error(store.Validate())
+}
+
+func TestWithExpiryOnlyUpdates(t *testing.T) {
+	require := require.New(t)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithSkipUnchanged(0),
+		WithExpiryOnlyUpdates(),
+	})
+	require.NoError(store.Validate())
+
+	// given a committed session
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.Equal(1, svc.count("PutItem"))
+
+	// when only the expiry changes
+	require.NoError(store.Commit("foo", []byte("bar"), expiry.Add(time.Minute)))
+	// then only the expiry is updated
+	require.Equal(1, svc.count("PutItem"))
+	require.Equal(1, svc.count("UpdateItem"))
+	_, actual, exists, err := store.FindWithExpiry(context.Background(), "foo")
+	require.NoError(err)
+	require.True(exists)
+	require.True(expiry.Add(time.Minute).Equal(actual), actual)
+
+	// when the stored data has been changed by another request
+	svc.failWith("UpdateItem", &types.ConditionalCheckFailedException{})
+	require.NoError(store.Commit("foo", []byte("bar"), expiry.Add(2*time.Minute)))
+	// then the whole item is written
+	require.Equal(2, svc.count("UpdateItem"))
+	require.Equal(2, svc.count("PutItem"))
+	svc.failWith("UpdateItem", nil)
+
+	// when the data changes
+	require.NoError(store.Commit("foo", []byte("baz"), expiry.Add(3*time.Minute)))
+	// then the whole item is written
+	require.Equal(2, svc.count("UpdateItem"))
+	require.Equal(3, svc.count("PutItem"))
+
+	// when the update fails
+	svc.failWith("UpdateItem", errors.New("oops"))
+	// then the error is returned
+	require.Error(store.Commit("foo", []byte("baz"), expiry.Add(4*time.Minute)))
+
+	// when used without WithSkipUnchanged
+	store = newStore(svc, DefaultTableName, []Option{WithExpiryOnlyUpdates()})
+	// then the configuration is rejected
+	require.Error(store.Validate())
+}
@@ -0,0 +1,78 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WithSlidingExpiration makes Find extend the expiry of the sessions it
+// returns to idle from now, so sessions expire once they haven't been used
+// for idle, even if the application doesn't commit them. Sessions are never
+// extended past the deadline recorded in the session data by scs, so the
+// session manager's Lifetime is still enforced.
+//
+// Extending a session costs a small write. To limit the cost, the expiry is
+// only extended once it would move by at least a tenth of idle.
+func WithSlidingExpiration(idle time.Duration) Option {
+	return func(s *DynamoStore) {
+		if idle <= 0 {
+			s.invalid("WithSlidingExpiration requires a positive idle duration")
+			return
+		}
+		s.slidingExpiration = idle
+	}
+}
+
+// extendExpiry moves the expiry of item to the end of the sliding expiration
+// window, if it is worth the write.
+func (s *DynamoStore) extendExpiry(ctx context.Context, item *sessionItem) error {
+	expiry := s.now().Add(s.slidingExpiration)
+	if deadline, _, err := s.codec.Decode(item.Data); err == nil && !deadline.IsZero() && deadline.Before(expiry) {
+		expiry = deadline
+	}
+	if expiry.Sub(item.TTL) < s.slidingExpiration/10 {
+		return nil
+	}
+
+	names := map[string]string{
+		"#token": "token",
+	}
+	values := map[string]types.AttributeValue{}
+	update := s.setExpiry(names, values, expiry)
+
+	units, err := s.limiter.waitWrite(ctx, 0)
+	if err != nil {
+		return err
+	}
+	result, err := s.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: item.Token,
+			},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(#token) AND #ttl < :ttl"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnConsumedCapacity:    s.limiter.returnConsumedCapacity(),
+	}, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// The session was deleted, or extended by another request.
+			return nil
+		}
+		return err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.rememberWrite(item.Token, expiry)
+	item.TTL = time.Unix(expiry.Unix(), 0)
+	s.recordEvent(ctx, AuditRefresh, item.Token, item.Data)
+	return nil
+}
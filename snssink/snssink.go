@@ -0,0 +1,85 @@
+// Package snssink publishes session revocations to an Amazon SNS topic, so
+// app servers and edge caches subscribed to the topic can drop a session
+// immediately instead of waiting for their cached copy to expire.
+//
+// It is a separate module so applications that don't use it don't depend on
+// the SNS client.
+package snssink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"github.com/sjansen/dynamostore"
+)
+
+// Client is the subset of the SNS API used by a Sink. It is implemented by
+// *sns.Client.
+type Client interface {
+	Publish(context.Context, *sns.PublishInput, ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Config configures a Sink.
+type Config struct {
+	Client   Client
+	TopicARN string
+}
+
+// Sink is a dynamostore.SessionEventSink that publishes a message whenever a
+// session is deleted, including by DeleteBySubject. Use it with
+// dynamostore.WithEventSink.
+//
+// The message is a dynamostore.EventDetail in JSON, and its "type" message
+// attribute is dynamostore.EventSessionRevoked, for subscription filter
+// policies. Sessions are identified by the hash returned by HashToken, so
+// caches must be keyed by the same hash to act on messages.
+type Sink struct {
+	cfg Config
+}
+
+// New returns a Sink, or an error if cfg is invalid.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Client == nil || cfg.TopicARN == "" {
+		return nil, errors.New("snssink: requires a client and a topic ARN")
+	}
+	return &Sink{cfg: cfg}, nil
+}
+
+// Created does nothing.
+func (p *Sink) Created(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return nil
+}
+
+// Refreshed does nothing.
+func (p *Sink) Refreshed(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return nil
+}
+
+// Revoked publishes a dynamostore.EventSessionRevoked message.
+func (p *Sink) Revoked(ctx context.Context, event *dynamostore.AuditEvent) error {
+	message, err := json.Marshal(dynamostore.NewEventDetail(event))
+	if err != nil {
+		return err
+	}
+	_, err = p.cfg.Client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(p.cfg.TopicARN),
+		Message:  aws.String(string(message)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(dynamostore.EventSessionRevoked),
+			},
+		},
+	})
+	return err
+}
+
+// Expired does nothing.
+func (p *Sink) Expired(ctx context.Context, event *dynamostore.AuditEvent) error {
+	return nil
+}
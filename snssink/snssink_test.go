@@ -0,0 +1,94 @@
+package snssink_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/snssink"
+)
+
+type fakeSNS struct {
+	messages []*sns.PublishInput
+}
+
+func (f *fakeSNS) Publish(
+	ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options),
+) (*sns.PublishOutput, error) {
+	f.messages = append(f.messages, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestSink(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	topic := &fakeSNS{}
+	sink, err := snssink.New(snssink.Config{
+		Client:   topic,
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:revocations",
+	})
+	require.NoError(err)
+	store := fake.New(
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithSubjectKey("userID"),
+		dynamostore.WithEventSink(sink, nil),
+	)
+	require.NoError(store.Validate())
+
+	expiry := now.Add(time.Hour)
+	for _, token := range []string{"a", "b", "c"} {
+		userID := "alice"
+		if token == "c" {
+			userID = "bob"
+		}
+		data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"userID": userID})
+		require.NoError(err)
+		require.NoError(store.Commit(token, data, expiry))
+	}
+	require.Empty(topic.messages)
+
+	// when
+	require.NoError(store.Delete("c"))
+	n, err := store.DeleteBySubject(context.Background(), "alice")
+
+	// then
+	require.NoError(err)
+	require.Equal(2, n)
+	require.Len(topic.messages, 3)
+
+	message := topic.messages[0]
+	require.Equal("arn:aws:sns:us-east-1:123456789012:revocations", aws.ToString(message.TopicArn))
+	require.Equal(dynamostore.EventSessionRevoked, aws.ToString(message.MessageAttributes["type"].StringValue))
+
+	var detail dynamostore.EventDetail
+	require.NoError(json.Unmarshal([]byte(aws.ToString(message.Message)), &detail))
+	require.Equal(dynamostore.TokenHash("c"), detail.TokenHash)
+	require.Equal("bob", detail.Subject)
+
+	subjects := map[string]string{}
+	for _, message := range topic.messages[1:] {
+		var detail dynamostore.EventDetail
+		require.NoError(json.Unmarshal([]byte(aws.ToString(message.Message)), &detail))
+		subjects[detail.TokenHash] = detail.Subject
+	}
+	require.Equal(map[string]string{
+		dynamostore.TokenHash("a"): "alice",
+		dynamostore.TokenHash("b"): "alice",
+	}, subjects)
+
+	// when
+	_, err = snssink.New(snssink.Config{Client: topic})
+
+	// then
+	require.EqualError(err, "snssink: requires a client and a topic ARN")
+}
@@ -0,0 +1,119 @@
+package dynamostore
+
+import (
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Stats counts the sessions read and written by a store since it was
+// created. The store doesn't compress session data, so DataBytes and
+// StoredBytes differ only by the size of the token and the other attributes
+// stored with each session.
+type Stats struct {
+	// Commits is the number of calls to Commit, and SkippedCommits is the
+	// number skipped by WithSkipUnchanged.
+	Commits        int64
+	SkippedCommits int64
+	// Writes is the number of sessions written in full, and ExpiryUpdates
+	// is the number updated by WithExpiryOnlyUpdates instead.
+	Writes        int64
+	ExpiryUpdates int64
+	// DataBytes is the total size of the session data written in full, and
+	// StoredBytes is the total size of the items that stored it, as DynamoDB
+	// measures it.
+	DataBytes   int64
+	StoredBytes int64
+
+	// Finds is the number of calls to Find, and CacheHits is the number
+	// answered by WithCache. Reads is the number of reads from the table,
+	// including reads that found nothing, and sessions loaded by Prefetch.
+	Finds     int64
+	CacheHits int64
+	Reads     int64
+	// ReadBytes is the total size of the items read, as DynamoDB measures
+	// it.
+	ReadBytes int64
+
+	// Invalidations is the number of invalidations received from other
+	// instances by WithCacheInvalidation, and InvalidationErrors is the
+	// number that couldn't be published.
+	Invalidations      int64
+	InvalidationErrors int64
+
+	// HotTokens lists the most used sessions, when WithHotTokens is used.
+	HotTokens []TokenCount
+}
+
+// StorageRatio returns StoredBytes divided by DataBytes, or zero if no
+// data has been written.
+func (s *Stats) StorageRatio() float64 {
+	if s.DataBytes < 1 {
+		return 0
+	}
+	return float64(s.StoredBytes) / float64(s.DataBytes)
+}
+
+// SkipRatio returns the fraction of commits skipped by WithSkipUnchanged.
+func (s *Stats) SkipRatio() float64 {
+	if s.Commits < 1 {
+		return 0
+	}
+	return float64(s.SkippedCommits) / float64(s.Commits)
+}
+
+// Stats returns a snapshot of the store's counters.
+func (s *DynamoStore) Stats() *Stats {
+	c := &s.stats
+	var hot []TokenCount
+	if s.hotTokens != nil {
+		hot = s.hotTokens.top(s.now())
+	}
+	return &Stats{
+		Commits:        atomic.LoadInt64(&c.commits),
+		SkippedCommits: atomic.LoadInt64(&c.skippedCommits),
+		Writes:         atomic.LoadInt64(&c.writes),
+		ExpiryUpdates:  atomic.LoadInt64(&c.expiryUpdates),
+		DataBytes:      atomic.LoadInt64(&c.dataBytes),
+		StoredBytes:    atomic.LoadInt64(&c.storedBytes),
+		Finds:          atomic.LoadInt64(&c.finds),
+		CacheHits:      atomic.LoadInt64(&c.cacheHits),
+		Reads:          atomic.LoadInt64(&c.reads),
+		ReadBytes:      atomic.LoadInt64(&c.readBytes),
+
+		Invalidations:      atomic.LoadInt64(&c.invalidations),
+		InvalidationErrors: atomic.LoadInt64(&c.invalidationErrors),
+
+		HotTokens: hot,
+	}
+}
+
+// storeStats holds the counters reported by Stats. It must be the first
+// field of DynamoStore, so the counters are 64-bit aligned on 32-bit
+// platforms.
+type storeStats struct {
+	commits        int64
+	skippedCommits int64
+	writes         int64
+	expiryUpdates  int64
+	dataBytes      int64
+	storedBytes    int64
+	finds          int64
+	cacheHits      int64
+	reads          int64
+	readBytes      int64
+
+	invalidations      int64
+	invalidationErrors int64
+}
+
+func (c *storeStats) wrote(data []byte, av map[string]types.AttributeValue) {
+	atomic.AddInt64(&c.writes, 1)
+	atomic.AddInt64(&c.dataBytes, int64(len(data)))
+	atomic.AddInt64(&c.storedBytes, int64(itemSize(av)))
+}
+
+func (c *storeStats) read(av map[string]types.AttributeValue) {
+	atomic.AddInt64(&c.reads, 1)
+	atomic.AddInt64(&c.readBytes, int64(itemSize(av)))
+}
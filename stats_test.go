@@ -0,0 +1,40 @@
+package dynamostore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	require := require.New(t)
+
+	expiry := time.Now().Add(time.Hour)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithSkipUnchanged(time.Minute),
+	})
+	require.Equal(&Stats{}, store.Stats())
+	require.Zero(store.Stats().StorageRatio())
+	require.Zero(store.Stats().SkipRatio())
+
+	// when sessions are committed and found
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+	_, _, err := store.Find("foo")
+	require.NoError(err)
+	_, _, err = store.Find("missing")
+	require.NoError(err)
+
+	// then
+	stats := store.Stats()
+	require.Equal(int64(2), stats.Commits)
+	require.Equal(int64(1), stats.SkippedCommits)
+	require.Equal(int64(1), stats.Writes)
+	require.Equal(int64(3), stats.DataBytes)
+	require.Equal(int64(2), stats.Finds)
+	require.Equal(int64(2), stats.Reads)
+	require.Equal(stats.StoredBytes, stats.ReadBytes)
+	require.True(stats.StorageRatio() > 1)
+	require.Equal(0.5, stats.SkipRatio())
+}
@@ -0,0 +1,147 @@
+// Package storetest verifies that an scs.Store behaves the way session
+// managers expect. It is intended for wrappers and adapters built on
+// dynamostore, such as caches and encrypting stores, so they can check that
+// they still satisfy the semantics of the store they wrap:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, NewCachingStore(fake.New()))
+//	}
+package storetest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// Run exercises store with a series of subtests. Each subtest uses its own
+// randomly generated tokens, so Run may be used with a store that contains
+// other sessions.
+func Run(t *testing.T, store scs.Store) {
+	prefix := randomPrefix(t)
+	token := func(name string) string {
+		return prefix + "-" + name
+	}
+
+	t.Run("FindMissing", func(t *testing.T) {
+		assertMissing(t, store, token("missing"))
+	})
+
+	t.Run("CommitAndFind", func(t *testing.T) {
+		tok := token("commit")
+		commit(t, store, tok, []byte("data"), time.Now().Add(time.Hour))
+		assertFound(t, store, tok, []byte("data"))
+	})
+
+	t.Run("CommitOverwrites", func(t *testing.T) {
+		tok := token("overwrite")
+		commit(t, store, tok, []byte("first"), time.Now().Add(time.Hour))
+		commit(t, store, tok, []byte("second"), time.Now().Add(2*time.Hour))
+		assertFound(t, store, tok, []byte("second"))
+	})
+
+	t.Run("CommitBinaryData", func(t *testing.T) {
+		tok := token("binary")
+		data := make([]byte, 256)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		commit(t, store, tok, data, time.Now().Add(time.Hour))
+		assertFound(t, store, tok, data)
+	})
+
+	t.Run("CommitExpired", func(t *testing.T) {
+		tok := token("expired")
+		commit(t, store, tok, []byte("data"), time.Now().Add(-time.Second))
+		assertMissing(t, store, tok)
+	})
+
+	t.Run("CommitShortensExpiry", func(t *testing.T) {
+		tok := token("shortened")
+		commit(t, store, tok, []byte("data"), time.Now().Add(time.Hour))
+		commit(t, store, tok, []byte("data"), time.Now().Add(-time.Second))
+		assertMissing(t, store, tok)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		tok := token("delete")
+		commit(t, store, tok, []byte("data"), time.Now().Add(time.Hour))
+		if err := store.Delete(tok); err != nil {
+			t.Fatalf("Delete(%q) failed: %v", tok, err)
+		}
+		assertMissing(t, store, tok)
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		tok := token("delete-missing")
+		if err := store.Delete(tok); err != nil {
+			t.Fatalf("Delete(%q) of missing session failed: %v", tok, err)
+		}
+	})
+
+	t.Run("CommitAfterDelete", func(t *testing.T) {
+		tok := token("recommit")
+		commit(t, store, tok, []byte("first"), time.Now().Add(time.Hour))
+		if err := store.Delete(tok); err != nil {
+			t.Fatalf("Delete(%q) failed: %v", tok, err)
+		}
+		commit(t, store, tok, []byte("second"), time.Now().Add(time.Hour))
+		assertFound(t, store, tok, []byte("second"))
+	})
+
+	t.Run("TokensAreIndependent", func(t *testing.T) {
+		a, b := token("independent-a"), token("independent-b")
+		commit(t, store, a, []byte("a"), time.Now().Add(time.Hour))
+		commit(t, store, b, []byte("b"), time.Now().Add(time.Hour))
+		if err := store.Delete(a); err != nil {
+			t.Fatalf("Delete(%q) failed: %v", a, err)
+		}
+		assertMissing(t, store, a)
+		assertFound(t, store, b, []byte("b"))
+	})
+}
+
+func randomPrefix(t *testing.T) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("unable to generate token prefix: %v", err)
+	}
+	return "storetest-" + hex.EncodeToString(b)
+}
+
+func commit(t *testing.T, store scs.Store, token string, data []byte, expiry time.Time) {
+	t.Helper()
+	if err := store.Commit(token, data, expiry); err != nil {
+		t.Fatalf("Commit(%q) failed: %v", token, err)
+	}
+}
+
+func assertFound(t *testing.T, store scs.Store, token string, expected []byte) {
+	t.Helper()
+	actual, exists, err := store.Find(token)
+	switch {
+	case err != nil:
+		t.Fatalf("Find(%q) failed: %v", token, err)
+	case !exists:
+		t.Fatalf("Find(%q) = not found, expected %q", token, expected)
+	case !bytes.Equal(actual, expected):
+		t.Fatalf("Find(%q) = %q, expected %q", token, actual, expected)
+	}
+}
+
+func assertMissing(t *testing.T, store scs.Store, token string) {
+	t.Helper()
+	actual, exists, err := store.Find(token)
+	switch {
+	case err != nil:
+		t.Fatalf("Find(%q) failed: %v", token, err)
+	case exists:
+		t.Fatalf("Find(%q) = %q, expected not found", token, actual)
+	case actual != nil:
+		t.Fatalf("Find(%q) returned %q with exists=false, expected nil", token, actual)
+	}
+}
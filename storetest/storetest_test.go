@@ -0,0 +1,33 @@
+package storetest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+	"github.com/sjansen/dynamostore/storetest"
+)
+
+func TestMemstore(t *testing.T) {
+	storetest.Run(t, memstore.NewWithCleanupInterval(0))
+}
+
+func TestDynamoStore(t *testing.T) {
+	storetest.Run(t, fake.New())
+}
+
+func TestWriteBehind(t *testing.T) {
+	store := fake.New(dynamostore.WithWriteBehind(2, nil))
+	defer store.Close(context.Background())
+	storetest.Run(t, store)
+}
+
+func TestDualWriteStore(t *testing.T) {
+	storetest.Run(t, dynamostore.NewDualWriteStore(
+		fake.New(), memstore.NewWithCleanupInterval(0), scs.GobCodec{},
+	))
+}
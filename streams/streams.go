@@ -0,0 +1,268 @@
+// Package streams consumes a session table's DynamoDB stream, and calls back
+// when sessions are deleted or expire, so applications can invalidate caches
+// or propagate a logout to other systems as it happens.
+//
+// Records can be delivered by a Lambda function subscribed to the table's
+// stream, or by Kinesis Data Streams for DynamoDB. The event types decode
+// the JSON Lambda receives, so a Handler can be passed to the Lambda runtime
+// directly:
+//
+//	h := &streams.Handler{
+//		OnDelete: func(ctx context.Context, s *streams.Session) error {
+//			cache.Remove(s.Token)
+//			return nil
+//		},
+//	}
+//	lambda.Start(h.HandleDynamoDBBatch)
+//
+// The table's stream view type determines what is available. KEYS_ONLY is
+// enough to learn the token. OLD_IMAGE, or NEW_AND_OLD_IMAGES, adds the data
+// and expiry of the session. Sessions revoked by dynamostore.WithRetention
+// are modified rather than removed, and are only reported when the stream
+// includes new images.
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTTLAttribute = "ttl"
+	revokedAttribute    = "Revoked"
+)
+
+// AttributeValue is an attribute in the DynamoDB JSON format. Only the types
+// used by session items are decoded.
+type AttributeValue struct {
+	S    *string `json:"S,omitempty"`
+	N    *string `json:"N,omitempty"`
+	B    []byte  `json:"B,omitempty"`
+	NULL *bool   `json:"NULL,omitempty"`
+}
+
+// StreamRecord is the change described by a Record.
+type StreamRecord struct {
+	SequenceNumber string                    `json:"SequenceNumber"`
+	Keys           map[string]AttributeValue `json:"Keys"`
+	NewImage       map[string]AttributeValue `json:"NewImage,omitempty"`
+	OldImage       map[string]AttributeValue `json:"OldImage,omitempty"`
+}
+
+// Identity identifies who made a change. Deletions by DynamoDB's TTL process
+// are made by the service principal "dynamodb.amazonaws.com".
+type Identity struct {
+	Type        string `json:"type"`
+	PrincipalID string `json:"principalId"`
+}
+
+// Record is a DynamoDB stream record.
+type Record struct {
+	EventID      string       `json:"eventID"`
+	EventName    string       `json:"eventName"`
+	UserIdentity *Identity    `json:"userIdentity,omitempty"`
+	DynamoDB     StreamRecord `json:"dynamodb"`
+}
+
+// Event is the event a Lambda function subscribed to a DynamoDB stream
+// receives.
+type Event struct {
+	Records []Record `json:"Records"`
+}
+
+// KinesisEvent is the event a Lambda function subscribed to Kinesis Data
+// Streams for DynamoDB receives.
+type KinesisEvent struct {
+	Records []KinesisRecord `json:"Records"`
+}
+
+// KinesisRecord is a Kinesis record. Its data is a Record in JSON.
+type KinesisRecord struct {
+	Kinesis struct {
+		SequenceNumber string `json:"sequenceNumber"`
+		Data           []byte `json:"data"`
+	} `json:"kinesis"`
+}
+
+// BatchResponse reports the records of a batch that weren't handled, for
+// Lambda event source mappings with ReportBatchItemFailures enabled. Lambda
+// retries the batch from the first failure, so records before it are never
+// handled again.
+type BatchResponse struct {
+	BatchItemFailures []BatchItemFailure `json:"batchItemFailures"`
+}
+
+// BatchItemFailure identifies a record by its sequence number.
+type BatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+// Reason describes why a session ended.
+type Reason string
+
+const (
+	// Deleted sessions were removed by Delete, or another client.
+	Deleted Reason = "delete"
+	// Expired sessions were removed by DynamoDB's TTL process.
+	Expired Reason = "expire"
+	// Revoked sessions were marked as revoked by a store using
+	// dynamostore.WithRetention.
+	Revoked Reason = "revoke"
+)
+
+// Session describes a session that has ended.
+type Session struct {
+	Token  string
+	Reason Reason
+	// Expiry and Data are only set if the stream includes old images.
+	Expiry time.Time
+	Data   []byte
+}
+
+// Handler calls back when sessions end. Callbacks that are nil are skipped.
+type Handler struct {
+	// OnDelete is called for deleted and revoked sessions, including
+	// expired sessions removed by dynamostore's DeleteExpired or
+	// WithLazyDelete.
+	OnDelete func(context.Context, *Session) error
+	// OnExpire is called for sessions removed by DynamoDB's TTL process,
+	// which removes each expired session once, so it is the place for
+	// "session expired" logic. Use HandleDynamoDBBatch or
+	// HandleKinesisBatch so records aren't handled again when a later
+	// record in the same batch fails. Removing the tombstone of a revoked
+	// session doesn't call either callback again, but tombstones can only
+	// be recognized if the stream includes old images.
+	OnExpire func(context.Context, *Session) error
+	// TTLAttribute is the name of the expiry attribute, if the store uses
+	// dynamostore.WithTTLAttribute. The default is "ttl".
+	TTLAttribute string
+}
+
+// HandleDynamoDB handles the records of a DynamoDB stream event, in order.
+// It stops at the first error returned by a callback, so the batch is
+// retried.
+func (h *Handler) HandleDynamoDB(ctx context.Context, event *Event) error {
+	for i := range event.Records {
+		if err := h.HandleRecord(ctx, &event.Records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleDynamoDBBatch handles the records of a DynamoDB stream event, in
+// order, stopping at the first record that fails. That record is reported,
+// so Lambda retries the batch from it, and the records before it aren't
+// handled again.
+func (h *Handler) HandleDynamoDBBatch(ctx context.Context, event *Event) (*BatchResponse, error) {
+	response := &BatchResponse{BatchItemFailures: []BatchItemFailure{}}
+	for i := range event.Records {
+		record := &event.Records[i]
+		if err := h.HandleRecord(ctx, record); err != nil {
+			response.BatchItemFailures = append(response.BatchItemFailures, BatchItemFailure{
+				ItemIdentifier: record.DynamoDB.SequenceNumber,
+			})
+			break
+		}
+	}
+	return response, nil
+}
+
+// HandleKinesis handles the records of a Kinesis Data Streams for DynamoDB
+// event, in order. It stops at the first error.
+func (h *Handler) HandleKinesis(ctx context.Context, event *KinesisEvent) error {
+	for i := range event.Records {
+		if err := h.handleKinesisRecord(ctx, &event.Records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleKinesisBatch is like HandleDynamoDBBatch, for Kinesis Data Streams
+// for DynamoDB events.
+func (h *Handler) HandleKinesisBatch(ctx context.Context, event *KinesisEvent) (*BatchResponse, error) {
+	response := &BatchResponse{BatchItemFailures: []BatchItemFailure{}}
+	for i := range event.Records {
+		record := &event.Records[i]
+		if err := h.handleKinesisRecord(ctx, record); err != nil {
+			response.BatchItemFailures = append(response.BatchItemFailures, BatchItemFailure{
+				ItemIdentifier: record.Kinesis.SequenceNumber,
+			})
+			break
+		}
+	}
+	return response, nil
+}
+
+func (h *Handler) handleKinesisRecord(ctx context.Context, r *KinesisRecord) error {
+	var record Record
+	if err := json.Unmarshal(r.Kinesis.Data, &record); err != nil {
+		return fmt.Errorf("streams: decoding record: %w", err)
+	}
+	return h.HandleRecord(ctx, &record)
+}
+
+// HandleRecord calls the callback for record, if it ended a session.
+func (h *Handler) HandleRecord(ctx context.Context, record *Record) error {
+	session := h.session(record)
+	if session == nil {
+		return nil
+	}
+	callback := h.OnDelete
+	if session.Reason == Expired {
+		callback = h.OnExpire
+	}
+	if callback == nil {
+		return nil
+	}
+	return callback(ctx, session)
+}
+
+// session returns the session ended by record, or nil.
+func (h *Handler) session(record *Record) *Session {
+	token := record.DynamoDB.Keys["token"].S
+	if token == nil {
+		return nil
+	}
+	session := &Session{Token: *token}
+	image := record.DynamoDB.OldImage
+	switch record.EventName {
+	case "REMOVE":
+		if _, ok := image[revokedAttribute]; ok {
+			// The session ended when it was revoked, and this only
+			// removes its tombstone.
+			return nil
+		}
+		session.Reason = Deleted
+		if id := record.UserIdentity; id != nil &&
+			id.Type == "Service" && id.PrincipalID == "dynamodb.amazonaws.com" {
+			session.Reason = Expired
+		}
+	case "MODIFY":
+		if _, ok := record.DynamoDB.NewImage[revokedAttribute]; !ok {
+			return nil
+		}
+		if _, ok := image[revokedAttribute]; ok {
+			return nil
+		}
+		session.Reason = Revoked
+	default:
+		return nil
+	}
+
+	ttlAttribute := h.TTLAttribute
+	if ttlAttribute == "" {
+		ttlAttribute = defaultTTLAttribute
+	}
+	if n := image[ttlAttribute].N; n != nil {
+		if seconds, err := strconv.ParseInt(*n, 10, 64); err == nil {
+			session.Expiry = time.Unix(seconds, 0)
+		}
+	}
+	session.Data = image["Data"].B
+	return session
+}
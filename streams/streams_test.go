@@ -0,0 +1,205 @@
+package streams_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore/streams"
+)
+
+const dynamoDBEvent = `{"Records": [
+  {
+    "eventID": "1",
+    "eventName": "INSERT",
+    "dynamodb": {
+      "SequenceNumber": "100",
+      "Keys": {"token": {"S": "new"}},
+      "NewImage": {"token": {"S": "new"}, "Data": {"B": "AQI="}, "ttl": {"N": "1700000000"}}
+    }
+  },
+  {
+    "eventID": "2",
+    "eventName": "REMOVE",
+    "dynamodb": {
+      "SequenceNumber": "200",
+      "Keys": {"token": {"S": "deleted"}},
+      "OldImage": {"token": {"S": "deleted"}, "Data": {"B": "AQI="}, "ttl": {"N": "1700000000"}}
+    }
+  },
+  {
+    "eventID": "3",
+    "eventName": "REMOVE",
+    "userIdentity": {"type": "Service", "principalId": "dynamodb.amazonaws.com"},
+    "dynamodb": {"SequenceNumber": "300", "Keys": {"token": {"S": "expired"}}}
+  },
+  {
+    "eventID": "4",
+    "eventName": "MODIFY",
+    "dynamodb": {
+      "SequenceNumber": "400",
+      "Keys": {"token": {"S": "revoked"}},
+      "OldImage": {"token": {"S": "revoked"}, "Data": {"NULL": true}, "ttl": {"N": "1700000000"}},
+      "NewImage": {"token": {"S": "revoked"}, "Data": {"NULL": true}, "ttl": {"N": "1800000000"}, "Revoked": {"N": "1"}}
+    }
+  },
+  {
+    "eventID": "5",
+    "eventName": "MODIFY",
+    "dynamodb": {
+      "SequenceNumber": "500",
+      "Keys": {"token": {"S": "refreshed"}},
+      "NewImage": {"token": {"S": "refreshed"}, "ttl": {"N": "1800000000"}}
+    }
+  },
+  {
+    "eventID": "6",
+    "eventName": "REMOVE",
+    "userIdentity": {"type": "Service", "principalId": "dynamodb.amazonaws.com"},
+    "dynamodb": {
+      "SequenceNumber": "600",
+      "Keys": {"token": {"S": "revoked"}},
+      "OldImage": {"token": {"S": "revoked"}, "Data": {"NULL": true}, "ttl": {"N": "1800000000"}, "Revoked": {"N": "1"}}
+    }
+  }
+]}`
+
+type recorder struct {
+	sessions []*streams.Session
+}
+
+func (r *recorder) handler() *streams.Handler {
+	record := func(ctx context.Context, s *streams.Session) error {
+		r.sessions = append(r.sessions, s)
+		return nil
+	}
+	return &streams.Handler{OnDelete: record, OnExpire: record}
+}
+
+func TestHandleDynamoDB(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var event streams.Event
+	require.NoError(json.Unmarshal([]byte(dynamoDBEvent), &event))
+	r := &recorder{}
+
+	// when
+	err := r.handler().HandleDynamoDB(context.Background(), &event)
+
+	// then
+	require.NoError(err)
+	require.Equal([]*streams.Session{
+		{
+			Token:  "deleted",
+			Reason: streams.Deleted,
+			Expiry: time.Unix(1700000000, 0),
+			Data:   []byte{1, 2},
+		},
+		{
+			Token:  "expired",
+			Reason: streams.Expired,
+		},
+		{
+			Token:  "revoked",
+			Reason: streams.Revoked,
+			Expiry: time.Unix(1700000000, 0),
+		},
+	}, r.sessions)
+}
+
+func TestHandleKinesis(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var event streams.Event
+	require.NoError(json.Unmarshal([]byte(dynamoDBEvent), &event))
+	var records []interface{}
+	for _, record := range event.Records {
+		data, err := json.Marshal(record)
+		require.NoError(err)
+		records = append(records, map[string]interface{}{
+			"kinesis": map[string]interface{}{"data": data},
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{"Records": records})
+	require.NoError(err)
+	var kinesis streams.KinesisEvent
+	require.NoError(json.Unmarshal(body, &kinesis))
+	r := &recorder{}
+	h := r.handler()
+	h.OnExpire = nil
+
+	// when
+	err = h.HandleKinesis(context.Background(), &kinesis)
+
+	// then
+	require.NoError(err)
+	require.Len(r.sessions, 2)
+	require.Equal("deleted", r.sessions[0].Token)
+	require.Equal("revoked", r.sessions[1].Token)
+}
+
+func TestHandleDynamoDBStopsAtError(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var event streams.Event
+	require.NoError(json.Unmarshal([]byte(dynamoDBEvent), &event))
+	calls := 0
+	h := &streams.Handler{
+		OnDelete: func(ctx context.Context, s *streams.Session) error {
+			calls++
+			return errors.New("unavailable")
+		},
+	}
+
+	// when
+	err := h.HandleDynamoDB(context.Background(), &event)
+
+	// then
+	require.EqualError(err, "unavailable")
+	require.Equal(1, calls)
+}
+
+func TestHandleDynamoDBBatch(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	var event streams.Event
+	require.NoError(json.Unmarshal([]byte(dynamoDBEvent), &event))
+	var deleted, expired []string
+	h := &streams.Handler{
+		OnDelete: func(ctx context.Context, s *streams.Session) error {
+			deleted = append(deleted, s.Token)
+			return nil
+		},
+		OnExpire: func(ctx context.Context, s *streams.Session) error {
+			expired = append(expired, s.Token)
+			return errors.New("unavailable")
+		},
+	}
+
+	// when
+	response, err := h.HandleDynamoDBBatch(context.Background(), &event)
+
+	// then
+	require.NoError(err)
+	require.Equal([]streams.BatchItemFailure{{ItemIdentifier: "300"}}, response.BatchItemFailures)
+	require.Equal([]string{"deleted"}, deleted)
+	require.Equal([]string{"expired"}, expired)
+
+	// when
+	h.OnExpire = nil
+	event.Records = event.Records[2:]
+	response, err = h.HandleDynamoDBBatch(context.Background(), &event)
+
+	// then
+	require.NoError(err)
+	require.Empty(response.BatchItemFailures)
+	require.Equal([]string{"deleted", "revoked"}, deleted)
+}
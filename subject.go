@@ -0,0 +1,178 @@
+package dynamostore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var errNoSubject = errors.New("requires WithSubjectKey")
+
+// WithSubjectKey identifies the data subject, usually a user, that each
+// session belongs to, for DeleteBySubject and ExportBySubject. Session data
+// is decoded with the store's codec, and the subject is the value stored
+// under key, such as the key passed to SessionManager.Put when a user logs
+// in. Sessions without the key belong to no subject.
+func WithSubjectKey(key string) Option {
+	return func(s *DynamoStore) {
+		if key == "" {
+			s.invalid("WithSubjectKey requires a key")
+			return
+		}
+		s.subjectKey = key
+	}
+}
+
+// subjectOf returns the subject of a session, or "" if it has none. Sessions
+// that can't be decoded have no subject.
+func (s *DynamoStore) subjectOf(data []byte) string {
+	_, values, err := s.codec.Decode(data)
+	if err != nil {
+		return ""
+	}
+	if v, ok := values[s.subjectKey]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// DeleteBySubject removes every session belonging to subject, including
+// expired sessions that DynamoDB hasn't removed yet, and returns the number
+// of sessions removed. It requires WithSubjectKey.
+//
+// The whole table is scanned. With WithSubjectIndex, the sessions in the
+// index are found with a query, but sessions committed before the option was
+// enabled aren't indexed, so the sessions without an indexed subject are
+// still scanned for, and only those are decoded. Use WithCapacityLimit to
+// limit the impact on other users of the table.
+func (s *DynamoStore) DeleteBySubject(ctx context.Context, subject string) (int, error) {
+	if s.subjectKey == "" {
+		return 0, errNoSubject
+	}
+	n := 0
+	if s.subjectIndex {
+		if subject == "" {
+			return 0, nil
+		}
+		err := s.querySubject(ctx, subject, func(av map[string]types.AttributeValue) error {
+			token, ok := av["token"].(*types.AttributeValueMemberS)
+			if !ok {
+				return nil
+			}
+			if err := s.DeleteCtx(ctx, token.Value); err != nil {
+				return err
+			}
+			n++
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	err := s.scanSubject(ctx, subject, func(item *sessionItem) error {
+		if err := s.DeleteCtx(ctx, item.Token); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// scanSubject calls fn for every session belonging to subject that isn't in
+// the subject index. Without WithSubjectIndex, that is every session
+// belonging to subject.
+func (s *DynamoStore) scanSubject(ctx context.Context, subject string, fn func(*sessionItem) error) error {
+	if subject == "" {
+		return nil
+	}
+	input := &dynamodb.ScanInput{}
+	if s.subjectIndex {
+		input.FilterExpression = aws.String("attribute_not_exists(#subject)")
+		input.ExpressionAttributeNames = map[string]string{"#subject": subjectAttribute}
+	}
+	return s.scan(ctx, input, func(av map[string]types.AttributeValue) error {
+		item, err := s.unmarshalItem(av)
+		if err != nil {
+			return err
+		}
+		if s.subjectOf(item.Data) != subject {
+			return nil
+		}
+		return fn(item)
+	})
+}
+
+// SubjectRecord is a single session, as written by ExportBySubject. Records
+// are encoded as JSON, one per line, with Data encoded as base64.
+//
+// Unlike ExportRecord, the token is omitted, since it grants access to the
+// session. Expired is true for sessions that have expired but haven't been
+// removed by DynamoDB yet.
+type SubjectRecord struct {
+	TokenHash string    `json:"token_hash"`
+	Expiry    time.Time `json:"expiry"`
+	Expired   bool      `json:"expired"`
+	Data      []byte    `json:"data"`
+}
+
+// ExportBySubject writes every session belonging to subject to w as JSON
+// lines, including expired sessions that DynamoDB hasn't removed yet, and
+// returns the number of sessions written. It requires WithSubjectKey.
+//
+// Like DeleteBySubject, ExportBySubject scans the whole table, and queries
+// the index first if WithSubjectIndex is used. The index doesn't include
+// session data, so each session found in it is then read from the table.
+func (s *DynamoStore) ExportBySubject(ctx context.Context, subject string, w io.Writer) (int, error) {
+	if s.subjectKey == "" {
+		return 0, errNoSubject
+	}
+	now := s.now()
+	enc := json.NewEncoder(w)
+	n := 0
+	export := func(item *sessionItem) error {
+		hash, err := s.HashToken(ctx, item.Token)
+		if err != nil {
+			return err
+		}
+		data, err := s.readHook(ctx, item.Data)
+		if err != nil {
+			return err
+		}
+		n++
+		return enc.Encode(&SubjectRecord{
+			TokenHash: hash,
+			Expiry:    item.TTL,
+			Expired:   s.expiredAt(item.TTL, now),
+			Data:      data,
+		})
+	}
+	if s.subjectIndex {
+		if subject == "" {
+			return 0, nil
+		}
+		err := s.querySubject(ctx, subject, func(av map[string]types.AttributeValue) error {
+			token, ok := av["token"].(*types.AttributeValueMemberS)
+			if !ok {
+				return nil
+			}
+			item, err := s.getItem(ctx, token.Value)
+			if err != nil || item.Token == "" {
+				return err
+			}
+			return export(item)
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	err := s.scanSubject(ctx, subject, export)
+	return n, err
+}
@@ -0,0 +1,120 @@
+package dynamostore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeSession(t *testing.T, expiry time.Time, values map[string]interface{}) []byte {
+	data, err := scs.GobCodec{}.Encode(expiry, values)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDeleteBySubject(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now()
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithSubjectKey("userID"),
+	})
+	ctx := context.Background()
+	for token, values := range map[string]map[string]interface{}{
+		"alice1": {"userID": "alice"},
+		"alice2": {"userID": "alice", "theme": "dark"},
+		"bob":    {"userID": "bob"},
+		"anon":   {"theme": "light"},
+	} {
+		expiry := now.Add(time.Hour)
+		require.NoError(store.Commit(token, encodeSession(t, expiry, values), expiry))
+	}
+	expired := now.Add(-time.Hour)
+	data := encodeSession(t, expired, map[string]interface{}{"userID": "alice"})
+	require.NoError(store.Commit("alice3", data, expired))
+	require.NoError(store.Commit("garbage", []byte("not gob"), now.Add(time.Hour)))
+
+	// when
+	n, err := store.DeleteBySubject(ctx, "alice")
+
+	// then
+	require.NoError(err)
+	require.Equal(3, n)
+	require.Len(svc.items, 3)
+	for _, token := range []string{"bob", "anon", "garbage"} {
+		require.Contains(svc.items, token)
+	}
+
+	// when
+	n, err = store.DeleteBySubject(ctx, "")
+
+	// then
+	require.NoError(err)
+	require.Zero(n)
+}
+
+func TestExportBySubject(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	now := time.Now().Truncate(time.Second)
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithClock(func() time.Time { return now }),
+		WithSubjectKey("userID"),
+	})
+	expiry := now.Add(time.Hour)
+	alice := encodeSession(t, expiry, map[string]interface{}{"userID": "alice"})
+	require.NoError(store.Commit("alice1", alice, expiry))
+	require.NoError(store.Commit("bob", encodeSession(t, expiry, map[string]interface{}{"userID": "bob"}), expiry))
+	expired := now.Add(-time.Hour)
+	stale := encodeSession(t, expired, map[string]interface{}{"userID": "alice"})
+	require.NoError(store.Commit("alice2", stale, expired))
+
+	// when
+	var buf bytes.Buffer
+	n, err := store.ExportBySubject(context.Background(), "alice", &buf)
+
+	// then
+	require.NoError(err)
+	require.Equal(2, n)
+	require.NotContains(buf.String(), "alice1")
+
+	records := map[string]SubjectRecord{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r SubjectRecord
+		require.NoError(dec.Decode(&r))
+		records[r.TokenHash] = r
+	}
+	require.Equal(map[string]SubjectRecord{
+		hashToken("alice1"): {
+			TokenHash: hashToken("alice1"),
+			Expiry:    expiry.UTC(),
+			Data:      alice,
+		},
+		hashToken("alice2"): {
+			TokenHash: hashToken("alice2"),
+			Expiry:    expired.UTC(),
+			Expired:   true,
+			Data:      stale,
+		},
+	}, records)
+}
+
+func TestSubjectRequiresKey(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, nil)
+	_, err := store.DeleteBySubject(context.Background(), "alice")
+	require.Equal(errNoSubject, err)
+	_, err = store.ExportBySubject(context.Background(), "alice", &bytes.Buffer{})
+	require.Equal(errNoSubject, err)
+}
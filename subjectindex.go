@@ -0,0 +1,205 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SubjectIndexName is the name of the global secondary index created by
+// CreateTable when WithSubjectIndex is used.
+const SubjectIndexName = "SubjectIndex"
+
+// subjectAttribute stores the subject of a session, when WithSubjectIndex is
+// used.
+const subjectAttribute = "Subject"
+
+var errNoSubjectIndex = errors.New("requires WithSubjectIndex")
+
+// WithSubjectIndex stores the subject found by WithSubjectKey with each
+// session, so the sessions of a subject, usually a user, are grouped
+// together in SubjectIndexName, a global secondary index partitioned by
+// subject and sorted by token. SubjectSessions and CountBySubject can then
+// list a user's sessions, such as for a "my devices" page, with a single
+// query.
+//
+// The table itself is still keyed by token, since Find is only given the
+// token, so changes to a user's sessions aren't atomic. CreateTable,
+// CloudFormationTemplate, and TerraformConfig include the index. It must be
+// added to existing tables, and sessions committed before the option is
+// enabled aren't indexed until they are committed again. DeleteBySubject and
+// ExportBySubject must find every session of a user, so they query the index
+// and then scan the table for sessions that aren't indexed.
+func WithSubjectIndex() Option {
+	return func(s *DynamoStore) {
+		s.subjectIndex = true
+	}
+}
+
+// subjectItem adds the subject of data to av, if WithSubjectIndex is used.
+func (s *DynamoStore) subjectItem(av map[string]types.AttributeValue, data []byte) {
+	if !s.subjectIndex || s.subjectKey == "" || data == nil {
+		return
+	}
+	if subject := s.subjectOf(data); subject != "" {
+		av[subjectAttribute] = &types.AttributeValueMemberS{Value: subject}
+	}
+}
+
+// subjectIndexDefinition returns the index created for WithSubjectIndex.
+// Attributes indexed by WithAttributeIndex are projected, so they can
+// describe each session.
+func (s *DynamoStore) subjectIndexDefinition() types.GlobalSecondaryIndex {
+	attrs := []string{s.ttlAttribute, revokedAttribute, createdAtAttribute}
+	for _, idx := range s.attributeIndexes {
+		attrs = append(attrs, idx.attribute)
+	}
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(SubjectIndexName),
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String(subjectAttribute),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("token"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		Projection: &types.Projection{
+			ProjectionType:   types.ProjectionTypeInclude,
+			NonKeyAttributes: attrs,
+		},
+		ProvisionedThroughput: s.billing.throughput(),
+	}
+}
+
+// SubjectSession is a session found by SubjectSessions.
+type SubjectSession struct {
+	Token  string
+	Expiry time.Time
+	// CreatedAt is when the session was first committed, if
+	// WithCreationTime is used.
+	CreatedAt time.Time
+	// Attributes contains the values of the attributes indexed by
+	// WithAttributeIndex, such as a device ID.
+	Attributes map[string]string
+}
+
+// SubjectSessions returns the unexpired sessions belonging to subject, in
+// order of token. Revoked sessions are skipped. It requires
+// WithSubjectIndex.
+//
+// The index is eventually consistent, so a session committed moments ago may
+// be missing.
+func (s *DynamoStore) SubjectSessions(ctx context.Context, subject string) ([]*SubjectSession, error) {
+	if !s.subjectIndex {
+		return nil, errNoSubjectIndex
+	}
+	var sessions []*SubjectSession
+	now := s.now()
+	err := s.querySubject(ctx, subject, func(av map[string]types.AttributeValue) error {
+		token, ok := av["token"].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil
+		}
+		if _, ok := av[revokedAttribute]; ok {
+			return nil
+		}
+		expiry, _ := unixAttribute(av, s.ttlAttribute)
+		if s.hideExpired(expiry, now) {
+			return nil
+		}
+		session := &SubjectSession{
+			Token:      token.Value,
+			Expiry:     expiry,
+			Attributes: map[string]string{},
+		}
+		session.CreatedAt, _ = unixAttribute(av, createdAtAttribute)
+		for _, idx := range s.attributeIndexes {
+			if v, ok := av[idx.attribute].(*types.AttributeValueMemberS); ok {
+				session.Attributes[idx.attribute] = v.Value
+			}
+		}
+		sessions = append(sessions, session)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// querySubject calls fn for every item in the subject index belonging to
+// subject.
+func (s *DynamoStore) querySubject(
+	ctx context.Context, subject string, fn func(map[string]types.AttributeValue) error,
+) error {
+	return s.query(ctx, &dynamodb.QueryInput{
+		IndexName:                aws.String(SubjectIndexName),
+		KeyConditionExpression:   aws.String("#subject = :subject"),
+		ExpressionAttributeNames: map[string]string{"#subject": subjectAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject},
+		},
+	}, fn)
+}
+
+// CountBySubject returns the number of unexpired sessions belonging to
+// subject, such as to show users how many devices are signed in, or to cap
+// concurrent sessions. Revoked sessions aren't counted. Only the count is
+// returned by DynamoDB, but the read capacity consumed still depends on the
+// size of the sessions indexed. It requires WithSubjectIndex.
+//
+// Like SubjectSessions, the count is eventually consistent.
+func (s *DynamoStore) CountBySubject(ctx context.Context, subject string) (int, error) {
+	if !s.subjectIndex {
+		return 0, errNoSubjectIndex
+	}
+	params := &dynamodb.QueryInput{
+		TableName:              s.table,
+		IndexName:              aws.String(SubjectIndexName),
+		Select:                 types.SelectCount,
+		KeyConditionExpression: aws.String("#subject = :subject"),
+		ExpressionAttributeNames: map[string]string{
+			"#subject": subjectAttribute,
+			"#revoked": revokedAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject},
+		},
+		FilterExpression:       aws.String("attribute_not_exists(#revoked)"),
+		ReturnConsumedCapacity: s.limiter.returnConsumedCapacity(),
+	}
+	if !s.skipExpiryCheck {
+		params.FilterExpression = aws.String("attribute_not_exists(#revoked) AND #ttl >= :now")
+		params.ExpressionAttributeNames["#ttl"] = s.ttlAttribute
+		params.ExpressionAttributeValues[":now"] = &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(s.now().Add(-s.gracePeriod).Unix(), 10),
+		}
+	}
+
+	n := 0
+	for {
+		units, err := s.limiter.waitRead(ctx)
+		if err != nil {
+			return 0, err
+		}
+		result, err := s.svc.Query(ctx, params, s.optFns...)
+		if err != nil {
+			return 0, err
+		}
+		s.limiter.consumedRead(units, result.ConsumedCapacity)
+		n += int(result.Count)
+
+		if len(result.LastEvaluatedKey) < 1 {
+			return n, nil
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
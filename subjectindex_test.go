@@ -0,0 +1,143 @@
+package dynamostore_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestSubjectSessions(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	store := dynamostore.New(fake.NewClient(),
+		dynamostore.WithoutSleep(),
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithSubjectKey("user"),
+		dynamostore.WithSubjectIndex(),
+		dynamostore.WithAttributeIndex("Device", "device"),
+		dynamostore.WithCreationTime(),
+	)
+	require.NoError(store.Validate())
+	require.NoError(store.CreateTable())
+
+	commit := func(token string, expiry time.Time, values map[string]interface{}) {
+		data, err := scs.GobCodec{}.Encode(expiry, values)
+		require.NoError(err)
+		require.NoError(store.Commit(token, data, expiry))
+	}
+
+	// given
+	expiry := now.Add(time.Hour)
+	commit("b", expiry, map[string]interface{}{"user": "alice", "device": "phone"})
+	commit("a", expiry, map[string]interface{}{"user": "alice", "device": "laptop"})
+	commit("c", expiry, map[string]interface{}{"user": "bob", "device": "phone"})
+	commit("d", now.Add(-time.Hour), map[string]interface{}{"user": "alice"})
+
+	// when alice's sessions are listed
+	sessions, err := store.SubjectSessions(ctx, "alice")
+
+	// then they are found in order of token, without expired sessions
+	require.NoError(err)
+	require.Len(sessions, 2)
+	require.Equal("a", sessions[0].Token)
+	require.Equal(map[string]string{"Device": "laptop"}, sessions[0].Attributes)
+	require.True(expiry.Equal(sessions[0].Expiry))
+	require.True(now.Equal(sessions[0].CreatedAt))
+	require.Equal("b", sessions[1].Token)
+
+	// when alice's sessions are counted
+	count, err := store.CountBySubject(ctx, "alice")
+
+	// then the expired session isn't counted
+	require.NoError(err)
+	require.Equal(2, count)
+
+	// when alice's sessions are deleted
+	n, err := store.DeleteBySubject(ctx, "alice")
+
+	// then only her sessions are removed
+	require.NoError(err)
+	require.Equal(3, n)
+	sessions, err = store.SubjectSessions(ctx, "alice")
+	require.NoError(err)
+	require.Empty(sessions)
+	sessions, err = store.SubjectSessions(ctx, "bob")
+	require.NoError(err)
+	require.Len(sessions, 1)
+}
+
+func TestSubjectIndexValidation(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New(dynamostore.WithSubjectIndex())
+	require.Error(store.Validate())
+
+	_, err := fake.New().SubjectSessions(context.Background(), "alice")
+	require.Error(err)
+	_, err = fake.New().CountBySubject(context.Background(), "alice")
+	require.Error(err)
+}
+
+func TestExportBySubjectWithIndex(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	expiry := now.Add(time.Hour)
+	client := fake.NewClient()
+	commit := func(store *dynamostore.DynamoStore, token string) {
+		data, err := scs.GobCodec{}.Encode(expiry, map[string]interface{}{"user": "alice"})
+		require.NoError(err)
+		require.NoError(store.Commit(token, data, expiry))
+	}
+
+	// given a session committed without the index, and one committed with it
+	store := dynamostore.New(client,
+		dynamostore.WithoutSleep(),
+		dynamostore.WithClock(func() time.Time { return now }),
+		dynamostore.WithSubjectKey("user"),
+		dynamostore.WithSubjectIndex(),
+	)
+	require.NoError(store.CreateTable())
+	commit(dynamostore.New(client, dynamostore.WithSubjectKey("user")), "old")
+	commit(store, "new")
+
+	// when
+	var buf bytes.Buffer
+	n, err := store.ExportBySubject(ctx, "alice", &buf)
+
+	// then the indexed session is exported, and the session that isn't
+	// indexed is found by scanning
+	require.NoError(err)
+	require.Equal(2, n)
+	dec := json.NewDecoder(&buf)
+	var record dynamostore.SubjectRecord
+	require.NoError(dec.Decode(&record))
+	require.Equal(dynamostore.TokenHash("new"), record.TokenHash)
+	require.True(expiry.Equal(record.Expiry))
+	require.NotEmpty(record.Data)
+	require.NoError(dec.Decode(&record))
+	require.Equal(dynamostore.TokenHash("old"), record.TokenHash)
+
+	// when
+	n, err = store.DeleteBySubject(ctx, "alice")
+
+	// then both sessions are removed
+	require.NoError(err)
+	require.Equal(2, n)
+	for _, token := range []string{"old", "new"} {
+		_, exists, err := store.Find(token)
+		require.NoError(err)
+		require.False(exists, token)
+	}
+}
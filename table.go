@@ -0,0 +1,306 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrDeleteTimedOut is returned when table deletion takes too long.
+var ErrDeleteTimedOut = errors.New("timed out waiting for table deletion")
+
+var errNoOnDemandLimits = errors.New("requires WithMaxOnDemandThroughput")
+
+// WithTTLAttribute sets the name of the attribute used to store session
+// expiry, and enabled as the table's TTL attribute by CreateTable. The
+// default is "ttl".
+func WithTTLAttribute(name string) Option {
+	return func(s *DynamoStore) {
+		s.ttlAttribute = name
+	}
+}
+
+// WithProvisionedThroughput makes CreateTable use provisioned capacity with
+// the given read and write capacity units, instead of on-demand capacity.
+func WithProvisionedThroughput(readUnits, writeUnits int64) Option {
+	return func(s *DynamoStore) {
+		s.billing.readUnits = readUnits
+		s.billing.writeUnits = writeUnits
+	}
+}
+
+// WithWarmThroughput makes CreateTable pre-warm the table, so it can serve
+// the given read and write units per second as soon as it is created, such
+// as ahead of a launch, instead of scaling up during the spike in traffic.
+// Either may be zero to leave it to DynamoDB. Pre-warming is billed once,
+// and works with both on-demand and provisioned capacity.
+func WithWarmThroughput(readUnitsPerSecond, writeUnitsPerSecond int64) Option {
+	return func(s *DynamoStore) {
+		if readUnitsPerSecond < 0 || writeUnitsPerSecond < 0 || readUnitsPerSecond+writeUnitsPerSecond < 1 {
+			s.invalid("WithWarmThroughput requires a positive read or write rate")
+			return
+		}
+		s.billing.warmReads = readUnitsPerSecond
+		s.billing.warmWrites = writeUnitsPerSecond
+	}
+}
+
+// WithMaxOnDemandThroughput limits the read and write request units per
+// second an on-demand table can consume, so a misbehaving client can't run
+// up an unbounded bill. Requests beyond the limits are throttled. Either may
+// be zero to leave it unlimited. CreateTable applies the limits to new
+// tables, and UpdateOnDemandThroughput to existing ones.
+func WithMaxOnDemandThroughput(maxReadRequestUnits, maxWriteRequestUnits int64) Option {
+	return func(s *DynamoStore) {
+		if maxReadRequestUnits < 0 || maxWriteRequestUnits < 0 || maxReadRequestUnits+maxWriteRequestUnits < 1 {
+			s.invalid("WithMaxOnDemandThroughput requires a positive read or write limit")
+			return
+		}
+		s.billing.maxReads = maxReadRequestUnits
+		s.billing.maxWrites = maxWriteRequestUnits
+	}
+}
+
+// WithContributorInsights makes CreateTable enable CloudWatch Contributor
+// Insights on new tables, so operators can see the most accessed and most
+// throttled session keys. Contributor Insights is billed by the number of
+// events it analyzes.
+func WithContributorInsights() Option {
+	return func(s *DynamoStore) {
+		s.contributorInsights = true
+	}
+}
+
+// WithTags sets tags applied to the table by CreateTable.
+func WithTags(tags map[string]string) Option {
+	return func(s *DynamoStore) {
+		s.tags = tags
+	}
+}
+
+// billing describes how capacity is provisioned for new tables.
+type billing struct {
+	readUnits  int64
+	writeUnits int64
+
+	// warmReads and warmWrites are set by WithWarmThroughput.
+	warmReads  int64
+	warmWrites int64
+	// maxReads and maxWrites are set by WithMaxOnDemandThroughput.
+	maxReads  int64
+	maxWrites int64
+}
+
+func (b billing) mode() types.BillingMode {
+	if b.provisioned() {
+		return types.BillingModeProvisioned
+	}
+	return types.BillingModePayPerRequest
+}
+
+func (b billing) provisioned() bool {
+	return b.readUnits > 0 || b.writeUnits > 0
+}
+
+func (b billing) throughput() *types.ProvisionedThroughput {
+	if !b.provisioned() {
+		return nil
+	}
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(b.readUnits),
+		WriteCapacityUnits: aws.Int64(b.writeUnits),
+	}
+}
+
+// warmThroughput returns the WarmThroughput parameter of CreateTable, or nil
+// if WithWarmThroughput isn't used.
+func (b billing) warmThroughput() *types.WarmThroughput {
+	if b.warmReads < 1 && b.warmWrites < 1 {
+		return nil
+	}
+	warm := &types.WarmThroughput{}
+	if b.warmReads > 0 {
+		warm.ReadUnitsPerSecond = aws.Int64(b.warmReads)
+	}
+	if b.warmWrites > 0 {
+		warm.WriteUnitsPerSecond = aws.Int64(b.warmWrites)
+	}
+	return warm
+}
+
+// onDemandThroughput returns the OnDemandThroughput parameter of CreateTable
+// and UpdateTable, or nil if WithMaxOnDemandThroughput isn't used. Limits that
+// aren't set are omitted, or set to -1 to remove them if remove is true.
+func (b billing) onDemandThroughput(remove bool) *types.OnDemandThroughput {
+	if b.maxReads < 1 && b.maxWrites < 1 {
+		return nil
+	}
+	limit := func(units int64) *int64 {
+		switch {
+		case units > 0:
+			return aws.Int64(units)
+		case remove:
+			return aws.Int64(-1)
+		default:
+			return nil
+		}
+	}
+	return &types.OnDemandThroughput{
+		MaxReadRequestUnits:  limit(b.maxReads),
+		MaxWriteRequestUnits: limit(b.maxWrites),
+	}
+}
+
+func (s *DynamoStore) tableTags() []types.Tag {
+	if len(s.tags) < 1 {
+		return nil
+	}
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]types.Tag, len(keys))
+	for i, k := range keys {
+		tags[i] = types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(s.tags[k]),
+		}
+	}
+	return tags
+}
+
+// TableInfo summarizes the state of the session store table.
+type TableInfo struct {
+	Name        string
+	ARN         string
+	Status      string
+	BillingMode string
+	CreatedAt   time.Time
+
+	// ItemCount and SizeBytes are updated by DynamoDB approximately
+	// every six hours.
+	ItemCount int64
+	SizeBytes int64
+
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+
+	TTLAttribute string
+	TTLStatus    string
+
+	// Replicas maps the regions of a global table to the status of their
+	// replicas, including the region of the table itself.
+	Replicas map[string]string
+}
+
+// DescribeTable returns information about the session store table.
+func (s *DynamoStore) DescribeTable() (*TableInfo, error) {
+	ctx := context.Background()
+	result, err := s.svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	table := result.Table
+	info := &TableInfo{
+		Name:        aws.ToString(table.TableName),
+		ARN:         aws.ToString(table.TableArn),
+		Status:      string(table.TableStatus),
+		BillingMode: string(types.BillingModeProvisioned),
+		ItemCount:   aws.ToInt64(table.ItemCount),
+		SizeBytes:   aws.ToInt64(table.TableSizeBytes),
+	}
+	if table.CreationDateTime != nil {
+		info.CreatedAt = *table.CreationDateTime
+	}
+	if table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode != "" {
+		info.BillingMode = string(table.BillingModeSummary.BillingMode)
+	}
+	if pt := table.ProvisionedThroughput; pt != nil {
+		info.ReadCapacityUnits = aws.ToInt64(pt.ReadCapacityUnits)
+		info.WriteCapacityUnits = aws.ToInt64(pt.WriteCapacityUnits)
+	}
+
+	if len(table.Replicas) > 0 {
+		info.Replicas = make(map[string]string, len(table.Replicas))
+		for _, replica := range table.Replicas {
+			info.Replicas[aws.ToString(replica.RegionName)] = string(replica.ReplicaStatus)
+		}
+	}
+
+	ttl, err := s.svc.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		return nil, err
+	}
+	if desc := ttl.TimeToLiveDescription; desc != nil {
+		info.TTLAttribute = aws.ToString(desc.AttributeName)
+		info.TTLStatus = string(desc.TimeToLiveStatus)
+	}
+
+	return info, nil
+}
+
+func (s *DynamoStore) enableContributorInsights(ctx context.Context) error {
+	_, err := s.svc.UpdateContributorInsights(ctx, &dynamodb.UpdateContributorInsightsInput{
+		TableName:                 s.table,
+		ContributorInsightsAction: types.ContributorInsightsActionEnable,
+	}, s.optFns...)
+	return err
+}
+
+// UpdateOnDemandThroughput applies the limits set by
+// WithMaxOnDemandThroughput to the existing table, and removes any limit that
+// isn't set. The table must use on-demand capacity.
+func (s *DynamoStore) UpdateOnDemandThroughput(ctx context.Context) error {
+	limits := s.billing.onDemandThroughput(true)
+	if limits == nil {
+		return errNoOnDemandLimits
+	}
+	_, err := s.svc.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:          s.table,
+		OnDemandThroughput: limits,
+	}, s.optFns...)
+	return err
+}
+
+// DeleteTable deletes the session store table, if it exists, and waits for
+// the deletion to finish. Like CreateTable, it is intended to make
+// development and testing easier.
+func (s *DynamoStore) DeleteTable() error {
+	ctx := context.Background()
+	_, err := s.svc.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		var notFoundErr *types.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return err
+	}
+
+	describeTable := &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}
+	for i := 0; i < 60; i++ {
+		s.sleep(1 * time.Second)
+		if _, err := s.svc.DescribeTable(ctx, describeTable, s.optFns...); err != nil {
+			var notFoundErr *types.ResourceNotFoundException
+			if errors.As(err, &notFoundErr) {
+				return nil
+			}
+			return err
+		}
+	}
+	return ErrDeleteTimedOut
+}
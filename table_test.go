@@ -0,0 +1,200 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTTLAttribute(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{WithTTLAttribute("expires_at")})
+
+	expiry := time.Now().Add(time.Minute)
+	require.NoError(store.Commit("foo", []byte("bar"), expiry))
+
+	item := svc.items["foo"]
+	require.Contains(item, "expires_at")
+	require.NotContains(item, "ttl")
+
+	actual, err := store.getItem(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal(expiry.Unix(), actual.TTL.Unix())
+}
+
+func TestTableOptions(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithProvisionedThroughput(5, 10),
+		WithTags(map[string]string{"team": "auth", "env": "prod"}),
+	})
+	require.Equal(types.BillingModeProvisioned, store.billing.mode())
+	require.Equal(int64(10), *store.billing.throughput().WriteCapacityUnits)
+	tags := store.tableTags()
+	require.Len(tags, 2)
+	require.Equal("env", *tags[0].Key)
+
+	store = newStore(newFakeClient(), DefaultTableName, nil)
+	require.Equal(types.BillingModePayPerRequest, store.billing.mode())
+	require.Nil(store.billing.throughput())
+	require.Nil(store.tableTags())
+}
+
+// racingClient simulates other callers changing the table. Each
+// DescribeTable reports the next status, where "" means the table doesn't
+// exist, and each DescribeTimeToLive reports whether TTL is enabled next.
+// The last of each is repeated.
+type racingClient struct {
+	*fakeClient
+	statuses []types.TableStatus
+	ttl      []bool
+}
+
+func (c *racingClient) DescribeTable(
+	ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTableOutput, error) {
+	if _, err := c.fakeClient.DescribeTable(ctx, params, optFns...); err != nil {
+		return nil, err
+	}
+	status := c.statuses[0]
+	if len(c.statuses) > 1 {
+		c.statuses = c.statuses[1:]
+	}
+	if status == "" {
+		return nil, &types.ResourceNotFoundException{}
+	}
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{TableName: params.TableName, TableStatus: status},
+	}, nil
+}
+
+func (c *racingClient) DescribeTimeToLive(
+	ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options),
+) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if _, err := c.fakeClient.DescribeTimeToLive(ctx, params, optFns...); err != nil {
+		return nil, err
+	}
+	enabled := c.ttl[0]
+	if len(c.ttl) > 1 {
+		c.ttl = c.ttl[1:]
+	}
+	desc := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if enabled {
+		desc.AttributeName = aws.String(defaultTTLAttribute)
+		desc.TimeToLiveStatus = types.TimeToLiveStatusEnabled
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: desc}, nil
+}
+
+func TestCreateTableCreatedByAnotherCaller(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		statuses  []types.TableStatus
+		ttl       []bool
+		ttlErr    error
+		err       error
+		updateTTL int
+	}{{
+		name:      "not configured",
+		statuses:  []types.TableStatus{"", types.TableStatusCreating, types.TableStatusActive},
+		ttl:       []bool{false},
+		updateTTL: 1,
+	}, {
+		name:     "configured",
+		statuses: []types.TableStatus{"", types.TableStatusActive},
+		ttl:      []bool{true},
+	}, {
+		name:      "configured concurrently",
+		statuses:  []types.TableStatus{"", types.TableStatusActive},
+		ttl:       []bool{false, true},
+		ttlErr:    errors.New("TimeToLive is already enabled"),
+		updateTTL: 1,
+	}, {
+		name:     "deleted",
+		statuses: []types.TableStatus{"", types.TableStatusCreating, ""},
+		ttl:      []bool{false},
+		err:      &types.ResourceNotFoundException{},
+	}, {
+		name:     "deleting",
+		statuses: []types.TableStatus{"", types.TableStatusDeleting},
+		ttl:      []bool{false},
+		err:      ErrDeleteInProgress,
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			// given another caller creates the table after it is checked
+			svc := newFakeClient()
+			svc.failWith("CreateTable", &types.ResourceInUseException{})
+			if tc.ttlErr != nil {
+				svc.failWith("UpdateTimeToLive", tc.ttlErr)
+			}
+			client := &racingClient{fakeClient: svc, statuses: tc.statuses, ttl: tc.ttl}
+			store := newStore(client, DefaultTableName, nil)
+			var slept time.Duration
+			store.sleep = func(d time.Duration) { slept += d }
+
+			// when
+			err := store.CreateTable()
+
+			// then the store waits for the table, and configures it if needed
+			if tc.err != nil {
+				require.Equal(tc.err, err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(1, svc.count("CreateTable"))
+			require.Equal(time.Duration(len(tc.statuses)-1)*time.Second, slept)
+			require.Equal(tc.updateTTL, svc.count("UpdateTimeToLive"))
+		})
+	}
+}
+
+func TestCreateDenylistTableCreatedByAnotherCaller(t *testing.T) {
+	require := require.New(t)
+
+	// given another caller creates the table, but doesn't enable TTL
+	svc := newFakeClient()
+	svc.failWith("CreateTable", &types.ResourceInUseException{})
+	client := &racingClient{
+		fakeClient: svc,
+		statuses:   []types.TableStatus{types.TableStatusCreating, types.TableStatusActive},
+		ttl:        []bool{false},
+	}
+	store := newStore(client, DefaultTableName, []Option{
+		WithDenylist(DenylistConfig{Table: "scs.denylist"}),
+	})
+	store.sleep = func(time.Duration) {}
+
+	// when
+	err := store.CreateDenylistTable(context.Background())
+
+	// then TTL is enabled once the table is active
+	require.NoError(err)
+	require.Equal(1, svc.count("UpdateTimeToLive"))
+
+	// when the other caller deletes the table instead
+	client.statuses = []types.TableStatus{""}
+	err = store.CreateDenylistTable(context.Background())
+
+	// then the error is returned
+	require.Equal(&types.ResourceNotFoundException{}, err)
+}
+
+// WithoutSleep stops the store from waiting between polls of a table's
+// status, so the external tests that create tables run quickly.
+func WithoutSleep() Option {
+	return func(s *DynamoStore) {
+		s.sleep = func(time.Duration) {}
+	}
+}
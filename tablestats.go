@@ -0,0 +1,141 @@
+package dynamostore
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultStatsSample is the number of items read by TableStats when the
+// sample size is zero or less.
+const DefaultStatsSample = 1000
+
+// TableStats describes the contents of the session table, as returned by
+// TableStats. Unlike Stats, it covers every instance of the application.
+type TableStats struct {
+	// ItemCount and SizeBytes are reported by DynamoDB, which updates them
+	// approximately every six hours.
+	ItemCount int64
+	SizeBytes int64
+
+	// Sampled is the number of items read to estimate the rest, and
+	// Complete is true if every item in the table was read.
+	Sampled  int
+	Complete bool
+	// ExpiredRatio is the fraction of sampled items that have expired, but
+	// haven't been removed by DynamoDB's TTL process yet.
+	ExpiredRatio float64
+	// AverageItemSize is the mean size of the sampled items, in bytes, as
+	// DynamoDB measures it.
+	AverageItemSize float64
+}
+
+// ExpiredItems estimates the number of items that have expired, but haven't
+// been removed yet.
+func (t *TableStats) ExpiredItems() int64 {
+	return int64(float64(t.ItemCount) * t.ExpiredRatio)
+}
+
+// TableStats combines the item count and size DynamoDB reports for the table
+// with estimates from reading up to sample items, for dashboards and
+// capacity reviews. Items are read in the order DynamoDB stores them, which
+// is effectively random for session tokens, and aren't decrypted. Items
+// count as expired after WithExpiryGracePeriod, like DeleteExpired.
+//
+// Use WithCapacityLimit to limit the impact of large samples on other users
+// of the table.
+func (s *DynamoStore) TableStats(ctx context.Context, sample int) (*TableStats, error) {
+	if sample < 1 {
+		sample = DefaultStatsSample
+	}
+	t, err := s.sampleTable(ctx, sample)
+	if err != nil {
+		return nil, err
+	}
+	stats := &TableStats{
+		ItemCount: t.itemCount,
+		SizeBytes: t.sizeBytes,
+		Sampled:   t.sampled,
+		Complete:  t.complete,
+	}
+	if t.sampled > 0 {
+		stats.ExpiredRatio = float64(t.expired) / float64(t.sampled)
+		stats.AverageItemSize = float64(t.bytes) / float64(t.sampled)
+	}
+	return stats, nil
+}
+
+// tableSample summarizes the items read by sampleTable.
+type tableSample struct {
+	itemCount int64
+	sizeBytes int64
+
+	sampled  int
+	complete bool
+	bytes    int64
+
+	expired      int
+	expiredBytes int64
+	oldestExpiry time.Time
+}
+
+// sampleTable reads up to limit items, or the whole table if limit is zero
+// or less, for TableStats and ReportExpired.
+func (s *DynamoStore) sampleTable(ctx context.Context, limit int) (*tableSample, error) {
+	result, err := s.svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: s.table,
+	}, s.optFns...)
+	if err != nil {
+		return nil, err
+	}
+	t := &tableSample{
+		itemCount: aws.ToInt64(result.Table.ItemCount),
+		sizeBytes: aws.ToInt64(result.Table.TableSizeBytes),
+	}
+
+	// One item more than the sample is read, to find out whether the whole
+	// table was sampled without reading another page.
+	input := &dynamodb.ScanInput{}
+	if limit > 0 && limit < math.MaxInt32 {
+		input.Limit = aws.Int32(int32(limit + 1))
+	}
+	now := s.now()
+	err = s.scan(ctx, input, func(av map[string]types.AttributeValue) error {
+		if limit > 0 && t.sampled >= limit {
+			return errStopScan
+		}
+		t.sampled++
+		size := int64(itemSize(av))
+		t.bytes += size
+		ttl, ok := av[s.ttlAttribute].(*types.AttributeValueMemberN)
+		if !ok {
+			return nil
+		}
+		sec, err := strconv.ParseInt(ttl.Value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		expiry := time.Unix(sec, 0)
+		if !s.expiredAt(expiry, now) {
+			return nil
+		}
+		t.expired++
+		t.expiredBytes += size
+		if t.oldestExpiry.IsZero() || expiry.Before(t.oldestExpiry) {
+			t.oldestExpiry = expiry
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		t.complete = true
+	case err != errStopScan:
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,51 @@
+package dynamostore_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestTableStats(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	now := time.Now()
+	store := fake.New(dynamostore.WithClock(func() time.Time { return now }))
+
+	// given one expired session for every three unexpired sessions
+	for i := 0; i < 8; i++ {
+		expiry := now.Add(time.Hour)
+		if i%4 == 0 {
+			expiry = now.Add(-time.Hour)
+		}
+		require.NoError(store.Commit("token"+strconv.Itoa(i), []byte("data"), expiry))
+	}
+
+	// when every item is sampled
+	stats, err := store.TableStats(ctx, 0)
+
+	// then
+	require.NoError(err)
+	require.Equal(int64(8), stats.ItemCount)
+	require.Greater(stats.SizeBytes, int64(0))
+	require.Equal(8, stats.Sampled)
+	require.True(stats.Complete)
+	require.Equal(0.25, stats.ExpiredRatio)
+	require.Equal(int64(2), stats.ExpiredItems())
+	require.Equal(float64(stats.SizeBytes)/8, stats.AverageItemSize)
+
+	// when fewer items are sampled
+	stats, err = store.TableStats(ctx, 3)
+
+	// then the sample is limited
+	require.NoError(err)
+	require.Equal(3, stats.Sampled)
+	require.False(stats.Complete)
+}
@@ -0,0 +1,155 @@
+package dynamostore
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TerraformConfig returns an aws_dynamodb_table resource block, in
+// Terraform's HCL syntax, that defines the session store table the same way
+// CreateTable would. The resource is labeled with resourceName. Each region
+// given to WithReplicas becomes a replica block, so the region of the
+// Terraform provider must not be one of them.
+func (s *DynamoStore) TerraformConfig(resourceName string) []byte {
+	input := s.createTableInput()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "resource \"aws_dynamodb_table\" %s {\n", hclString(resourceName))
+
+	attrs := [][2]string{
+		{"name", hclString(aws.ToString(input.TableName))},
+		{"billing_mode", hclString(string(input.BillingMode))},
+		{"hash_key", hclString(aws.ToString(input.KeySchema[0].AttributeName))},
+	}
+	if pt := input.ProvisionedThroughput; pt != nil {
+		attrs = append(attrs,
+			[2]string{"read_capacity", strconv.FormatInt(aws.ToInt64(pt.ReadCapacityUnits), 10)},
+			[2]string{"write_capacity", strconv.FormatInt(aws.ToInt64(pt.WriteCapacityUnits), 10)},
+		)
+	}
+	if len(s.replicas) > 0 {
+		attrs = append(attrs,
+			[2]string{"stream_enabled", "true"},
+			[2]string{"stream_view_type", hclString(string(types.StreamViewTypeNewAndOldImages))},
+		)
+	}
+	writeHCLAttributes(&buf, "  ", attrs)
+
+	for _, attr := range input.AttributeDefinitions {
+		buf.WriteString("\n  attribute {\n")
+		writeHCLAttributes(&buf, "    ", [][2]string{
+			{"name", hclString(aws.ToString(attr.AttributeName))},
+			{"type", hclString(string(attr.AttributeType))},
+		})
+		buf.WriteString("  }\n")
+	}
+
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		attrs := [][2]string{{"name", hclString(aws.ToString(gsi.IndexName))}}
+		for _, key := range gsi.KeySchema {
+			name := "hash_key"
+			if key.KeyType == types.KeyTypeRange {
+				name = "range_key"
+			}
+			attrs = append(attrs, [2]string{name, hclString(aws.ToString(key.AttributeName))})
+		}
+		attrs = append(attrs, [2]string{"projection_type", hclString(string(gsi.Projection.ProjectionType))})
+		if len(gsi.Projection.NonKeyAttributes) > 0 {
+			quoted := make([]string, len(gsi.Projection.NonKeyAttributes))
+			for i, name := range gsi.Projection.NonKeyAttributes {
+				quoted[i] = hclString(name)
+			}
+			attrs = append(attrs, [2]string{"non_key_attributes", "[" + strings.Join(quoted, ", ") + "]"})
+		}
+		if pt := gsi.ProvisionedThroughput; pt != nil {
+			attrs = append(attrs,
+				[2]string{"read_capacity", strconv.FormatInt(aws.ToInt64(pt.ReadCapacityUnits), 10)},
+				[2]string{"write_capacity", strconv.FormatInt(aws.ToInt64(pt.WriteCapacityUnits), 10)},
+			)
+		}
+		buf.WriteString("\n  global_secondary_index {\n")
+		writeHCLAttributes(&buf, "    ", attrs)
+		buf.WriteString("  }\n")
+	}
+
+	if b := s.billing; b.warmThroughput() != nil {
+		var attrs [][2]string
+		if b.warmReads > 0 {
+			attrs = append(attrs, [2]string{"read_units_per_second", strconv.FormatInt(b.warmReads, 10)})
+		}
+		if b.warmWrites > 0 {
+			attrs = append(attrs, [2]string{"write_units_per_second", strconv.FormatInt(b.warmWrites, 10)})
+		}
+		buf.WriteString("\n  warm_throughput {\n")
+		writeHCLAttributes(&buf, "    ", attrs)
+		buf.WriteString("  }\n")
+	}
+
+	if b := s.billing; b.onDemandThroughput(false) != nil {
+		var attrs [][2]string
+		if b.maxReads > 0 {
+			attrs = append(attrs, [2]string{"max_read_request_units", strconv.FormatInt(b.maxReads, 10)})
+		}
+		if b.maxWrites > 0 {
+			attrs = append(attrs, [2]string{"max_write_request_units", strconv.FormatInt(b.maxWrites, 10)})
+		}
+		buf.WriteString("\n  on_demand_throughput {\n")
+		writeHCLAttributes(&buf, "    ", attrs)
+		buf.WriteString("  }\n")
+	}
+
+	buf.WriteString("\n  ttl {\n")
+	writeHCLAttributes(&buf, "    ", [][2]string{
+		{"attribute_name", hclString(s.ttlAttribute)},
+		{"enabled", "true"},
+	})
+	buf.WriteString("  }\n")
+
+	for _, region := range s.replicas {
+		buf.WriteString("\n  replica {\n")
+		writeHCLAttributes(&buf, "    ", [][2]string{{"region_name", hclString(region)}})
+		buf.WriteString("  }\n")
+	}
+
+	if len(input.Tags) > 0 {
+		tags := make([][2]string, len(input.Tags))
+		for i, tag := range input.Tags {
+			tags[i] = [2]string{
+				hclString(aws.ToString(tag.Key)),
+				hclString(aws.ToString(tag.Value)),
+			}
+		}
+		buf.WriteString("\n  tags = {\n")
+		writeHCLAttributes(&buf, "    ", tags)
+		buf.WriteString("  }\n")
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// writeHCLAttributes writes name = value pairs, aligned the way
+// "terraform fmt" aligns them.
+func writeHCLAttributes(buf *bytes.Buffer, indent string, attrs [][2]string) {
+	width := 0
+	for _, attr := range attrs {
+		if len(attr[0]) > width {
+			width = len(attr[0])
+		}
+	}
+	for _, attr := range attrs {
+		fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, attr[0], attr[1])
+	}
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences.
+func hclString(s string) string {
+	s = strconv.Quote(s)
+	s = strings.Replace(s, "${", "$${", -1)
+	return strings.Replace(s, "%{", "%%{", -1)
+}
@@ -0,0 +1,145 @@
+package dynamostore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerraformConfig(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), "sessions", []Option{
+		WithTTLAttribute("expires"),
+		WithProvisionedThroughput(5, 10),
+		WithTags(map[string]string{"team": "web", "cost-center": "${shared}"}),
+	})
+	expected := `resource "aws_dynamodb_table" "sessions" {
+  name           = "sessions"
+  billing_mode   = "PROVISIONED"
+  hash_key       = "token"
+  read_capacity  = 5
+  write_capacity = 10
+
+  attribute {
+    name = "token"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires"
+    enabled        = true
+  }
+
+  tags = {
+    "cost-center" = "$${shared}"
+    "team"        = "web"
+  }
+}
+`
+	require.Equal(expected, string(store.TerraformConfig("sessions")))
+
+	store = newStore(newFakeClient(), DefaultTableName, nil)
+	expected = `resource "aws_dynamodb_table" "sessions" {
+  name         = "scs.session"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "token"
+
+  attribute {
+    name = "token"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+}
+`
+	require.Equal(expected, string(store.TerraformConfig("sessions")))
+	store = newStore(newFakeClient(), DefaultTableName, []Option{
+		WithExpiryIndex(time.Hour),
+	})
+	expected = `resource "aws_dynamodb_table" "sessions" {
+  name         = "scs.session"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "token"
+
+  attribute {
+    name = "token"
+    type = "S"
+  }
+
+  attribute {
+    name = "ExpiryBucket"
+    type = "N"
+  }
+
+  attribute {
+    name = "ttl"
+    type = "N"
+  }
+
+  global_secondary_index {
+    name               = "ExpiryIndex"
+    hash_key           = "ExpiryBucket"
+    range_key          = "ttl"
+    projection_type    = "INCLUDE"
+    non_key_attributes = ["Revoked"]
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+}
+`
+	require.Equal(expected, string(store.TerraformConfig("sessions")))
+
+	store = newStore(newFakeClient(), DefaultTableName, []Option{
+		WithReplicas("us-west-2", "eu-west-1"),
+	})
+	expected = `resource "aws_dynamodb_table" "sessions" {
+  name             = "scs.session"
+  billing_mode     = "PAY_PER_REQUEST"
+  hash_key         = "token"
+  stream_enabled   = true
+  stream_view_type = "NEW_AND_OLD_IMAGES"
+
+  attribute {
+    name = "token"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  replica {
+    region_name = "us-west-2"
+  }
+
+  replica {
+    region_name = "eu-west-1"
+  }
+}
+`
+	require.Equal(expected, string(store.TerraformConfig("sessions")))
+
+	store = newStore(newFakeClient(), DefaultTableName, []Option{WithWarmThroughput(12000, 4000)})
+	require.Contains(string(store.TerraformConfig("sessions")), `
+  warm_throughput {
+    read_units_per_second  = 12000
+    write_units_per_second = 4000
+  }
+`)
+
+	store = newStore(newFakeClient(), DefaultTableName, []Option{WithMaxOnDemandThroughput(0, 500)})
+	require.Contains(string(store.TerraformConfig("sessions")), `
+  on_demand_throughput {
+    max_write_request_units = 500
+  }
+`)
+}
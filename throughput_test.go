@@ -0,0 +1,97 @@
+package dynamostore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func describeTable(t *testing.T, client *fake.Client) *types.TableDescription {
+	result, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{
+		TableName: aws.String(dynamostore.DefaultTableName),
+	})
+	require.NoError(t, err)
+	return result.Table
+}
+
+func TestWarmThroughput(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	client := fake.NewClient()
+	store := dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithWarmThroughput(12000, 4000))
+	require.NoError(store.Validate())
+
+	// when
+	require.NoError(store.CreateTable())
+
+	// then the table is pre-warmed
+	table := describeTable(t, client)
+	require.Equal(types.BillingModePayPerRequest, table.BillingModeSummary.BillingMode)
+	require.NotNil(table.WarmThroughput)
+	require.Equal(int64(12000), aws.ToInt64(table.WarmThroughput.ReadUnitsPerSecond))
+	require.Equal(int64(4000), aws.ToInt64(table.WarmThroughput.WriteUnitsPerSecond))
+
+	// when the option isn't used
+	client = fake.NewClient()
+	require.NoError(dynamostore.New(client, dynamostore.WithoutSleep()).CreateTable())
+
+	// then
+	require.Nil(describeTable(t, client).WarmThroughput)
+
+	// when the rates are invalid
+	store = dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithWarmThroughput(0, 0))
+
+	// then
+	require.Error(store.Validate())
+}
+
+func TestMaxOnDemandThroughput(t *testing.T) {
+	require := require.New(t)
+
+	// given
+	ctx := context.Background()
+	client := fake.NewClient()
+	store := dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithMaxOnDemandThroughput(100, 50))
+	require.NoError(store.Validate())
+
+	// when a table is created
+	require.NoError(store.CreateTable())
+
+	// then both limits are applied
+	limits := describeTable(t, client).OnDemandThroughput
+	require.NotNil(limits)
+	require.Equal(int64(100), aws.ToInt64(limits.MaxReadRequestUnits))
+	require.Equal(int64(50), aws.ToInt64(limits.MaxWriteRequestUnits))
+
+	// when an existing table is updated by a store without a write limit
+	store = dynamostore.New(client, dynamostore.WithoutSleep(), dynamostore.WithMaxOnDemandThroughput(200, 0))
+	require.NoError(store.UpdateOnDemandThroughput(ctx))
+
+	// then the limit that isn't set is removed
+	limits = describeTable(t, client).OnDemandThroughput
+	require.NotNil(limits)
+	require.Equal(int64(200), aws.ToInt64(limits.MaxReadRequestUnits))
+	require.Nil(limits.MaxWriteRequestUnits)
+
+	// when the option isn't used
+	// then
+	require.Error(dynamostore.New(client).UpdateOnDemandThroughput(ctx))
+
+	// when the table uses provisioned capacity
+	store = dynamostore.New(client,
+		dynamostore.WithoutSleep(),
+		dynamostore.WithProvisionedThroughput(5, 5),
+		dynamostore.WithMaxOnDemandThroughput(100, 100),
+	)
+
+	// then
+	require.Error(store.Validate())
+}
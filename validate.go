@@ -0,0 +1,199 @@
+package dynamostore
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ConfigError lists the problems found by Validate.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid DynamoStore configuration: " + e.Problems[0]
+	}
+	return fmt.Sprintf(
+		"invalid DynamoStore configuration: %d problems: %s",
+		len(e.Problems), strings.Join(e.Problems, "; "),
+	)
+}
+
+// tableNamePattern matches valid DynamoDB table names.
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// Validate checks the table name and the options the store was created with,
+// and returns a *ConfigError listing every problem found, or nil. Otherwise,
+// most problems aren't reported until the first request fails. Validate
+// doesn't send any requests, so it can't detect missing permissions or
+// tables.
+//
+// Stores are usable even if Validate fails, so call Close before discarding
+// a store that won't be used.
+func (s *DynamoStore) Validate() error {
+	problems := append([]string(nil), s.problems...)
+	invalid := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if s.svc == nil {
+		invalid("a DynamoDB client is required")
+	}
+	if s.reader == nil {
+		invalid("WithDAX requires a client")
+	}
+	if table := *s.table; !tableNamePattern.MatchString(table) {
+		invalid("table name %q must be 3 to 255 letters, digits, '_', '-', or '.'", table)
+	}
+
+	switch s.ttlAttribute {
+	case "":
+		invalid("WithTTLAttribute requires a name")
+	case "token", "Data":
+		invalid("WithTTLAttribute name %q is already used to store sessions", s.ttlAttribute)
+	default:
+		if len(s.ttlAttribute) > 255 {
+			invalid("WithTTLAttribute name must be at most 255 bytes")
+		}
+	}
+
+	if s.billing.provisioned() && (s.billing.readUnits < 1 || s.billing.writeUnits < 1) {
+		invalid("WithProvisionedThroughput requires at least 1 read and 1 write capacity unit")
+	}
+	for k, v := range s.tags {
+		switch {
+		case k == "" || len(k) > 128:
+			invalid("WithTags key %q must be 1 to 128 characters", k)
+		case strings.HasPrefix(k, "aws:"):
+			invalid("WithTags key %q uses the reserved prefix \"aws:\"", k)
+		case len(v) > 256:
+			invalid("WithTags value for %q must be at most 256 characters", k)
+		}
+	}
+
+	if s.unchanged != nil && s.writeBehind != nil {
+		invalid("WithSkipUnchanged can't be used with WithWriteBehind")
+	}
+	if s.versioned && s.writeBehind != nil {
+		invalid("WithOptimisticLocking can't be used with WithWriteBehind")
+	}
+	if s.skipExpiryCheck && s.lazyDelete != nil {
+		invalid("WithLazyDelete has no effect with WithoutExpiryCheck")
+	}
+	if s.skipExpiryCheck && s.slidingExpiration > 0 {
+		invalid("WithSlidingExpiration can't be used with WithoutExpiryCheck")
+	}
+	if s.expiryOnlyUpdates && s.unchanged == nil {
+		invalid("WithExpiryOnlyUpdates requires WithSkipUnchanged")
+	}
+	if s.expiryOnlyUpdates && s.versioned {
+		invalid("WithExpiryOnlyUpdates can't be used with WithOptimisticLocking")
+	}
+	if s.versioned && s.adaptive != nil {
+		invalid("WithAdaptiveConsistency can't be used with WithOptimisticLocking")
+	} else if s.versioned && s.recentWrites != nil {
+		invalid("WithEventualReads can't be used with WithOptimisticLocking")
+	}
+	if s.cache != nil && s.slidingExpiration > 0 {
+		invalid("WithCache can't be used with WithSlidingExpiration")
+	}
+	if s.cache != nil && s.versioned {
+		invalid("WithCache can't be used with WithOptimisticLocking")
+	}
+	if s.invalidation != nil && s.cache == nil {
+		invalid("WithCacheInvalidation requires WithCache")
+	}
+	if s.coalescer != nil && s.writeBehind != nil {
+		invalid("WithCommitCoalescing can't be used with WithWriteBehind")
+	}
+	if s.coalescer != nil && s.versioned {
+		invalid("WithCommitCoalescing can't be used with WithOptimisticLocking")
+	}
+	if s.versioned && s.monotonicExpiry {
+		invalid("WithMonotonicExpiry can't be used with WithOptimisticLocking")
+	}
+	if s.versioned && s.retention > 0 {
+		invalid("WithRetention can't be used with WithOptimisticLocking")
+	}
+	if s.versioned && s.revocationTTL > 0 {
+		invalid("WithRevocationMarkers can't be used with WithOptimisticLocking")
+	}
+	if s.dbesdk && s.slidingExpiration > 0 {
+		invalid("WithSlidingExpiration can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.expiryOnlyUpdates {
+		invalid("WithExpiryOnlyUpdates can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.versioned {
+		invalid("WithOptimisticLocking can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.retention > 0 {
+		invalid("WithRetention can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.revocationTTL > 0 {
+		invalid("WithRevocationMarkers can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.creationTime {
+		invalid("WithCreationTime can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.dbesdk && s.encryption != nil {
+		invalid("WithEncryption and WithKeyProvider can't be used with WithDatabaseEncryptionSDK")
+	}
+	if s.autoScaling != nil && !s.billing.provisioned() {
+		invalid("WithAutoScaling requires WithProvisionedThroughput")
+	}
+	if s.billing.provisioned() && s.billing.onDemandThroughput(false) != nil {
+		invalid("WithMaxOnDemandThroughput can't be used with WithProvisionedThroughput")
+	}
+	if s.dbesdk && s.global != nil {
+		invalid("WithGlobalTable can't be used with WithDatabaseEncryptionSDK")
+	}
+	seen := map[string]bool{}
+	for _, attr := range s.reservedAttributes() {
+		seen[attr] = true
+	}
+	for _, idx := range s.attributeIndexes {
+		if seen[idx.attribute] {
+			invalid("WithAttributeIndex can't use attribute %q", idx.attribute)
+		}
+		seen[idx.attribute] = true
+	}
+	if s.subjectIndex && s.subjectKey == "" {
+		invalid("WithSubjectIndex requires WithSubjectKey")
+	}
+	if s.tokenDigest && s.pepper == nil {
+		invalid("WithTokenDigest requires WithTokenPepper")
+	}
+	if s.codec == nil {
+		invalid("WithCodec requires a codec")
+	}
+	if s.now == nil {
+		invalid("WithClock requires a function")
+	}
+
+	if s.endpoint != "" {
+		u, err := url.Parse(s.endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("WithEndpoint URL %q must include a scheme and host", s.endpoint)
+		}
+		if s.endpointVariant.enabled() {
+			invalid("WithFIPSEndpoint and WithDualStackEndpoint have no effect with WithEndpoint")
+		}
+		if s.failover != nil {
+			invalid("WithRegionFailover has no effect with WithEndpoint")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// invalid records a problem with an option, to be reported by Validate.
+func (s *DynamoStore) invalid(format string, args ...interface{}) {
+	s.problems = append(s.problems, fmt.Sprintf(format, args...))
+}
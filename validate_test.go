@@ -0,0 +1,49 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require := require.New(t)
+
+	store := newStore(newFakeClient(), DefaultTableName, []Option{
+		WithCapacityLimit(10, 10),
+		WithEndpoint("http://localhost:8000"),
+		WithTags(map[string]string{"team": "identity"}),
+	})
+	require.NoError(store.Validate())
+
+	store = newStore(newFakeClient(), "x", []Option{
+		WithTTLAttribute("Data"),
+		WithProvisionedThroughput(5, 0),
+		WithTags(map[string]string{"aws:owner": "me"}),
+		WithEndpoint("localhost:8000"),
+		WithFIPSEndpoint(),
+		WithMaxAttempts(0),
+		WithWriteBehind(0, nil),
+		WithHTTPClient(&stubHTTPClient{}),
+		WithHTTPSettings(&HTTPSettings{}),
+	})
+	defer store.Close(context.Background())
+
+	err := store.Validate()
+	var configErr *ConfigError
+	require.True(errors.As(err, &configErr))
+	require.Equal([]string{
+		"WithMaxAttempts requires at least 1 attempt",
+		"WithWriteBehind requires at least 1 worker",
+		"the HTTP client is set more than once by WithHTTPClient or WithHTTPSettings",
+		`table name "x" must be 3 to 255 letters, digits, '_', '-', or '.'`,
+		`WithTTLAttribute name "Data" is already used to store sessions`,
+		"WithProvisionedThroughput requires at least 1 read and 1 write capacity unit",
+		`WithTags key "aws:owner" uses the reserved prefix "aws:"`,
+		`WithEndpoint URL "localhost:8000" must include a scheme and host`,
+		"WithFIPSEndpoint and WithDualStackEndpoint have no effect with WithEndpoint",
+	}, configErr.Problems)
+	require.Contains(err.Error(), "9 problems")
+}
@@ -0,0 +1,196 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrVersionConflict is returned by Commit when optimistic locking is
+// enabled, and the session was changed by another request after it was
+// found.
+var ErrVersionConflict = errors.New("session was changed by another request")
+
+// versionAttribute stores the number of times a session has been committed,
+// when WithOptimisticLocking is used.
+const versionAttribute = "Version"
+
+// WithOptimisticLocking stores a version number with each session, and
+// makes CommitCtx fail with ErrVersionConflict if the session was committed
+// by another request since it was found by FindCtx. Otherwise, when
+// concurrent requests change the same session, the last commit wins and the
+// other changes are lost.
+//
+// Versions are only checked when FindCtx and CommitCtx are called with a
+// context returned by TrackVersions, such as the request context inside the
+// TrackVersionsHandler middleware. Other commits succeed, and still update
+// the version. Optimistic locking can't be used with WithWriteBehind.
+func WithOptimisticLocking() Option {
+	return func(s *DynamoStore) {
+		s.versioned = true
+	}
+}
+
+type versionsKey struct{}
+
+// versionTracker records the version of each session found during a
+// request.
+type versionTracker struct {
+	mu       sync.Mutex
+	versions map[string]int64
+}
+
+// TrackVersions returns a copy of ctx that records the versions of the
+// sessions found using it, for WithOptimisticLocking.
+func TrackVersions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, versionsKey{}, &versionTracker{
+		versions: map[string]int64{},
+	})
+}
+
+// TrackVersionsHandler is HTTP middleware that calls TrackVersions for the
+// context of every request. It must wrap scs.SessionManager.LoadAndSave, so
+// the session manager uses the tracking context:
+//
+//	handler := dynamostore.TrackVersionsHandler(sessionManager.LoadAndSave(mux))
+func TrackVersionsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(TrackVersions(r.Context())))
+	})
+}
+
+func versionsFrom(ctx context.Context) *versionTracker {
+	tracker, _ := ctx.Value(versionsKey{}).(*versionTracker)
+	return tracker
+}
+
+func (t *versionTracker) get(token string) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	version, ok := t.versions[token]
+	return version, ok
+}
+
+func (t *versionTracker) set(token string, version int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.versions[token] = version
+}
+
+func (t *versionTracker) forget(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.versions, token)
+}
+
+// trackVersion records the version of item, which is empty if the session
+// wasn't found.
+func (s *DynamoStore) trackVersion(ctx context.Context, token string, item *sessionItem) {
+	if !s.versioned {
+		return
+	}
+	if tracker := versionsFrom(ctx); tracker != nil {
+		tracker.set(token, item.Version)
+	}
+}
+
+// setVersionedItem saves a session and increments its version. If the
+// version found earlier in the request is known, the write is conditional on
+// the session still having that version.
+func (s *DynamoStore) setVersionedItem(
+	ctx context.Context, token string, data []byte, expiry time.Time, returnValues types.ReturnValue,
+) (map[string]types.AttributeValue, error) {
+	av, err := s.marshalItem(token, data, expiry)
+	if err != nil {
+		return nil, err
+	}
+	delete(av, "token")
+
+	attrs := make([]string, 0, len(av))
+	for name := range av {
+		attrs = append(attrs, name)
+	}
+	sort.Strings(attrs)
+
+	names := map[string]string{
+		"#version": versionAttribute,
+	}
+	values := map[string]types.AttributeValue{
+		":zero": &types.AttributeValueMemberN{Value: "0"},
+		":one":  &types.AttributeValueMemberN{Value: "1"},
+	}
+	actions := []string{"#version = if_not_exists(#version, :zero) + :one"}
+	if s.creationTime {
+		names["#created"] = createdAtAttribute
+		values[":created"] = &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(s.now().Unix(), 10),
+		}
+		actions = append(actions, "#created = if_not_exists(#created, :created)")
+	}
+	for i, name := range attrs {
+		n := strconv.Itoa(i)
+		names["#a"+n] = name
+		values[":a"+n] = av[name]
+		actions = append(actions, "#a"+n+" = :a"+n)
+	}
+	releaseItemMap(av)
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: s.table,
+		Key: map[string]types.AttributeValue{
+			"token": &types.AttributeValueMemberS{
+				Value: token,
+			},
+		},
+		UpdateExpression:          aws.String("SET " + strings.Join(actions, ", ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnConsumedCapacity:    s.limiter.returnConsumedCapacity(),
+		ReturnValues:              s.eventReturnValues(returnValues, types.ReturnValueUpdatedOld),
+	}
+	tracker := versionsFrom(ctx)
+	var expected int64
+	var tracked bool
+	if tracker != nil {
+		expected, tracked = tracker.get(token)
+	}
+	if tracked && expected == 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(#version)")
+	} else if tracked {
+		input.ConditionExpression = aws.String("#version = :expected")
+		values[":expected"] = &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(expected, 10),
+		}
+	}
+
+	units, err := s.limiter.waitWrite(ctx, itemSize(av))
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.svc.UpdateItem(ctx, input, s.optFns...)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			tracker.forget(token)
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+	s.limiter.consumedWrite(units, result.ConsumedCapacity)
+	s.rememberWrite(token, expiry)
+	s.stats.wrote(data, av)
+	if tracked {
+		tracker.set(token, expected+1)
+	}
+	s.recordWrite(ctx, token, data, result.Attributes)
+	return result.Attributes, nil
+}
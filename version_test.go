@@ -0,0 +1,89 @@
+package dynamostore_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjansen/dynamostore"
+	"github.com/sjansen/dynamostore/fake"
+)
+
+func TestWithOptimisticLocking(t *testing.T) {
+	require := require.New(t)
+
+	store := fake.New(dynamostore.WithOptimisticLocking())
+	require.NoError(store.Validate())
+	expiry := time.Now().Add(time.Hour)
+
+	// given two requests that find the same new session
+	first := dynamostore.TrackVersions(context.Background())
+	second := dynamostore.TrackVersions(context.Background())
+	_, exists, err := store.FindCtx(first, "foo")
+	require.NoError(err)
+	require.False(exists)
+	_, exists, err = store.FindCtx(second, "foo")
+	require.NoError(err)
+	require.False(exists)
+
+	// when both commit
+	require.NoError(store.CommitCtx(first, "foo", []byte("first"), expiry))
+	err = store.CommitCtx(second, "foo", []byte("second"), expiry)
+
+	// then the second fails
+	require.Equal(dynamostore.ErrVersionConflict, err)
+	data, _, err := store.FindCtx(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal([]byte("first"), data)
+
+	// when a request commits more than once
+	require.NoError(store.CommitCtx(first, "foo", []byte("again"), expiry))
+
+	// then each commit is checked against the previous one
+	third := dynamostore.TrackVersions(context.Background())
+	_, _, err = store.FindCtx(third, "foo")
+	require.NoError(err)
+	require.NoError(store.CommitCtx(first, "foo", []byte("and again"), expiry))
+	require.Equal(dynamostore.ErrVersionConflict, store.CommitCtx(third, "foo", []byte("third"), expiry))
+
+	// when commits aren't tracked
+	require.NoError(store.Commit("foo", []byte("untracked"), expiry))
+	details, err := store.Inspect(context.Background(), "foo")
+	require.NoError(err)
+
+	// then they still increment the version
+	require.Equal(&types.AttributeValueMemberN{Value: "4"}, details.Attributes["Version"])
+
+	// when the previous session is requested
+	data, _, existed, err := store.CommitAndReturnPrevious(first, "foo", []byte("previous"), expiry)
+
+	// then the version is still checked
+	require.Equal(dynamostore.ErrVersionConflict, err)
+	require.False(existed)
+	require.Nil(data)
+	data, _, existed, err = store.CommitAndReturnPrevious(context.Background(), "foo", []byte("previous"), expiry)
+	require.NoError(err)
+	require.True(existed)
+	require.Equal([]byte("untracked"), data)
+}
+
+func TestTrackVersionsHandler(t *testing.T) {
+	require := require.New(t)
+
+	var tracked bool
+	handler := dynamostore.TrackVersionsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store := fake.New(dynamostore.WithOptimisticLocking())
+		_, _, err := store.FindCtx(r.Context(), "foo")
+		require.NoError(err)
+		require.NoError(store.Commit("foo", []byte("other"), time.Now().Add(time.Hour)))
+		tracked = store.CommitCtx(r.Context(), "foo", []byte("bar"), time.Now().Add(time.Hour)) ==
+			dynamostore.ErrVersionConflict
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	require.True(tracked)
+}
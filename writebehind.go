@@ -0,0 +1,180 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// errWriteBehind is returned by methods that must write synchronously.
+var errWriteBehind = errors.New("not supported with write-behind")
+
+// WithWriteBehind enables asynchronous commits. Commit and Delete queue
+// their changes and return immediately, while the given number of background
+// workers write them to DynamoDB. Successive commits for a token that hasn't
+// been written yet are merged, so only the latest data is saved.
+//
+// Write-behind trades durability for request latency: queued changes are lost
+// if the process exits without calling Close. Failed writes are reported to
+// onError. If onError is nil, Close reports the number of failed writes and
+// the last error instead.
+func WithWriteBehind(workers int, onError func(token string, err error)) Option {
+	return func(s *DynamoStore) {
+		n := workers
+		if n < 1 {
+			s.invalid("WithWriteBehind requires at least 1 worker")
+			n = 1
+		}
+		s.writeBehind = &writeBehind{
+			workers:  n,
+			onError:  onError,
+			pending:  map[string]*writeOp{},
+			inflight: map[string]*writeOp{},
+		}
+	}
+}
+
+// writeOp is a queued change. A nil item means the session was deleted.
+type writeOp struct {
+	item *sessionItem
+}
+
+type writeBehind struct {
+	store   *DynamoStore
+	workers int
+	onError func(token string, err error)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	closed   bool
+	order    []string
+	pending  map[string]*writeOp
+	inflight map[string]*writeOp
+	failed   int
+	lastErr  error
+	wg       sync.WaitGroup
+}
+
+func (w *writeBehind) start(s *DynamoStore) {
+	w.store = s
+	w.cond = sync.NewCond(&w.mu)
+	w.wg.Add(w.workers)
+	for i := 0; i < w.workers; i++ {
+		go w.run()
+	}
+}
+
+// enqueue queues op for token, replacing any change that hasn't been picked
+// up by a worker yet. It returns false if the queue has been closed.
+func (w *writeBehind) enqueue(token string, op *writeOp) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	_, queued := w.pending[token]
+	w.pending[token] = op
+	if !queued && w.inflight[token] == nil {
+		// A token that is in flight is requeued by its worker when the
+		// current write finishes, which keeps writes for a token ordered.
+		w.order = append(w.order, token)
+		w.cond.Signal()
+	}
+	return true
+}
+
+// find returns the queued state of a session. The ok flag is false if
+// nothing is queued for token and the table must be checked instead.
+func (w *writeBehind) find(token string) (item *sessionItem, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	op := w.pending[token]
+	if op == nil {
+		op = w.inflight[token]
+	}
+	switch {
+	case op == nil:
+		return nil, false
+	case op.item == nil:
+		return nil, true
+	case w.store.hideExpired(op.item.TTL, w.store.now()):
+		return nil, true
+	}
+	return op.item, true
+}
+
+func (w *writeBehind) run() {
+	defer w.wg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for len(w.order) < 1 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.order) < 1 {
+			return
+		}
+
+		token := w.order[0]
+		w.order = w.order[1:]
+		op := w.pending[token]
+		delete(w.pending, token)
+		w.inflight[token] = op
+
+		w.mu.Unlock()
+		err := w.apply(token, op)
+		if err != nil && w.onError != nil {
+			w.onError(token, err)
+		}
+		w.mu.Lock()
+
+		if err != nil && w.onError == nil {
+			w.failed++
+			w.lastErr = err
+		}
+
+		delete(w.inflight, token)
+		if _, ok := w.pending[token]; ok {
+			w.order = append(w.order, token)
+		}
+	}
+}
+
+func (w *writeBehind) apply(token string, op *writeOp) error {
+	ctx := context.Background()
+	if op.item == nil {
+		_, err := w.store.deleteItem(ctx, token, types.ReturnValueNone)
+		return err
+	}
+	_, err := w.store.write(ctx, token, op.item.Data, op.item.TTL, types.ReturnValueNone)
+	return ignoreDroppedCommit(err)
+}
+
+func (w *writeBehind) close(ctx context.Context) error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed > 0 {
+		return fmt.Errorf("write-behind: %d writes failed: %w", w.failed, w.lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBehind(t *testing.T) {
+	require := require.New(t)
+
+	svc := newFakeClient()
+	store := newStore(svc, DefaultTableName, []Option{
+		WithWriteBehind(2, func(token string, err error) {
+			t.Errorf("unexpected error writing %q: %v", token, err)
+		}),
+	})
+
+	expiry := time.Now().Add(time.Minute)
+	for i := 0; i < 10; i++ {
+		err := store.Commit("foo", []byte{byte(i)}, expiry)
+		require.NoError(err)
+	}
+	err := store.Commit("bar", []byte("bar"), expiry)
+	require.NoError(err)
+	err = store.Delete("bar")
+	require.NoError(err)
+
+	// queued changes are visible before they are flushed
+	actual, exists, err := store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte{9}, actual)
+
+	actual, exists, err = store.Find("bar")
+	require.NoError(err)
+	require.False(exists)
+	require.Nil(actual)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = store.Close(ctx)
+	require.NoError(err)
+
+	// successive commits were merged
+	require.LessOrEqual(svc.count("PutItem"), 10)
+	require.Equal(0, svc.count("GetItem"))
+
+	actual, exists, err = store.Find("foo")
+	require.NoError(err)
+	require.True(exists)
+	require.Equal([]byte{9}, actual)
+
+	actual, exists, err = store.Find("bar")
+	require.NoError(err)
+	require.False(exists)
+	require.Nil(actual)
+
+	// commits after close are written synchronously
+	puts := svc.count("PutItem")
+	err = store.Commit("baz", []byte("baz"), expiry)
+	require.NoError(err)
+	require.Equal(puts+1, svc.count("PutItem"))
+}